@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+	"golang.org/x/term"
+)
+
+func browseMain(args []string) {
+	fs := newFlagSet("browse", "<stats.json>...", `Browse the scores of projects in the terminal, from the raw stats saved by the collect command,
+or from JSON reports. The arrows left and right (or tab) switch between the projects, up and
+down between the axes, enter shows the raw values of the axis, page up and page down scroll,
+and q quits.`)
+	policy := fs.String("policy", "", "score the projects with this Rego or CUE policy")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		usageError(fs, "no stats file to browse")
+	}
+
+	opts := &qsos.ScoreOptions{Config: loadConfigFromEnv(), Policy: *policy}
+	var evaluations []*qsos.Evaluation
+	for _, path := range fs.Args() {
+		scored, err := scoreRawStats(path, opts)
+		if err != nil {
+			fatal(err)
+		}
+		evaluations = append(evaluations, scored...)
+	}
+	if len(evaluations) == 0 {
+		fatal(errors.New("No project to browse"))
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		fatal(errors.New("The browser needs a terminal, use the score command to print the reports"))
+	}
+	reportOptions.Colors = !noColor
+	if err := (&browser{evaluations: evaluations}).run(); err != nil {
+		fatal(err)
+	}
+}
+
+// browser is the state of the terminal UI.
+type browser struct {
+	evaluations []*qsos.Evaluation
+	project     int
+	axis        int
+	details     bool
+	scroll      int
+}
+
+// The keys of the browser, as sent by the terminals.
+const (
+	keyUp       = "\033[A"
+	keyDown     = "\033[B"
+	keyRight    = "\033[C"
+	keyLeft     = "\033[D"
+	keyPageUp   = "\033[5~"
+	keyPageDown = "\033[6~"
+	keyCtrlC    = "\x03"
+)
+
+func (b *browser) run() error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("Cannot set the terminal in raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+	// Alternate screen, without the cursor
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Print(b.render(width, height))
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return fmt.Errorf("Cannot read the keys: %w", err)
+		}
+		if !b.handle(string(buf[:n]), height) {
+			return nil
+		}
+	}
+}
+
+// handle updates the state for a key, and returns false to quit.
+func (b *browser) handle(key string, height int) bool {
+	switch key {
+	case "q", "Q", keyCtrlC:
+		return false
+	case keyRight, "l", "\t":
+		b.project = (b.project + 1) % len(b.evaluations)
+		b.scroll = 0
+	case keyLeft, "h":
+		b.project = (b.project + len(b.evaluations) - 1) % len(b.evaluations)
+		b.scroll = 0
+	case keyDown, "j":
+		b.axis = (b.axis + 1) % len(qsos.BrowseAxes)
+		b.scroll = 0
+	case keyUp, "k":
+		b.axis = (b.axis + len(qsos.BrowseAxes) - 1) % len(qsos.BrowseAxes)
+		b.scroll = 0
+	case "\r", " ":
+		b.details = !b.details
+		b.scroll = 0
+	case keyPageDown:
+		b.scroll += max(1, height/2)
+	case keyPageUp:
+		b.scroll = max(0, b.scroll-max(1, height/2))
+	}
+	return true
+}
+
+// browserHelp is the last line of the screen.
+const browserHelp = "←/→ project  ↑/↓ axis  enter raw values  PgUp/PgDn scroll  q quit"
+
+// render returns the screen: the tabs of the projects and of the axes, the
+// page of the current axis, and the help.
+func (b *browser) render(width, height int) string {
+	var names []string
+	for _, evaluation := range b.evaluations {
+		names = append(names, evaluation.Name())
+	}
+	var page bytes.Buffer
+	qsos.PrintAxis(&page, b.evaluations[b.project], qsos.BrowseAxes[b.axis], b.details, reportOptions)
+	lines := strings.Split(strings.TrimRight(page.String(), "\n"), "\n")
+	// The tabs take 4 lines, and the help 2
+	visible := max(1, height-6)
+	b.scroll = min(b.scroll, max(0, len(lines)-visible))
+	lines = lines[b.scroll:min(len(lines), b.scroll+visible)]
+
+	var screen strings.Builder
+	screen.WriteString("\033[H\033[2J")
+	screen.WriteString(tabs(names, b.project) + "\n\n")
+	var axes []string
+	for _, axis := range qsos.BrowseAxes {
+		axes = append(axes, reportOptions.Language.Translate(axis))
+	}
+	screen.WriteString(tabs(axes, b.axis) + "\n\n")
+	screen.WriteString(strings.Join(lines, "\n"))
+	fmt.Fprintf(&screen, "\033[%d;1H%s", height, truncate(reportOptions.Language.Translate(browserHelp), width))
+	// The terminal is in raw mode
+	return strings.ReplaceAll(screen.String(), "\n", "\r\n")
+}
+
+// tabs returns a line of tabs, with the current one highlighted.
+func tabs(names []string, current int) string {
+	var line []string
+	for i, name := range names {
+		switch {
+		case i != current:
+			line = append(line, " "+name+" ")
+		case reportOptions.Colors:
+			line = append(line, "\033[7m "+name+" \033[0m")
+		default:
+			line = append(line, "["+name+"]")
+		}
+	}
+	return strings.Join(line, " ")
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	return string(runes[:min(len(runes), width)])
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string)
+}
+
+var commands = []command{
+	{"evaluate", "collect the stats of projects and compute their scores", evaluateMain},
+	{"collect", "collect the raw stats of projects, to score them later", collectMain},
+	{"score", "compute the scores from raw stats saved by collect", scoreMain},
+	{"compare", "evaluate projects and compare them side by side", compareMain},
+	{"product", "evaluate the repositories of a product and aggregate their scores", productMain},
+	{"browse", "browse the scores of projects in the terminal", browseMain},
+	{"history", "list, search and tag the evaluations of the history", historyMain},
+	{"trend", "print the evolution of the scores of a project from the history", trendMain},
+	{"diff", "print the scores and the metrics that have changed between two reports", diffMain},
+	{"badge", "write the shields.io badge of the overall score of a project", badgeMain},
+	{"serve", "start the HTTP server", serveMain},
+	{"daemon", "evaluate projects again on a schedule and notify the score changes", daemonMain},
+	{"schema", "print the JSON schema of the reports", schemaMain},
+	{"init", "write a commented default configuration file", initMain},
+	{"doctor", "check the tools, the services and the tokens before a run", doctorMain},
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: qsos <command> [flags] [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintf(w, "\nRun 'qsos <command> --help' for the flags of a command.\n")
+}
+
+// newFlagSet returns the flag set of a command, with its usage message and
+// the flags for the logs.
+func newFlagSet(name, arguments, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: qsos %s [flags] %s\n\n%s\n\nFlags:\n", name, arguments, description)
+		fs.PrintDefaults()
+	}
+	fs.BoolFunc("verbose", "log the details of the evaluations", func(string) error {
+		logLevel.Set(min(logLevel.Level(), slog.LevelDebug))
+		return nil
+	})
+	fs.BoolFunc("debug", "log the details of the evaluations, the HTTP requests, and the source of the logs", func(string) error {
+		logLevel.Set(qsos.LevelTrace)
+		logSource = true
+		setLogOutput(os.Stderr)
+		return nil
+	})
+	fs.BoolFunc("quiet", "only print the scores, the warnings and the errors, without the progress and the output of the tools", func(string) error {
+		quiet = true
+		logLevel.Set(slog.LevelWarn)
+		return nil
+	})
+	fs.Func("lang", "language of the reports: "+strings.Join(qsos.Languages, " or ")+" (default $QSOS_LANG, or en)", setLanguage)
+	fs.BoolFunc("no-color", "disable the colors of the reports and the progress bar (default true if $NO_COLOR is set)", func(string) error {
+		noColor = true
+		return nil
+	})
+	return fs
+}
+
+// quiet only prints the scores, with --quiet.
+var quiet bool
+
+// reportOptions are the options of the reports, with the language of
+// QSOS_LANG or --lang.
+var reportOptions = &qsos.ReportOptions{}
+
+func setLanguage(code string) error {
+	lang, err := qsos.NewLanguage(code)
+	if err != nil {
+		return err
+	}
+	reportOptions.Language = lang
+	return nil
+}
+
+// noColor disables the ANSI escapes, with --no-color or NO_COLOR
+// (https://no-color.org).
+var noColor = os.Getenv("NO_COLOR") != ""
+
+// logLevel is the minimal level of the logs, info by default.
+var logLevel = new(slog.LevelVar)
+
+// logSource adds the source of the logs, with --debug.
+var logSource bool
+
+// setLogOutput sets the default logger, writing to w.
+func setLogOutput(w io.Writer) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level:     logLevel,
+		AddSource: logSource,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == qsos.LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	})))
+}
+
+// fatal logs an error, and exits.
+func fatal(err error) {
+	slog.Error(err.Error())
+	exit(1)
+}
+
+// shutdownTracing flushes the spans, if the tracing is enabled.
+var shutdownTracing = func(context.Context) error { return nil }
+
+func flushSpans() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("cannot export the spans", "err", err)
+	}
+}
+
+// exit flushes the spans, and exits with the code.
+func exit(code int) {
+	flushSpans()
+	os.Exit(code)
+}
+
+// interruptContext returns a context canceled on the first SIGINT or
+// SIGTERM: the evaluations in progress stop, and remove their containers and
+// their temporary dirs. The next signal kills the process.
+func interruptContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		slog.Warn("interrupted, cleaning up (interrupt again to exit now)")
+	}()
+	return ctx
+}
+
+// exitIfInterrupted exits if the context has been canceled by a signal,
+// with the status of the shells for SIGINT.
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		slog.Error("interrupted")
+		exit(130)
+	}
+}
+
+// fatalUsage prints the usage of a command without flag set, and exits.
+func fatalUsage(usage string) {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(1)
+}
+
+// usageError prints the usage of a command, and exits.
+func usageError(fs *flag.FlagSet, format string, args ...any) {
+	fmt.Fprintf(fs.Output(), "ERROR: "+format+"\n\n", args...)
+	fs.Usage()
+	os.Exit(2)
+}
+
+// executorFlags are the flags for the tokens and URLs of the services. When
+// they are set, they override the env variables.
+type executorFlags struct {
+	forge          *string
+	forgeURL       *string
+	githubToken    *string
+	githubFile     *string
+	githubAPIURL   *string
+	githubUpload   *string
+	githubApp      *string
+	githubInstall  *string
+	githubAppKey   *string
+	gitlabToken    *string
+	giteaToken     *string
+	bitbucketUser  *string
+	bitbucketToken *string
+	sonarqubeURL   *string
+	sonarqubeToken *string
+	sonarqubeFile  *string
+	sonarqubeCA    *string
+	sonarqubeCert  *string
+	sonarqubeKey   *string
+	sonarInsecure  *bool
+	analyzer       *string
+	advisories     *bool
+	noGitHubCache  *bool
+	workers        *int
+	forgeWorkers   *int
+	dockerWorkers  *int
+	contribMonths  *int
+	contribCommits *int64
+	bots           *string
+	offline        *bool
+	noCache        *bool
+	// local is set when only local working copies are evaluated.
+	local bool
+}
+
+func addExecutorFlags(fs *flag.FlagSet) *executorFlags {
+	return &executorFlags{
+		forge:          fs.String("forge", "", "forge hosting the projects given as owner/repo: github, gitlab, gitea, bitbucket or git (default $QSOS_FORGE, or github)"),
+		forgeURL:       fs.String("forge-url", "", "URL of a self-hosted forge (default $QSOS_FORGE_URL, or the public instance)"),
+		githubToken:    fs.String("github-token", "", "token for the GitHub API (default $GITHUB_TOKEN, or the token of the gh CLI)"),
+		githubFile:     fs.String("github-token-file", "", "file of the token for the GitHub API (default $GITHUB_TOKEN_FILE)"),
+		githubAPIURL:   fs.String("github-api-url", "", "URL of the API of GitHub Enterprise Server (default $QSOS_GITHUB_API_URL, or the one of --forge-url)"),
+		githubUpload:   fs.String("github-upload-url", "", "upload URL of GitHub Enterprise Server (default $QSOS_GITHUB_UPLOAD_URL, or the one of --forge-url)"),
+		githubApp:      fs.String("github-app-id", "", "ID of the GitHub App to authenticate as, instead of a token (default $GITHUB_APP_ID)"),
+		githubInstall:  fs.String("github-app-installation-id", "", "installation of the GitHub App, optional if it has a single one (default $GITHUB_APP_INSTALLATION_ID)"),
+		githubAppKey:   fs.String("github-app-key-file", "", "file of the PEM private key of the GitHub App (default $GITHUB_APP_PRIVATE_KEY_FILE, or the key in $GITHUB_APP_PRIVATE_KEY)"),
+		gitlabToken:    fs.String("gitlab-token", "", "token for the GitLab API (default $GITLAB_TOKEN)"),
+		giteaToken:     fs.String("gitea-token", "", "token for the Gitea, Forgejo or Codeberg API (default $GITEA_TOKEN)"),
+		bitbucketUser:  fs.String("bitbucket-username", "", "user of the Bitbucket app password given as token (default $BITBUCKET_USERNAME)"),
+		bitbucketToken: fs.String("bitbucket-token", "", "access token or app password for the Bitbucket API (default $BITBUCKET_TOKEN)"),
+		sonarqubeURL:   fs.String("sonarqube-url", "", "URL of the Sonarqube server (default $SONARQUBE_URL)"),
+		sonarqubeToken: fs.String("sonarqube-token", "", "token for the Sonarqube server (default $SONARQUBE_TOKEN)"),
+		sonarqubeFile:  fs.String("sonarqube-token-file", "", "file of the token for the Sonarqube server (default $SONARQUBE_TOKEN_FILE)"),
+		sonarqubeCA:    fs.String("sonarqube-ca-cert", "", "PEM bundle of the CAs of a self-hosted Sonarqube server (default $SONARQUBE_CA_CERT)"),
+		sonarqubeCert:  fs.String("sonarqube-client-cert", "", "PEM file of a client certificate for the Sonarqube server (default $SONARQUBE_CLIENT_CERT)"),
+		sonarqubeKey:   fs.String("sonarqube-client-key", "", "PEM file of the key of the client certificate (default $SONARQUBE_CLIENT_KEY)"),
+		sonarInsecure:  fs.Bool("sonarqube-insecure-skip-verify", false, "do not verify the certificate of the Sonarqube server, which is insecure (default $SONARQUBE_INSECURE_SKIP_VERIFY)"),
+		analyzer:       fs.String("analyzer", "", "backend for the tech stats: sonarqube or lite (default $QSOS_ANALYZER, or sonarqube)"),
+		advisories:     fs.Bool("advisories", false, "collect the security advisories, to score the security process (default $QSOS_ADVISORIES)"),
+		noGitHubCache:  fs.Bool("no-github-cache", false, "do not cache the GitHub responses (default $QSOS_NO_GITHUB_CACHE)"),
+		workers:        fs.Int("workers", 0, "number of projects evaluated concurrently (default $QSOS_WORKERS, or 1)"),
+		forgeWorkers:   fs.Int("forge-workers", 0, "maximal number of concurrent evaluations of the projects of a same forge (default $QSOS_FORGE_WORKERS, or no limit)"),
+		noCache:        fs.Bool("no-cache", false, "collect the stats again, instead of reusing the cached results of the same commit (default $QSOS_NO_CACHE)"),
+		offline:        fs.Bool("offline", false, "answer from the cache only, with the stats of the last online evaluations, and fail on the projects not in it (default $QSOS_OFFLINE)"),
+		dockerWorkers:  fs.Int("docker-workers", 0, "maximal number of concurrent containers of the scanners, 1 to run them one at a time (default $QSOS_DOCKER_WORKERS, or no limit)"),
+		contribMonths:  fs.Int("contributors-months", 0, "window of the active contributors, in months (default $QSOS_CONTRIBUTORS_MONTHS, or 6)"),
+		contribCommits: fs.Int64("contributors-min-commits", 0, "minimal number of commits in the window of the active contributors (default $QSOS_CONTRIBUTORS_MIN_COMMITS, or 4)"),
+		bots:           fs.String("bots", "", "comma-separated bots added to the known ones: logins or names, emails or @domains, and /regexps/ of the names (default $QSOS_BOTS)"),
+	}
+}
+
+func (f *executorFlags) newExecutor() (*qsos.Executor, error) {
+	opts := qsos.ExecutorOptionsFromEnv()
+	opts.Local = f.local
+	if quiet {
+		opts.ToolOutput = io.Discard
+	}
+	if *f.forge != "" {
+		opts.Forge = *f.forge
+	}
+	if *f.forgeURL != "" {
+		opts.ForgeURL = *f.forgeURL
+	}
+	if *f.githubToken != "" {
+		opts.GitHubToken = *f.githubToken
+	}
+	if *f.githubFile != "" {
+		opts.GitHubToken, opts.GitHubTokenFile = "", *f.githubFile
+	}
+	if *f.githubAPIURL != "" {
+		opts.GitHubAPIURL = *f.githubAPIURL
+	}
+	if *f.githubUpload != "" {
+		opts.GitHubUploadURL = *f.githubUpload
+	}
+	if *f.githubApp != "" {
+		opts.GitHubAppID = *f.githubApp
+	}
+	if *f.githubInstall != "" {
+		opts.GitHubAppInstallationID = *f.githubInstall
+	}
+	if *f.githubAppKey != "" {
+		opts.GitHubAppKey, opts.GitHubAppKeyFile = "", *f.githubAppKey
+	}
+	if *f.gitlabToken != "" {
+		opts.GitLabToken = *f.gitlabToken
+	}
+	if *f.giteaToken != "" {
+		opts.GiteaToken = *f.giteaToken
+	}
+	if *f.bitbucketUser != "" {
+		opts.BitbucketUsername = *f.bitbucketUser
+	}
+	if *f.bitbucketToken != "" {
+		opts.BitbucketToken = *f.bitbucketToken
+	}
+	if *f.sonarqubeURL != "" {
+		opts.SonarqubeURL = *f.sonarqubeURL
+	}
+	if *f.sonarqubeToken != "" {
+		opts.SonarqubeToken = *f.sonarqubeToken
+	}
+	if *f.sonarqubeFile != "" {
+		opts.SonarqubeToken, opts.SonarqubeTokenFile = "", *f.sonarqubeFile
+	}
+	if *f.sonarqubeCA != "" {
+		opts.SonarqubeCACert = *f.sonarqubeCA
+	}
+	if *f.sonarqubeCert != "" {
+		opts.SonarqubeClientCert = *f.sonarqubeCert
+	}
+	if *f.sonarqubeKey != "" {
+		opts.SonarqubeClientKey = *f.sonarqubeKey
+	}
+	if *f.sonarInsecure {
+		opts.SonarqubeInsecure = true
+	}
+	if *f.analyzer != "" {
+		opts.Analyzer = *f.analyzer
+	}
+	if *f.advisories {
+		opts.Advisories = true
+	}
+	if *f.noGitHubCache {
+		opts.NoGitHubCache = true
+	}
+	if *f.noCache {
+		opts.NoCache = true
+	}
+	if *f.offline {
+		opts.Offline = true
+	}
+	if *f.workers > 0 {
+		opts.Workers = *f.workers
+	}
+	if *f.forgeWorkers > 0 {
+		opts.ForgeWorkers = *f.forgeWorkers
+	}
+	if *f.dockerWorkers > 0 {
+		opts.DockerWorkers = *f.dockerWorkers
+	}
+	if *f.contribMonths != 0 {
+		opts.ContributorsMonths = *f.contribMonths
+	}
+	if *f.contribCommits != 0 {
+		opts.ContributorsMinCommits = *f.contribCommits
+	}
+	if *f.bots != "" {
+		opts.Bots = *f.bots
+	}
+	return qsos.NewExecutor(opts)
+}
+
+// subdirFlags are the flags restricting the evaluation to a sub-directory of
+// the repositories, for the components of monorepos.
+type subdirFlags struct {
+	subdir  *string
+	commits *bool
+}
+
+func addSubdirFlags(fs *flag.FlagSet) *subdirFlags {
+	return &subdirFlags{
+		subdir:  fs.String("subdir", "", "restrict the tech stats to this sub-directory of the repositories, like services/api/"),
+		commits: fs.Bool("subdir-commits", false, "with --subdir, also restrict the community stats to the commits touching the sub-directory"),
+	}
+}
+
+// apply checks the sub-directory and sets it in the executor.
+func (f *subdirFlags) apply(fs *flag.FlagSet, executor *qsos.Executor) {
+	if *f.subdir == "" {
+		if *f.commits {
+			usageError(fs, "--subdir-commits requires --subdir")
+		}
+		return
+	}
+	subdir := filepath.Clean(*f.subdir)
+	if !filepath.IsLocal(subdir) {
+		usageError(fs, "invalid sub-directory %q, must be a relative path inside the repositories", *f.subdir)
+	}
+	executor.Subdir, executor.SubdirCommits = filepath.ToSlash(subdir), *f.commits
+}
+
+// outputFlags are the flags for the format and the destination of the
+// output of a command.
+type outputFlags struct {
+	formats []string
+	format  *string
+	output  *string
+}
+
+func addOutputFlags(fs *flag.FlagSet, formats ...string) *outputFlags {
+	return &outputFlags{
+		formats: formats,
+		format:  fs.String("format", formats[0], "format of the output: "+strings.Join(formats, " or ")),
+		output:  fs.String("output", "-", "file where the output is written (- for the standard output)"),
+	}
+}
+
+func (f *outputFlags) check(fs *flag.FlagSet) {
+	if !slices.Contains(f.formats, *f.format) {
+		usageError(fs, "invalid format %q, must be %s", *f.format, strings.Join(f.formats, " or "))
+	}
+}
+
+// open returns the writer for the output. It must be closed.
+func (f *outputFlags) open() (io.WriteCloser, error) {
+	if *f.output == "-" {
+		reportOptions.Colors = !noColor && isTerminal(os.Stdout)
+		return nopCloser{os.Stdout}, nil
+	}
+	file, err := os.Create(*f.output)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the output file: %w", err)
+	}
+	return file, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
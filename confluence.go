@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// Confluence publishes the reports to the pages of a Confluence space, with
+// the REST API of Confluence Cloud, Server and Data Center.
+type Confluence struct {
+	// URL is the base URL of Confluence, like
+	// https://example.atlassian.net/wiki.
+	URL string
+	// Username is the email of the account of the API token on Confluence
+	// Cloud. Without it, the token is a personal access token of Confluence
+	// Server or Data Center.
+	Username string
+	Token    string
+	Space    string
+	// Parent is the ID of the parent page of the new pages, optional.
+	Parent string
+	// Title is the title of the page of the reports.
+	Title string
+}
+
+// ConfluenceFromEnv returns the Confluence server configured by the
+// QSOS_CONFLUENCE_URL, QSOS_CONFLUENCE_USERNAME and QSOS_CONFLUENCE_TOKEN
+// env variables. It returns nil if QSOS_CONFLUENCE_URL is not set.
+func ConfluenceFromEnv() *Confluence {
+	base := os.Getenv("QSOS_CONFLUENCE_URL")
+	if base == "" {
+		return nil
+	}
+	return &Confluence{
+		URL:      strings.TrimSuffix(base, "/"),
+		Username: os.Getenv("QSOS_CONFLUENCE_USERNAME"),
+		Token:    os.Getenv("QSOS_CONFLUENCE_TOKEN"),
+	}
+}
+
+// confluencePage has the fields of the pages of the content API that are
+// used.
+type confluencePage struct {
+	ID        string               `json:"id,omitempty"`
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Space     *confluenceSpace     `json:"space,omitempty"`
+	Ancestors []confluenceAncestor `json:"ancestors,omitempty"`
+	Version   *confluenceVersion   `json:"version,omitempty"`
+	Body      *confluenceBody      `json:"body,omitempty"`
+	Links     *confluenceLinks     `json:"_links,omitempty"`
+}
+
+type confluenceAncestor struct {
+	ID string `json:"id"`
+}
+
+type confluenceLinks struct {
+	Base  string `json:"base"`
+	WebUI string `json:"webui"`
+}
+
+type confluenceSpace struct {
+	Key string `json:"key"`
+}
+
+type confluenceVersion struct {
+	Number int `json:"number"`
+}
+
+type confluenceBody struct {
+	Storage struct {
+		Value          string `json:"value"`
+		Representation string `json:"representation"`
+	} `json:"storage"`
+}
+
+// Publish writes the report of the evaluations to the page with the title in
+// the space, creating it under the parent page if it does not exist. It
+// returns the URL of the page.
+func (c *Confluence) Publish(evaluations []*qsos.Evaluation) (string, error) {
+	var content bytes.Buffer
+	if err := qsos.WriteConfluenceReport(&content, evaluations, reportOptions); err != nil {
+		return "", err
+	}
+	page := &confluencePage{Type: "page", Title: c.Title, Space: &confluenceSpace{Key: c.Space}, Body: &confluenceBody{}}
+	page.Body.Storage.Value = content.String()
+	page.Body.Storage.Representation = "storage"
+	if c.Parent != "" {
+		page.Ancestors = []confluenceAncestor{{ID: c.Parent}}
+	}
+
+	var found struct {
+		Results []confluencePage
+	}
+	query := url.Values{"spaceKey": {c.Space}, "title": {c.Title}, "type": {"page"}, "expand": {"version"}}
+	if err := c.do(http.MethodGet, "/rest/api/content?"+query.Encode(), nil, &found); err != nil {
+		return "", fmt.Errorf("Cannot search the Confluence page: %w", err)
+	}
+	var published confluencePage
+	if len(found.Results) == 0 {
+		if err := c.do(http.MethodPost, "/rest/api/content", page, &published); err != nil {
+			return "", fmt.Errorf("Cannot create the Confluence page: %w", err)
+		}
+	} else {
+		previous := found.Results[0]
+		page.ID = previous.ID
+		page.Version = &confluenceVersion{Number: 1}
+		if previous.Version != nil {
+			page.Version.Number = previous.Version.Number + 1
+		}
+		if err := c.do(http.MethodPut, "/rest/api/content/"+previous.ID, page, &published); err != nil {
+			return "", fmt.Errorf("Cannot update the Confluence page: %w", err)
+		}
+	}
+	if published.Links == nil {
+		return "", nil
+	}
+	return published.Links.Base + published.Links.WebUI, nil
+}
+
+// do sends a request to the REST API, and decodes its JSON response.
+func (c *Confluence) do(method, path string, body, response any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Token)
+	} else if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	res, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected response: %d %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(res.Body).Decode(response)
+}
+
+// confluenceFlags are the flags of the publication of the reports to
+// Confluence.
+type confluenceFlags struct {
+	space  *string
+	parent *string
+	title  *string
+}
+
+func addConfluenceFlags(fs *flag.FlagSet) *confluenceFlags {
+	return &confluenceFlags{
+		space:  fs.String("confluence-space", "", "publish the report to a page of this Confluence space, with the QSOS_CONFLUENCE_* settings"),
+		parent: fs.String("confluence-parent", "", "with --confluence-space, the ID of the parent page of the report"),
+		title:  fs.String("confluence-title", "QSOS report", "with --confluence-space, the title of the page of the report, updated by the next runs"),
+	}
+}
+
+// confluence returns the Confluence space of the flags, or nil if the
+// reports are not published.
+func (f *confluenceFlags) confluence() *Confluence {
+	if *f.space == "" {
+		return nil
+	}
+	confluence := ConfluenceFromEnv()
+	if confluence == nil {
+		fatal(errors.New("QSOS_CONFLUENCE_URL environment variable is not set, it is needed with --confluence-space"))
+	}
+	confluence.Space, confluence.Parent, confluence.Title = *f.space, *f.parent, *f.title
+	return confluence
+}
+
+// publishReport publishes the report of the evaluations to Confluence. The
+// errors are logged.
+func publishReport(confluence *Confluence, evaluations []*qsos.Evaluation) {
+	page, err := confluence.Publish(evaluations)
+	if err != nil {
+		slog.Error("cannot publish the report", "err", err)
+		return
+	}
+	slog.Info("report published to Confluence", "url", page)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// Daemon re-evaluates a list of projects on a cron schedule, saves the
+// evaluations in the history, and notifies the changes of their scores.
+type Daemon struct {
+	Executor *qsos.Executor
+	Config   *qsos.Config
+	History  *qsos.History
+	Cron     *qsos.CronSchedule
+	// Projects are the projects to evaluate, with the ones of List.
+	Projects []string
+	// List is a file with one project per line, read again at each run.
+	List string
+	// Paths are the local working copies to evaluate.
+	Paths []string
+	// Tags are the tags of the evaluations saved in the history.
+	Tags []string
+	// Notifier is optional. It notifies the evaluations, on the changes of
+	// their scores by default.
+	Notifier *Notifier
+	// Reports is optional. It sends the report of each run by email.
+	Reports *ReportMailer
+}
+
+// Run runs the evaluations at the times of the schedule, and right away if
+// now is set, until the context is canceled. It fails if the schedule has no
+// next run.
+func (d *Daemon) Run(ctx context.Context, now bool) error {
+	if d.Cron.Next(time.Now()).IsZero() {
+		return errors.New("The cron schedule never matches")
+	}
+	for {
+		if !now {
+			next := d.Cron.Next(time.Now())
+			if next.IsZero() {
+				return errors.New("The cron schedule has no next run")
+			}
+			slog.Info("waiting for the next run", "at", next.Format(time.DateTime))
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+		now = false
+		err := d.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	}
+}
+
+// RunOnce evaluates the projects, and notifies their score changes. When
+// the context is canceled, the evaluations done are still notified, and the
+// error of the context is returned.
+func (d *Daemon) RunOnce(ctx context.Context) error {
+	projects := d.Projects
+	if d.List != "" {
+		listed, err := qsos.ReadProjectList(d.List)
+		if err != nil {
+			return err
+		}
+		projects = append(projects[:len(projects):len(projects)], listed...)
+	}
+	start := time.Now()
+	slog.Info("evaluating the projects", "projects", len(projects)+len(d.Paths))
+	evaluations, errs := evaluateProjects(ctx, d.Executor, d.Config, d.History, nil, projects, d.Paths, "", d.Tags)
+	var notifyErrs []error
+	if d.Notifier != nil {
+		for _, evaluation := range evaluations {
+			if err := d.Notifier.Notify(evaluation, start); err != nil {
+				notifyErrs = append(notifyErrs, err)
+			}
+		}
+	}
+	if d.Reports != nil && len(evaluations) > 0 {
+		if err := d.Reports.Send(evaluations); err != nil {
+			notifyErrs = append(notifyErrs, err)
+		}
+	}
+	slog.Info("run done", "evaluated", len(evaluations), "failed", len(errs), "duration", time.Since(start).Round(time.Second))
+	return errors.Join(append(notifyErrs, ctx.Err())...)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// TestDaemonInterrupted checks that an interrupted run returns, instead of
+// exiting like the CLI.
+func TestDaemonInterrupted(t *testing.T) {
+	executor, err := qsos.NewExecutor(&qsos.ExecutorOptions{Local: true, Analyzer: "lite", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cron, err := qsos.ParseCron("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{Executor: executor, Config: qsos.DefaultConfig(), Cron: cron, Paths: []string{t.TempDir()}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := daemon.RunOnce(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunOnce = %v, want %v", err, context.Canceled)
+	}
+	if err := daemon.Run(ctx, true); err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// dashboardProject is a project of the history, with its evaluations from
+// the oldest.
+type dashboardProject struct {
+	Name    string
+	Records []*qsos.HistoryRecord
+}
+
+func (p *dashboardProject) Latest() *qsos.HistoryRecord {
+	return p.Records[len(p.Records)-1]
+}
+
+// historyProjects returns the projects of the history, by name.
+func historyProjects(history *qsos.History, filter *qsos.HistoryFilter) ([]*dashboardProject, error) {
+	records, err := history.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(records, func(a, b *qsos.HistoryRecord) int {
+		return a.Date.Compare(b.Date)
+	})
+	byName := map[string]*dashboardProject{}
+	var projects []*dashboardProject
+	for _, record := range records {
+		// The names are not case sensitive on the forges
+		key := strings.ToLower(record.Evaluation.Name())
+		project, ok := byName[key]
+		if !ok {
+			project = &dashboardProject{Name: record.Evaluation.Name()}
+			byName[key] = project
+			projects = append(projects, project)
+		}
+		project.Records = append(project.Records, record)
+	}
+	slices.SortFunc(projects, func(a, b *dashboardProject) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return projects, nil
+}
+
+var dashboardTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	// scoreClass returns the CSS class of a score, from s1 to s5
+	"scoreClass": func(score float64) string {
+		return fmt.Sprintf("s%.0f", min(max(score, 1), 5))
+	},
+	"float":    func(score *int64) float64 { return float64(*score) },
+	"contains": slices.Contains[[]int],
+	"join":     strings.Join,
+}).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>QSOS dashboard{{with .Title}} - {{.}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+td.score { text-align: right; }
+.s1, .s2 { background: #f4cccc; }
+.s3 { background: #fff2cc; }
+.s4, .s5 { background: #d9ead3; }
+.best { font-weight: bold; }
+.flag { color: #c00; }
+</style>
+</head>
+<body>
+<p><a href="/dashboard">Projects</a> | <a href="/requests">Request an evaluation</a></p>
+<h1>{{or .Title "Evaluated projects"}}</h1>
+{{end}}
+
+{{define "index"}}{{template "header" .}}
+{{if .Projects}}
+<form action="/dashboard/compare">
+<table>
+<tr><th></th><th>Project</th><th>Last evaluation</th><th>Evaluations</th><th>overall</th>
+{{range .Criteria}}<th>{{.}}</th>{{end}}</tr>
+{{range .Projects}}{{$scores := .Latest.Evaluation.Scores}}
+<tr><td><input type="checkbox" name="project" value="{{.Name}}"></td>
+<td><a href="/dashboard/projects/{{.Name}}">{{.Name}}</a>{{if .Latest.Evaluation.RedFlags}} <span class="flag">&#9873;</span>{{end}}</td>
+<td>{{.Latest.Date.Format "2006-01-02"}}</td>
+<td class="score">{{len .Records}}</td>
+<td class="score {{scoreClass $scores.Overall}}">{{printf "%.2f" $scores.Overall}}</td>
+{{range $scores.Criteria}}<td class="score {{scoreClass (float .Score)}}">{{.Score}}</td>{{end}}</tr>
+{{end}}
+</table>
+<p><button type="submit">Compare the selected projects</button></p>
+</form>
+{{else}}<p>No evaluation in the history.</p>
+{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "project"}}{{template "header" .}}
+{{with .Project.Latest}}
+{{range .Evaluation.RedFlags}}<p class="flag"><strong>Red flag</strong>: {{.Message}}</p>
+{{end}}
+<h2>Last evaluation, {{.Date.Format "2006-01-02 15:04"}}</h2>
+<table>
+<tr><th>Criterion</th><th>Score</th></tr>
+{{range .Evaluation.Scores.Criteria}}<tr><td>{{.Name}}</td><td class="score {{scoreClass (float .Score)}}">{{.Score}}</td></tr>
+{{end}}<tr><th>overall</th><th class="score {{scoreClass .Evaluation.Scores.Overall}}">{{printf "%.2f" .Evaluation.Scores.Overall}}</th></tr>
+</table>
+{{end}}
+{{.Radar}}
+<h2>Trend</h2>
+{{.Trend}}
+<h2>Evaluations</h2>
+<table>
+<tr><th>Date</th><th>overall</th><th>Tags</th><th></th></tr>
+{{range .Records}}<tr><td>{{.Date.Format "2006-01-02 15:04"}}</td><td class="score {{scoreClass .Evaluation.Scores.Overall}}">{{printf "%.2f" .Evaluation.Scores.Overall}}</td>
+<td>{{join .Tags ", "}}</td><td><a href="/api/history/{{.ID}}">JSON</a></td></tr>
+{{end}}
+</table>
+</body>
+</html>
+{{end}}
+
+{{define "compare"}}{{template "header" .}}
+{{range $project, $flags := .Comparison.RedFlags}}{{range $flags}}<p class="flag"><strong>Red flag</strong>: {{$project}}: {{.Message}}</p>
+{{end}}{{end}}
+<table>
+<tr><th>Criterion</th>{{range .Comparison.Projects}}<th><a href="/dashboard/projects/{{.}}">{{.}}</a></th>{{end}}</tr>
+{{range .Comparison.Rows}}{{$row := .}}<tr><td>{{.Criterion}}</td>
+{{range $i, $score := .Scores}}<td class="score {{scoreClass $score}}{{if contains $row.Best $i}} best{{end}}">{{if eq $row.Criterion "overall"}}{{printf "%.2f" $score}}{{else}}{{$score}}{{end}}</td>{{end}}</tr>
+{{end}}
+</table>
+</body>
+</html>
+{{end}}
+`))
+
+type dashboardPage struct {
+	Title string
+	// Index
+	Projects []*dashboardProject
+	Criteria []string
+	// Project
+	Project *dashboardProject
+	Records []*qsos.HistoryRecord
+	Radar   template.HTML
+	Trend   template.HTML
+	// Comparison
+	Comparison *qsos.Comparison
+}
+
+func (s *Server) renderDashboard(w http.ResponseWriter, name string, page *dashboardPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplates.ExecuteTemplate(w, name, page); err != nil {
+		slog.Error("cannot write response", "err", err)
+	}
+}
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	projects, err := historyProjects(s.History, &qsos.HistoryFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page := &dashboardPage{Projects: projects}
+	if len(projects) > 0 {
+		for _, criterion := range projects[0].Latest().Evaluation.Scores.Criteria() {
+			page.Criteria = append(page.Criteria, criterion.Name)
+		}
+	}
+	s.renderDashboard(w, "index", page)
+}
+
+func (s *Server) handleDashboardProject(w http.ResponseWriter, r *http.Request) {
+	filter := &qsos.HistoryFilter{Owner: r.PathValue("owner"), Repo: r.PathValue("repo")}
+	projects, err := historyProjects(s.History, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(projects) == 0 {
+		http.Error(w, "no evaluation of the project in the history", http.StatusNotFound)
+		return
+	}
+	project := projects[0]
+	page := &dashboardPage{Title: project.Name, Project: project, Records: slices.Clone(project.Records)}
+	slices.Reverse(page.Records)
+	var radar, trend bytes.Buffer
+	if err := qsos.WriteRadarSVG(&radar, project.Latest().Evaluation); err != nil {
+		slog.Error("cannot draw the radar", "project", project.Name, "err", err)
+	}
+	if evolution, err := qsos.ComputeTrend(project.Records, nil); err != nil {
+		slog.Error("cannot compute the trend", "project", project.Name, "err", err)
+	} else if err := qsos.WriteTrendSVG(&trend, evolution); err != nil {
+		slog.Error("cannot draw the trend", "project", project.Name, "err", err)
+	}
+	// The SVG are generated with escaped texts
+	page.Radar, page.Trend = template.HTML(radar.String()), template.HTML(trend.String())
+	s.renderDashboard(w, "project", page)
+}
+
+// handleDashboardCompare compares the last evaluations of the projects given
+// by the project query parameters.
+func (s *Server) handleDashboardCompare(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["project"]
+	if len(names) < 2 {
+		http.Error(w, "at least 2 projects are needed", http.StatusBadRequest)
+		return
+	}
+	var evaluations []*qsos.Evaluation
+	for _, name := range names {
+		owner, repo, err := qsos.ParseProject(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		projects, err := historyProjects(s.History, &qsos.HistoryFilter{Owner: owner, Repo: repo})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(projects) == 0 {
+			http.Error(w, fmt.Sprintf("%s: %s", qsos.ErrNotInHistory, name), http.StatusNotFound)
+			return
+		}
+		evaluations = append(evaluations, projects[0].Latest().Evaluation)
+	}
+	s.renderDashboard(w, "compare", &dashboardPage{Title: "Comparison", Comparison: qsos.Compare(evaluations)})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// stringsFlag is a flag that can be repeated.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// scoresFlag is a repeatable flag for criteria scores, like
+// --min-score tech.codesmells=3.
+type scoresFlag map[string]int64
+
+func (s scoresFlag) String() string {
+	var parts []string
+	for name, score := range s {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, score))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s scoresFlag) Set(value string) error {
+	name, score, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("must be in the format criterion=score")
+	}
+	if !qsos.IsCriterion(name) {
+		return fmt.Errorf("unknown criterion %s", name)
+	}
+	nb, err := strconv.ParseInt(score, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid score %q", score)
+	}
+	s[name] = nb
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+	"github.com/linagora/qsos-lng/pkg/qsospb"
+)
+
+// progressHub dispatches the progress of the evaluations of the server to
+// the gRPC streams, by project.
+type progressHub struct {
+	mu          sync.Mutex
+	subscribers map[*progressSubscriber]struct{}
+}
+
+type progressSubscriber struct {
+	owner  string
+	repo   string
+	events chan *qsos.ProgressEvent
+}
+
+// subscribe returns the progress events of the evaluations of a project, and
+// the function ending the subscription.
+func (h *progressHub) subscribe(owner, repo string) (<-chan *qsos.ProgressEvent, func()) {
+	subscriber := &progressSubscriber{owner: owner, repo: repo, events: make(chan *qsos.ProgressEvent, 64)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = map[*progressSubscriber]struct{}{}
+	}
+	h.subscribers[subscriber] = struct{}{}
+	return subscriber.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, subscriber)
+	}
+}
+
+// dispatch receives the progress of the evaluations of the server. The events are dropped
+// for the streams that are too slow, so that they never block the
+// evaluations.
+func (h *progressHub) dispatch(event *qsos.ProgressEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscriber := range h.subscribers {
+		if !event.IsFor(subscriber.owner, subscriber.repo) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+		}
+	}
+}
+
+// grpcServer implements the Evaluations gRPC service, with the evaluations
+// of the HTTP server.
+type grpcServer struct {
+	qsospb.UnimplementedEvaluationsServer
+	server *Server
+}
+
+// newGRPCServer returns the gRPC server of the evaluations.
+func (s *Server) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer()
+	qsospb.RegisterEvaluationsServer(server, &grpcServer{server: s})
+	return server
+}
+
+func (g *grpcServer) Evaluate(req *qsospb.EvaluateRequest, stream grpc.ServerStreamingServer[qsospb.EvaluateEvent]) error {
+	s := g.server
+	if s.draining.Load() {
+		return status.Error(codes.Unavailable, "shutting down")
+	}
+	executor, owner, repo, err := s.forProject(req.Project)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	select {
+	case s.inFlight <- struct{}{}:
+		defer func() { <-s.inFlight }()
+	default:
+		return status.Error(codes.ResourceExhausted, "too many evaluations in progress")
+	}
+
+	events, unsubscribe := s.progress.subscribe(owner, repo)
+	defer unsubscribe()
+	type result struct {
+		evaluation *qsos.Evaluation
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		evaluation, err := qsos.Evaluate(s.evaluations, executor, s.Config, owner, repo, "")
+		done <- result{evaluation, err}
+	}()
+	// The events are sent until the end of the evaluation, even if the
+	// client has gone, like with the HTTP API
+	streaming := true
+	for {
+		select {
+		case event := <-events:
+			if streaming {
+				streaming = stream.Send(progressMessage(event)) == nil
+			}
+		case res := <-done:
+			for len(events) > 0 {
+				if event := <-events; streaming {
+					streaming = stream.Send(progressMessage(event)) == nil
+				}
+			}
+			if res.err != nil {
+				slog.Error(res.err.Error(), "project", req.Project)
+				return status.Error(codes.Unavailable, res.err.Error())
+			}
+			var recordID string
+			if s.History != nil {
+				if record, err := s.History.Save(res.evaluation, req.Tags); err != nil {
+					slog.Error(err.Error(), "project", req.Project)
+				} else {
+					recordID = record.ID
+				}
+			}
+			message, err := resultMessage(res.evaluation, recordID)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			return stream.Send(message)
+		}
+	}
+}
+
+func progressMessage(event *qsos.ProgressEvent) *qsospb.EvaluateEvent {
+	progress := &qsospb.Progress{
+		Phase: event.Phase,
+		Step:  event.Step,
+		Count: int32(event.Count),
+		Total: int32(event.Total),
+		Time:  timestamppb.New(time.Now()),
+	}
+	if event.Elapsed > 0 {
+		progress.Elapsed = durationpb.New(event.Elapsed)
+	}
+	return &qsospb.EvaluateEvent{Event: &qsospb.EvaluateEvent_Progress{Progress: progress}}
+}
+
+func resultMessage(evaluation *qsos.Evaluation, recordID string) (*qsospb.EvaluateEvent, error) {
+	data, err := json.Marshal(evaluation)
+	if err != nil {
+		return nil, err
+	}
+	result := &qsospb.Result{
+		Project:    evaluation.Name(),
+		Overall:    evaluation.Scores.Overall,
+		RecordId:   recordID,
+		Evaluation: string(data),
+	}
+	for _, criterion := range evaluation.Scores.Criteria() {
+		result.Criteria = append(result.Criteria, &qsospb.CriterionScore{Name: criterion.Name, Score: *criterion.Score})
+	}
+	for _, flag := range evaluation.RedFlags {
+		result.RedFlags = append(result.RedFlags, flag.Message)
+	}
+	return &qsospb.EvaluateEvent{Event: &qsospb.EvaluateEvent_Result{Result: result}}, nil
+}
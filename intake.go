@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// Mailer sends the notifications and the reports of the evaluations by
+// email.
+type Mailer struct {
+	// Addr is the host:port of the SMTP server.
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// MailerFromEnv returns the mailer configured by the QSOS_SMTP_ADDR,
+// QSOS_SMTP_FROM, QSOS_SMTP_USERNAME and QSOS_SMTP_PASSWORD env variables.
+// It returns nil if QSOS_SMTP_ADDR is not set.
+func MailerFromEnv() *Mailer {
+	addr := os.Getenv("QSOS_SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	return &Mailer{
+		Addr:     addr,
+		From:     os.Getenv("QSOS_SMTP_FROM"),
+		Username: os.Getenv("QSOS_SMTP_USERNAME"),
+		Password: os.Getenv("QSOS_SMTP_PASSWORD"),
+	}
+}
+
+func (m *Mailer) Send(to, subject, body string) error {
+	msg := fmt.Sprintf("Content-Type: text/plain; charset=utf-8\r\n\r\n%s", strings.ReplaceAll(body, "\n", "\r\n"))
+	return m.send(to, subject, msg)
+}
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// SendAttachments sends an email with attached files.
+func (m *Mailer) SendAttachments(to, subject, body string, attachments []Attachment) error {
+	var msg bytes.Buffer
+	parts := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+	text, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return err
+	}
+	text.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	for _, attachment := range attachments {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			return err
+		}
+		// The lines of base64 are at most 76 characters long
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+	if err := parts.Close(); err != nil {
+		return err
+	}
+	return m.send(to, subject, msg.String())
+}
+
+// send sends a message, given with its content headers.
+func (m *Mailer) send(to, subject, msg string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, _ := strings.Cut(m.Addr, ":")
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	headers := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n",
+		m.From, to, mime.QEncoding.Encode("utf-8", subject))
+	if err := smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(headers+msg)); err != nil {
+		return fmt.Errorf("Cannot send email to %s: %w", to, err)
+	}
+	return nil
+}
+
+// runIntake runs the queued evaluation requests, until the context is
+// canceled. The requests that were queued or running when the server was
+// stopped are run on the restart.
+func (s *Server) runIntake(ctx context.Context) {
+	for {
+		s.runQueuedRequests(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.requests:
+		}
+	}
+}
+
+func (s *Server) runQueuedRequests(ctx context.Context) {
+	requests, err := s.History.ListRequests()
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	for _, request := range requests {
+		if request.Status != qsos.RequestQueued && request.Status != qsos.RequestRunning {
+			continue
+		}
+		// The requested evaluations share the slots with the API ones
+		select {
+		case s.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		s.runRequest(request)
+		<-s.inFlight
+	}
+}
+
+// runRequest evaluates the project of a request, saves the evaluation in the
+// history, and notifies the requester.
+func (s *Server) runRequest(request *qsos.IntakeRequest) {
+	request.Status = qsos.RequestRunning
+	if err := s.History.SaveRequest(request); err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	slog.Info("running request", "request", request.ID, "project", request.Project)
+	record, err := s.evaluateRequest(request)
+	request.FinishedAt = time.Now().UTC()
+	if err != nil {
+		slog.Error(err.Error(), "request", request.ID)
+		request.Status = qsos.RequestFailed
+		request.Error = err.Error()
+	} else {
+		request.Status = qsos.RequestDone
+		request.RecordID = record.ID
+	}
+	if err := s.History.SaveRequest(request); err != nil {
+		slog.Error(err.Error())
+	}
+	if err := s.notifyRequester(request); err != nil {
+		slog.Error(err.Error(), "request", request.ID)
+	}
+}
+
+func (s *Server) evaluateRequest(request *qsos.IntakeRequest) (*qsos.HistoryRecord, error) {
+	config, err := s.profileConfig(request.Profile)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo, err := qsos.ParseProject(request.Project)
+	if err != nil {
+		return nil, err
+	}
+	evaluation, err := qsos.Evaluate(s.evaluations, s.Executor, config, owner, repo, "")
+	if err != nil {
+		return nil, err
+	}
+	tags := request.Tags
+	if len(tags) == 0 {
+		tags = []string{"requested"}
+	}
+	return s.History.Save(evaluation, tags)
+}
+
+func (s *Server) notifyRequester(request *qsos.IntakeRequest) error {
+	if request.Email == "" || s.Mailer == nil {
+		return nil
+	}
+	subject := fmt.Sprintf("QSOS evaluation of %s: %s", request.Project, request.Status)
+	body := fmt.Sprintf("The evaluation of %s is %s.\n", request.Project, request.Status)
+	if request.Error != "" {
+		body += "\nError: " + request.Error + "\n"
+	}
+	body += "\nSee " + s.PublicURL + "/requests/" + request.ID + "\n"
+	return s.Mailer.Send(request.Email, subject, body)
+}
+
+// IntakeForm is the body of the API requests for an evaluation.
+type IntakeForm struct {
+	// URL is the URL of the GitHub repository, or owner/repo.
+	URL     string
+	Profile string
+	Email   string
+}
+
+// queueRequest validates and saves a new evaluation request, and wakes up
+// the intake.
+func (s *Server) queueRequest(form *IntakeForm) (*qsos.IntakeRequest, error) {
+	request, err := qsos.NewIntakeRequest(form.URL, form.Profile, form.Email)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.profileConfig(request.Profile); err != nil {
+		return nil, err
+	}
+	if err := s.enqueue(request); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInternal, err)
+	}
+	return request, nil
+}
+
+// enqueue saves a new evaluation request, and wakes up the intake.
+func (s *Server) enqueue(request *qsos.IntakeRequest) error {
+	if err := s.History.SaveRequest(request); err != nil {
+		return err
+	}
+	select {
+	case s.requests <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+var errInternal = errors.New("internal error")
+
+func requestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, qsos.ErrNoSuchRequest):
+		return http.StatusNotFound
+	case errors.Is(err, errInternal):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// profiles returns the names of the configurations of the profiles dir.
+func (s *Server) profiles() []string {
+	if s.ProfilesDir == "" {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(s.ProfilesDir, "*.json"))
+	var profiles []string
+	for _, path := range paths {
+		profiles = append(profiles, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	return profiles
+}
+
+func (s *Server) handleRequestCreate(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	var form IntakeForm
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	request, err := s.queueRequest(&form)
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+	w.Header().Set("Location", "/api/requests/"+request.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, request)
+}
+
+func (s *Server) handleRequestGet(w http.ResponseWriter, r *http.Request) {
+	request, err := s.History.GetRequest(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+	writeJSON(w, request)
+}
+
+var intakeTemplates = template.Must(template.New("").Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if .Refresh}}<meta http-equiv="refresh" content="30">{{end}}
+<title>QSOS evaluation request</title>
+</head>
+<body>
+<h1>QSOS evaluation request</h1>
+{{end}}
+
+{{define "form"}}{{template "header" .}}
+{{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+<form method="post" action="/requests">
+<p><label>Repository URL<br><input name="URL" size="60" placeholder="https://github.com/owner/repo" value="{{.Form.URL}}" required></label></p>
+<p><label>Profile<br><select name="Profile">
+<option value="">default</option>
+{{range .Profiles}}<option{{if eq . $.Form.Profile}} selected{{end}}>{{.}}</option>
+{{end}}</select></label></p>
+<p><label>Email, to be notified when the evaluation is done (optional)<br><input name="Email" type="email" size="40" value="{{.Form.Email}}"></label></p>
+<p><button type="submit">Request the evaluation</button></p>
+</form>
+</body>
+</html>
+{{end}}
+
+{{define "status"}}{{template "header" .}}
+<p>Evaluation of <strong>{{.Request.Project}}</strong>{{with .Request.Profile}} with the {{.}} profile{{end}}: {{.Request.Status}}.</p>
+{{if .Refresh}}<p>This page is refreshed every 30 seconds.</p>{{end}}
+{{with .Request.Error}}<p>Error: {{.}}</p>{{end}}
+{{with .Record}}
+<table>
+<tr><th>Criterion</th><th>Score</th></tr>
+{{range .Evaluation.Scores.Criteria}}<tr><td>{{.Name}}</td><td>{{.Score}}</td></tr>
+{{end}}<tr><th>overall</th><th>{{printf "%.2f" .Evaluation.Scores.Overall}}</th></tr>
+</table>
+{{range .Evaluation.RedFlags}}<p><strong>Red flag</strong>: {{.Message}}</p>
+{{end}}
+<p><a href="/api/history/{{.ID}}">Full evaluation (JSON)</a></p>
+{{end}}
+<p><a href="/requests">New request</a></p>
+</body>
+</html>
+{{end}}
+`))
+
+type intakePage struct {
+	Refresh  bool
+	Error    string
+	Form     *IntakeForm
+	Profiles []string
+	Request  *qsos.IntakeRequest
+	Record   *qsos.HistoryRecord
+}
+
+func (s *Server) renderIntake(w http.ResponseWriter, status int, name string, page *intakePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := intakeTemplates.ExecuteTemplate(w, name, page); err != nil {
+		slog.Error("cannot write response", "err", err)
+	}
+}
+
+func (s *Server) handleIntakeForm(w http.ResponseWriter, r *http.Request) {
+	s.renderIntake(w, http.StatusOK, "form", &intakePage{Form: &IntakeForm{}, Profiles: s.profiles()})
+}
+
+func (s *Server) handleIntakeSubmit(w http.ResponseWriter, r *http.Request) {
+	form := &IntakeForm{URL: r.FormValue("URL"), Profile: r.FormValue("Profile"), Email: r.FormValue("Email")}
+	if s.draining.Load() {
+		s.renderIntake(w, http.StatusServiceUnavailable, "form", &intakePage{Error: "The server is shutting down, retry later.", Form: form, Profiles: s.profiles()})
+		return
+	}
+	request, err := s.queueRequest(form)
+	if err != nil {
+		s.renderIntake(w, requestErrorStatus(err), "form", &intakePage{Error: err.Error(), Form: form, Profiles: s.profiles()})
+		return
+	}
+	http.Redirect(w, r, "/requests/"+request.ID, http.StatusSeeOther)
+}
+
+func (s *Server) handleIntakeStatus(w http.ResponseWriter, r *http.Request) {
+	request, err := s.History.GetRequest(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+	page := &intakePage{
+		Refresh: request.Status == qsos.RequestQueued || request.Status == qsos.RequestRunning,
+		Request: request,
+	}
+	if request.RecordID != "" {
+		if page.Record, err = s.History.Get(request.RecordID); err != nil {
+			slog.Error(err.Error(), "request", request.ID)
+		}
+	}
+	s.renderIntake(w, http.StatusOK, "status", page)
+}
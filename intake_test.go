@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+func TestIntakeRequests(t *testing.T) {
+	history, err := qsos.OpenHistory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(nil, qsos.DefaultConfig(), history, 1)
+	handler := server.Handler()
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"URL": "https://github.com/minio/minio", "Email": "dev@example.com"}`, http.StatusAccepted},
+		{`{"URL": "github.com/minio/minio.git"}`, http.StatusAccepted},
+		{`{"URL": "https://gitlab.com/group/repo"}`, http.StatusBadRequest},
+		{`{"URL": "https://github.com/minio/minio", "Email": "not an email"}`, http.StatusBadRequest},
+		{`{"URL": "https://github.com/minio/minio", "Profile": "storage"}`, http.StatusBadRequest},
+		{`{"URL": "https://github.com/minio/minio", "Profile": "../config"}`, http.StatusBadRequest},
+		{`not JSON`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(test.body)))
+		if w.Code != test.status {
+			t.Errorf("POST /api/requests %s = %d %s, want %d", test.body, w.Code, w.Body, test.status)
+			continue
+		}
+		if w.Code != http.StatusAccepted {
+			continue
+		}
+		var request qsos.IntakeRequest
+		if err := json.Unmarshal(w.Body.Bytes(), &request); err != nil {
+			t.Fatal(err)
+		}
+		if request.Project != "minio/minio" || request.Status != qsos.RequestQueued {
+			t.Errorf("request = %+v, want a queued request for minio/minio", request)
+		}
+		location := w.Header().Get("Location")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, location, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), request.ID) {
+			t.Errorf("GET %s = %d %s, want the request", location, w.Code, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/requests/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /api/requests/unknown = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// The form redirects to the status page of the request
+	form := url.Values{"URL": {"https://github.com/minio/minio"}}
+	req := httptest.NewRequest(http.MethodPost, "/requests", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if location := w.Header().Get("Location"); w.Code != http.StatusSeeOther || !strings.HasPrefix(location, "/requests/") {
+		t.Fatalf("POST /requests = %d to %q, want a redirection to the request", w.Code, location)
+	}
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, w.Header().Get("Location"), nil))
+	if w2.Code != http.StatusOK || !strings.Contains(w2.Body.String(), "minio/minio") {
+		t.Errorf("GET %s = %d, want the status page of the request", w.Header().Get("Location"), w2.Code)
+	}
+}
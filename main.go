@@ -1,110 +1,1108 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+	"golang.org/x/sync/errgroup"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatalf("Usage: go run . <owner/repo>")
-	}
-
-	parts := strings.Split(os.Args[1], "/")
-	if len(parts) != 2 {
-		log.Fatalf("Invalid project format. Must be in the format: owner/repo")
-	}
-	owner, repo := parts[0], parts[1]
-
-	executor, err := NewExecutorFromEnv()
-	if err != nil {
-		log.Fatalf("ERROR: %s", err)
-	}
-
-	stats, err := executor.GetProjectStats(owner, repo)
-	if err != nil {
-		log.Fatalf("Failed to retrieve repository statistics: %v", err)
-	}
-
-	day := (24 * 60 * 60 * time.Second).Nanoseconds()
-	month := 30 * day
-	year := 365 * day
-	thresholds := &Thresholds{
-		Community: &CommunityThreshold{
-			Maturity:     [4]int64{1 * year, 5 * year, 10 * year, 20 * year},
-			Activity:     [4]int64{1 * month, 6 * month, 1 * year, 2 * year},
-			Popularity:   [4]int64{5_000, 20_000, 40_000, 80_000},
-			Contributors: [4]int64{1, 5, 20, 50},
-		},
-		Tech: &TechThreshold{
-			Size:                 [4]int64{1_000, 10_000, 100_000, 1_000_000},
-			CyclomaticComplexity: [4]int64{1, 5, 10, 20},
-			CognitiveComplexity:  [4]int64{1, 3, 5, 10},
-			Duplication:          [4]int64{3, 5, 10, 20},
-			CodeSmells:           [4]int64{50, 200, 500, 1_000},
-		},
-	}
-
-	weights := &Weights{
-		// https://scorecard.dev/#the-checks
-		// 1 for low upto 4 for critical
-		ScoreCard: map[string]int64{
-			"Vulnerabilities":        2, // Only known vulnerabilities, so it may give better scores for less known projects
-			"Dependency-Update-Tool": 3,
-			// "Maintained" is disabled, as it's already in the community section
-			"Security-Policy": 2,
-			// "License" is disabled, as it's not for the security section
-			// "CII-Best-Practices" is disabled, as it's not relevant for us
-			// "CI-Tests" is disabled, as it's for more for the tech section
-			"Fuzzing":            1, // Only some tools are detected
-			"SAST":               1, // Only some tools are detected
-			"Binary-Artifacts":   3,
-			"Branch-Protection":  3,
-			"Dangerous-Workflow": 4,
-			"Code-Review":        3,
-			// "Contributors" is disabled, as it's already in the community section
-			"Pinned-Dependencies": 2,
-			"Token-Permissions":   3,
-			"Packaging":           2,
-			"Signed-Releases":     3,
-		},
-	}
-
-	fmt.Printf("\n--- GitHub Project Statistics ---\n")
-	fmt.Printf("Date of the First Commit: %s\n", stats.GitHub.FirstCommitDate.Format("2006-01-02 15:04:05 MST"))
-	fmt.Printf("Date of the Last Commit:  %s\n", stats.GitHub.LastCommitDate.Format("2006-01-02 15:04:05 MST"))
-	fmt.Printf("Number of Stars:          %d\n", stats.GitHub.Stars)
-	fmt.Printf("Active contributors:      %d\n", stats.GitHub.ActiveContributors)
-	fmt.Printf("\n--- Sonarqube Statistics ---\n")
-	fmt.Printf("Number of lines of code: %d\n", stats.Sonar.LinesOfCode)
-	fmt.Printf("Number of functions:     %d\n", stats.Sonar.Functions)
-	fmt.Printf("Cyclomatic complexity:   %d\n", stats.Sonar.CyclomaticComplexity)
-	fmt.Printf("Cognitive complexity:    %d\n", stats.Sonar.CognitiveComplexity)
-	fmt.Printf("Brain-overload issues:   %d\n", stats.Sonar.BrainOverload)
-	fmt.Printf("Number of code smells:   %d\n", stats.Sonar.CodeSmells)
-	fmt.Printf("Duplication density:     %.1f\n", stats.Sonar.DuplicationDensity)
-	fmt.Printf("\n--- ScoreCard checks ---\n")
-	for _, check := range stats.ScoreCard.Checks {
-		fmt.Printf("%-24s: %d\n", check.Name, check.Score)
-	}
-
-	scores := ComputeScores(stats, thresholds, weights)
-	fmt.Printf("\n--- Community ---\n")
-	fmt.Printf("Maturity:     %d\n", scores.Community.Maturity)
-	fmt.Printf("Activity:     %d\n", scores.Community.Activity)
-	fmt.Printf("Popularity:   %d\n", scores.Community.Popularity)
-	fmt.Printf("Contributors: %d\n", scores.Community.Contributors)
-	fmt.Printf("\n--- Tech ---\n")
-	fmt.Printf("Code size:             %d\n", scores.Tech.Size)
-	fmt.Printf("Cyclomatic complexity: %d\n", scores.Tech.CyclomaticComplexity)
-	fmt.Printf("Cognitive complexity:  %d\n", scores.Tech.CognitiveComplexity)
-	fmt.Printf("Duplication:           %d\n", scores.Tech.Duplication)
-	fmt.Printf("Code smells:           %d\n", scores.Tech.CodeSmells)
-	fmt.Printf("\n--- Security ---\n")
-	fmt.Printf("Scorecard: %d\n", scores.Security.ScoreCard)
-
-	fmt.Printf("\n--- Summary ---\n%s\n", stats.Summary)
+	setLogOutput(os.Stderr)
+	if lang := os.Getenv("QSOS_LANG"); lang != "" {
+		if err := setLanguage(lang); err != nil {
+			fatal(err)
+		}
+	}
+	shutdown, err := qsos.SetupTracing(context.Background())
+	if err != nil {
+		fatal(err)
+	}
+	shutdownTracing = shutdown
+	defer flushSpans()
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		printUsage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.Name == name {
+			c.Run(os.Args[2:])
+			return
+		}
+	}
+	// For compatibility, "qsos owner/repo" is "qsos evaluate owner/repo"
+	if strings.Contains(name, "/") || strings.HasPrefix(name, "-") {
+		evaluateMain(os.Args[1:])
+		return
+	}
+	fmt.Fprintf(os.Stderr, "ERROR: unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(2)
+}
+
+func evaluateMain(args []string) {
+	fs := newFlagSet("evaluate", "<owner/repo or URL>...", "Collect the stats of the projects, compute their scores, and print the reports.")
+	list := fs.String("list", "", "evaluate the projects listed in this file (one owner/repo per line)")
+	org := fs.String("org", "", "evaluate all the repositories of this GitHub organization")
+	include := fs.String("include", "", "with --org, only evaluate the repositories matching these comma-separated patterns")
+	exclude := fs.String("exclude", "", "with --org, skip the repositories matching these comma-separated patterns")
+	output := addOutputFlags(fs, reportFormats...)
+	validateOutput := fs.Bool("validate-output", false, "with --format json, check the report against the JSON schema")
+	reportAll := fs.Bool("report-all", false, "also write the report in all the formats (JSON, Markdown, HTML, CSV, radar SVG and shields.io badge) to --out-dir")
+	outDir := fs.String("out-dir", "reports", "with --report-all, the directory of the reports")
+	heatmap := fs.String("heatmap", "", "write a heatmap of the scores to this file (SVG, or HTML with the .html extension)")
+	rank := fs.Bool("rank", false, "sort the evaluations by overall score, and print the leaderboard")
+	policy := fs.String("policy", "", "evaluate the projects with this Rego or CUE policy")
+	baseline := fs.String("baseline", "", "compare the scores with this baseline file, and fail if a criterion has regressed")
+	maxRegression := fs.Float64("max-regression", 0, "with --baseline, the maximal decrease of a score that is not a regression")
+	writeBaseline := fs.String("write-baseline", "", "write the scores to this baseline file")
+	minScores := scoresFlag{}
+	fs.Var(minScores, "min-score", "fail if the score of a criterion is below this minimum, like tech.codesmells=3 (can be repeated)")
+	minOverall := fs.Float64("min-overall", 0, "fail if the overall score is below this minimum")
+	refs := fs.String("refs", "", "also collect the tech stats for these comma-separated git refs, like main,v2.8.0")
+	saveStats := fs.String("save-stats", "", "save the raw stats to this file, for scoring them again later")
+	summaryFile := fs.String("summary-file", "", "append a summary of the run to this file, in the GitHub Actions outputs format")
+	prCommentFlag := fs.String("pr-comment", "", "post the summary of the run and the regressions from --baseline, which is required, as a comment of this GitHub pull request, like owner/repo#123, updated by the next runs")
+	githubActions := fs.Bool("github-actions", os.Getenv("GITHUB_ACTIONS") == "true", "write the summary of the run to the outputs ($GITHUB_OUTPUT) and the job summary ($GITHUB_STEP_SUMMARY) of GitHub Actions (default true in GitHub Actions)")
+	var tags stringsFlag
+	fs.Var(&tags, "tag", "tag the evaluations saved in the history (can be repeated)")
+	var paths stringsFlag
+	fs.Var(&paths, "path", "evaluate the git working copy in this local dir, without any forge (can be repeated)")
+	subdir := addSubdirFlags(fs)
+	checkpointFile := fs.String("checkpoint", "", "record the evaluated projects in this file, and resume an interrupted run from it")
+	dryRun := fs.Bool("dry-run", false, "print the commands and the API requests of the evaluation, without running them")
+	notify := addNotifyFlags(fs, "always")
+	confluenceFlags := addConfluenceFlags(fs)
+	progress := addProgressFlag(fs)
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+
+	projects := fs.Args()
+	if *list != "" {
+		listed, err := qsos.ReadProjectList(*list)
+		if err != nil {
+			fatal(err)
+		}
+		projects = append(projects, listed...)
+	}
+	if len(projects) == 0 && *org == "" && len(paths) == 0 {
+		usageError(fs, "no project to evaluate")
+	}
+
+	var prComment *pullRequest
+	if *prCommentFlag != "" {
+		// Without the scores of the base branch, there is no regression
+		if *baseline == "" {
+			usageError(fs, "--pr-comment requires --baseline")
+		}
+		pr, err := parsePullRequest(*prCommentFlag)
+		if err != nil {
+			usageError(fs, "%s", err)
+		}
+		prComment = pr
+	}
+
+	config := loadConfigFromEnv()
+	executorFlags.local = len(projects) == 0 && *org == ""
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	executor.Refs = qsos.SplitList(*refs)
+	subdir.apply(fs, executor)
+	setProgress(fs, executor, *progress)
+
+	if *dryRun {
+		if *org != "" {
+			fmt.Printf("# %s\nGET %sorgs/%s/repos?per_page=100\n", *org, executor.GitHub.BaseURL, *org)
+			projects = append(projects, *org+"/<repo>")
+		}
+		printPlan(executor, projects)
+		for _, dir := range paths {
+			fmt.Printf("# %s\n", dir)
+			for _, step := range executor.PlanLocal(dir) {
+				fmt.Println(step)
+			}
+		}
+		return
+	}
+
+	history, err := qsos.OpenHistoryFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	notifier := notify.notifier(fs, history, config)
+	reportMailer := notify.reportMailer(fs)
+	confluence := confluenceFlags.confluence()
+
+	ctx := interruptContext()
+	if *org != "" {
+		listed, err := executor.ListOrgProjects(ctx, *org)
+		if err != nil {
+			exitIfInterrupted(ctx)
+			fatal(err)
+		}
+		listed, err = qsos.FilterProjects(listed, *include, *exclude)
+		if err != nil {
+			fatal(err)
+		}
+		projects = append(projects, listed...)
+	}
+
+	var checkpoint *qsos.Checkpoint
+	if *checkpointFile != "" {
+		if checkpoint, err = qsos.OpenCheckpoint(*checkpointFile); err != nil {
+			fatal(err)
+		}
+		if n := checkpoint.Len(); n > 0 {
+			slog.Info("resuming from the checkpoint", "path", *checkpointFile, "evaluated", n)
+		}
+	}
+	start := time.Now()
+	evaluations, violations := evaluateProjects(ctx, executor, config, history, checkpoint, projects, paths, *policy, tags)
+	exitIfInterrupted(ctx)
+	if checkpoint != nil {
+		// The checkpoint is kept to retry the failed evaluations
+		if len(violations) == 0 {
+			err = checkpoint.Remove()
+		} else {
+			err = checkpoint.Close()
+			slog.Info("some evaluations failed, run again with the same checkpoint to retry them", "path", *checkpointFile)
+		}
+		if err != nil {
+			slog.Warn(err.Error())
+		}
+	}
+	if notifier != nil {
+		for _, evaluation := range evaluations {
+			if err := notifier.Notify(evaluation, start); err != nil {
+				slog.Error("cannot notify the evaluation", "project", evaluation.Name(), "err", err)
+			}
+		}
+	}
+	if reportMailer != nil && len(evaluations) > 0 {
+		if err := reportMailer.Send(evaluations); err != nil {
+			slog.Error("cannot send the report", "err", err)
+		}
+	}
+	for _, evaluation := range evaluations {
+		for _, msg := range evaluation.Denied {
+			violations = append(violations, fmt.Sprintf("%s denied by policy: %s", evaluation.Name(), msg))
+		}
+	}
+
+	if *saveStats != "" {
+		if err := qsos.WriteRawStats(*saveStats, evaluations); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *rank {
+		evaluations = qsos.Rank(evaluations)
+	}
+
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	text := *output.format == "text"
+
+	if text && quiet {
+		qsos.PrintScores(w, evaluations)
+	} else if text {
+		for _, evaluation := range evaluations {
+			qsos.PrintReport(w, evaluation, reportOptions)
+		}
+		if len(evaluations) > 1 || *org != "" {
+			qsos.PrintSummaryTable(w, evaluations, reportOptions)
+		}
+		if *rank {
+			qsos.PrintRanking(w, evaluations, reportOptions)
+		}
+	} else if err := writeReport(w, *output.format, evaluations, *validateOutput); err != nil {
+		fatal(err)
+	}
+
+	if *reportAll {
+		writeReportBundle(*outDir, evaluations, *validateOutput)
+	}
+	if confluence != nil && len(evaluations) > 0 {
+		publishReport(confluence, evaluations)
+	}
+
+	if *heatmap != "" {
+		if err := qsos.WriteHeatmapFile(*heatmap, evaluations); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *writeBaseline != "" {
+		if err := qsos.NewBaseline(evaluations).Write(*writeBaseline); err != nil {
+			fatal(err)
+		}
+	}
+
+	var regressions []qsos.Regression
+	if *baseline != "" {
+		reference, err := qsos.ReadBaseline(*baseline)
+		if err != nil {
+			fatal(err)
+		}
+		regressions = reference.Regressions(evaluations, *maxRegression)
+		if text {
+			qsos.PrintRegressions(w, regressions, reportOptions)
+		} else {
+			for _, r := range regressions {
+				slog.Warn("regression", "project", r.Project, "criterion", r.Criterion, "baseline", r.Baseline, "current", r.Current)
+			}
+		}
+	}
+
+	if len(minScores) > 0 || *minOverall > 0 {
+		below := qsos.CheckMinScores(evaluations, minScores, *minOverall)
+		if text {
+			qsos.PrintViolations(w, below, reportOptions)
+		} else {
+			for _, v := range below {
+				slog.Warn("score below the minimum", "project", v.Project, "criterion", v.Criterion, "score", v.Score, "min", v.Min)
+			}
+		}
+		for _, v := range below {
+			violations = append(violations, fmt.Sprintf("score below the minimum for %s", v))
+		}
+	}
+
+	summary := NewSummary(evaluations, violations, regressions)
+	if *summaryFile != "" {
+		if err := summary.Write(*summaryFile); err != nil {
+			fatal(err)
+		}
+	}
+	if *githubActions {
+		if path := os.Getenv("GITHUB_OUTPUT"); path != "" && path != *summaryFile {
+			if err := summary.Write(path); err != nil {
+				fatal(err)
+			}
+		}
+		if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+			if err := summary.WriteMarkdown(path); err != nil {
+				fatal(err)
+			}
+		}
+	}
+
+	if prComment != nil {
+		if err := postPRComment(ctx, executor.GitHub, prComment, summary.Markdown()); err != nil {
+			slog.Error("cannot comment the pull request", "pull_request", prComment, "err", err)
+		}
+	}
+
+	if !summary.Passed {
+		w.Close()
+		exit(1)
+	}
+}
+
+// reportFormats are the formats of the reports of evaluate and score.
+var reportFormats = []string{"text", "json", "markdown", "html", "csv"}
+
+// writeReport writes the evaluations in a format other than text.
+func writeReport(w io.Writer, format string, evaluations []*qsos.Evaluation, validate bool) error {
+	switch format {
+	case "markdown":
+		return qsos.WriteMarkdownReport(w, evaluations, reportOptions)
+	case "html":
+		return qsos.WriteHTMLReport(w, evaluations, reportOptions)
+	case "csv":
+		return qsos.WriteCSVReport(w, evaluations)
+	default:
+		return qsos.WriteJSONReport(w, evaluations, validate)
+	}
+}
+
+func writeReportBundle(dir string, evaluations []*qsos.Evaluation, validate bool) {
+	paths, err := qsos.WriteReportBundle(dir, evaluations, validate, reportOptions)
+	if err != nil {
+		fatal(err)
+	}
+	for _, path := range paths {
+		slog.Info("report written", "path", path)
+	}
+}
+
+// printPlan prints the commands and the requests of the evaluations of the
+// projects, for --dry-run.
+func printPlan(executor *qsos.Executor, projects []string) {
+	for _, project := range projects {
+		projectExecutor, owner, repo, err := executor.ForProject(project)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("# %s\n", project)
+		for _, step := range projectExecutor.Plan(owner, repo) {
+			fmt.Println(step)
+		}
+	}
+}
+
+func loadConfigFromEnv() *qsos.Config {
+	config := qsos.DefaultConfig()
+	if path := os.Getenv("QSOS_CONFIG"); path != "" {
+		c, err := qsos.LoadConfig(path)
+		if err != nil {
+			fatal(err)
+		}
+		config = c
+	}
+	return config
+}
+
+// evaluateProjects evaluates the projects, then the local working copies in
+// paths, one after the other, and saves them in the history if it is not
+// nil. The projects that cannot be evaluated are logged and skipped, and the
+// errors are returned. When the context is canceled, the evaluations in
+// progress are dropped.
+func evaluateProjects(ctx context.Context, executor *qsos.Executor, config *qsos.Config, history *qsos.History, checkpoint *qsos.Checkpoint, projects, paths []string, policy string, tags []string) ([]*qsos.Evaluation, []string) {
+	// The evaluations run concurrently on the workers of the executor, their
+	// results are in the order of the projects and of the paths
+	evaluations := make([]*qsos.Evaluation, len(projects)+len(paths))
+	errs := make([][]string, len(evaluations))
+	var mu sync.Mutex
+	add := func(i int, project string, evaluation *qsos.Evaluation, err error) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Error(err.Error(), "project", project)
+			errs[i] = append(errs[i], fmt.Sprintf("%s: %s", project, err))
+			return
+		}
+		if checkpoint != nil {
+			if err := checkpoint.Save(project, evaluation); err != nil {
+				slog.Warn(err.Error(), "project", project)
+			}
+		}
+		if history != nil {
+			mu.Lock()
+			_, err := history.Save(evaluation, tags)
+			mu.Unlock()
+			if err != nil {
+				slog.Error(err.Error(), "project", project)
+				errs[i] = append(errs[i], fmt.Sprintf("%s: %s", project, err))
+			}
+		}
+		evaluations[i] = evaluation
+	}
+	// resume scores the stats of a project evaluated by an interrupted run,
+	// which is already in the history
+	resume := func(i int, project string) bool {
+		raw := checkpoint.Done(project)
+		if raw == nil {
+			return false
+		}
+		evaluation, err := qsos.ScoreStats(config, raw.Owner, raw.Repo, raw.Stats, policy)
+		if err != nil {
+			slog.Error(err.Error(), "project", project)
+			errs[i] = append(errs[i], fmt.Sprintf("%s: %s", project, err))
+		}
+		evaluations[i] = evaluation
+		return true
+	}
+	var group errgroup.Group
+	group.SetLimit(executor.Workers)
+	for i, project := range projects {
+		if checkpoint != nil && resume(i, project) {
+			continue
+		}
+		group.Go(func() error {
+			projectExecutor, owner, repo, err := executor.ForProject(project)
+			if err != nil {
+				slog.Error(err.Error())
+				errs[i] = append(errs[i], err.Error())
+				return nil
+			}
+			evaluation, err := qsos.Evaluate(ctx, projectExecutor, config, owner, repo, policy)
+			add(i, project, evaluation, err)
+			return nil
+		})
+	}
+	for i, dir := range paths {
+		if checkpoint != nil && resume(len(projects)+i, dir) {
+			continue
+		}
+		group.Go(func() error {
+			evaluation, err := qsos.EvaluateLocal(ctx, executor, config, dir, policy)
+			add(len(projects)+i, dir, evaluation, err)
+			return nil
+		})
+	}
+	group.Wait()
+	return slices.DeleteFunc(evaluations, func(evaluation *qsos.Evaluation) bool {
+		return evaluation == nil
+	}), slices.Concat(errs...)
+}
+
+func compareMain(args []string) {
+	fs := newFlagSet("compare", "<owner/repo> <owner/repo>...", "Evaluate the projects, and print their scores side by side.")
+	output := addOutputFlags(fs, "text", "json")
+	progress := addProgressFlag(fs)
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() < 2 {
+		usageError(fs, "at least 2 projects are needed")
+	}
+
+	config := loadConfigFromEnv()
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	setProgress(fs, executor, *progress)
+	history, err := qsos.OpenHistoryFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx := interruptContext()
+	evaluations, errs := evaluateProjects(ctx, executor, config, history, nil, fs.Args(), nil, "", nil)
+	exitIfInterrupted(ctx)
+	comparison := qsos.Compare(evaluations)
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	if *output.format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(comparison); err != nil {
+			fatal(err)
+		}
+	} else {
+		qsos.PrintComparison(w, comparison, reportOptions)
+	}
+	if len(errs) > 0 {
+		w.Close()
+		exit(1)
+	}
+}
+
+func productMain(args []string) {
+	fs := newFlagSet("product", "<product.json>", "Evaluate the repositories of a product, and aggregate their scores with their weights into\nthe scores of the product.")
+	output := addOutputFlags(fs, "text", "json")
+	progress := addProgressFlag(fs)
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() != 1 {
+		usageError(fs, "a product file is needed")
+	}
+	product, err := qsos.ReadProduct(fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+
+	config := loadConfigFromEnv()
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	setProgress(fs, executor, *progress)
+	history, err := qsos.OpenHistoryFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+
+	// The evaluations are in the order of the repositories, nil for the
+	// ones that could not be evaluated
+	evaluations := make([]*qsos.Evaluation, len(product.Repos))
+	var errs []string
+	ctx := interruptContext()
+	for i, repo := range product.Repos {
+		evaluated, repoErrs := evaluateProjects(ctx, executor, config, history, nil, []string{repo.Project}, nil, "", nil)
+		exitIfInterrupted(ctx)
+		if len(evaluated) > 0 {
+			evaluations[i] = evaluated[0]
+		}
+		errs = append(errs, repoErrs...)
+	}
+	result := qsos.AggregateProduct(product, evaluations)
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	if *output.format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			fatal(err)
+		}
+	} else {
+		qsos.PrintProduct(w, result, reportOptions)
+	}
+	if len(errs) > 0 {
+		w.Close()
+		exit(1)
+	}
+}
+
+func collectMain(args []string) {
+	fs := newFlagSet("collect", "<owner/repo or URL>...", "Collect the raw stats of the projects, without scoring them. They can be scored later with\nthe score command.")
+	output := addOutputFlags(fs, "json")
+	fs.StringVar(output.output, "out", "-", "alias of --output")
+	refs := fs.String("refs", "", "also collect the tech stats for these comma-separated git refs, like main,v2.8.0")
+	subdir := addSubdirFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "print the commands and the API requests of the collection, without running them")
+	progress := addProgressFlag(fs)
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() == 0 {
+		usageError(fs, "no project to collect")
+	}
+
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	subdir.apply(fs, executor)
+	setProgress(fs, executor, *progress)
+	if *dryRun {
+		executor.Refs = qsos.SplitList(*refs)
+		printPlan(executor, fs.Args())
+		return
+	}
+	var raw []*qsos.RawStats
+	ctx := interruptContext()
+	for _, project := range fs.Args() {
+		projectExecutor, owner, repo, err := executor.ForProject(project)
+		if err != nil {
+			fatal(err)
+		}
+		r, err := qsos.Collect(ctx, owner, repo, &qsos.CollectOptions{Executor: projectExecutor, Refs: qsos.SplitList(*refs)})
+		if err != nil {
+			exitIfInterrupted(ctx)
+			fatal(fmt.Errorf("%s: %w", project, err))
+		}
+		raw = append(raw, r)
+	}
+	if err := qsos.WriteRawStatsFile(*output.output, raw); err != nil {
+		fatal(err)
+	}
+}
+
+func scoreMain(args []string) {
+	fs := newFlagSet("score", "<stats.json>", "Compute the scores from the raw stats saved by the collect command, or by evaluate\n--save-stats, and print the reports.")
+	output := addOutputFlags(fs, reportFormats...)
+	validateOutput := fs.Bool("validate-output", false, "with --format json, check the report against the JSON schema")
+	reportAll := fs.Bool("report-all", false, "also write the report in all the formats (JSON, Markdown, HTML, CSV, radar SVG and shields.io badge) to --out-dir")
+	outDir := fs.String("out-dir", "reports", "with --report-all, the directory of the reports")
+	policy := fs.String("policy", "", "score the projects with this Rego or CUE policy")
+	confluenceFlags := addConfluenceFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() != 1 {
+		usageError(fs, "exactly one stats file is needed")
+	}
+	confluence := confluenceFlags.confluence()
+
+	evaluations, err := scoreRawStats(fs.Arg(0), &qsos.ScoreOptions{Config: loadConfigFromEnv(), Policy: *policy})
+	if err != nil {
+		fatal(err)
+	}
+
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	if *reportAll {
+		writeReportBundle(*outDir, evaluations, *validateOutput)
+	}
+	if confluence != nil {
+		publishReport(confluence, evaluations)
+	}
+	if *output.format != "text" {
+		if err := writeReport(w, *output.format, evaluations, *validateOutput); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if quiet {
+		qsos.PrintScores(w, evaluations)
+		return
+	}
+	for _, evaluation := range evaluations {
+		qsos.PrintReport(w, evaluation, reportOptions)
+	}
+	if len(evaluations) > 1 {
+		qsos.PrintSummaryTable(w, evaluations, reportOptions)
+	}
+}
+
+func serveMain(args []string) {
+	fs := newFlagSet("serve", "", "Start the HTTP server, with the evaluation API and the scheduled evaluations.")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	maxInFlight := fs.Int("max-in-flight", 2, "maximal number of evaluations in progress")
+	drainTimeout := fs.Duration("drain-timeout", 30*time.Minute, "maximal duration to wait for the evaluations in progress on shutdown")
+	grpcAddr := fs.String("grpc-addr", "", "also serve the gRPC API on this address, like :9090")
+	allowForges := fs.String("allow-forges", "", "hosts of the other forges, separated by commas, of the projects that can be evaluated by URL, like gitlab.com,codeberg.org (default only the forge of --forge-url)")
+	watch := fs.String("watch", "", "evaluate again the projects listed in this file (one owner/repo per line) on their GitHub push and release webhooks")
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+
+	config := loadConfigFromEnv()
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	history, err := qsos.OpenHistoryFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := NewServer(executor, config, history, *maxInFlight)
+	server.ProfilesDir = os.Getenv("QSOS_PROFILES_DIR")
+	server.Mailer = MailerFromEnv()
+	server.GRPCAddr = *grpcAddr
+	if *allowForges != "" {
+		server.AllowedForges = strings.Split(*allowForges, ",")
+	}
+	if *watch != "" {
+		if server.Watched, err = qsos.ReadProjectList(*watch); err != nil {
+			fatal(err)
+		}
+		if server.WebhookSecret = os.Getenv("QSOS_GITHUB_WEBHOOK_SECRET"); server.WebhookSecret == "" {
+			fatal(errors.New("QSOS_GITHUB_WEBHOOK_SECRET environment variable is not set, it is needed with --watch"))
+		}
+		if history == nil {
+			fatal(errors.New("QSOS_HISTORY_DIR or QSOS_HISTORY_DB environment variable is not set, it is needed with --watch"))
+		}
+	}
+	server.PublicURL = strings.TrimSuffix(os.Getenv("QSOS_PUBLIC_URL"), "/")
+	if server.PublicURL == "" {
+		host, port, _ := net.SplitHostPort(*addr)
+		server.PublicURL = "http://" + net.JoinHostPort(cmp.Or(host, "localhost"), port)
+	}
+	if err := server.ListenAndServe(ctx, *addr, *drainTimeout); err != nil {
+		fatal(err)
+	}
+}
+
+func historyMain(args []string) {
+	usage := "Usage: qsos history list [owner/repo] | search [flags] | tag <id> <tag>..."
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		fatalUsage(usage)
+	}
+	history := requireHistory()
+	var err error
+
+	switch args[0] {
+	case "list":
+		filter := &qsos.HistoryFilter{}
+		if len(args) > 1 {
+			filter.Owner, filter.Repo, err = qsos.ParseProject(args[1])
+			if err != nil {
+				fatal(err)
+			}
+		}
+		records, err := history.Search(filter)
+		if err != nil {
+			fatal(err)
+		}
+		qsos.PrintHistory(os.Stdout, records)
+	case "search":
+		fs := newFlagSet("history search", "", "Search the evaluations of the history.")
+		filter := &qsos.HistoryFilter{MinScores: scoresFlag{}, MaxScores: scoresFlag{}}
+		var tags stringsFlag
+		fs.Var(&tags, "tag", "only the evaluations with this tag (can be repeated)")
+		fs.StringVar(&filter.Owner, "owner", "", "only the evaluations of projects of this owner")
+		fs.StringVar(&filter.Repo, "repo", "", "only the evaluations of projects with this repository name")
+		fs.Var(scoresFlag(filter.MinScores), "min-score", "only the evaluations with at least this score for a criterion, like tech.size=3 (can be repeated)")
+		fs.Var(scoresFlag(filter.MaxScores), "max-score", "only the evaluations with at most this score for a criterion, like tech.size=3 (can be repeated)")
+		since := fs.String("since", "", "only the evaluations since this date (YYYY-MM-DD)")
+		until := fs.String("until", "", "only the evaluations until this date (YYYY-MM-DD)")
+		format := fs.String("format", "text", "format of the results: text or json")
+		fs.Parse(args[1:])
+		filter.Tags = tags
+		if filter.Since, err = parseDate(*since); err != nil {
+			fatal(fmt.Errorf("invalid --since: %w", err))
+		}
+		if filter.Until, err = parseDate(*until); err != nil {
+			fatal(fmt.Errorf("invalid --until: %w", err))
+		}
+		if !filter.Until.IsZero() {
+			filter.Until = filter.Until.AddDate(0, 0, 1)
+		}
+		records, err := history.Search(filter)
+		if err != nil {
+			fatal(err)
+		}
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(records); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		qsos.PrintHistory(os.Stdout, records)
+	case "tag":
+		if len(args) < 3 {
+			fatalUsage(usage)
+		}
+		record, err := history.Tag(args[1], args[2:]...)
+		if err != nil {
+			fatal(err)
+		}
+		qsos.PrintHistory(os.Stdout, []*qsos.HistoryRecord{record})
+	default:
+		fatalUsage(usage)
+	}
+}
+
+func daemonMain(args []string) {
+	fs := newFlagSet("daemon", "<owner/repo or URL>...", "Evaluate the projects again on a cron schedule, save the evaluations in the history, and\nnotify the changes of their scores.")
+	cron := fs.String("cron", "0 3 * * *", "cron expression of the evaluations, like \"0 3 * * 1\" for every Monday at 3:00")
+	list := fs.String("list", "", "also evaluate the projects listed in this file (one owner/repo per line), read again at each run")
+	now := fs.Bool("now", false, "also evaluate the projects right away")
+	var tags stringsFlag
+	fs.Var(&tags, "tag", "tag the evaluations saved in the history (can be repeated)")
+	var paths stringsFlag
+	fs.Var(&paths, "path", "evaluate the git working copy in this local dir, without any forge (can be repeated)")
+	notify := addNotifyFlags(fs, "change")
+	metricsAddr := fs.String("metrics-addr", "", "serve the scores and the collection metrics to Prometheus on this address, at /metrics")
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 && *list == "" && len(paths) == 0 {
+		usageError(fs, "no project to evaluate")
+	}
+	schedule, err := qsos.ParseCron(*cron)
+	if err != nil {
+		fatal(err)
+	}
+
+	executorFlags.local = fs.NArg() == 0 && *list == ""
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		fatal(err)
+	}
+	// The notifications are not printed on a terminal
+	reportOptions.Colors = false
+	config := loadConfigFromEnv()
+	history := requireHistory()
+	daemon := &Daemon{
+		Executor: executor,
+		Config:   config,
+		History:  history,
+		Cron:     schedule,
+		Projects: fs.Args(),
+		List:     *list,
+		Paths:    paths,
+		Tags:     append([]string{"scheduled"}, tags...),
+		Notifier: notify.notifier(fs, history, config),
+		Reports:  notify.reportMailer(fs),
+	}
+	if *metricsAddr != "" {
+		metrics := &exporter{history: daemon.History}
+		executor.SetProgress(metrics.observe)
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics)
+		go func() {
+			fatal(http.ListenAndServe(*metricsAddr, mux))
+		}()
+		slog.Info("serving the metrics", "addr", *metricsAddr)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := daemon.Run(ctx, *now); err != nil {
+		fatal(err)
+	}
+}
+
+// requireHistory opens the history, which must be configured.
+func requireHistory() *qsos.History {
+	history, err := qsos.OpenHistoryFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	if history == nil {
+		fatal(errors.New("QSOS_HISTORY_DIR or QSOS_HISTORY_DB environment variable is not set"))
+	}
+	return history
+}
+
+func trendMain(args []string) {
+	fs := newFlagSet("trend", "<owner/repo>", "Print the evolution of the scores of a project, from its evaluations in the history.")
+	output := addOutputFlags(fs, "text", "csv", "svg", "json")
+	var criteria stringsFlag
+	fs.Var(&criteria, "criterion", "only this criterion, like community.activity or overall (can be repeated)")
+	var tags stringsFlag
+	fs.Var(&tags, "tag", "only the evaluations with this tag (can be repeated)")
+	since := fs.String("since", "", "only the evaluations since this date (YYYY-MM-DD)")
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() != 1 {
+		usageError(fs, "a project is needed")
+	}
+	filter := &qsos.HistoryFilter{Tags: tags}
+	var err error
+	if filter.Owner, filter.Repo, err = qsos.ParseProject(fs.Arg(0)); err != nil {
+		fatal(err)
+	}
+	if filter.Since, err = parseDate(*since); err != nil {
+		fatal(fmt.Errorf("invalid --since: %w", err))
+	}
+
+	records, err := requireHistory().Search(filter)
+	if err != nil {
+		fatal(err)
+	}
+	trend, err := qsos.ComputeTrend(records, criteria)
+	if err != nil {
+		fatal(err)
+	}
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	switch *output.format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(trend)
+	case "csv":
+		err = qsos.WriteTrendCSV(w, trend)
+	case "svg":
+		err = qsos.WriteTrendSVG(w, trend)
+	default:
+		qsos.PrintTrend(w, trend, reportOptions)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func badgeMain(args []string) {
+	fs := newFlagSet("badge", "<owner/repo>", "Write the shields.io endpoint badge of the overall score of a project, from its last\nevaluation in the history, or in a JSON report.")
+	output := addOutputFlags(fs, "json")
+	report := fs.String("report", "", "read the evaluation from this JSON report instead of the history")
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() != 1 {
+		usageError(fs, "a project is needed")
+	}
+	owner, repo, err := qsos.ParseProject(fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	var evaluation *qsos.Evaluation
+	if *report != "" {
+		r, err := qsos.ReadReport(*report)
+		if err != nil {
+			fatal(err)
+		}
+		for _, e := range r.Evaluations {
+			if strings.EqualFold(e.Name(), fs.Arg(0)) {
+				evaluation = e
+				break
+			}
+		}
+		if evaluation == nil {
+			fatal(fmt.Errorf("No evaluation of %s in %s", fs.Arg(0), *report))
+		}
+	} else {
+		projects, err := historyProjects(requireHistory(), &qsos.HistoryFilter{Owner: owner, Repo: repo})
+		if err != nil {
+			fatal(err)
+		}
+		if len(projects) == 0 {
+			fatal(fmt.Errorf("%w: %s", qsos.ErrNotInHistory, fs.Arg(0)))
+		}
+		evaluation = projects[0].Latest().Evaluation
+	}
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	if err := qsos.WriteBadge(w, evaluation); err != nil {
+		fatal(err)
+	}
+}
+
+func diffMain(args []string) {
+	fs := newFlagSet("diff", "<old.json> <new.json>", "Print the scores and the raw metrics that have changed between two JSON reports, with the\nthresholds crossed by the values of the criteria.")
+	output := addOutputFlags(fs, "text", "json")
+	fs.Parse(args)
+	output.check(fs)
+	if fs.NArg() != 2 {
+		usageError(fs, "exactly two reports are needed")
+	}
+	old, err := qsos.ReadReport(fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	new, err := qsos.ReadReport(fs.Arg(1))
+	if err != nil {
+		fatal(err)
+	}
+	diff := qsos.ComputeDiff(old, new, loadConfigFromEnv())
+
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	if *output.format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	qsos.PrintDiff(w, diff, reportOptions)
+}
+
+func schemaMain(args []string) {
+	fs := newFlagSet("schema", "", "Print the JSON schema of the reports written with --format json.")
+	fs.Parse(args)
+	os.Stdout.Write(qsos.ReportSchema)
+}
+
+func initMain(args []string) {
+	fs := newFlagSet("init", "[file]", "Write the default configuration, with comments, to a file (qsos.json by default). It is\nused with QSOS_CONFIG=<file>.")
+	force := fs.Bool("force", false, "overwrite the file if it exists")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		usageError(fs, "too many arguments")
+	}
+	path := "qsos.json"
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		fatal(fmt.Errorf("%s already exists, use --force to overwrite it", path))
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if err := qsos.WriteConfigTemplate(f); err != nil {
+		fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Configuration written to %s, use it with QSOS_CONFIG=%s\n", path, path)
+}
+
+// scoreRawStats reads a file of raw stats, and scores the projects.
+func scoreRawStats(path string, opts *qsos.ScoreOptions) ([]*qsos.Evaluation, error) {
+	raw, err := qsos.ReadRawStats(path)
+	if err != nil {
+		return nil, err
+	}
+	var evaluations []*qsos.Evaluation
+	for _, r := range raw {
+		evaluation, err := qsos.Score(r.Owner, r.Repo, r.Stats, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", r.Owner, r.Repo, err)
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, nil
+}
+
+func doctorMain(args []string) {
+	fs := newFlagSet("doctor", "", "Check that the tools (git, docker), the services (GitHub, Sonarqube) and their tokens are\navailable, and that the GitHub rate limit is not exhausted.")
+	output := addOutputFlags(fs, "text", "json")
+	executorFlags := addExecutorFlags(fs)
+	fs.Parse(args)
+	output.check(fs)
+
+	var diagnostics []*qsos.Diagnostic
+	executor, err := executorFlags.newExecutor()
+	if err != nil {
+		diagnostics = append(diagnostics, &qsos.Diagnostic{
+			Name:   "configuration",
+			Detail: err.Error(),
+			Fix:    "set the env variables or the flags, see qsos doctor --help",
+		})
+	} else {
+		diagnostics = executor.Diagnose()
+	}
+
+	w, err := output.open()
+	if err != nil {
+		fatal(err)
+	}
+	defer w.Close()
+	failed := false
+	for _, diagnostic := range diagnostics {
+		failed = failed || !diagnostic.OK
+	}
+	if *output.format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diagnostics); err != nil {
+			fatal(err)
+		}
+	} else {
+		for _, diagnostic := range diagnostics {
+			status := "OK"
+			if !diagnostic.OK {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "[%-4s] %s: %s\n", status, diagnostic.Name, diagnostic.Detail)
+			if diagnostic.Fix != "" {
+				fmt.Fprintf(w, "       -> %s\n", diagnostic.Fix)
+			}
+		}
+	}
+	if failed {
+		w.Close()
+		exit(1)
+	}
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
 }
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// notifyTrigger tells which evaluations are notified.
+type notifyTrigger struct {
+	// On is always, change (a score has changed since the previous
+	// evaluation), regression (a score has decreased) or below (the overall
+	// score is below Below).
+	On    string
+	Below float64
+}
+
+func parseNotifyTrigger(value string) (notifyTrigger, error) {
+	on, threshold, hasThreshold := strings.Cut(value, "=")
+	switch on {
+	case "always", "change", "regression":
+		if !hasThreshold {
+			return notifyTrigger{On: on}, nil
+		}
+	case "below":
+		if below, err := strconv.ParseFloat(threshold, 64); err == nil && hasThreshold {
+			return notifyTrigger{On: on, Below: below}, nil
+		}
+	}
+	return notifyTrigger{}, fmt.Errorf("Invalid trigger %q, must be always, change, regression or below=<overall score>", value)
+}
+
+// Notification is the summary of an evaluation, with the changes of its
+// scores since the previous evaluation of the project.
+type Notification struct {
+	Project  string
+	Overall  float64
+	RedFlags []string `json:",omitempty"`
+	// Since is the date of the previous evaluation, if it is in the history.
+	Since time.Time `json:",omitzero"`
+	// Scores are the scores that have changed since the previous
+	// evaluation, with the overall one.
+	Scores []qsos.ScoreChange `json:",omitempty"`
+	// Diff has the changes of the scores and of the raw metrics.
+	Diff *qsos.ProjectDiff `json:"-"`
+}
+
+// text returns the summary of the notification, for Slack and the emails.
+func (n *Notification) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "QSOS evaluation of %s: overall score %.2f/5\n", n.Project, n.Overall)
+	if len(n.Scores) > 0 {
+		fmt.Fprintf(&b, "Changes since the evaluation of %s:\n", n.Since.Format(time.DateOnly))
+		for _, change := range n.Scores {
+			fmt.Fprintf(&b, "- %s: %g -> %g\n", change.Criterion, change.Old, change.New)
+		}
+	}
+	for _, flag := range n.RedFlags {
+		fmt.Fprintf(&b, "Red flag: %s\n", flag)
+	}
+	return b.String()
+}
+
+// slackMessage is the payload of the Slack incoming webhooks, also accepted
+// by Mattermost and Rocket.Chat.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notifier notifies the evaluations to webhooks, in JSON, to Slack, and by
+// email, when its trigger fires.
+type Notifier struct {
+	Trigger notifyTrigger
+	// Targets are the URLs where the notifications are posted, in JSON.
+	Targets []string
+	// Slack are the URLs of Slack incoming webhooks.
+	Slack []string
+	// Emails are the recipients of the notifications, sent by the Mailer.
+	Emails []string
+	Mailer *Mailer
+	// History has the previous evaluations of the projects. It is optional
+	// with the always and below triggers.
+	History *qsos.History
+	Config  *qsos.Config
+}
+
+// Notify notifies an evaluation, if the trigger fires. The previous
+// evaluation of the project is the last one saved in the history before
+// start, the start of the run.
+func (n *Notifier) Notify(evaluation *qsos.Evaluation, start time.Time) error {
+	notification, err := n.notification(evaluation, start)
+	if err != nil {
+		return err
+	}
+	if !n.fires(notification) {
+		return nil
+	}
+	slog.Info("notifying the evaluation", "project", notification.Project, "trigger", n.Trigger.On, "changes", len(notification.Scores))
+	var errs []error
+	for _, target := range n.Targets {
+		if err := postJSON(target, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	text := notification.text()
+	for _, target := range n.Slack {
+		if err := postJSON(target, slackMessage{Text: text}); err != nil {
+			// The URLs of the Slack webhooks are secrets
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			errs = append(errs, fmt.Errorf("Slack: %w", err))
+		}
+	}
+	if len(n.Emails) > 0 {
+		body := bytes.NewBufferString(text)
+		if notification.Diff != nil {
+			body.WriteString("\n")
+			qsos.PrintDiff(body, &qsos.Diff{Projects: []*qsos.ProjectDiff{notification.Diff}}, reportOptions)
+		}
+		subject := fmt.Sprintf("QSOS evaluation of %s: %.2f/5", notification.Project, notification.Overall)
+		for _, email := range n.Emails {
+			if err := n.Mailer.Send(email, subject, body.String()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) fires(notification *Notification) bool {
+	switch n.Trigger.On {
+	case "always":
+		return true
+	case "below":
+		return notification.Overall < n.Trigger.Below
+	case "regression":
+		return slices.ContainsFunc(notification.Scores, func(change qsos.ScoreChange) bool {
+			return change.New < change.Old
+		})
+	default:
+		return len(notification.Scores) > 0
+	}
+}
+
+// notification returns the notification of an evaluation, with the changes
+// since the previous evaluation of the project in the history, before the
+// start of the run.
+func (n *Notifier) notification(evaluation *qsos.Evaluation, start time.Time) (*Notification, error) {
+	notification := &Notification{Project: evaluation.Name(), Overall: evaluation.Scores.Overall}
+	for _, flag := range evaluation.RedFlags {
+		notification.RedFlags = append(notification.RedFlags, flag.Message)
+	}
+	if n.History == nil {
+		return notification, nil
+	}
+	records, err := n.History.Search(&qsos.HistoryFilter{Owner: evaluation.Owner, Repo: evaluation.Repo, Until: start})
+	if err != nil {
+		return nil, err
+	}
+	var previous *qsos.HistoryRecord
+	for _, record := range records {
+		if record.Evaluation.Stats.Subdir == evaluation.Stats.Subdir && (previous == nil || record.Date.After(previous.Date)) {
+			previous = record
+		}
+	}
+	if previous == nil {
+		return notification, nil
+	}
+	diff := qsos.ComputeDiff(
+		&qsos.Report{GeneratedAt: previous.Date, Evaluations: []*qsos.Evaluation{previous.Evaluation}},
+		&qsos.Report{GeneratedAt: time.Now(), Evaluations: []*qsos.Evaluation{evaluation}},
+		n.Config)
+	if len(diff.Projects) == 0 {
+		return notification, nil
+	}
+	notification.Project = diff.Projects[0].Project
+	notification.Since = previous.Date
+	notification.Diff = diff.Projects[0]
+	for _, change := range diff.Projects[0].Scores {
+		if change.Old != change.New {
+			notification.Scores = append(notification.Scores, change)
+		}
+	}
+	return notification, nil
+}
+
+// reportAttachments are the files of the reports attached to the emails,
+// by format.
+var reportAttachments = map[string]Attachment{
+	"html":     {Name: "qsos-report.html", ContentType: "text/html; charset=utf-8"},
+	"markdown": {Name: "qsos-report.md", ContentType: "text/markdown; charset=utf-8"},
+	"csv":      {Name: "qsos-report.csv", ContentType: "text/csv; charset=utf-8"},
+	"json":     {Name: "qsos-report.json", ContentType: "application/json"},
+}
+
+// ReportMailer emails the report of the evaluations of a run.
+type ReportMailer struct {
+	Mailer *Mailer
+	To     []string
+	// Formats are the formats of the attached reports: html, markdown, csv
+	// or json.
+	Formats []string
+}
+
+// Send sends the report of the evaluations, with their overall scores in
+// the body of the email.
+func (r *ReportMailer) Send(evaluations []*qsos.Evaluation) error {
+	var attachments []Attachment
+	for _, format := range r.Formats {
+		var data bytes.Buffer
+		if err := writeReport(&data, format, evaluations, false); err != nil {
+			return err
+		}
+		attachment := reportAttachments[format]
+		attachment.Data = data.Bytes()
+		attachments = append(attachments, attachment)
+	}
+	subject := fmt.Sprintf("QSOS report of %d projects", len(evaluations))
+	if len(evaluations) == 1 {
+		subject = "QSOS report of " + evaluations[0].Name()
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "The QSOS report of the evaluations of %s is attached.\n\n", time.Now().Format(time.DateTime))
+	for _, evaluation := range evaluations {
+		fmt.Fprintf(&body, "- %s: overall score %.2f/5", evaluation.Name(), evaluation.Scores.Overall)
+		if len(evaluation.RedFlags) > 0 {
+			fmt.Fprintf(&body, ", %d red flags", len(evaluation.RedFlags))
+		}
+		body.WriteString("\n")
+	}
+	var errs []error
+	for _, to := range r.To {
+		if err := r.Mailer.SendAttachments(to, subject, body.String(), attachments); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		slog.Info("report sent by email", "recipients", len(r.To), "evaluations", len(evaluations))
+	}
+	return errors.Join(errs...)
+}
+
+// notifyFlags are the flags of the notifications of the evaluations, and of
+// their reports sent by email.
+type notifyFlags struct {
+	targets       stringsFlag
+	slack         stringsFlag
+	emails        stringsFlag
+	on            *string
+	reportEmails  stringsFlag
+	reportFormats *string
+}
+
+func addNotifyFlags(fs *flag.FlagSet, defaultTrigger string) *notifyFlags {
+	f := &notifyFlags{}
+	fs.Var(&f.targets, "notify", "post the summary of the evaluations and the changes of their scores in JSON to this URL (can be repeated)")
+	fs.Var(&f.slack, "notify-slack", "post the summary of the evaluations to this Slack incoming webhook URL (can be repeated)")
+	fs.Var(&f.emails, "notify-email", "send the summary of the evaluations to this email address, with the QSOS_SMTP_* settings (can be repeated)")
+	f.on = fs.String("notify-on", defaultTrigger, "notify the evaluations: always, on a change of their scores (change), on a decrease (regression), or when the overall score is below a minimum (below=3)")
+	fs.Var(&f.reportEmails, "email-report", "send the report of the evaluations of each run to this email address, with the QSOS_SMTP_* settings (can be repeated)")
+	f.reportFormats = fs.String("email-report-format", "html", "comma-separated formats of the reports attached to the emails: html, markdown, csv or json")
+	return f
+}
+
+// reportMailer returns the mailer of the reports of the flags, or nil if
+// the reports are not sent by email.
+func (f *notifyFlags) reportMailer(fs *flag.FlagSet) *ReportMailer {
+	formats := qsos.SplitList(*f.reportFormats)
+	for _, format := range formats {
+		if _, ok := reportAttachments[format]; !ok {
+			usageError(fs, "invalid report format %q, must be html, markdown, csv or json", format)
+		}
+	}
+	if len(f.reportEmails) == 0 {
+		return nil
+	}
+	mailer := MailerFromEnv()
+	if mailer == nil {
+		fatal(errors.New("QSOS_SMTP_ADDR environment variable is not set, it is needed with --email-report"))
+	}
+	return &ReportMailer{Mailer: mailer, To: f.reportEmails, Formats: formats}
+}
+
+// notifier returns the notifier of the flags, or nil without any
+// notification. The history is needed by the change and regression
+// triggers.
+func (f *notifyFlags) notifier(fs *flag.FlagSet, history *qsos.History, config *qsos.Config) *Notifier {
+	trigger, err := parseNotifyTrigger(*f.on)
+	if err != nil {
+		usageError(fs, "%s", err)
+	}
+	if len(f.targets)+len(f.slack)+len(f.emails) == 0 {
+		return nil
+	}
+	if history == nil && (trigger.On == "change" || trigger.On == "regression") {
+		fatal(fmt.Errorf("QSOS_HISTORY_DIR or QSOS_HISTORY_DB environment variable is not set, it is needed with --notify-on %s", trigger.On))
+	}
+	notifier := &Notifier{Trigger: trigger, Targets: f.targets, Slack: f.slack, Emails: f.emails, History: history, Config: config}
+	if len(f.emails) > 0 {
+		if notifier.Mailer = MailerFromEnv(); notifier.Mailer == nil {
+			fatal(errors.New("QSOS_SMTP_ADDR environment variable is not set, it is needed with --notify-email"))
+		}
+	}
+	return notifier
+}
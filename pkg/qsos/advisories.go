@@ -0,0 +1,120 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// AdvisoriesCollector collects the security advisories of a project.
+type AdvisoriesCollector interface {
+	GetAdvisoriesStats(ctx context.Context, owner, repo string) (*AdvisoriesStats, error)
+}
+
+// AdvisoriesStats describe how the past security incidents of a project were
+// handled, from its published security advisories.
+type AdvisoriesStats struct {
+	Published int64
+	// WithCredits is the number of advisories crediting the reporters.
+	WithCredits int64
+	// WithCVSS is the number of advisories with a CVSS score or vector.
+	WithCVSS int64
+	// WithRemediation is the number of advisories giving the patched
+	// versions or a workaround.
+	WithRemediation int64
+	// Postmortems are the links to the postmortems and blog posts about
+	// the incidents, found in the advisories.
+	Postmortems []string
+}
+
+// maxAdvisoriesPages is the maximal number of pages of advisories fetched.
+const maxAdvisoriesPages = 10
+
+// GitHubAdvisoriesCollector collects the repository security advisories
+// published on GitHub.
+type GitHubAdvisoriesCollector struct {
+	Client *github.Client
+}
+
+func (c *GitHubAdvisoriesCollector) GetAdvisoriesStats(ctx context.Context, owner, repo string) (*AdvisoriesStats, error) {
+	opts := &github.ListRepositorySecurityAdvisoriesOptions{
+		State:             "published",
+		ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+	}
+	stats := &AdvisoriesStats{}
+	for range maxAdvisoriesPages {
+		advisories, resp, err := c.Client.SecurityAdvisories.ListRepositorySecurityAdvisories(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("ListRepositorySecurityAdvisories failed: %w", err)
+		}
+		for _, advisory := range advisories {
+			stats.add(advisory)
+		}
+		if resp.After == "" {
+			break
+		}
+		opts.After = resp.After
+	}
+	return stats, nil
+}
+
+var (
+	linkRegexp = regexp.MustCompile(`https?://[^\s)\]>"']+`)
+	// postmortemRegexp matches the links to postmortems and blog posts.
+	postmortemRegexp = regexp.MustCompile(`(?i)post-?mortem|incident|retrospective|blog`)
+	// workaroundRegexp matches the remediation guidance in the descriptions.
+	workaroundRegexp = regexp.MustCompile(`(?i)workaround|mitigat|upgrade to|update to|patched in|fixed in`)
+)
+
+func (s *AdvisoriesStats) add(advisory *github.SecurityAdvisory) {
+	s.Published++
+	if len(advisory.Credits) > 0 || len(advisory.CreditsDetailed) > 0 {
+		s.WithCredits++
+	}
+	if cvss := advisory.GetCVSS(); cvss != nil && (cvss.GetScore() != nil && *cvss.Score > 0 || cvss.GetVectorString() != "") {
+		s.WithCVSS++
+	}
+	remediation := workaroundRegexp.MatchString(advisory.GetDescription())
+	for _, vulnerability := range advisory.Vulnerabilities {
+		if vulnerability.GetPatchedVersions() != "" || vulnerability.GetFirstPatchedVersion().GetIdentifier() != "" {
+			remediation = true
+		}
+	}
+	if remediation {
+		s.WithRemediation++
+	}
+	links := linkRegexp.FindAllString(advisory.GetDescription(), -1)
+	for _, reference := range advisory.References {
+		links = append(links, reference.GetURL())
+	}
+	for _, link := range links {
+		link = strings.TrimRight(link, ".,;:")
+		if postmortemRegexp.MatchString(link) && !slices.Contains(s.Postmortems, link) {
+			s.Postmortems = append(s.Postmortems, link)
+		}
+	}
+}
+
+// computeSecurityProcessScore scores the maturity of the security process,
+// from 1 to 5, with the share of the advisories that have credits, a CVSS
+// score and a remediation guidance, and the postmortems. There is no score
+// without advisories.
+func computeSecurityProcessScore(metrics Metrics) *int64 {
+	published := metrics.Value("advisories.published")
+	if published == 0 {
+		return nil
+	}
+	quality := metrics.Value("advisories.with_credits")/published +
+		metrics.Value("advisories.with_cvss")/published +
+		metrics.Value("advisories.with_remediation")/published
+	if metrics.Value("advisories.postmortems") > 0 {
+		quality++
+	}
+	score := 1 + int64(math.Round(quality))
+	return &score
+}
@@ -0,0 +1,63 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// issueBacklog is the number of open and closed issues of a repository, and
+// of the issues opened and closed in the last year.
+type issueBacklog struct {
+	Open           int64
+	Closed         int64
+	OpenedLastYear int64
+	ClosedLastYear int64
+}
+
+// getIssueBacklog counts the issues with the search API, which gives the
+// number of matching issues without listing them.
+func (c *GitHubAPICollector) getIssueBacklog(ctx context.Context, owner, repo string) (*issueBacklog, error) {
+	since := reportTime().AddDate(-1, 0, 0).UTC().Format(time.DateOnly)
+	backlog := &issueBacklog{}
+	for _, search := range []struct {
+		qualifiers string
+		count      *int64
+	}{
+		{"is:open", &backlog.Open},
+		{"is:closed", &backlog.Closed},
+		{"created:>=" + since, &backlog.OpenedLastYear},
+		{"closed:>=" + since, &backlog.ClosedLastYear},
+	} {
+		query := fmt.Sprintf("repo:%s/%s is:issue %s", owner, repo, search.qualifiers)
+		result, _, err := c.Client.Search.Issues(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		if err != nil {
+			return nil, fmt.Errorf("Search.Issues failed: %w", err)
+		}
+		*search.count = int64(result.GetTotal())
+	}
+	return backlog, nil
+}
+
+// setIssueBacklog sets the issues of the stats.
+func (s *GitHubStats) setIssueBacklog(backlog *issueBacklog) {
+	s.OpenIssues = backlog.Open
+	s.ClosedIssues = backlog.Closed
+	s.IssuesOpenedLastYear = backlog.OpenedLastYear
+	s.IssuesClosedLastYear = backlog.ClosedLastYear
+}
+
+// openIssueShare returns the percentage of the issues that are open.
+func (s *GitHubStats) openIssueShare() float64 {
+	return share(s.OpenIssues, s.OpenIssues+s.ClosedIssues)
+}
+
+// backlogGrowth returns the growth of the open issues in the last year, in
+// percent of the open issues one year ago. A backlog growing from 0 issues
+// grows by 100% per issue.
+func (s *GitHubStats) backlogGrowth() float64 {
+	growth := s.IssuesOpenedLastYear - s.IssuesClosedLastYear
+	return share(growth, max(s.OpenIssues-growth, 1))
+}
@@ -0,0 +1,58 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Badge is a shields.io endpoint badge, showing the overall score of a
+// project. See https://shields.io/badges/endpoint-badge.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	// CacheSeconds is how long shields.io caches the badges served by the
+	// API.
+	CacheSeconds int `json:"cacheSeconds,omitempty"`
+}
+
+// badgeColors are the colors of the badges, by minimal overall score.
+var badgeColors = []struct {
+	min   float64
+	color string
+}{
+	{4, "brightgreen"},
+	{3.5, "green"},
+	{3, "yellowgreen"},
+	{2.5, "yellow"},
+	{2, "orange"},
+	{0, "red"},
+}
+
+// NewBadge returns the badge of an evaluation, colored by its overall score.
+func NewBadge(evaluation *Evaluation) *Badge {
+	badge := &Badge{
+		SchemaVersion: 1,
+		Label:         "QSOS",
+		Message:       fmt.Sprintf("%.2f/5", evaluation.Scores.Overall),
+	}
+	for _, c := range badgeColors {
+		if evaluation.Scores.Overall >= c.min {
+			badge.Color = c.color
+			break
+		}
+	}
+	return badge
+}
+
+// WriteBadge writes the badge of an evaluation in JSON.
+func WriteBadge(w io.Writer, evaluation *Evaluation) error {
+	data, err := json.MarshalIndent(NewBadge(evaluation), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode the badge: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
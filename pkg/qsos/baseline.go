@@ -0,0 +1,114 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Baseline has the scores of projects, used as a reference for detecting
+// regressions. It maps the projects (owner/repo) to the scores of their
+// criteria, including the overall score.
+type Baseline map[string]map[string]float64
+
+// Regression is a criterion whose score has decreased since the baseline.
+type Regression struct {
+	Project   string
+	Criterion string
+	Baseline  float64
+	Current   float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %g -> %g", r.Project, r.Criterion, r.Baseline, r.Current)
+}
+
+func NewBaseline(evaluations []*Evaluation) Baseline {
+	baseline := Baseline{}
+	for _, evaluation := range evaluations {
+		baseline[evaluation.Name()] = evaluationScores(evaluation)
+	}
+	return baseline
+}
+
+// evaluationScores returns the scores of the criteria of an evaluation, and
+// its overall score.
+func evaluationScores(evaluation *Evaluation) map[string]float64 {
+	scores := map[string]float64{"overall": evaluation.Scores.Overall}
+	for _, criterion := range evaluation.Scores.Criteria() {
+		scores[criterion.Name] = float64(*criterion.Score)
+	}
+	return scores
+}
+
+func ReadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read baseline: %w", err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("Invalid baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+func (b Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("Cannot write baseline: %w", err)
+	}
+	return nil
+}
+
+// Regressions returns the criteria of the evaluations whose score has
+// decreased by more than delta since the baseline. The projects that are not
+// in the baseline are ignored.
+func (b Baseline) Regressions(evaluations []*Evaluation, delta float64) []Regression {
+	var regressions []Regression
+	for _, evaluation := range evaluations {
+		reference, ok := b[evaluation.Name()]
+		if !ok {
+			continue
+		}
+		current := evaluationScores(evaluation)
+		for _, name := range append(criteriaNames(evaluation.Scores), "overall") {
+			before, ok := reference[name]
+			if !ok {
+				continue
+			}
+			if before-current[name] > delta {
+				regressions = append(regressions, Regression{
+					Project:   evaluation.Name(),
+					Criterion: name,
+					Baseline:  before,
+					Current:   current[name],
+				})
+			}
+		}
+	}
+	return regressions
+}
+
+func criteriaNames(scores *ProjectScores) []string {
+	var names []string
+	for _, criterion := range scores.Criteria() {
+		names = append(names, criterion.Name)
+	}
+	return names
+}
+
+func PrintRegressions(w io.Writer, regressions []Regression, opts *ReportOptions) {
+	lang := opts.language()
+	printSection(w, lang, "Regressions")
+	if len(regressions) == 0 {
+		fmt.Fprintf(w, "%s\n", lang.tr("None"))
+	}
+	for _, r := range regressions {
+		fmt.Fprintf(w, "%s\n", r)
+	}
+}
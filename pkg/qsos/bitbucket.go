@@ -0,0 +1,374 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BitbucketCollector collects the community stats of the projects hosted on
+// Bitbucket Cloud with its API. Bitbucket has no stars: the watchers are
+// counted instead. The license, the weekly commits and the maintainers are
+// not collected.
+type BitbucketCollector struct {
+	// APIURL is the base URL of the API, DefaultBitbucketAPIURL by default.
+	APIURL string
+	// Username is the user of an app password. Without it, the token is an
+	// access token of the workspace or of the repository. The token is
+	// optional for the public projects.
+	Username string
+	Token    string
+	HTTP     *http.Client
+	// Progress is optional.
+	Progress ProgressFunc
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+}
+
+// DefaultBitbucketAPIURL is the URL of the API of Bitbucket Cloud.
+const DefaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// maxBitbucketCommitPages limits the number of requests for the commits of
+// the last 6 months.
+const maxBitbucketCommitPages = 20
+
+// bitbucketPage is a page of the paginated responses. Size is only given by
+// some endpoints.
+type bitbucketPage[T any] struct {
+	Size   int64  `json:"size"`
+	Next   string `json:"next"`
+	Values []T    `json:"values"`
+}
+
+type bitbucketTag struct {
+	Name   string `json:"name"`
+	Target struct {
+		Date time.Time `json:"date"`
+	} `json:"target"`
+}
+
+type bitbucketRepository struct {
+	CreatedOn  time.Time `json:"created_on"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+type bitbucketUser struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+type bitbucketCommit struct {
+	Date   time.Time `json:"date"`
+	Author struct {
+		// Raw is like "Name <email>".
+		Raw  string         `json:"raw"`
+		User *bitbucketUser `json:"user"`
+	} `json:"author"`
+}
+
+// isBot returns true if the commit has been authored by a bot.
+func (c *bitbucketCommit) isBot(bots *BotFilter) bool {
+	if c.Author.User != nil && bots.isBot(c.Author.User.Nickname, "") {
+		return true
+	}
+	return bots.isBotAuthor(c.author())
+}
+
+// author returns the name and the email of the author of the commit.
+func (c *bitbucketCommit) author() (string, string) {
+	name, email, _ := strings.Cut(c.Author.Raw, "<")
+	return strings.TrimSpace(name), strings.ToLower(strings.TrimSuffix(strings.TrimSpace(email), ">"))
+}
+
+type bitbucketPullRequest struct {
+	UpdatedOn time.Time     `json:"updated_on"`
+	Author    bitbucketUser `json:"author"`
+}
+
+type bitbucketDownload struct {
+	Name string `json:"name"`
+}
+
+func (c *BitbucketCollector) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	stats := &GitHubStats{}
+	project := "repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+
+	// 1. Get the repository info (main branch, creation date), and count
+	// the watchers and the forks
+	var info bitbucketRepository
+	if err := c.get(ctx, c.apiURL(project, nil), &info); err != nil {
+		return nil, err
+	}
+	if info.MainBranch == nil {
+		return nil, fmt.Errorf("could not find the main branch of %s/%s", owner, repo)
+	}
+	var watchers, forks bitbucketPage[json.RawMessage]
+	if err := c.get(ctx, c.apiURL(project+"/watchers", url.Values{"pagelen": {"1"}}), &watchers); err != nil {
+		return nil, err
+	}
+	if err := c.get(ctx, c.apiURL(project+"/forks", url.Values{"pagelen": {"1"}}), &forks); err != nil {
+		return nil, err
+	}
+	stats.Stars = watchers.Size
+	stats.Forks = forks.Size
+
+	// 2. Get the date of the last commit, and of the last one not authored
+	// by a bot
+	commits := project + "/commits/" + url.PathEscape(info.MainBranch.Name)
+	var lastCommits bitbucketPage[bitbucketCommit]
+	if err := c.get(ctx, c.apiURL(commits, url.Values{"pagelen": {"100"}}), &lastCommits); err != nil {
+		return nil, err
+	}
+	if len(lastCommits.Values) == 0 {
+		return nil, fmt.Errorf("could not find last commit date")
+	}
+	stats.LastCommitDate = lastCommits.Values[0].Date.UTC()
+	for _, commit := range lastCommits.Values {
+		// If the last 100 commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.Date.UTC()
+		if !commit.isBot(c.Bots) {
+			break
+		}
+	}
+
+	// 3. Bitbucket does not count the commits: the creation date of the
+	// repository is used for the first commit
+	stats.FirstCommitDate = info.CreatedOn.UTC()
+
+	// 4. Get the number of active contributors, the authors of at least 4
+	// commits in the last 6 months by default
+	contribs, err := c.getContributions(ctx, owner, repo, info.MainBranch.Name, c.Contributors.orDefault())
+	if err != nil {
+		return nil, err
+	}
+	stats.ActiveContributors = contribs.Active
+	stats.ContributorsWindow = c.Contributors.orDefault()
+	stats.BotCommits = contribs.BotCommits
+	stats.setOrganizations(contribs.Organizations)
+	stats.BotCommitShare = share(contribs.BotCommits, contribs.Commits)
+
+	sixMonthsAgo := reportTime().AddDate(0, -6, 0)
+	// 5. Get the share of the pull requests merged in the last 6 months
+	// that were opened by bots
+	stats.BotPullRequestShare, err = c.getBotPullRequestShare(ctx, owner, repo, sixMonthsAgo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. Get the platforms of the downloads, Bitbucket has no releases
+	stats.ReleasePlatforms, err = c.getDownloadPlatforms(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("platforms of the downloads not available", "project", owner+"/"+repo, "err", err)
+	}
+
+	// 7. Get the last tags, the releases of Bitbucket
+	stats.Releases, err = c.getTags(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("tags not available", "project", owner+"/"+repo, "err", err)
+	}
+	return stats, nil
+}
+
+// getTags returns the last tags of a repository. The date of a tag is the
+// one of its commit.
+func (c *BitbucketCollector) getTags(ctx context.Context, owner, repo string) ([]Release, error) {
+	var list bitbucketPage[bitbucketTag]
+	u := c.apiURL("repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/refs/tags", url.Values{
+		"pagelen": {strconv.Itoa(maxReleases)},
+		"sort":    {"-target.date"},
+	})
+	if err := c.get(ctx, u, &list); err != nil {
+		return nil, err
+	}
+	var tags []Release
+	for _, tag := range list.Values {
+		if tag, ok := newRelease(tag.Name, tag.Target.Date, false); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return mergeReleases(nil, tags), nil
+}
+
+// getContributions counts the commits of the window. Bitbucket has no
+// filter on the dates: the commits are listed from the newest one until an
+// older one is found.
+func (c *BitbucketCollector) getContributions(ctx context.Context, owner, repo, branch string, window *ContributorsWindow) (*contributions, error) {
+	since := window.since()
+	result := &contributions{}
+	contributors := newIdentities()
+	next := c.apiURL("repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/commits/"+url.PathEscape(branch), url.Values{"pagelen": {"100"}})
+	for page := 1; page <= maxBitbucketCommitPages && next != ""; page++ {
+		var commits bitbucketPage[bitbucketCommit]
+		if err := c.get(ctx, next, &commits); err != nil {
+			return nil, err
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page, Total: maxBitbucketCommitPages})
+		next = commits.Next
+		for _, commit := range commits.Values {
+			if commit.Date.Before(since) {
+				next = ""
+				break
+			}
+			result.Commits++
+			if commit.isBot(c.Bots) {
+				result.BotCommits++
+				continue
+			}
+			name, email := commit.author()
+			var login string
+			if commit.Author.User != nil {
+				login = commit.Author.User.Nickname
+			}
+			contributors.add(name, email, login)
+		}
+	}
+	result.Active = contributors.active(window.MinCommits)
+	result.Organizations = contributors.organizationStats()
+	return result, nil
+}
+
+// getBotPullRequestShare returns the percentage of the pull requests merged
+// since the given date that have been opened by bots. Bitbucket does not give
+// the merge date: the date of the last update is used.
+func (c *BitbucketCollector) getBotPullRequestShare(ctx context.Context, owner, repo string, since time.Time) (float64, error) {
+	var merged, bots int64
+	next := c.apiURL("repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/pullrequests", url.Values{
+		"state":   {"MERGED"},
+		"q":       {fmt.Sprintf("updated_on >= %s", since.UTC().Format(time.RFC3339))},
+		"sort":    {"-updated_on"},
+		"pagelen": {"50"},
+	})
+	for page := 1; page <= maxPullRequestPages && next != ""; page++ {
+		var pulls bitbucketPage[bitbucketPullRequest]
+		if err := c.get(ctx, next, &pulls); err != nil {
+			return 0, err
+		}
+		next = pulls.Next
+		for _, pull := range pulls.Values {
+			if pull.UpdatedOn.Before(since) {
+				next = ""
+				break
+			}
+			merged++
+			if c.Bots.isBot(pull.Author.Nickname, "") || c.Bots.isBot(pull.Author.DisplayName, "") {
+				bots++
+			}
+		}
+	}
+	return share(bots, merged), nil
+}
+
+// getDownloadPlatforms returns the platforms covered by the last files of
+// the downloads of the repository.
+func (c *BitbucketCollector) getDownloadPlatforms(ctx context.Context, owner, repo string) ([]string, error) {
+	var downloads bitbucketPage[bitbucketDownload]
+	if err := c.get(ctx, c.apiURL("repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/downloads", url.Values{"pagelen": {"100"}}), &downloads); err != nil {
+		return nil, err
+	}
+	var platforms []string
+	for _, download := range downloads.Values {
+		platforms = append(platforms, assetPlatforms(download.Name)...)
+	}
+	slices.Sort(platforms)
+	return slices.Compact(platforms), nil
+}
+
+// GetReadme returns the content of the README of a project.
+func (c *BitbucketCollector) GetReadme(ctx context.Context, owner, repo string) (string, error) {
+	project := "repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	var info bitbucketRepository
+	if err := c.get(ctx, c.apiURL(project, nil), &info); err != nil {
+		return "", err
+	}
+	if info.MainBranch == nil {
+		return "", fmt.Errorf("no README in %s/%s", owner, repo)
+	}
+	for _, name := range readmeNames {
+		req, err := c.newRequest(ctx, c.apiURL(project+"/src/"+url.PathEscape(info.MainBranch.Name)+"/"+name, nil))
+		if err != nil {
+			return "", err
+		}
+		res, err := c.HTTP.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("Error on request: %w", err)
+		}
+		content, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("Bitbucket API %s: unexpected response: %d", name, res.StatusCode)
+		}
+		if err != nil {
+			return "", fmt.Errorf("Cannot read the README: %w", err)
+		}
+		return string(content), nil
+	}
+	return "", fmt.Errorf("no README in %s/%s", owner, repo)
+}
+
+// apiURL returns the URL of an endpoint of the Bitbucket API.
+func (c *BitbucketCollector) apiURL(path string, query url.Values) string {
+	base := c.APIURL
+	if base == "" {
+		base = DefaultBitbucketAPIURL
+	}
+	u := strings.TrimSuffix(base, "/") + "/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *BitbucketCollector) newRequest(ctx context.Context, u string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the request: %w", err)
+	}
+	switch {
+	case c.Token != "" && c.Username != "":
+		req.SetBasicAuth(c.Username, c.Token)
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// get decodes the JSON response of a request to the Bitbucket API, given by
+// its full URL like the next pages of the paginated responses.
+func (c *BitbucketCollector) get(ctx context.Context, u string, data any) error {
+	req, err := c.newRequest(ctx, u)
+	if err != nil {
+		return err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	path := strings.TrimPrefix(req.URL.Path, "/2.0/")
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("Bitbucket API %s: not found", path)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Bitbucket API %s: unexpected response: %d", path, res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return fmt.Errorf("Bitbucket API %s: invalid response: %w", path, err)
+	}
+	return nil
+}
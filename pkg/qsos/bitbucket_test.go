@@ -0,0 +1,97 @@
+package qsos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBitbucketCollector(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1780272000")
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	commit := func(name, nickname string, days int) map[string]any {
+		author := map[string]any{"raw": name + " <" + name + "@example.com>"}
+		if nickname != "" {
+			author["user"] = map[string]string{"nickname": nickname}
+		}
+		return map[string]any{"date": ago(days), "author": author}
+	}
+	var credentials []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		credentials = append(credentials, username+":"+password)
+		switch r.URL.Path {
+		case "/2.0/repositories/workspace/repo":
+			serveJSON(w, map[string]any{"created_on": ago(700), "mainbranch": map[string]string{"name": "main"}})
+		case "/2.0/repositories/workspace/repo/watchers":
+			serveJSON(w, map[string]any{"size": 8, "values": []any{}})
+		case "/2.0/repositories/workspace/repo/forks":
+			serveJSON(w, map[string]any{"size": 2, "values": []any{}})
+		case "/2.0/repositories/workspace/repo/commits/main":
+			if r.URL.Query().Get("page") == "2" {
+				serveJSON(w, map[string]any{"values": []map[string]any{
+					commit("Jane", "jane", 4), commit("Jane Doe", "", 5), commit("John", "", 6), commit("Max", "", 300),
+				}})
+				return
+			}
+			serveJSON(w, map[string]any{
+				"next": server.URL + "/2.0/repositories/workspace/repo/commits/main?pagelen=100&page=2",
+				"values": []map[string]any{
+					commit("dependabot[bot]", "", 1), commit("Jane Doe", "jane", 2), commit("jane", "jane", 3),
+				},
+			})
+		case "/2.0/repositories/workspace/repo/pullrequests":
+			serveJSON(w, map[string]any{"values": []map[string]any{
+				{"updated_on": ago(2), "author": map[string]string{"nickname": "renovate[bot]"}},
+				{"updated_on": ago(4), "author": map[string]string{"nickname": "jane"}},
+				{"updated_on": ago(300), "author": map[string]string{"nickname": "renovate[bot]"}},
+			}})
+		case "/2.0/repositories/workspace/repo/downloads":
+			serveJSON(w, map[string]any{"values": []map[string]string{{"name": "tool_windows_amd64.zip"}}})
+		case "/2.0/repositories/workspace/repo/refs/tags":
+			serveJSON(w, map[string]any{"values": []map[string]any{
+				{"name": "v1.0.0", "target": map[string]string{"date": ago(50)}},
+				{"name": "docs", "target": map[string]string{"date": ago(60)}},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	collector := &BitbucketCollector{APIURL: server.URL + "/2.0", Username: "user", Token: "secret", HTTP: server.Client()}
+	stats, err := collector.GetGitHubStats(context.Background(), "workspace", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stars != 8 || stats.Forks != 2 {
+		t.Errorf("stars and forks = %d and %d, want the 8 watchers and 2", stats.Stars, stats.Forks)
+	}
+	if want := now.AddDate(0, 0, -700); !stats.FirstCommitDate.Equal(want) {
+		t.Errorf("first commit = %v, want the creation date %v", stats.FirstCommitDate, want)
+	}
+	if want := now.AddDate(0, 0, -2); !stats.LastHumanCommitDate.Equal(want) {
+		t.Errorf("last human commit = %v, want %v", stats.LastHumanCommitDate, want)
+	}
+	if stats.ActiveContributors != 1 || stats.BotCommits != 1 || stats.BotCommitShare != 16.67 {
+		t.Errorf("active contributors, bot commits and bot share = %d, %d and %v, want 1, 1 and 16.67",
+			stats.ActiveContributors, stats.BotCommits, stats.BotCommitShare)
+	}
+	if stats.BotPullRequestShare != 50 {
+		t.Errorf("bot share of the pull requests = %v, want 50", stats.BotPullRequestShare)
+	}
+	if want := []string{"windows/amd64"}; !slices.Equal(stats.ReleasePlatforms, want) {
+		t.Errorf("release platforms = %v, want %v", stats.ReleasePlatforms, want)
+	}
+	if len(stats.Releases) != 1 || stats.Releases[0].Name != "v1.0.0" {
+		t.Errorf("releases = %+v, want the v1.0.0 tag", stats.Releases)
+	}
+	if i := slices.IndexFunc(credentials, func(c string) bool { return c != "user:secret" }); i >= 0 {
+		t.Errorf("request %d has the credentials %q, want user:secret", i, credentials[i])
+	}
+}
@@ -0,0 +1,184 @@
+package qsos
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// BotFilter identifies the bots among the authors of the commits and of the
+// pull requests. The accounts flagged as bots by GitHub, and the logins and
+// names ending with [bot], like dependabot[bot], are always bots.
+type BotFilter struct {
+	// Logins are the logins or the names of the bots, case-insensitive.
+	Logins []string
+	// Names are regular expressions matching the names or the logins of
+	// the bots.
+	Names []*regexp.Regexp
+	// Emails are the emails of the bots, case-insensitive, or the domains
+	// of their emails starting with @.
+	Emails []string
+}
+
+// DefaultBotFilter has the well-known bots that are not flagged as such by
+// GitHub, or that commit with a plain name.
+var DefaultBotFilter = &BotFilter{
+	Logins: []string{"dependabot", "dependabot-preview", "renovate", "renovate-bot", "github-actions"},
+	Emails: []string{"bot@renovateapp.com", "support@dependabot.com"},
+}
+
+// ParseBotFilter parses a comma-separated list of bots, added to the default
+// ones: the emails or @domains contain a @, the regular expressions of the
+// names are between slashes, like /-ci$/, and the other entries are logins
+// or names.
+func ParseBotFilter(value string) (*BotFilter, error) {
+	filter := &BotFilter{
+		Logins: slices.Clone(DefaultBotFilter.Logins),
+		Names:  slices.Clone(DefaultBotFilter.Names),
+		Emails: slices.Clone(DefaultBotFilter.Emails),
+	}
+	for _, entry := range SplitList(value) {
+		switch {
+		case len(entry) > 1 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/"):
+			re, err := regexp.Compile("(?i)" + entry[1:len(entry)-1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid bot pattern %q: %w", entry, err)
+			}
+			filter.Names = append(filter.Names, re)
+		case strings.Contains(entry, "@"):
+			filter.Emails = append(filter.Emails, strings.ToLower(entry))
+		default:
+			filter.Logins = append(filter.Logins, strings.ToLower(entry))
+		}
+	}
+	return filter, nil
+}
+
+// orDefault returns the filter, or the default one if it is nil.
+func (f *BotFilter) orDefault() *BotFilter {
+	if f == nil {
+		return DefaultBotFilter
+	}
+	return f
+}
+
+// cacheKey identifies the filter in the keys of the result cache. It is
+// empty for the default filter.
+func (f *BotFilter) cacheKey() string {
+	key := func(f *BotFilter) string {
+		var names []string
+		for _, re := range f.Names {
+			names = append(names, re.String())
+		}
+		return strings.Join(f.Logins, ",") + "|" + strings.Join(names, ",") + "|" + strings.Join(f.Emails, ",")
+	}
+	if value := key(f.orDefault()); value != key(DefaultBotFilter) {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))[:8]
+	}
+	return ""
+}
+
+// isBot returns true if the GitHub account, or the name of a commit author,
+// is a bot.
+func (f *BotFilter) isBot(login, accountType string) bool {
+	if accountType == "Bot" {
+		return true
+	}
+	login = strings.ToLower(strings.TrimSpace(login))
+	if login == "" {
+		return false
+	}
+	if strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+	f = f.orDefault()
+	if slices.Contains(f.Logins, login) {
+		return true
+	}
+	return slices.ContainsFunc(f.Names, func(re *regexp.Regexp) bool {
+		return re.MatchString(login)
+	})
+}
+
+// isBotAuthor returns true if the author of a commit, given by its name and
+// its email, is a bot. The noreply emails of the bot accounts of GitHub, like
+// 49699333+dependabot[bot]@users.noreply.github.com, are the ones of bots.
+func (f *BotFilter) isBotAuthor(name, email string) bool {
+	if f.isBot(name, "") {
+		return true
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	if match := noreplyEmail.FindStringSubmatch(email); match != nil && f.isBot(match[1], "") {
+		return true
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return slices.ContainsFunc(f.orDefault().Emails, func(bot string) bool {
+		return bot == email || bot == "@"+domain
+	})
+}
+
+// isBotCommit returns true if the commit has been authored by a bot.
+func (f *BotFilter) isBotCommit(commit *github.RepositoryCommit) bool {
+	author := commit.GetAuthor()
+	return f.isBot(author.GetLogin(), author.GetType()) || f.isBotAuthor(commit.GetCommit().GetAuthor().GetName(), commit.GetCommit().GetAuthor().GetEmail())
+}
+
+// share returns part as a percentage of total.
+func share(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return roundFloat(100*float64(part)/float64(total), 2)
+}
+
+// maxPullRequestPages limits the number of requests for the bot share of
+// the pull requests, as a busy project can merge thousands of them in a few
+// months.
+const maxPullRequestPages = 10
+
+// getBotPullRequestShare returns the percentage of the pull requests merged
+// since the given date that have been opened by bots.
+func (c *GitHubAPICollector) getBotPullRequestShare(ctx context.Context, owner, repo string, since time.Time) (float64, error) {
+	opts := &github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var merged, bots int64
+	for page := range maxPullRequestPages {
+		pulls, resp, err := c.Client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return 0, fmt.Errorf("PullRequests.List failed: %w", err)
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page + 1, Total: maxPullRequestPages})
+		done := false
+		for _, pull := range pulls {
+			if pull.GetUpdatedAt().Before(since) {
+				done = true
+				break
+			}
+			if pull.MergedAt == nil || pull.GetMergedAt().Before(since) {
+				continue
+			}
+			merged++
+			if user := pull.GetUser(); c.Bots.isBot(user.GetLogin(), user.GetType()) {
+				bots++
+			}
+		}
+		if done || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return share(bots, merged), nil
+}
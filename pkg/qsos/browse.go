@@ -0,0 +1,217 @@
+package qsos
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BrowseAxes are the pages of an evaluation in the browser: the axes of the
+// scores, and the red flags and warnings.
+var BrowseAxes = []string{"community", "tech", "security", "industrialization", "adoption", "flags"}
+
+// PrintAxis prints the scores of the criteria of an axis, with the raw value
+// each one is computed from. With details, all the stats collected for the
+// axis are printed below.
+func PrintAxis(w io.Writer, evaluation *Evaluation, axis string, details bool, opts *ReportOptions) {
+	lang := opts.language()
+	stats, scores := evaluation.Stats, evaluation.Scores
+	table := newTextTable(opts, "Criterion", "Score", "Raw value").alignRight(1)
+	switch axis {
+	case "community":
+		github := stats.GitHub
+		table.add(lang.tr("Maturity"), opts.formatScore(scores.Community.Maturity), lang.trf("first commit on %s", github.FirstCommitDate.Format(time.DateOnly)))
+		last := github.LastHumanCommitDate
+		if last.IsZero() {
+			last = github.LastCommitDate
+		}
+		activity := lang.trf("last commit by a human on %s", last.Format(time.DateOnly))
+		if weeks, ok := stats.metrics().Get("github.active_weeks"); ok {
+			activity = lang.trf("last commit by a human on %s, commits in %d of the last 52 weeks", last.Format(time.DateOnly), int64(weeks.Value))
+		}
+		table.add(lang.tr("Activity"), opts.formatScore(scores.Community.Activity), activity)
+		table.add(lang.tr("Popularity"), opts.formatScore(scores.Community.Popularity), lang.tr("weighted average of the sources"))
+		sources := popularitySources(stats)
+		for _, name := range slices.Sorted(maps.Keys(scores.Community.PopularitySources)) {
+			table.add("  - "+name, opts.formatScore(scores.Community.PopularitySources[name]), fmt.Sprint(sources[name]))
+		}
+		contributors := lang.trf("%d active contributors", github.ActiveContributors)
+		if github.ElephantFactor > 0 {
+			contributors = lang.trf("%d active contributors, elephant factor %d", github.ActiveContributors, github.ElephantFactor)
+		}
+		table.add(lang.tr("Contributors"), opts.formatScore(scores.Community.Contributors), contributors)
+		table.add(lang.tr("Responsiveness"), opts.formatScore(scores.Community.Responsiveness), formatIssueResponseTime(lang, github))
+		table.add(lang.tr("Backlog"), opts.formatScore(scores.Community.Backlog), formatIssueBacklog(lang, github))
+	case "tech":
+		sonar := stats.Sonar
+		table.add(lang.tr("Code size"), opts.formatScore(scores.Tech.Size), lang.trf("%d lines of code", sonar.LinesOfCode))
+		table.add(lang.tr("Cyclomatic complexity"), opts.formatScore(scores.Tech.CyclomaticComplexity), lang.trf("%d brain-overload issues for %d functions", sonar.BrainOverload, sonar.Functions))
+		table.add(lang.tr("Cognitive complexity"), opts.formatScore(scores.Tech.CognitiveComplexity), lang.trf("%d for %d functions", sonar.CognitiveComplexity, sonar.Functions))
+		table.add(lang.tr("Duplication"), opts.formatScore(scores.Tech.Duplication), lang.trf("%.1f%% of duplicated lines", sonar.DuplicationDensity))
+		table.add(lang.tr("Code smells"), opts.formatScore(scores.Tech.CodeSmells), lang.trf("%d code smells", sonar.CodeSmells))
+	case "security":
+		table.add(lang.tr("Scorecard"), opts.formatScore(scores.Security.ScoreCard), lang.trf("%d checks", len(stats.ScoreCard.Checks)))
+		if scores.Security.Process != nil {
+			table.add(lang.tr("Process"), opts.formatScore(*scores.Security.Process), lang.trf("%d published advisories", stats.Advisories.Published))
+		}
+	case "industrialization":
+		table.add(lang.tr("Release cadence"), opts.formatScore(scores.Industrialization.ReleaseCadence), formatReleaseCadence(lang, stats.GitHub, stats.collectedAt()))
+		table.add(lang.tr("Release freshness"), opts.formatScore(scores.Industrialization.ReleaseFreshness), formatLatestRelease(lang, stats.GitHub, stats.collectedAt()))
+		table.add(lang.tr("Versioning"), opts.formatScore(scores.Industrialization.Versioning), formatVersioning(lang, stats.GitHub, stats.collectedAt()))
+	case "adoption":
+		table.add(lang.tr("Platforms"), opts.formatScore(scores.Adoption.Platforms), cmp.Or(strings.Join(coveredPlatforms(stats), ", "), lang.tr("none detected")))
+	case "flags":
+		printFlags(w, opts, evaluation)
+		return
+	}
+	table.print(w)
+	fmt.Fprintf(w, "\n%s: %s\n", lang.tr("Overall"), opts.formatOverall(scores.Overall))
+	if details {
+		printAxisDetails(w, opts, evaluation, axis)
+	}
+}
+
+// printAxisDetails prints all the stats collected for an axis.
+func printAxisDetails(w io.Writer, opts *ReportOptions, evaluation *Evaluation, axis string) {
+	lang := opts.language()
+	stats, scores := evaluation.Stats, evaluation.Scores
+	switch axis {
+	case "community":
+		github := stats.GitHub
+		fields := [][2]string{
+			{"First commit", github.FirstCommitDate.Format("2006-01-02 15:04:05 MST")},
+			{"Last commit", github.LastCommitDate.Format("2006-01-02 15:04:05 MST")},
+			{"Last commit by a human", github.LastHumanCommitDate.Format("2006-01-02 15:04:05 MST")},
+			{"Stars", formatStarsCount(lang, github, github.Stars)},
+			{"Forks", formatStarsCount(lang, github, github.Forks)},
+			{"Active contributors", formatActiveContributors(lang, github)},
+			{"Commits by bots", formatBotCommits(lang, github)},
+			{"Elephant factor", formatElephantFactor(lang, github)},
+			{"Issue response time", formatIssueResponseTime(lang, github)},
+			{"Issues", formatIssueBacklog(lang, github)},
+			{"Merged PRs by bots", fmt.Sprintf("%.0f%%", github.BotPullRequestShare)},
+			{"Archived", formatBool(lang, github.Archived)},
+		}
+		if stats.Packages != nil {
+			fields = append(fields,
+				[2]string{"Downloads", fmt.Sprint(stats.Packages.Downloads)},
+				[2]string{"Dependents", fmt.Sprint(stats.Packages.Dependents)},
+				[2]string{"Container pulls", fmt.Sprint(stats.Packages.ContainerPulls)})
+		}
+		printFields(w, lang, "Raw values", fields)
+		if len(github.WeeklyCommits) > 0 {
+			var weeks []string
+			for _, nb := range github.WeeklyCommits {
+				weeks = append(weeks, fmt.Sprint(nb))
+			}
+			fmt.Fprintf(w, "\n%s:\n%s\n", lang.tr("Weekly commits of the last year"), strings.Join(weeks, " "))
+		}
+		if len(github.Maintainers) > 0 {
+			printSection(w, lang, "Maintainers")
+			table := newTextTable(opts, "Login", "Identified by", "Commits (6 months)", "Last activity").alignRight(2)
+			for _, m := range github.Maintainers {
+				table.add(m.Login, strings.Join(m.Sources, ", "), fmt.Sprint(m.Commits), formatLastActivity(lang, m.LastActivity))
+			}
+			table.print(w)
+		}
+	case "tech":
+		sonar := stats.Sonar
+		printFields(w, lang, "Raw values", [][2]string{
+			{"Lines of code", fmt.Sprint(sonar.LinesOfCode)},
+			{"Functions", fmt.Sprint(sonar.Functions)},
+			{"Functions estimated", formatBool(lang, sonar.FunctionsEstimated)},
+			{"Cyclomatic complexity", fmt.Sprint(sonar.CyclomaticComplexity)},
+			{"Cognitive complexity", fmt.Sprint(sonar.CognitiveComplexity)},
+			{"Brain-overload issues", fmt.Sprint(sonar.BrainOverload)},
+			{"Code smells", fmt.Sprint(sonar.CodeSmells)},
+			{"Duplication density", fmt.Sprintf("%.1f", sonar.DuplicationDensity)},
+			{"Incomplete", formatBool(lang, sonar.Incomplete)},
+		})
+		if len(scores.Refs) > 0 {
+			printRefs(w, opts, stats, scores)
+		}
+	case "security":
+		printSection(w, lang, "ScoreCard checks")
+		checks := newTextTable(opts, "Check", "Score").alignRight(1)
+		for _, check := range stats.ScoreCard.Checks {
+			checks.add(check.Name, fmt.Sprint(check.Score))
+		}
+		checks.print(w)
+		if advisories := stats.Advisories; advisories != nil {
+			fields := [][2]string{
+				{"Published", fmt.Sprint(advisories.Published)},
+				{"With credits", fmt.Sprint(advisories.WithCredits)},
+				{"With CVSS", fmt.Sprint(advisories.WithCVSS)},
+				{"With remediation", fmt.Sprint(advisories.WithRemediation)},
+			}
+			for _, link := range advisories.Postmortems {
+				fields = append(fields, [2]string{"Postmortem", link})
+			}
+			printFields(w, lang, "Security advisories", fields)
+		}
+	case "industrialization":
+		printFields(w, lang, "Raw values", [][2]string{
+			{"Release cadence", formatReleaseCadence(lang, stats.GitHub, stats.collectedAt())},
+			{"Latest release", formatLatestRelease(lang, stats.GitHub, stats.collectedAt())},
+			{"Versioning", formatVersioning(lang, stats.GitHub, stats.collectedAt())},
+		})
+		if len(stats.GitHub.Releases) > 0 {
+			printSection(w, lang, "Releases")
+			table := newTextTable(opts, "Version", "Date")
+			for _, release := range stats.GitHub.Releases[:min(len(stats.GitHub.Releases), 10)] {
+				name := release.Name
+				if release.Prerelease {
+					name += " " + lang.tr("(prerelease)")
+				}
+				table.add(name, release.Date.Format(time.DateOnly))
+			}
+			table.print(w)
+		}
+	case "adoption":
+		fields := [][2]string{
+			{"Release platforms", cmp.Or(strings.Join(stats.GitHub.ReleasePlatforms, ", "), lang.tr("none"))},
+		}
+		if stats.Packages != nil {
+			fields = append(fields, [2]string{"Container platforms", cmp.Or(strings.Join(stats.Packages.ContainerPlatforms, ", "), lang.tr("none"))})
+		}
+		fields = append(fields, [2]string{"License", cmp.Or(stats.GitHub.License, lang.tr("unknown"))})
+		if licensing := scores.Licensing; licensing != nil {
+			fields = append(fields,
+				[2]string{"License class", licensing.Class},
+				[2]string{"Rationale", licensing.Rationale})
+		}
+		printFields(w, lang, "Raw values", fields)
+	}
+}
+
+func formatBool(lang *Language, b bool) string {
+	if b {
+		return lang.tr("yes")
+	}
+	return lang.tr("no")
+}
+
+// printFlags prints the red flags, the warnings, the policy result and the
+// summary of an evaluation.
+func printFlags(w io.Writer, opts *ReportOptions, evaluation *Evaluation) {
+	lang := opts.language()
+	if len(evaluation.RedFlags) == 0 && len(evaluation.Stats.Warnings) == 0 {
+		fmt.Fprintf(w, "%s\n", opts.colorize(colorGreen, lang.tr("No red flag or warning")))
+	}
+	for _, flag := range evaluation.RedFlags {
+		fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorRed, lang.tr("RED FLAG")), flag.Message)
+	}
+	for _, warning := range evaluation.Stats.Warnings {
+		fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorYellow, lang.tr("WARNING")), warning.Message)
+	}
+	for _, msg := range evaluation.Denied {
+		fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorRed, lang.tr("Denied")), msg)
+	}
+	if evaluation.Stats.Summary != "" {
+		fmt.Fprintf(w, "\n%s\n", evaluation.Stats.Summary)
+	}
+}
@@ -0,0 +1,118 @@
+package qsos
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Checkpoint records the stats of the projects evaluated by a batch run, one
+// JSON line per project, so that an interrupted run can resume without
+// collecting them again.
+type Checkpoint struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	done map[string]*RawStats
+}
+
+// checkpointEntry is a line of a checkpoint file.
+type checkpointEntry struct {
+	// Project is the project or the local dir, as given to the batch run.
+	Project string
+	RawStats
+}
+
+// OpenCheckpoint opens a checkpoint file, and reads the projects already
+// evaluated if it exists.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, done: map[string]*RawStats{}}
+	file, err := os.Open(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("Cannot read the checkpoint: %w", err)
+	}
+	// size is the length of the complete lines
+	var size int64
+	if err == nil {
+		defer file.Close()
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Cannot read the checkpoint: %w", err)
+			}
+			size += int64(len(line))
+			var entry checkpointEntry
+			if err := json.Unmarshal(line, &entry); err != nil || entry.Stats == nil {
+				continue
+			}
+			c.done[entry.Project] = &entry.RawStats
+		}
+	}
+	if c.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644); err != nil {
+		return nil, fmt.Errorf("Cannot open the checkpoint: %w", err)
+	}
+	// The last line of an interrupted run can be partial: it is removed, so
+	// that the next entry starts on a new line
+	if err := c.file.Truncate(size); err != nil {
+		c.file.Close()
+		return nil, fmt.Errorf("Cannot repair the checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// Done returns the stats of a project evaluated by a previous run, or nil.
+func (c *Checkpoint) Done(project string) *RawStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[project]
+}
+
+// Len returns the number of projects already evaluated.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Save records the evaluation of a project.
+func (c *Checkpoint) Save(project string, evaluation *Evaluation) error {
+	entry := checkpointEntry{Project: project, RawStats: RawStats{Owner: evaluation.Owner, Repo: evaluation.Repo, Stats: evaluation.Stats}}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("Cannot encode the checkpoint: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("Cannot write the checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("Cannot write the checkpoint: %w", err)
+	}
+	c.done[project] = &entry.RawStats
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// Remove closes and removes the checkpoint file, once the run is complete.
+func (c *Checkpoint) Remove() error {
+	c.file.Close()
+	if err := os.Remove(c.path); err != nil {
+		return fmt.Errorf("Cannot remove the checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package qsos
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	checkpoint, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, project := range []string{"owner/one", "owner/two"} {
+		if err := checkpoint.Save(project, &Evaluation{Owner: "owner", Repo: project[6:], Stats: &ProjectStats{GitHub: &GitHubStats{Stars: 1}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkpoint.Close()
+
+	// An interrupted run leaves a partial line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"Project":"owner/three","Owner":"own`)
+	file.Close()
+
+	checkpoint, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Len() != 2 || checkpoint.Done("owner/two") == nil || checkpoint.Done("owner/three") != nil {
+		t.Fatalf("resumed projects = %d, want owner/one and owner/two", checkpoint.Len())
+	}
+	if err := checkpoint.Save("owner/three", &Evaluation{Owner: "owner", Repo: "three", Stats: &ProjectStats{GitHub: &GitHubStats{}}}); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Close()
+
+	checkpoint, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+	if checkpoint.Len() != 3 || checkpoint.Done("owner/three") == nil {
+		t.Errorf("resumed projects = %d, want 3 with owner/three", checkpoint.Len())
+	}
+}
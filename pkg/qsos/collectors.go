@@ -0,0 +1,42 @@
+package qsos
+
+import "context"
+
+// The collectors stop their requests and their commands when the context is
+// done.
+
+// GitHubCollector collects the community stats of a project.
+type GitHubCollector interface {
+	GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error)
+}
+
+// SonarCollector collects the tech stats of a project.
+type SonarCollector interface {
+	GetSonarStats(ctx context.Context, owner, repo string) (*SonarStats, error)
+	// AnalyzeDir computes the tech stats of the sources in dir. The
+	// component identifies them for the analyzers that keep the results.
+	AnalyzeDir(ctx context.Context, dir, component string) (*SonarStats, error)
+}
+
+// historyCollector is implemented by the collectors of the community stats
+// that can read the dates of the commits and the contributors from a clone of
+// the repository with its history, instead of sending requests for them.
+type historyCollector interface {
+	GetGitHubStatsFromHistory(ctx context.Context, owner, repo, dir string) (*GitHubStats, error)
+}
+
+// sourcesCollector is implemented by the collectors of the tech stats that
+// may analyze a clone of the repository.
+type sourcesCollector interface {
+	// clonesSources tells if the collector analyzes a clone, instead of
+	// reading the results of previous analyses.
+	clonesSources() bool
+}
+
+// ScorecardCollector collects the security stats of a project.
+type ScorecardCollector interface {
+	GetScoreCardStats(ctx context.Context, owner, repo string) (*ScoreCardStats, error)
+	// AnalyzeDir runs the checks that do not need the API of a forge on the
+	// working copy in dir.
+	AnalyzeDir(ctx context.Context, dir string) (*ScoreCardStats, error)
+}
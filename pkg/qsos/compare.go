@@ -0,0 +1,100 @@
+package qsos
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Comparison is a matrix of the scores of several projects, with one row per
+// criterion and one column per project.
+type Comparison struct {
+	Projects []string
+	Rows     []*ComparisonRow
+	// RedFlags are the red flags of the projects, by project name.
+	RedFlags map[string][]RedFlag
+}
+
+type ComparisonRow struct {
+	Criterion string
+	Scores    []float64
+	// Best are the indexes of the projects with the best score for this
+	// criterion.
+	Best []int
+}
+
+func Compare(evaluations []*Evaluation) *Comparison {
+	comparison := &Comparison{RedFlags: map[string][]RedFlag{}}
+	if len(evaluations) == 0 {
+		return comparison
+	}
+	for _, evaluation := range evaluations {
+		comparison.Projects = append(comparison.Projects, evaluation.Name())
+		if len(evaluation.RedFlags) > 0 {
+			comparison.RedFlags[evaluation.Name()] = evaluation.RedFlags
+		}
+	}
+	for i, criterion := range evaluations[0].Scores.Criteria() {
+		row := &ComparisonRow{Criterion: criterion.Name}
+		for _, evaluation := range evaluations {
+			row.Scores = append(row.Scores, float64(*evaluation.Scores.Criteria()[i].Score))
+		}
+		row.Best = bestIndexes(row.Scores)
+		comparison.Rows = append(comparison.Rows, row)
+	}
+	overall := &ComparisonRow{Criterion: "overall"}
+	for _, evaluation := range evaluations {
+		overall.Scores = append(overall.Scores, evaluation.Scores.Overall)
+	}
+	overall.Best = bestIndexes(overall.Scores)
+	comparison.Rows = append(comparison.Rows, overall)
+	return comparison
+}
+
+func bestIndexes(scores []float64) []int {
+	var best []int
+	for i, score := range scores {
+		switch {
+		case len(best) == 0 || score > scores[best[0]]:
+			best = []int{i}
+		case score == scores[best[0]]:
+			best = append(best, i)
+		}
+	}
+	return best
+}
+
+// PrintComparison prints the comparison matrix. The best scores of each row
+// are marked with a star. The red flags of the projects are printed first.
+func PrintComparison(w io.Writer, comparison *Comparison, opts *ReportOptions) {
+	lang := opts.language()
+	for _, project := range comparison.Projects {
+		for _, flag := range comparison.RedFlags[project] {
+			fmt.Fprintf(w, "%s: %s: %s\n", opts.colorize(colorRed, lang.tr("RED FLAG")), project, flag.Message)
+		}
+	}
+	if len(comparison.RedFlags) > 0 {
+		fmt.Fprintf(w, "\n")
+	}
+	table := newTextTable(opts, append([]string{"criterion"}, comparison.Projects...)...)
+	for i := range comparison.Projects {
+		table.alignRight(i + 1)
+	}
+	for _, row := range comparison.Rows {
+		cells := []string{row.Criterion}
+		for i, score := range row.Scores {
+			cell := opts.formatScore(int64(score))
+			if row.Criterion == "overall" {
+				cell = opts.formatOverall(score)
+			}
+			if slices.Contains(row.Best, i) {
+				cell += " *"
+			} else {
+				cell += "  "
+			}
+			cells = append(cells, cell)
+		}
+		table.add(cells...)
+	}
+	table.print(w)
+}
@@ -0,0 +1,187 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config is the configuration of an evaluation: the thresholds and weights
+// used for computing the scores, and the external scorers that can replace
+// the built-in scoring of some criteria.
+type Config struct {
+	Thresholds *Thresholds
+	Weights    *Weights
+	// Scorers maps a criterion name (like "tech.size") to the path of an
+	// executable or a WASM module that computes the score of this criterion.
+	Scorers map[string]string
+	// TargetPlatforms are the platforms where the adopter wants to run the
+	// projects, like "linux/amd64" or "darwin/arm64".
+	TargetPlatforms []string
+	// Licenses is the policy for classifying the licenses of the projects.
+	Licenses *LicensePolicy
+	// RedFlags are the settings of the rules raising the red flags.
+	RedFlags *RedFlagRules
+}
+
+func DefaultConfig() *Config {
+	day := (24 * 60 * 60 * time.Second).Nanoseconds()
+	month := 30 * day
+	year := 365 * day
+	thresholds := &Thresholds{
+		Community: &CommunityThreshold{
+			Maturity: [4]int64{1 * year, 5 * year, 10 * year, 20 * year},
+			Activity: [4]int64{1 * month, 6 * month, 1 * year, 2 * year},
+			// Out of the 52 weeks of the last year
+			ActiveWeeks: [4]int64{4, 13, 26, 39},
+			Popularity: map[string][4]int64{
+				"stars":      {5_000, 20_000, 40_000, 80_000},
+				"forks":      {500, 2_000, 5_000, 10_000},
+				"downloads":  {10_000, 100_000, 1_000_000, 10_000_000},
+				"dependents": {10, 100, 1_000, 10_000},
+				"pulls":      {100_000, 1_000_000, 10_000_000, 100_000_000},
+			},
+			Contributors: [4]int64{1, 5, 20, 50},
+			// 1 is a single-vendor project
+			ElephantFactor: [4]int64{1, 2, 3, 5},
+			Responsiveness: [4]int64{1 * day, 3 * day, 7 * day, 1 * month},
+			// Percentages, a shrinking backlog has a negative growth
+			OpenIssues:    [4]int64{10, 20, 35, 50},
+			BacklogGrowth: [4]int64{-10, 0, 10, 25},
+		},
+		Tech: &TechThreshold{
+			Size:                 [4]int64{1_000, 10_000, 100_000, 1_000_000},
+			CyclomaticComplexity: [4]int64{1, 5, 10, 20},
+			CognitiveComplexity:  [4]int64{1, 3, 5, 10},
+			Duplication:          [4]int64{3, 5, 10, 20},
+			CodeSmells:           [4]int64{50, 200, 500, 1_000},
+		},
+		Industrialization: &IndustrializationThreshold{
+			ReleaseInterval: [4]int64{1 * month, 3 * month, 6 * month, 1 * year},
+			// A coefficient of variation of 100% is as irregular as random
+			// releases
+			ReleaseIrregularity: [4]int64{25, 50, 100, 150},
+			ReleaseAge:          [4]int64{3 * month, 6 * month, 1 * year, 2 * year},
+			SemverShare:         [4]int64{25, 50, 75, 90},
+			// A new major version per year is a regular breaking change
+			MajorBumps: [4]int64{1, 2, 3, 4},
+		},
+		Adoption: &AdoptionThreshold{
+			Platforms: [4]int64{0, 34, 67, 99},
+		},
+	}
+
+	weights := &Weights{
+		// https://scorecard.dev/#the-checks
+		// 1 for low upto 4 for critical
+		ScoreCard: map[string]int64{
+			"Vulnerabilities":        2, // Only known vulnerabilities, so it may give better scores for less known projects
+			"Dependency-Update-Tool": 3,
+			// "Maintained" is disabled, as it's already in the community section
+			"Security-Policy": 2,
+			// "License" is disabled, as it's not for the security section
+			// "CII-Best-Practices" is disabled, as it's not relevant for us
+			// "CI-Tests" is disabled, as it's for more for the tech section
+			"Fuzzing":            1, // Only some tools are detected
+			"SAST":               1, // Only some tools are detected
+			"Binary-Artifacts":   3,
+			"Branch-Protection":  3,
+			"Dangerous-Workflow": 4,
+			"Code-Review":        3,
+			// "Contributors" is disabled, as it's already in the community section
+			"Pinned-Dependencies": 2,
+			"Token-Permissions":   3,
+			"Packaging":           2,
+			"Signed-Releases":     3,
+		},
+		// The popularity sources without data are ignored
+		Popularity: map[string]int64{
+			"stars":      3,
+			"forks":      1,
+			"downloads":  2,
+			"dependents": 2,
+			"pulls":      1,
+		},
+		// Each axis has the same weight in the overall score
+		Criteria: map[string]int64{
+			// The responsiveness to the issues matters more than the
+			// popularity
+			"community.maturity":                 3,
+			"community.activity":                 4,
+			"community.popularity":               2,
+			"community.contributors":             4,
+			"community.responsiveness":           4,
+			"community.backlog":                  3,
+			"tech.size":                          4,
+			"tech.cyclomaticcomplexity":          4,
+			"tech.cognitivecomplexity":           4,
+			"tech.duplication":                   4,
+			"tech.codesmells":                    4,
+			"security.scorecard":                 20,
+			"industrialization.releasecadence":   7,
+			"industrialization.releasefreshness": 7,
+			"industrialization.versioning":       6,
+			// The adoption criteria depend on the adopter, and are
+			// disabled by default
+			"adoption.platforms": 0,
+		},
+	}
+
+	licenses := &LicensePolicy{
+		Approved: []string{
+			"MIT", "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "0BSD", "Unlicense",
+			"MPL-2.0", "EPL-2.0", "LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only", "LGPL-3.0-or-later",
+			"GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0-only", "GPL-3.0-or-later",
+			"AGPL-3.0-only", "AGPL-3.0-or-later",
+		},
+		ReviewRequired: []string{"CC-BY-SA-4.0", "EUPL-1.2", "CDDL-1.0"},
+		// Source-available licenses, which are not open source
+		Forbidden: []string{"SSPL-1.0", "BUSL-1.1", "Elastic-2.0", "Commons-Clause"},
+	}
+
+	redFlags := &RedFlagRules{
+		Inactivity:           18 * month,
+		MinContributors:      2,
+		MaxOrganizationShare: 75,
+		MaxVulnerabilities:   0,
+		Disabled:             []string{},
+	}
+
+	return &Config{
+		Thresholds:      thresholds,
+		Weights:         weights,
+		Scorers:         map[string]string{},
+		TargetPlatforms: []string{"linux/amd64", "linux/arm64"},
+		Licenses:        licenses,
+		RedFlags:        redFlags,
+	}
+}
+
+// LoadConfig reads a JSON configuration file. The values from the file
+// override the ones from the default configuration. The lines starting with
+// // are comments.
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read config file: %w", err)
+	}
+	if err := json.Unmarshal(stripComments(data), config); err != nil {
+		return nil, fmt.Errorf("Invalid config file: %w", err)
+	}
+	return config, nil
+}
+
+// Clone returns a deep copy of the configuration.
+func (c *Config) Clone() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot copy the config: %w", err)
+	}
+	cloned := &Config{}
+	if err := json.Unmarshal(data, cloned); err != nil {
+		return nil, fmt.Errorf("Cannot copy the config: %w", err)
+	}
+	return cloned, nil
+}
@@ -0,0 +1,181 @@
+package qsos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// configSection is a field of the configuration in the template, with its
+// comment. The fields of a section with subsections are written one by one.
+type configSection struct {
+	Name        string
+	Comment     string
+	Value       any
+	Subsections []configSection
+}
+
+func configTemplateSections(config *Config) []configSection {
+	thresholds, weights := config.Thresholds, config.Weights
+	return []configSection{
+		{Name: "Thresholds", Comment: `
+The thresholds of the scores, from 1 to 5. A value up to the first
+threshold has the score 1 (or 5 when smaller is better), etc.`,
+			Subsections: []configSection{
+				{Name: "Community", Comment: `
+Maturity (age of the project) and Activity (time since the last commit)
+are durations in nanoseconds: 1 month is 2592000000000000, 1 year is
+31536000000000000. ActiveWeeks is the number of weeks of the last year with
+commits, averaged with Activity when the weekly commits are known. Popularity
+has the thresholds of each source, and Contributors is the number of active
+contributors, with at least 4 commits in the last 6 months by default
+(QSOS_CONTRIBUTORS_MIN_COMMITS and QSOS_CONTRIBUTORS_MONTHS). ElephantFactor
+is the smallest number of organizations authoring half of their commits,
+averaged with Contributors when the organizations are known. Responsiveness
+is the median time to the first response to the issues, in nanoseconds.
+OpenIssues is the percentage of the issues that are open, and BacklogGrowth
+the growth of the open issues in the last year, in percent (negative when
+the backlog shrinks); their scores are averaged.`, Value: thresholds.Community},
+				{Name: "Tech", Comment: `
+Size is the number of lines of code, CyclomaticComplexity is the percentage
+of the functions with a high complexity, CognitiveComplexity is the average
+per function, Duplication is the percentage of duplicated lines, and
+CodeSmells is the average number of lines between 2 code smells.`, Value: thresholds.Tech},
+				{Name: "Industrialization", Comment: `
+ReleaseInterval is the median time between 2 stable releases of the last 2
+years, in nanoseconds, and ReleaseIrregularity the coefficient of variation
+of the times between them, in percent; their scores are averaged. ReleaseAge
+is the time since the latest release, in nanoseconds. SemverShare is the
+percentage of the releases with a semantic version, and MajorBumps the number
+of new major versions of the last 2 years; their scores are averaged.`, Value: thresholds.Industrialization},
+				{Name: "Adoption", Comment: `
+Platforms is the percentage of the target platforms covered by the releases.`, Value: thresholds.Adoption},
+			}},
+		{Name: "Weights", Comment: `
+The weights of the scores. The maps are merged with the default ones, and a
+weight of 0 disables an entry.`,
+			Subsections: []configSection{
+				{Name: "ScoreCard", Comment: `
+The scorecard checks (https://scorecard.dev/#the-checks), from 1 for a low
+risk to 4 for a critical one. Maintained, License, CII-Best-Practices,
+CI-Tests and Contributors are not used by default: they are already in other
+criteria, or not relevant.`, Value: weights.ScoreCard},
+				{Name: "Popularity", Comment: `
+The popularity sources. The sources without data are ignored.`, Value: weights.Popularity},
+				{Name: "Criteria", Comment: `
+The criteria, for the overall score. By default, each axis has the same
+weight, the responsiveness weighs more than the popularity in the community
+axis, and the adoption criteria are disabled.`, Value: weights.Criteria},
+			}},
+		{Name: "Scorers", Comment: `
+External scorers, by criterion (like "tech.size"): the path of an executable
+or of a WASM module that computes the score of the criterion.`, Value: config.Scorers},
+		{Name: "TargetPlatforms", Comment: `
+The platforms where the projects will run, for the adoption.platforms
+criterion.`, Value: config.TargetPlatforms},
+		{Name: "Licenses", Comment: `
+The classification of the SPDX licenses. The unknown licenses require a
+review.`, Value: config.Licenses},
+		{Name: "RedFlags", Comment: `
+The rules of the red flags. Inactivity is a duration in nanoseconds, and
+MaxOrganizationShare a percentage of the commits. The codes of the rules are:
+archived, inactive, single-maintainer, single-vendor, no-license,
+forbidden-license and known-vulnerabilities.`, Value: config.RedFlags},
+	}
+}
+
+// configTemplateHeader is the comment at the top of the template. The
+// settings of the services are not in the configuration file.
+const configTemplateHeader = `
+Configuration of QSOS::LNG, with the default values. Use it with
+QSOS_CONFIG=<this file>. The lines starting with // are comments. The
+fields can be removed to keep their default values.
+
+The services are configured with env variables or flags, not in this file:
+GITHUB_TOKEN (--github-token, or GITHUB_TOKEN_FILE with
+--github-token-file) for the GitHub API, or GITHUB_APP_ID
+(--github-app-id) and GITHUB_APP_PRIVATE_KEY (--github-app-key-file) for a
+GitHub App, QSOS_GITHUB_API_URL
+(--github-api-url) for GitHub Enterprise Server, QSOS_FORGE (--forge),
+QSOS_FORGE_URL (--forge-url), GITLAB_TOKEN (--gitlab-token), GITEA_TOKEN
+(--gitea-token), BITBUCKET_USERNAME (--bitbucket-username) and
+BITBUCKET_TOKEN (--bitbucket-token) for the projects hosted on GitLab, Gitea
+or Bitbucket, SONARQUBE_URL
+(--sonarqube-url) and SONARQUBE_TOKEN (--sonarqube-token, or SONARQUBE_TOKEN_FILE) for the Sonarqube
+server, and QSOS_ANALYZER (--analyzer) to use the lite analyzer instead of
+Sonarqube.`
+
+// WriteConfigTemplate writes the default configuration in JSON, with
+// comments explaining the fields. It can be read by LoadConfig.
+func WriteConfigTemplate(w io.Writer) error {
+	var b strings.Builder
+	writeConfigComment(&b, "", configTemplateHeader)
+	b.WriteString("{\n")
+	if err := writeConfigSections(&b, "  ", configTemplateSections(DefaultConfig())); err != nil {
+		return fmt.Errorf("Cannot encode the configuration: %w", err)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeConfigSections(b *strings.Builder, indent string, sections []configSection) error {
+	for i, section := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeConfigComment(b, indent, section.Comment)
+		fmt.Fprintf(b, "%s%q: ", indent, section.Name)
+		if section.Subsections != nil {
+			b.WriteString("{\n")
+			if err := writeConfigSections(b, indent+"  ", section.Subsections); err != nil {
+				return err
+			}
+			b.WriteString(indent + "}")
+		} else {
+			value, err := json.MarshalIndent(section.Value, indent, "  ")
+			if err != nil {
+				return err
+			}
+			b.Write(compactNumberArrays(value))
+		}
+		if i < len(sections)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	return nil
+}
+
+var numberArrayRegexp = regexp.MustCompile(`\[\s*(-?\d+,\s*)*-?\d+\s*\]`)
+var spacesRegexp = regexp.MustCompile(`\s+`)
+
+// compactNumberArrays writes the arrays of numbers, like the thresholds, on
+// one line.
+func compactNumberArrays(data []byte) []byte {
+	return numberArrayRegexp.ReplaceAllFunc(data, func(array []byte) []byte {
+		array = spacesRegexp.ReplaceAll(array, []byte(" "))
+		return bytes.ReplaceAll(bytes.ReplaceAll(array, []byte("[ "), []byte("[")), []byte(" ]"), []byte("]"))
+	})
+}
+
+func writeConfigComment(b *strings.Builder, indent, comment string) {
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		fmt.Fprintf(b, "%s\n", strings.TrimRight(indent+"// "+line, " "))
+	}
+}
+
+// stripComments removes the lines starting with //, so that the
+// configuration files can have comments.
+func stripComments(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("//")) {
+			lines[i] = nil
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,77 @@
+package qsos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr  string
+		valid bool
+	}{
+		{"30 2 * * 1-5", true},
+		{"*/15 * * * *", true},
+		{"0 0 1-10/2 * 7", true},
+		{"@daily", true},
+		{"0 0 29 2 *", true},
+		{"0 0 * *", false},
+		{"60 * * * *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+		// Never matches
+		{"0 0 30 2 *", false},
+		{"0 0 31 4,6,9,11 *", false},
+	}
+	for _, test := range tests {
+		_, err := ParseCron(test.expr)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseCron(%q) error = %v, want valid %v", test.expr, err, test.valid)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	location := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("no time zone %s: %v", name, err)
+		}
+		return loc
+	}
+	utc := time.UTC
+	tests := []struct {
+		name string
+		expr string
+		t    time.Time
+		want time.Time
+	}{
+		{"next minute", "* * * * *", time.Date(2026, 1, 1, 10, 0, 30, 0, utc), time.Date(2026, 1, 1, 10, 1, 0, 0, utc)},
+		{"strictly after", "0 10 * * *", time.Date(2026, 1, 1, 10, 0, 0, 0, utc), time.Date(2026, 1, 2, 10, 0, 0, 0, utc)},
+		{"weekday", "30 2 * * 1-5", time.Date(2026, 1, 3, 12, 0, 0, 0, utc), time.Date(2026, 1, 5, 2, 30, 0, 0, utc)},
+		{"day or weekday", "0 0 15 * 1", time.Date(2026, 1, 6, 0, 0, 0, 0, utc), time.Date(2026, 1, 12, 0, 0, 0, 0, utc)},
+		{"end of year", "0 0 1 1 *", time.Date(2026, 12, 31, 23, 59, 0, 0, utc), time.Date(2027, 1, 1, 0, 0, 0, 0, utc)},
+		{"leap day", "0 0 29 2 *", time.Date(2026, 3, 1, 0, 0, 0, 0, utc), time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		{
+			"half-hour offset", "0 * * * *",
+			time.Date(2026, 1, 1, 10, 45, 0, 0, location("Asia/Kolkata")),
+			time.Date(2026, 1, 1, 11, 0, 0, 0, location("Asia/Kolkata")),
+		},
+		{
+			"daylight saving time gap", "30 2 * * *",
+			time.Date(2026, 3, 8, 1, 0, 0, 0, location("America/New_York")),
+			time.Date(2026, 3, 9, 2, 30, 0, 0, location("America/New_York")),
+		},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCron(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := schedule.Next(test.t); !got.Equal(test.want) {
+			t.Errorf("%s: Next(%v) = %v, want %v", test.name, test.t, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package qsos
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Diff is the difference between two snapshots of evaluations, the JSON
+// reports of two runs, for the periodic review of the approved projects.
+type Diff struct {
+	Projects []*ProjectDiff
+	// Added and Removed are the projects only in the new or in the old
+	// snapshot.
+	Added   []string
+	Removed []string
+}
+
+// ProjectDiff has the scores and the metrics of a project that have changed.
+type ProjectDiff struct {
+	Project string
+	Scores  []ScoreChange
+	Metrics []MetricChange
+}
+
+// ScoreChange is a criterion whose score has changed, or whose value has
+// crossed some of its thresholds.
+type ScoreChange struct {
+	Criterion string
+	Old       float64
+	New       float64
+	// Band is the value compared with the thresholds of the criterion, for
+	// the criteria with thresholds.
+	Band *BandChange `json:",omitempty"`
+}
+
+// BandChange is the change of the value compared with the thresholds of a
+// criterion, like the lines of code per code smell.
+type BandChange struct {
+	Unit string
+	Old  int64
+	New  int64
+	// Crossed are the thresholds between the old and the new value.
+	Crossed []int64
+}
+
+// MetricChange is a raw metric that has changed. Old or New is nil when the
+// metric is missing from a snapshot.
+type MetricChange struct {
+	Name string
+	Unit string
+	Old  *float64
+	New  *float64
+}
+
+// unitDuration is the unit of the values of the maturity and the activity,
+// in nanoseconds.
+const unitDuration = "duration"
+
+// ReadReport reads a JSON report written by the evaluate or the score
+// command.
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("Invalid report %s: %w", path, err)
+	}
+	for _, evaluation := range report.Evaluations {
+		if evaluation.Stats == nil || evaluation.Scores == nil || evaluation.Scores.Community == nil {
+			return nil, fmt.Errorf("Invalid report %s: missing stats or scores for %s", path, evaluation.Name())
+		}
+	}
+	return &report, nil
+}
+
+// snapshotName returns the name of an evaluation in a snapshot, with its
+// sub-directory.
+func snapshotName(evaluation *Evaluation) string {
+	if evaluation.Stats.Subdir != "" {
+		return evaluation.Name() + " (" + evaluation.Stats.Subdir + ")"
+	}
+	return evaluation.Name()
+}
+
+// ComputeDiff compares two snapshots. The crossed thresholds are the ones of
+// the configuration, and the ages of the projects are computed at the dates
+// of the snapshots.
+func ComputeDiff(old, new *Report, config *Config) *Diff {
+	diff := &Diff{}
+	olds := map[string]*Evaluation{}
+	for _, evaluation := range old.Evaluations {
+		olds[snapshotName(evaluation)] = evaluation
+	}
+	news := map[string]bool{}
+	for _, evaluation := range new.Evaluations {
+		name := snapshotName(evaluation)
+		news[name] = true
+		before, ok := olds[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		project := &ProjectDiff{
+			Project: name,
+			Scores:  diffScores(before, snapshotTime(old), evaluation, snapshotTime(new), config.Thresholds),
+			Metrics: diffMetrics(before.Stats.metrics(), evaluation.Stats.metrics()),
+		}
+		diff.Projects = append(diff.Projects, project)
+	}
+	for _, evaluation := range old.Evaluations {
+		if name := snapshotName(evaluation); !news[name] {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	return diff
+}
+
+// snapshotTime returns the date of a report, or now for the reports without
+// one.
+func snapshotTime(report *Report) time.Time {
+	if report.GeneratedAt.IsZero() {
+		return time.Now()
+	}
+	return report.GeneratedAt
+}
+
+func diffScores(old *Evaluation, oldAt time.Time, new *Evaluation, newAt time.Time, thresholds *Thresholds) []ScoreChange {
+	oldScores, newScores := evaluationScores(old), evaluationScores(new)
+	oldBands := bandValues(old.Stats.metrics(), thresholds, oldAt)
+	newBands := bandValues(new.Stats.metrics(), thresholds, newAt)
+	var changes []ScoreChange
+	for _, name := range append(criteriaNames(new.Scores), "overall") {
+		change := ScoreChange{Criterion: name, Old: oldScores[name], New: newScores[name]}
+		before, okOld := oldBands[name]
+		after, okNew := newBands[name]
+		if okOld && okNew {
+			change.Band = &BandChange{Unit: after.unit, Old: before.value, New: after.value}
+			for _, threshold := range after.thresholds {
+				if (before.value > threshold) != (after.value > threshold) {
+					change.Band.Crossed = append(change.Band.Crossed, threshold)
+				}
+			}
+		}
+		if change.Old != change.New || (change.Band != nil && len(change.Band.Crossed) > 0) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// bandValue is the value of a criterion compared with its thresholds.
+type bandValue struct {
+	value      int64
+	thresholds [4]int64
+	unit       string
+}
+
+// bandValues returns the values compared with the thresholds by the
+// scoring, by criterion, at the given date. The criteria without their
+// metrics are missing.
+func bandValues(metrics Metrics, thresholds *Thresholds, at time.Time) map[string]bandValue {
+	values := map[string]bandValue{}
+	if first := metrics.date("github.first_commit"); !first.IsZero() {
+		values["community.maturity"] = bandValue{at.Sub(first).Nanoseconds(), thresholds.Community.Maturity, unitDuration}
+	}
+	last := metrics.date("github.last_human_commit")
+	if last.IsZero() {
+		last = metrics.date("github.last_commit")
+	}
+	if !last.IsZero() {
+		values["community.activity"] = bandValue{at.Sub(last).Nanoseconds(), thresholds.Community.Activity, unitDuration}
+	}
+	if _, ok := metrics.Get("github.active_contributors"); ok {
+		values["community.contributors"] = bandValue{metrics.int("github.active_contributors"), thresholds.Community.Contributors, UnitCount}
+	}
+	if open, ok := metrics.Get("github.open_issue_share"); ok {
+		values["community.backlog"] = bandValue{int64(open.Value), thresholds.Community.OpenIssues, UnitPercent}
+	}
+	if median, ok := metrics.Get("github.issue_response_time"); ok {
+		values["community.responsiveness"] = bandValue{(time.Duration(median.Value) * time.Second).Nanoseconds(), thresholds.Community.Responsiveness, unitDuration}
+	}
+	if latest := metrics.date("github.latest_release"); !latest.IsZero() {
+		values["industrialization.releasefreshness"] = bandValue{at.Sub(latest).Nanoseconds(), thresholds.Industrialization.ReleaseAge, unitDuration}
+	}
+	if semver, ok := metrics.Get("github.semver_share"); ok {
+		values["industrialization.versioning"] = bandValue{int64(semver.Value), thresholds.Industrialization.SemverShare, UnitPercent}
+	}
+	if _, ok := metrics.Get("sonar.ncloc"); !ok {
+		return values
+	}
+	ncloc := metrics.int("sonar.ncloc")
+	values["tech.size"] = bandValue{ncloc, thresholds.Tech.Size, UnitLines}
+	values["tech.duplication"] = bandValue{metrics.int("sonar.duplicated_lines_density"), thresholds.Tech.Duplication, UnitPercent}
+	if functions := metrics.int("sonar.functions"); functions > 0 {
+		values["tech.cyclomaticcomplexity"] = bandValue{100 * metrics.int("sonar.brain_overload") / functions, thresholds.Tech.CyclomaticComplexity, UnitPercent}
+		values["tech.cognitivecomplexity"] = bandValue{metrics.int("sonar.cognitive_complexity") / functions, thresholds.Tech.CognitiveComplexity, UnitCount}
+	}
+	if smells := metrics.int("sonar.code_smells"); smells > 0 {
+		values["tech.codesmells"] = bandValue{ncloc / smells, thresholds.Tech.CodeSmells, UnitLines}
+	}
+	return values
+}
+
+func diffMetrics(old, new Metrics) []MetricChange {
+	var changes []MetricChange
+	for _, metric := range new {
+		before, ok := old.Get(metric.Name)
+		switch {
+		case !ok:
+			changes = append(changes, MetricChange{Name: metric.Name, Unit: metric.Unit, New: &metric.Value})
+		case before.Value != metric.Value:
+			changes = append(changes, MetricChange{Name: metric.Name, Unit: metric.Unit, Old: &before.Value, New: &metric.Value})
+		}
+	}
+	for _, metric := range old {
+		if _, ok := new.Get(metric.Name); !ok {
+			changes = append(changes, MetricChange{Name: metric.Name, Unit: metric.Unit, Old: &metric.Value})
+		}
+	}
+	slices.SortFunc(changes, func(a, b MetricChange) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return changes
+}
+
+// formatBandValue formats a value compared with thresholds.
+func formatBandValue(value int64, unit string) string {
+	switch unit {
+	case unitDuration:
+		return fmt.Sprintf("%dd", value/int64(24*time.Hour))
+	case UnitPercent:
+		return fmt.Sprintf("%d%%", value)
+	}
+	return fmt.Sprint(value)
+}
+
+// formatMetricValue formats the value of a metric, or "-" if it is missing.
+func formatMetricValue(value *float64, unit string) string {
+	if value == nil {
+		return "-"
+	}
+	switch unit {
+	case UnitDate:
+		return time.Unix(int64(*value), 0).UTC().Format(time.DateOnly)
+	case UnitSeconds:
+		return formatDuration(time.Duration(*value) * time.Second)
+	}
+	return fmt.Sprint(*value)
+}
+
+// formatChange formats the change of a score or a metric, colored in green
+// if it is an improvement.
+func formatChange(opts *ReportOptions, change float64, format string, better bool) string {
+	text := fmt.Sprintf(format, change)
+	switch {
+	case change == 0:
+		return text
+	case better:
+		return opts.colorize(colorGreen, text)
+	default:
+		return opts.colorize(colorRed, text)
+	}
+}
+
+// PrintDiff prints the changes of the scores, with the thresholds crossed by
+// the values of the criteria, and the changes of the raw metrics.
+func PrintDiff(w io.Writer, diff *Diff, opts *ReportOptions) {
+	lang := opts.language()
+	for _, name := range diff.Added {
+		fmt.Fprintf(w, "%s: %s\n", lang.tr("New project"), name)
+	}
+	for _, name := range diff.Removed {
+		fmt.Fprintf(w, "%s: %s\n", lang.tr("Removed project"), name)
+	}
+	for _, project := range diff.Projects {
+		fmt.Fprintf(w, "\n=== %s ===\n", opts.colorize(colorBold, project.Project))
+		if len(project.Scores) == 0 && len(project.Metrics) == 0 {
+			fmt.Fprintf(w, "%s\n", lang.tr("No change"))
+			continue
+		}
+		if len(project.Scores) > 0 {
+			printSection(w, lang, "Scores")
+			table := newTextTable(opts, "criterion", "old", "new", "change", "thresholds crossed").alignRight(1, 2, 3)
+			for _, change := range project.Scores {
+				format := "%+g"
+				if change.Criterion == "overall" {
+					format = "%+.2f"
+				}
+				cells := []string{change.Criterion, fmt.Sprint(change.Old), fmt.Sprint(change.New), formatChange(opts, change.New-change.Old, format, change.New > change.Old)}
+				if band := change.Band; band != nil && len(band.Crossed) > 0 {
+					var crossed []string
+					for _, threshold := range band.Crossed {
+						crossed = append(crossed, formatBandValue(threshold, band.Unit))
+					}
+					cells = append(cells, fmt.Sprintf("%s -> %s (%s)", formatBandValue(band.Old, band.Unit), formatBandValue(band.New, band.Unit), strings.Join(crossed, ", ")))
+				}
+				table.add(cells...)
+			}
+			table.print(w)
+		}
+		if len(project.Metrics) > 0 {
+			printSection(w, lang, "Metrics")
+			table := newTextTable(opts, "metric", "old", "new", "change").alignRight(1, 2, 3)
+			for _, change := range project.Metrics {
+				cells := []string{change.Name, formatMetricValue(change.Old, change.Unit), formatMetricValue(change.New, change.Unit)}
+				if change.Old != nil && change.New != nil {
+					delta := *change.New - *change.Old
+					switch change.Unit {
+					case UnitDate:
+						cells = append(cells, fmt.Sprintf("%+.0fd", delta/(24*60*60)))
+					case UnitSeconds:
+						cells = append(cells, fmt.Sprintf("%+.0fh", delta/(60*60)))
+					default:
+						cells = append(cells, fmt.Sprintf("%+g", delta))
+					}
+				}
+				table.add(cells...)
+			}
+			table.print(w)
+		}
+	}
+}
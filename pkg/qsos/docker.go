@@ -0,0 +1,29 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os/exec"
+	"time"
+)
+
+// dockerRun returns a docker run command for a container removed at its
+// end. The container is named, to be removed when the context is done:
+// killing the docker client would leave it running.
+func dockerRun(ctx context.Context, args ...string) *exec.Cmd {
+	name := fmt.Sprintf("qsos-%08x", rand.Uint32())
+	cmd := exec.CommandContext(ctx, "docker", append([]string{"run", "--rm", "--name", name}, args...)...)
+	cmd.Cancel = func() error {
+		// The context is done, the removal has its own timeout
+		rmCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := exec.CommandContext(rmCtx, "docker", "rm", "--force", name).Run(); err != nil {
+			slog.Warn("cannot remove the container", "name", name, "err", err)
+		}
+		return cmd.Process.Kill()
+	}
+	cmd.WaitDelay = 10 * time.Second
+	return cmd
+}
@@ -0,0 +1,126 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// minRateLimit is the number of GitHub API requests below which the
+// evaluation of a project may hit the rate limit.
+const minRateLimit = 500
+
+// Diagnostic is the result of a check of the environment.
+type Diagnostic struct {
+	Name   string
+	OK     bool
+	Detail string
+	// Fix is the action to take when the check has failed.
+	Fix string `json:",omitempty"`
+}
+
+// Diagnose checks that the tools and the services used for the evaluations
+// are available, so that the problems are reported before a long run.
+func (e *Executor) Diagnose() []*Diagnostic {
+	diagnostics := []*Diagnostic{
+		diagnoseCommand("git", "install git", "git", "--version"),
+		diagnoseCommand("docker", "install docker and start its daemon, it is used for scorecard and sonar-scanner-cli",
+			"docker", "version", "--format", "{{.Server.Version}}"),
+		e.diagnoseGitHub(),
+	}
+	if sonar, ok := e.Sonar.(*SonarqubeCollector); ok {
+		diagnostics = append(diagnostics, sonar.diagnoseServer(), sonar.diagnoseToken())
+	}
+	return diagnostics
+}
+
+func diagnoseCommand(name, fix string, command ...string) *Diagnostic {
+	output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		detail := strings.TrimSpace(string(output))
+		if detail == "" {
+			detail = err.Error()
+		}
+		return &Diagnostic{Name: name, Detail: detail, Fix: fix}
+	}
+	return &Diagnostic{Name: name, OK: true, Detail: strings.TrimSpace(string(output))}
+}
+
+// diagnoseGitHub checks the token and the rate limit, with the rate limit
+// endpoint that doesn't count in the rate limit.
+func (e *Executor) diagnoseGitHub() *Diagnostic {
+	diagnostic := &Diagnostic{Name: "github"}
+	limits, _, err := e.GitHub.RateLimit.Get(context.Background())
+	if err != nil {
+		diagnostic.Detail = err.Error()
+		diagnostic.Fix = "check the network, and that GITHUB_TOKEN is a valid token"
+		return diagnostic
+	}
+	core := limits.GetCore()
+	diagnostic.Detail = fmt.Sprintf("%d/%d API requests left, reset at %s",
+		core.Remaining, core.Limit, core.Reset.Format(time.TimeOnly))
+	if c, ok := e.GitHubStats.(*GitHubAPICollector); ok && c.Anonymous {
+		diagnostic.Fix = "set GITHUB_TOKEN or log in with gh auth login, the GitHub API is used anonymously"
+		return diagnostic
+	}
+	if core.Remaining < minRateLimit {
+		diagnostic.Fix = fmt.Sprintf("wait for the reset of the rate limit, the evaluation of a project may need %d requests", minRateLimit)
+		return diagnostic
+	}
+	diagnostic.OK = true
+	return diagnostic
+}
+
+func (c *SonarqubeCollector) diagnoseServer() *Diagnostic {
+	version, err := c.getSonarqubeVersion(context.Background())
+	if err != nil {
+		return &Diagnostic{
+			Name:   "sonarqube",
+			Detail: fmt.Sprintf("%s: %s", c.URL, err),
+			Fix:    "check that SONARQUBE_URL is the URL of a running Sonarqube server",
+		}
+	}
+	return &Diagnostic{Name: "sonarqube", OK: true, Detail: fmt.Sprintf("%s, version %s", c.URL, version)}
+}
+
+func (c *SonarqubeCollector) diagnoseToken() *Diagnostic {
+	if c.Token == "" {
+		return &Diagnostic{Name: "sonarqube token", OK: true, Detail: "no token, only the public analyses are read"}
+	}
+	diagnostic := &Diagnostic{Name: "sonarqube token", Fix: "check that SONARQUBE_TOKEN is a valid token for the Sonarqube server"}
+	cloned := *c.URL
+	cloned.Path = "/api/authentication/validate"
+	req, err := http.NewRequest(http.MethodGet, cloned.String(), nil)
+	if err != nil {
+		diagnostic.Detail = err.Error()
+		return diagnostic
+	}
+	req.Header.Add("Authorization", "Bearer "+c.Token)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		diagnostic.Detail = err.Error()
+		return diagnostic
+	}
+	defer res.Body.Close()
+	var data struct {
+		Valid bool
+	}
+	if res.StatusCode != http.StatusOK {
+		diagnostic.Detail = fmt.Sprintf("unexpected response: %d", res.StatusCode)
+		return diagnostic
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		diagnostic.Detail = fmt.Sprintf("invalid response: %s", err)
+		return diagnostic
+	}
+	if !data.Valid {
+		diagnostic.Detail = "the token is not valid"
+		return diagnostic
+	}
+	diagnostic.OK, diagnostic.Detail, diagnostic.Fix = true, "the token is valid", ""
+	return diagnostic
+}
@@ -0,0 +1,378 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/otiai10/openaigo"
+)
+
+// dryRunDir is the placeholder for the temporary dirs in the dry runs.
+const dryRunDir = "<tmp dir>"
+
+// planner is implemented by the collectors that can describe the commands
+// they run and the requests they send, for the dry runs.
+type planner interface {
+	plan(owner, repo string) []string
+}
+
+// historyPlanner is implemented by the history collectors that can describe
+// the commands and the requests of GetGitHubStatsFromHistory.
+type historyPlanner interface {
+	planFromHistory(owner, repo string) []string
+}
+
+// Plan returns the external commands and the API requests of the evaluation
+// of a project, without running them. The commands start with $, and the
+// secrets are redacted.
+func (e *Executor) Plan(owner, repo string) []string {
+	var steps []string
+	clone := e.clonesSources()
+	if clone {
+		steps = append(steps, commandLine(cloneCommand(context.Background(), e.Forge.cloneURL(owner, repo), dryRunDir)))
+	}
+	collectors := []any{e.GitHubStats, e.ScoreCard}
+	if !clone {
+		collectors = append(collectors, e.Sonar)
+	}
+	if e.Advisories != nil {
+		collectors = append(collectors, e.Advisories)
+	}
+	for _, collector := range collectors {
+		if p, ok := collector.(historyPlanner); ok && clone && collector == e.GitHubStats {
+			steps = append(steps, p.planFromHistory(owner, repo)...)
+		} else if p, ok := collector.(planner); ok {
+			steps = append(steps, p.plan(owner, repo)...)
+		} else {
+			steps = append(steps, fmt.Sprintf("# %T: unknown commands and requests", collector))
+		}
+		if collector == e.GitHubStats && e.Subdir != "" && e.SubdirCommits {
+			steps = append(steps, planHistory(e.Subdir)...)
+		}
+	}
+	if clone {
+		steps = append(steps, e.planCloneAnalysis(e.component(owner, repo))...)
+	}
+	if _, ok := e.GitHubStats.(readmeCollector); !ok {
+		steps = append(steps, "GET "+e.GitHub.BaseURL.String()+fmt.Sprintf("repos/%s/%s/readme", owner, repo))
+	}
+	steps = append(steps,
+		"POST "+cmp.Or(e.AI.BaseURL, openaigo.DefaultOpenAIAPIURL)+"/chat/completions",
+		"GET "+packagesLookupURL+"?"+url.Values{"repository_url": []string{e.Forge.RepositoryURL(owner, repo)}}.Encode(),
+		"GET "+dockerHubURL+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/",
+		"GET "+dockerHubURL+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/tags?page_size=1&ordering=last_updated",
+	)
+	if len(e.Refs) > 0 {
+		remote := e.Forge.cloneURL(owner, repo)
+		steps = append(steps, "$ git init --quiet", "$ git remote add origin "+remote)
+		for _, ref := range e.Refs {
+			steps = append(steps, "$ git fetch --depth=1 origin "+ref, "$ git checkout --quiet --force --detach FETCH_HEAD", "$ git clean --quiet -fdx")
+			refComponent := e.component(owner, repo) + "-" + unsafeRefChars.ReplaceAllString(ref, "_")
+			if sonar, ok := e.Sonar.(*SonarqubeCollector); ok {
+				steps = append(steps, sonar.planAnalysis(refComponent)...)
+			} else if _, ok := e.Sonar.(*LiteCollector); ok {
+				steps = append(steps, "$ git ls-files --stage -z")
+			}
+		}
+	}
+	return steps
+}
+
+// planCloneAnalysis returns the commands of the analysis of the sources of
+// a clone, or of its sub-directory.
+func (e *Executor) planCloneAnalysis(component string) []string {
+	if sonar, ok := e.Sonar.(*SonarqubeCollector); ok && sonar.Token != "" {
+		return sonar.planAnalysis(component)
+	}
+	return []string{"$ git ls-files --stage -z"}
+}
+
+// PlanLocal returns the external commands of the evaluation of a local
+// working copy, like Plan.
+func (e *Executor) PlanLocal(dir string) []string {
+	steps := planHistory("")
+	if e.Subdir != "" && e.SubdirCommits {
+		steps = planHistory(e.Subdir)
+	}
+	if c, ok := e.ScoreCard.(*ScorecardCLICollector); ok {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		steps = append(steps, commandLine(c.localCommand(context.Background(), dir)))
+	}
+	switch e.Sonar.(type) {
+	case *SonarqubeCollector, *LiteCollector:
+		steps = append(steps, e.planCloneAnalysis(e.component(LocalOwner, localName(dir)))...)
+	}
+	return append(steps, "POST "+cmp.Or(e.AI.BaseURL, openaigo.DefaultOpenAIAPIURL)+"/chat/completions")
+}
+
+// planHistory returns the commands of gitHistoryStats.
+func planHistory(subdir string) []string {
+	first, paths := " --max-parents=0", ""
+	if subdir != "" {
+		first, paths = "", " -- "+subdir
+	}
+	return []string{
+		"$ git rev-parse --is-shallow-repository",
+		fmt.Sprintf("$ git for-each-ref --sort=-creatordate --count=%d --format=%%(refname:short)%%00%%(creatordate:unix) refs/tags", maxReleases),
+		"$ git log --format=%ct%x00%aN%x00%aE --max-count=100" + paths,
+		"$ git log --format=%ct%x00%aN%x00%aE" + first + paths,
+		"$ git log --format=%ct%x00%aN%x00%aE --since=<1 year ago>" + paths,
+	}
+}
+
+func (c *GitHubAPICollector) planFromHistory(owner, repo string) []string {
+	var steps []string
+	if c.PublicDataURL != nil {
+		steps = append(steps, c.planPublicData(owner, repo)...)
+	}
+	api := c.Client.BaseURL.String() + fmt.Sprintf("repos/%s/%s", owner, repo)
+	steps = append(append(steps, planHistory("")...), "GET "+api)
+	if c.Anonymous {
+		return append(append(steps, c.planIssueSearch(owner, repo)...), "GET "+api+"/releases?per_page=100", "GET "+api+"/releases/latest")
+	}
+	return append(append(steps, "GET "+api+"/stats/contributors"), c.planPullRequestsAndMaintainers(owner, repo, false)...)
+}
+
+// planIssueSearch returns the searches of the open and closed issues.
+func (c *GitHubAPICollector) planIssueSearch(owner, repo string) []string {
+	var steps []string
+	for _, qualifiers := range []string{"is:open", "is:closed", "created:>=<1 year ago>", "closed:>=<1 year ago>"} {
+		steps = append(steps, fmt.Sprintf("GET %ssearch/issues?per_page=1&q=repo:%s/%s+is:issue+%s", c.Client.BaseURL, owner, repo, qualifiers))
+	}
+	return steps
+}
+
+func (c *GitHubAPICollector) planPublicData(owner, repo string) []string {
+	cloned := *c.PublicDataURL
+	cloned.Path = path.Join(cloned.Path, owner, repo+".json")
+	return []string{"GET " + cloned.String(), "# if the project is not in the public data:"}
+}
+
+func (c *GitHubAPICollector) plan(owner, repo string) []string {
+	var steps []string
+	if c.PublicDataURL != nil {
+		steps = append(steps, c.planPublicData(owner, repo)...)
+	}
+	api := c.Client.BaseURL.String() + fmt.Sprintf("repos/%s/%s", owner, repo)
+	if c.Anonymous {
+		steps = append(steps,
+			"GET "+api,
+			"GET "+api+"/commits?per_page=100&sha=<default branch>",
+			"GET "+api+"/commits?per_page=1&sha=<default branch>",
+			"GET "+api+"/commits?page=<last page>&per_page=1&sha=<default branch>",
+		)
+	} else {
+		steps = append(steps,
+			"POST "+c.graphQLURL()+" # repository and last commits",
+			"# if there are more than 100 commits:",
+			"POST "+c.graphQLURL()+" # first commit",
+		)
+	}
+	steps = append(steps,
+		"GET "+api+"/stats/contributors",
+		"# if the contributors stats are not available, each page of:",
+		"GET "+api+"/commits?per_page=100&sha=<default branch>&since=<6 months ago>",
+	)
+	if c.Anonymous {
+		// The pull requests and the maintainers are not collected
+		steps = append(steps, c.planIssueSearch(owner, repo)...)
+		return append(steps, "GET "+api+"/stats/participation", "GET "+api+"/releases?per_page=100", "GET "+api+"/releases/latest")
+	}
+	steps = append(steps,
+		fmt.Sprintf("# with the contributors stats, for the top committers, up to %d:", maxProfileLookups),
+		"GET "+c.Client.BaseURL.String()+"users/<login>",
+	)
+	return append(steps, c.planPullRequestsAndMaintainers(owner, repo, true)...)
+}
+
+// planPullRequestsAndMaintainers returns the requests of the pull requests
+// of bots, of the issues, of the weekly commits if they are not known yet, of
+// the releases, and of the maintainers.
+func (c *GitHubAPICollector) planPullRequestsAndMaintainers(owner, repo string, participation bool) []string {
+	api := c.Client.BaseURL.String() + fmt.Sprintf("repos/%s/%s", owner, repo)
+	steps := []string{
+		"# each page, until the pull requests are older than 6 months:",
+		"GET " + api + "/pulls?direction=desc&per_page=100&sort=updated&state=closed",
+		fmt.Sprintf("# each page, until the issues are older than 6 months, up to %d:", maxIssuePages),
+		"POST " + c.graphQLURL() + " # issues and their first comments",
+	}
+	steps = append(steps, c.planIssueSearch(owner, repo)...)
+	if participation {
+		steps = append(steps, "GET "+api+"/stats/participation")
+	}
+	return append(steps,
+		"POST "+c.graphQLURL()+" # releases and tags",
+		"GET "+api+"/releases/latest",
+		"# until a CODEOWNERS file is found:",
+		"GET "+api+"/contents/.github/CODEOWNERS",
+		"GET "+api+"/contents/CODEOWNERS",
+		"GET "+api+"/contents/docs/CODEOWNERS",
+		"# for each team of the CODEOWNERS file:",
+		"GET "+c.Client.BaseURL.String()+"orgs/<org>/teams/<team>/members?per_page=100",
+		"# for each maintainer who is not a top committer:",
+		"GET "+api+"/commits?author=<login>&per_page=1",
+	)
+}
+
+func (c *GitLabCollector) plan(owner, repo string) []string {
+	project := "projects/" + url.PathEscape(owner+"/"+repo)
+	commits := c.apiURL(project+"/repository/commits", nil)
+	return []string{
+		"GET " + c.apiURL(project, url.Values{"license": {"true"}}),
+		"GET " + commits + "?per_page=100&ref_name=<default branch>",
+		"GET " + commits + "?per_page=1&ref_name=<default branch>",
+		"GET " + commits + "?page=<last page>&per_page=1&ref_name=<default branch>",
+		"# each page, up to " + fmt.Sprint(maxGitLabCommitPages) + ":",
+		"GET " + commits + "?page=<page>&per_page=100&ref_name=<default branch>&since=<6 months ago>",
+		"# each page, up to " + fmt.Sprint(maxPullRequestPages) + ":",
+		"GET " + c.apiURL(project+"/merge_requests", nil) + "?page=<page>&per_page=100&state=merged&updated_after=<6 months ago>",
+		"GET " + c.apiURL(project+"/releases", url.Values{"per_page": {"1"}}),
+		"GET " + c.apiURL(project+"/releases", url.Values{"per_page": {fmt.Sprint(maxReleases)}}),
+		"GET " + c.apiURL(project+"/repository/tags", url.Values{"order_by": {"updated"}, "per_page": {fmt.Sprint(maxReleases)}}),
+		"GET " + c.apiURL(project, nil),
+		"GET " + c.apiURL(project+"/repository/files/<README>/raw", nil) + "?ref=<default branch>",
+	}
+}
+
+func (c *GiteaCollector) plan(owner, repo string) []string {
+	project := "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	commits := c.apiURL(project+"/commits", nil) + "?files=false"
+	return []string{
+		"GET " + c.apiURL(project, nil),
+		"GET " + commits + "&limit=" + fmt.Sprint(giteaPageSize) + "&page=1&sha=<default branch>&stat=false&verification=false",
+		"GET " + commits + "&limit=1&page=<commit count>&sha=<default branch>&stat=false&verification=false",
+		"# each page, up to " + fmt.Sprint(maxGiteaCommitPages) + ":",
+		"GET " + commits + "&limit=" + fmt.Sprint(giteaPageSize) + "&page=<page>&sha=<default branch>&since=<6 months ago>&stat=false&verification=false",
+		"# each page, up to " + fmt.Sprint(maxPullRequestPages) + ":",
+		"GET " + c.apiURL(project+"/pulls", nil) + "?limit=" + fmt.Sprint(giteaPageSize) + "&page=<page>&sort=recentupdate&state=closed",
+		"GET " + c.apiURL(project+"/releases/latest", nil),
+		"GET " + c.apiURL(project+"/releases", url.Values{"limit": {fmt.Sprint(giteaPageSize)}}),
+		"GET " + c.apiURL(project+"/tags", url.Values{"limit": {fmt.Sprint(giteaPageSize)}}),
+		"# until a README is found:",
+		"GET " + c.apiURL(project+"/raw/<README>", nil),
+	}
+}
+
+func (c *BitbucketCollector) plan(owner, repo string) []string {
+	project := "repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	commits := c.apiURL(project+"/commits/<main branch>", url.Values{"pagelen": {"100"}})
+	return []string{
+		"GET " + c.apiURL(project, nil),
+		"GET " + c.apiURL(project+"/watchers", url.Values{"pagelen": {"1"}}),
+		"GET " + c.apiURL(project+"/forks", url.Values{"pagelen": {"1"}}),
+		"GET " + commits,
+		"# each page, until a commit older than 6 months, up to " + fmt.Sprint(maxBitbucketCommitPages) + ":",
+		"GET " + commits + "&page=<page>",
+		"# each page, up to " + fmt.Sprint(maxPullRequestPages) + ":",
+		"GET " + c.apiURL(project+"/pullrequests", nil) + "?page=<page>&pagelen=50&q=updated_on+>%3D+<6 months ago>&sort=-updated_on&state=MERGED",
+		"GET " + c.apiURL(project+"/downloads", url.Values{"pagelen": {"100"}}),
+		"GET " + c.apiURL(project+"/refs/tags", url.Values{"pagelen": {fmt.Sprint(maxReleases)}, "sort": {"-target.date"}}),
+		"GET " + c.apiURL(project, nil),
+		"# until a README is found:",
+		"GET " + c.apiURL(project+"/src/<main branch>/<README>", nil),
+	}
+}
+
+func (c *GitHistoryCollector) plan(owner, repo string) []string {
+	remote := c.Forge.cloneURL(owner, repo)
+	steps := append([]string{"$ git clone --quiet --bare --filter=blob:none " + remote + " ."}, planHistory("")...)
+	return append(steps, c.planReadme(owner, repo)...)
+}
+
+func (c *GitHistoryCollector) planFromHistory(owner, repo string) []string {
+	return append(planHistory(""), c.planReadme(owner, repo)...)
+}
+
+func (c *GitHistoryCollector) planReadme(owner, repo string) []string {
+	return []string{
+		"$ git clone --quiet --bare --depth=1 " + c.Forge.cloneURL(owner, repo) + " .",
+		"# until a README is found:",
+		"$ git show HEAD:<README>",
+	}
+}
+
+func (c *ScorecardCLICollector) plan(owner, repo string) []string {
+	if kind := c.Forge.orDefault().Kind; kind != ForgeGitHub && kind != ForgeGitLab {
+		return []string{"# " + ErrScorecardUnsupported.Error()}
+	}
+	if c.Forge.orDefault().Kind == ForgeGitHub && c.GitHubToken == "" && c.GitHubApp == nil {
+		return []string{"# " + ErrScorecardNoToken.Error()}
+	}
+	return []string{commandLine(c.command(context.Background(), owner, repo))}
+}
+
+func (c *SonarqubeCollector) plan(owner, repo string) []string {
+	if c.Token == "" {
+		steps := []string{"# for each component, until a public analysis is found:"}
+		for _, component := range publicSonarComponents(owner, repo) {
+			steps = append(steps, "GET "+c.measuresURL(component), "GET "+c.issuesURL(component))
+		}
+		if c.Fallback != nil {
+			steps = append(steps, "# if there is no public analysis:")
+			steps = append(steps, c.Fallback.plan(owner, repo)...)
+		}
+		return steps
+	}
+	return append([]string{commandLine(cloneCommand(context.Background(), c.Forge.cloneURL(owner, repo), dryRunDir))}, c.planAnalysis(componentName(owner, repo))...)
+}
+
+func (c *SonarqubeCollector) planAnalysis(component string) []string {
+	steps := []string{
+		commandLine(c.scannerCommand(context.Background(), dryRunDir, component)),
+		"# until the measures are available:",
+		"GET " + c.measuresURL(component),
+		"GET " + c.issuesURL(component),
+	}
+	if c.Fallback != nil {
+		steps = append(steps, "# if Sonarqube reports no functions:", "$ git ls-files --stage -z")
+	}
+	return steps
+}
+
+func (c *SonarqubeCollector) measuresURL(component string) string {
+	cloned := *c.URL
+	cloned.Path = "/api/measures/component"
+	return cloned.String() + "?component=" + url.QueryEscape(component) + "&metricKeys=..."
+}
+
+func (c *SonarqubeCollector) issuesURL(component string) string {
+	cloned := *c.URL
+	cloned.Path = "/api/issues/search"
+	return cloned.String() + "?components=" + url.QueryEscape(component) + "&tags=brain-overload"
+}
+
+func (c *GitHubAdvisoriesCollector) plan(owner, repo string) []string {
+	return []string{
+		"# each page of:",
+		"GET " + c.Client.BaseURL.String() + fmt.Sprintf("repos/%s/%s/security-advisories?per_page=100&state=published", owner, repo),
+	}
+}
+
+func (c *LiteCollector) plan(owner, repo string) []string {
+	return []string{commandLine(cloneCommand(context.Background(), c.Forge.cloneURL(owner, repo), dryRunDir)), "$ git ls-files --stage -z"}
+}
+
+var secretRegexp = regexp.MustCompile(`^(\w*(TOKEN|KEY|SECRET|PASSWORD)\w*)=.+$`)
+
+// commandLine formats a command, with the secrets given in env variables
+// redacted.
+func commandLine(cmd *exec.Cmd) string {
+	args := []string{"$"}
+	for _, arg := range cmd.Args {
+		arg = secretRegexp.ReplaceAllString(arg, "$1=<redacted>")
+		if strings.ContainsAny(arg, " <>&;|") {
+			arg = "'" + arg + "'"
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,88 @@
+package qsos
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Evaluation is the result of the evaluation of a project.
+type Evaluation struct {
+	Owner  string
+	Repo   string
+	Stats  *ProjectStats
+	Scores *ProjectScores
+	// RedFlags are the issues raised by the red flag rules, independently of
+	// the scores.
+	RedFlags []RedFlag
+	// Denied is the list of messages from the policy, if the project has
+	// been denied.
+	Denied []string
+}
+
+func (e *Evaluation) Name() string {
+	return e.Owner + "/" + e.Repo
+}
+
+// Evaluate collects the stats of a project and computes its scores. If
+// policy is not empty, it is the path of a policy file used for the scoring.
+func Evaluate(ctx context.Context, executor *Executor, config *Config, owner, repo, policy string) (*Evaluation, error) {
+	stats, err := executor.GetProjectStats(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve repository statistics: %w", err)
+	}
+	return ScoreStats(config, owner, repo, stats, policy)
+}
+
+// ScoreStats computes the scores of a project from its stats.
+func ScoreStats(config *Config, owner, repo string, stats *ProjectStats, policy string) (*Evaluation, error) {
+	var err error
+	evaluation := &Evaluation{Owner: owner, Repo: repo, Stats: stats, RedFlags: CheckRedFlags(stats, config)}
+	if policy != "" {
+		evaluation.Scores, evaluation.Denied, err = ApplyPolicy(policy, stats, config)
+		if evaluation.Denied == nil {
+			evaluation.Denied = []string{}
+		}
+	} else {
+		evaluation.Scores, err = ComputeScores(stats, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compute scores: %w", err)
+	}
+	return evaluation, nil
+}
+
+// ParseProject parses a project in the owner/repo format.
+func ParseProject(project string) (string, string, error) {
+	parts := strings.Split(project, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Invalid project format %q. Must be in the format: owner/repo", project)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ReadProjectList reads a file with one project per line. Empty lines and
+// lines starting with # are ignored.
+func ReadProjectList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open project list: %w", err)
+	}
+	defer f.Close()
+
+	var projects []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		projects = append(projects, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot read project list: %w", err)
+	}
+	return projects, nil
+}
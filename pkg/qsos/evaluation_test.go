@@ -0,0 +1,100 @@
+package qsos
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestEvaluateReplay evaluates a Codeberg project with the responses of the
+// APIs recorded in testdata/replay, with QSOS_HTTP_RECORD and
+// SOURCE_DATE_EPOCH=1780272000 (2026-06-01).
+func TestEvaluateReplay(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1780272000")
+	t.Setenv("AI_MODEL", "")
+	t.Setenv("SKIP_SONAR_SCANNER", "")
+	e, err := NewExecutor(&ExecutorOptions{
+		Forge:      ForgeGitea,
+		ForgeURL:   "https://codeberg.org",
+		HTTPReplay: "testdata/replay",
+		CacheDir:   t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluation, err := Evaluate(context.Background(), e, DefaultConfig(), "example", "tasks", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := evaluation.Stats
+	if stats.GitHub.Stars != 420 || stats.GitHub.ActiveContributors != 4 || stats.GitHub.BotCommits != 1 {
+		t.Errorf("stars, active contributors and bot commits = %d, %d and %d, want 420, 4 and 1",
+			stats.GitHub.Stars, stats.GitHub.ActiveContributors, stats.GitHub.BotCommits)
+	}
+	if got, want := len(stats.GitHub.Releases), 7; got != want {
+		t.Errorf("releases = %d, want %d", got, want)
+	}
+	if got, want := stats.GitHub.ReleasePlatforms, []string{"darwin/arm64", "linux/amd64", "linux/arm64", "windows/amd64"}; !slices.Equal(got, want) {
+		t.Errorf("release platforms = %v, want %v", got, want)
+	}
+	if stats.Sonar.LinesOfCode != 48210 || stats.Sonar.BrainOverload != 6 {
+		t.Errorf("lines of code and brain overload = %d and %d, want 48210 and 6", stats.Sonar.LinesOfCode, stats.Sonar.BrainOverload)
+	}
+	if stats.Packages.Downloads != 15200 {
+		t.Errorf("downloads = %d, want 15200", stats.Packages.Downloads)
+	}
+	if stats.Summary == "" {
+		t.Error("no summary")
+	}
+	var warnings []string
+	for _, warning := range stats.Warnings {
+		warnings = append(warnings, warning.Code)
+	}
+	if want := []string{"scorecard-unsupported", "responsiveness-unknown", "backlog-unknown"}; !slices.Equal(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+
+	scores := evaluation.Scores
+	want := map[string]int64{
+		"community.maturity":                 3,
+		"community.activity":                 5,
+		"community.popularity":               2,
+		"community.contributors":             2,
+		"tech.size":                          3,
+		"tech.codesmells":                    2,
+		"security.scorecard":                 1,
+		"industrialization.releasecadence":   5,
+		"industrialization.releasefreshness": 5,
+		"industrialization.versioning":       5,
+		"adoption.platforms":                 5,
+	}
+	for _, criterion := range scores.Criteria() {
+		if score, ok := want[criterion.Name]; ok && *criterion.Score != score {
+			t.Errorf("%s = %d, want %d", criterion.Name, *criterion.Score, score)
+		}
+	}
+	if scores.Overall != 3.3 {
+		t.Errorf("overall = %v, want 3.3", scores.Overall)
+	}
+	if scores.Licensing.Class != LicenseApproved {
+		t.Errorf("license class = %s, want %s", scores.Licensing.Class, LicenseApproved)
+	}
+
+	// The report is the same for each replay
+	var first, second bytes.Buffer
+	if err := WriteJSONReport(&first, []*Evaluation{evaluation}, true); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Evaluate(context.Background(), e, DefaultConfig(), "example", "tasks", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSONReport(&second, []*Evaluation{again}, true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("the reports of the replays are different")
+	}
+}
@@ -0,0 +1,958 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/otiai10/openaigo"
+	"golang.org/x/sync/errgroup"
+)
+
+type Executor struct {
+	// HTTP is the client for the calls to the APIs, which can record and
+	// replay the responses.
+	HTTP *http.Client
+	// GitHub is used for the organizations and the README of the projects.
+	GitHub *github.Client
+	AI     *openaigo.Client
+	// Forge hosts the projects, GitHub when it is nil.
+	Forge *Forge
+	// The collectors can be replaced, for tests or for other data sources.
+	// GitHubStats collects the community stats on the forge of the projects.
+	GitHubStats GitHubCollector
+	Sonar       SonarCollector
+	ScoreCard   ScorecardCollector
+	// Advisories is optional.
+	Advisories AdvisoriesCollector
+	// Analyzer is the backend for the tech stats: "sonarqube" or "lite".
+	Analyzer string
+	// Refs are the git refs for which the tech stats are also collected.
+	Refs []string
+	// Subdir restricts the tech stats to a sub-directory of the
+	// repositories, for a component of a monorepo. With SubdirCommits, the
+	// commits of the community stats are also the ones touching it.
+	Subdir        string
+	SubdirCommits bool
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+	// Timeouts limit the phases of the collection.
+	Timeouts Timeouts
+	// Workers is the number of projects to evaluate concurrently.
+	Workers int
+	// forgeLimit limits the concurrent evaluations on the forge.
+	forgeLimit limiter
+	// cacheDir has the stats of the projects, read in offline mode.
+	cacheDir string
+	offline  bool
+	// results caches the results of the collectors, nil if disabled.
+	results *resultCache
+	// Progress is optional. It is set with SetProgress.
+	Progress ProgressFunc
+	// ToolOutput receives the output of the external tools (git and
+	// sonar-scanner-cli), discarded when it is nil.
+	ToolOutput io.Writer
+	// options are the settings of the executor, for creating the executors
+	// of the other forges.
+	options *ExecutorOptions
+}
+
+type ProjectStats struct {
+	GitHub    *GitHubStats
+	Sonar     *SonarStats
+	ScoreCard *ScoreCardStats
+	Packages  *PackagesStats
+	// Advisories are collected only if the executor has an advisories
+	// collector.
+	Advisories *AdvisoriesStats
+	// Refs has the tech stats for other git refs, if they were asked.
+	Refs     map[string]*SonarStats
+	Summary  string
+	Warnings []Warning
+	// Subdir is the sub-directory of the repository covered by the tech
+	// stats, if any.
+	Subdir string `json:",omitempty"`
+	// Metrics is the registry of the values collected for the scores, in
+	// which the collectors record their stats.
+	Metrics Metrics `json:",omitempty"`
+	// collected is when the stats were collected, read from the metadata of
+	// the raw stats.
+	collected time.Time
+}
+
+type GitHubStats struct {
+	FirstCommitDate time.Time
+	LastCommitDate  time.Time
+	// LastHumanCommitDate is the date of the last commit not authored by a
+	// bot. It is used for the activity score.
+	LastHumanCommitDate time.Time
+	Stars               int64
+	Forks               int64
+	// NoStars is true when the forge has no stars nor forks, like the plain
+	// git servers. Their metrics are missing, not 0.
+	NoStars            bool `json:",omitempty"`
+	ActiveContributors int64
+	// ContributorsWindow is the definition of the active contributors used
+	// for ActiveContributors.
+	ContributorsWindow *ContributorsWindow `json:",omitempty"`
+	// BotCommits is the number of commits of the window of the active
+	// contributors authored by bots, which are excluded from the count of
+	// the contributors.
+	BotCommits int64 `json:",omitempty"`
+	// ElephantFactor is the smallest number of organizations authoring half
+	// of the human commits of the window, 0 if it is unknown. The
+	// organization of a commit is the email domain of its author, or the
+	// company of their GitHub profile; each independent contributor is an
+	// organization.
+	ElephantFactor int64 `json:",omitempty"`
+	// TopOrganization is the organization with the most commits, empty for
+	// an independent contributor, and TopOrganizationShare the percentage of
+	// its commits.
+	TopOrganization      string  `json:",omitempty"`
+	TopOrganizationShare float64 `json:",omitempty"`
+	// BotCommitShare is the percentage of the commits of the window authored
+	// by bots (dependabot, renovate, GitHub Actions, etc.), and
+	// BotPullRequestShare the one of the pull requests merged in the last 6
+	// months.
+	BotCommitShare      float64
+	BotPullRequestShare float64
+	// Issues is the number of issues opened by the users in the last 6
+	// months, and IssueResponseTime the median time to the first response
+	// of a maintainer, a comment or the closing of the issue. It is 0 if it
+	// is unknown.
+	Issues            int64         `json:",omitempty"`
+	IssueResponseTime time.Duration `json:",omitempty"`
+	// OpenIssues and ClosedIssues are the numbers of issues, and
+	// IssuesOpenedLastYear and IssuesClosedLastYear the ones opened and
+	// closed in the last year, for the growth of the backlog.
+	OpenIssues           int64 `json:",omitempty"`
+	ClosedIssues         int64 `json:",omitempty"`
+	IssuesOpenedLastYear int64 `json:",omitempty"`
+	IssuesClosedLastYear int64 `json:",omitempty"`
+	// WeeklyCommits is the number of commits for each week of the last
+	// year, from the oldest to the most recent, without the ones of the bots.
+	WeeklyCommits []int64
+	// WeeklyCommitsWithBots is true when the weekly commits include the ones
+	// of the bots, like the participation statistics of GitHub: a dormant
+	// project with weekly dependency updates would look active, and they are
+	// not used for the activity.
+	WeeklyCommitsWithBots bool `json:",omitempty"`
+	// ShallowHistory is true when the stats of the commits come from a
+	// shallow clone, without the oldest commits.
+	ShallowHistory bool `json:",omitempty"`
+	Archived       bool
+	// License is the SPDX identifier of the license, if it has been detected.
+	License string
+	// Releases are the last releases and tags, the most recent first.
+	Releases []Release `json:",omitempty"`
+	// ReleasePlatforms are the platforms (like "linux/amd64") of the assets
+	// of the latest release.
+	ReleasePlatforms []string
+	// Maintainers are the people the project depends on, from the
+	// CODEOWNERS file and the top committers.
+	Maintainers []*Maintainer `json:",omitempty"`
+}
+
+type SonarStats struct {
+	LinesOfCode          int64
+	Functions            int64
+	CodeSmells           int64
+	BrainOverload        int64
+	CyclomaticComplexity int64
+	CognitiveComplexity  int64
+	DuplicationDensity   float64
+	// FunctionsEstimated is true if the functions were not reported by
+	// Sonarqube, and have been counted by the lite analyzer.
+	FunctionsEstimated bool
+	// Incomplete is true if the measures were still not available after
+	// waiting for Sonarqube.
+	Incomplete bool
+}
+
+type ScoreCardStats struct {
+	Scorecard struct {
+		Version string
+		Commit  string
+	}
+	Checks []ScoreCardCheck
+	// Local is true when the checks have been run on a local working copy,
+	// without the ones that need the API of the forge.
+	Local bool `json:",omitempty"`
+}
+
+// ScoreCardCheck is the score of a check of the OpenSSF scorecard.
+type ScoreCardCheck struct {
+	Name  string
+	Score int64
+}
+
+// ExecutorOptions are the settings of the services used for collecting the
+// stats.
+type ExecutorOptions struct {
+	// Forge is the kind of forge hosting the projects, github by default.
+	// ForgeURL is the URL of a self-hosted forge.
+	Forge       string
+	ForgeURL    string
+	GitHubToken string
+	// GitHubTokenFile, GitHubAppKeyFile and SonarqubeTokenFile are files
+	// with the secrets, like the secrets mounted by Docker or Kubernetes.
+	// They are read when the secret is not set.
+	GitHubTokenFile    string
+	GitHubAppKeyFile   string
+	SonarqubeTokenFile string
+	// GitHubAPIURL and GitHubUploadURL are the URLs of the API of GitHub
+	// Enterprise Server, by default the ones of ForgeURL.
+	GitHubAPIURL    string
+	GitHubUploadURL string
+	// GitHubAppID, GitHubAppInstallationID and GitHubAppKey (in the PEM
+	// format) authenticate as a GitHub App, instead of GitHubToken. The
+	// installation is optional if the app has a single one.
+	GitHubAppID             string
+	GitHubAppInstallationID string
+	GitHubAppKey            string
+	GitLabToken             string
+	GiteaToken              string
+	// BitbucketUsername is the user of the app password given as
+	// BitbucketToken, if it is not an access token.
+	BitbucketUsername string
+	BitbucketToken    string
+	SonarqubeURL      string
+	SonarqubeToken    string
+	// SonarqubeCACert is a PEM bundle of the CAs of a self-hosted Sonarqube,
+	// added to the system ones. SonarqubeClientCert and SonarqubeClientKey
+	// are the PEM files of a client certificate. SonarqubeInsecure skips the
+	// verification of the certificate of the server.
+	SonarqubeCACert     string
+	SonarqubeClientCert string
+	SonarqubeClientKey  string
+	SonarqubeInsecure   bool
+	// Analyzer is the backend for the tech stats: "sonarqube" (the default)
+	// or "lite".
+	Analyzer string
+	// CacheDir is the directory for the cache of the lite analyzer and of
+	// the GitHub responses. By default, it is qsos in the user cache dir.
+	CacheDir string
+	// NoGitHubCache disables the cache of the GitHub responses.
+	NoGitHubCache bool
+	PublicDataURL string
+	AIAPIKey      string
+	AIBaseURL     string
+	// HTTPRecord and HTTPReplay are the directories where the HTTP
+	// responses are recorded or replayed.
+	HTTPRecord string
+	HTTPReplay string
+	// Advisories enables the collection of the security advisories.
+	Advisories bool
+	// Retries is the number of retries of the transient failures of the
+	// APIs and of the scorecard, 0 disables them. RetryDelay is the delay
+	// before the first retry, doubled after each one, 1s by default.
+	Retries    int
+	RetryDelay time.Duration
+	// Timeouts limit the phases of the collection. ExecutorOptionsFromEnv
+	// sets DefaultTimeouts, and the QSOS_*_TIMEOUT variables.
+	Timeouts Timeouts
+	// Workers is the number of projects evaluated concurrently by the CLI, 1
+	// by default. ForgeWorkers limits the concurrent evaluations of the
+	// projects of a same forge, and DockerWorkers the concurrent containers
+	// of the scanners (1 if the host cannot run several ones), 0 is no limit.
+	Workers       int
+	ForgeWorkers  int
+	DockerWorkers int
+	// limits are shared by the executors created with these options.
+	limits *limits
+	// NoCache disables the cache of the results of the collectors, which
+	// are reused for the same commit of a repository until CacheTTL, 24
+	// hours with ExecutorOptionsFromEnv.
+	NoCache  bool
+	CacheTTL time.Duration
+	// Offline answers from the cache only: the stats of the projects saved
+	// by their last online evaluation, and the cached GitHub responses. The
+	// tokens are not required.
+	Offline bool
+	// Local is set when only local working copies are evaluated: the
+	// tokens of the forges are not required.
+	Local bool
+	// ContributorsMonths and ContributorsMinCommits define the active
+	// contributors: the authors of at least ContributorsMinCommits commits
+	// in the last ContributorsMonths months, 4 commits in 6 months by
+	// default.
+	ContributorsMonths     int
+	ContributorsMinCommits int64
+	// Bots are the bots added to the default ones, in the format of
+	// ParseBotFilter.
+	Bots string
+	// ToolOutput receives the output of the external tools, the standard
+	// error when it is nil.
+	ToolOutput io.Writer
+}
+
+// ExecutorOptionsFromEnv returns the options given by the env variables.
+func ExecutorOptionsFromEnv() *ExecutorOptions {
+	advisories, _ := strconv.ParseBool(os.Getenv("QSOS_ADVISORIES"))
+	sonarInsecure, _ := strconv.ParseBool(os.Getenv("SONARQUBE_INSECURE_SKIP_VERIFY"))
+	noGitHubCache, _ := strconv.ParseBool(os.Getenv("QSOS_NO_GITHUB_CACHE"))
+	offline, _ := strconv.ParseBool(os.Getenv("QSOS_OFFLINE"))
+	noCache, _ := strconv.ParseBool(os.Getenv("QSOS_NO_CACHE"))
+	cacheTTL, err := time.ParseDuration(os.Getenv("QSOS_CACHE_TTL"))
+	if err != nil {
+		cacheTTL = defaultCacheTTL
+	}
+	retries, err := strconv.Atoi(os.Getenv("QSOS_RETRIES"))
+	if err != nil {
+		retries = defaultRetries
+	}
+	retryDelay, _ := time.ParseDuration(os.Getenv("QSOS_RETRY_DELAY"))
+	contributorsMonths, _ := strconv.Atoi(os.Getenv("QSOS_CONTRIBUTORS_MONTHS"))
+	contributorsMinCommits, _ := strconv.ParseInt(os.Getenv("QSOS_CONTRIBUTORS_MIN_COMMITS"), 10, 64)
+	return &ExecutorOptions{
+		Forge:                   os.Getenv("QSOS_FORGE"),
+		ForgeURL:                os.Getenv("QSOS_FORGE_URL"),
+		GitHubToken:             os.Getenv("GITHUB_TOKEN"),
+		GitHubTokenFile:         os.Getenv("GITHUB_TOKEN_FILE"),
+		GitHubAppKeyFile:        os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"),
+		SonarqubeTokenFile:      os.Getenv("SONARQUBE_TOKEN_FILE"),
+		GitHubAPIURL:            os.Getenv("QSOS_GITHUB_API_URL"),
+		GitHubUploadURL:         os.Getenv("QSOS_GITHUB_UPLOAD_URL"),
+		GitHubAppID:             os.Getenv("GITHUB_APP_ID"),
+		GitHubAppInstallationID: os.Getenv("GITHUB_APP_INSTALLATION_ID"),
+		GitHubAppKey:            os.Getenv("GITHUB_APP_PRIVATE_KEY"),
+		GitLabToken:             os.Getenv("GITLAB_TOKEN"),
+		GiteaToken:              os.Getenv("GITEA_TOKEN"),
+		BitbucketUsername:       os.Getenv("BITBUCKET_USERNAME"),
+		BitbucketToken:          os.Getenv("BITBUCKET_TOKEN"),
+		SonarqubeURL:            os.Getenv("SONARQUBE_URL"),
+		SonarqubeToken:          os.Getenv("SONARQUBE_TOKEN"),
+		SonarqubeCACert:         os.Getenv("SONARQUBE_CA_CERT"),
+		SonarqubeClientCert:     os.Getenv("SONARQUBE_CLIENT_CERT"),
+		SonarqubeClientKey:      os.Getenv("SONARQUBE_CLIENT_KEY"),
+		SonarqubeInsecure:       sonarInsecure,
+		Analyzer:                os.Getenv("QSOS_ANALYZER"),
+		CacheDir:                os.Getenv("QSOS_CACHE_DIR"),
+		NoGitHubCache:           noGitHubCache,
+		PublicDataURL:           os.Getenv("PUBLIC_DATA_URL"),
+		AIAPIKey:                os.Getenv("AI_API_KEY"),
+		AIBaseURL:               os.Getenv("AI_BASE_URL"),
+		HTTPRecord:              os.Getenv("QSOS_HTTP_RECORD"),
+		HTTPReplay:              os.Getenv("QSOS_HTTP_REPLAY"),
+		Advisories:              advisories,
+		Retries:                 retries,
+		RetryDelay:              retryDelay,
+		Timeouts:                timeoutsFromEnv(),
+		Workers:                 workersFromEnv("QSOS_WORKERS", 1),
+		ForgeWorkers:            workersFromEnv("QSOS_FORGE_WORKERS", 0),
+		DockerWorkers:           workersFromEnv("QSOS_DOCKER_WORKERS", 0),
+		Offline:                 offline,
+		NoCache:                 noCache,
+		CacheTTL:                cacheTTL,
+		ContributorsMonths:      contributorsMonths,
+		ContributorsMinCommits:  contributorsMinCommits,
+		Bots:                    os.Getenv("QSOS_BOTS"),
+	}
+}
+
+// readSecretFiles returns a copy of the options, with the secrets read from
+// their files if they are not set.
+func readSecretFiles(opts *ExecutorOptions) (*ExecutorOptions, error) {
+	cloned := *opts
+	secrets := []struct {
+		name         string
+		file, secret *string
+	}{
+		{"GITHUB_TOKEN_FILE", &cloned.GitHubTokenFile, &cloned.GitHubToken},
+		{"GITHUB_APP_PRIVATE_KEY_FILE", &cloned.GitHubAppKeyFile, &cloned.GitHubAppKey},
+		{"SONARQUBE_TOKEN_FILE", &cloned.SonarqubeTokenFile, &cloned.SonarqubeToken},
+	}
+	for _, s := range secrets {
+		if *s.secret != "" || *s.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(*s.file)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %w", s.name, err)
+		}
+		*s.secret = strings.TrimSpace(string(data))
+	}
+	return &cloned, nil
+}
+
+// newGitHubApp returns the GitHub App of the options, or nil if they have no
+// app ID.
+func newGitHubApp(opts *ExecutorOptions, httpClient *http.Client) (*GitHubApp, error) {
+	if opts.GitHubAppID == "" {
+		return nil, nil
+	}
+	app := &GitHubApp{HTTP: httpClient}
+	var err error
+	if app.AppID, err = strconv.ParseInt(opts.GitHubAppID, 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid GITHUB_APP_ID %q: %w", opts.GitHubAppID, err)
+	}
+	if opts.GitHubAppInstallationID != "" {
+		if app.InstallationID, err = strconv.ParseInt(opts.GitHubAppInstallationID, 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid GITHUB_APP_INSTALLATION_ID %q: %w", opts.GitHubAppInstallationID, err)
+		}
+	}
+	if opts.GitHubAppKey == "" {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY environment variable is not set")
+	}
+	if app.Key, err = ParseGitHubAppKey([]byte(opts.GitHubAppKey)); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// ghAuthToken returns the token stored by the GitHub CLI for a host, or an
+// empty string if gh is not installed or not logged in.
+func ghAuthToken(host string) string {
+	output, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		slog.Debug("no token from the GitHub CLI", "host", host, "err", err)
+		return ""
+	}
+	slog.Debug("using the token of the GitHub CLI", "host", host)
+	return strings.TrimSpace(string(output))
+}
+
+func NewExecutorFromEnv() (*Executor, error) {
+	return NewExecutor(ExecutorOptionsFromEnv())
+}
+
+func NewExecutor(opts *ExecutorOptions) (*Executor, error) {
+	retry := RetryPolicy{Retries: opts.Retries, Delay: opts.RetryDelay}
+	httpClient, err := newHTTPClient(opts.HTTPRecord, opts.HTTPReplay, nil, retry)
+	if err != nil {
+		return nil, err
+	}
+	// The tokens are not needed to replay recorded responses, or offline
+	replay := opts.HTTPReplay != ""
+	noToken := replay || opts.Local || opts.Offline
+
+	forge, err := NewForge(opts.Forge, opts.ForgeURL)
+	if err != nil {
+		return nil, err
+	}
+	if opts, err = readSecretFiles(opts); err != nil {
+		return nil, err
+	}
+	if opts.limits == nil {
+		opts.limits = &limits{docker: newLimiter(opts.DockerWorkers), forgeWorkers: opts.ForgeWorkers}
+	}
+	var app *GitHubApp
+	if !opts.Offline {
+		if app, err = newGitHubApp(opts, httpClient); err != nil {
+			return nil, err
+		}
+	}
+	token := opts.GitHubToken
+	if token == "" && app == nil && !noToken && forge.Kind == ForgeGitHub {
+		token = ghAuthToken(forge.URL.Host)
+	}
+	anonymous := token == "" && app == nil && !noToken && forge.Kind == ForgeGitHub
+	if anonymous {
+		slog.Warn("GITHUB_TOKEN environment variable is not set, the GitHub API is used anonymously with a rate limit of 60 requests per hour")
+	}
+	cacheDir := opts.CacheDir
+	if cacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot find a cache dir, set QSOS_CACHE_DIR: %w", err)
+		}
+		cacheDir = filepath.Join(dir, "qsos")
+	}
+	if opts.Offline {
+		// All the requests are answered from the cache of GitHub
+		httpClient = &http.Client{Transport: &cachingTransport{Dir: filepath.Join(cacheDir, "github"), Offline: true}}
+	}
+
+	// The responses of GitHub are revalidated with conditional requests,
+	// which do not count in the rate limit. They are not cached when they
+	// are recorded or replayed. The requests wait for the reset of the rate
+	// limit when it is exhausted.
+	githubTransport := httpClient.Transport
+	if !opts.NoGitHubCache && opts.HTTPRecord == "" && !replay && !opts.Offline {
+		githubTransport = &cachingTransport{Dir: filepath.Join(cacheDir, "github"), Next: githubTransport}
+	}
+	if !replay && !opts.Offline {
+		githubTransport = &rateLimitTransport{Next: githubTransport}
+	}
+	client := github.NewClient(&http.Client{Transport: githubTransport})
+	if app != nil {
+		client = github.NewClient(&http.Client{Transport: &githubAppTransport{App: app, Next: githubTransport}})
+	} else if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if forge.Kind == ForgeGitHub && (forge.URL.Host != "github.com" || opts.GitHubAPIURL != "") {
+		// The URLs of GitHub Enterprise Server are completed with /api/v3/
+		// and /api/uploads/
+		client, err = client.WithEnterpriseURLs(cmp.Or(opts.GitHubAPIURL, forge.URL.String()), cmp.Or(opts.GitHubUploadURL, forge.URL.String()))
+		if err != nil {
+			return nil, fmt.Errorf("Cannot parse the URLs of the GitHub API: %w", err)
+		}
+	}
+	if app != nil {
+		app.APIURL = client.BaseURL
+	}
+
+	analyzer := opts.Analyzer
+	if analyzer == "" {
+		analyzer = "sonarqube"
+	}
+	if analyzer != "sonarqube" && analyzer != "lite" {
+		return nil, fmt.Errorf("Invalid QSOS_ANALYZER %q. Must be sonarqube or lite", analyzer)
+	}
+
+	// Sonarqube is not needed for the lite analyzer. Without a token, the
+	// public analyses of SonarCloud are read by default.
+	sonarqube := opts.SonarqubeURL
+	sonarToken := opts.SonarqubeToken
+	if sonarqube == "" && analyzer == "sonarqube" {
+		if sonarToken != "" {
+			return nil, errors.New("SONARQUBE_URL environment variable is not set")
+		}
+		sonarqube = sonarCloudURL
+	}
+	u, err := url.Parse(sonarqube)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse SONARQUBE_URL: %w", err)
+	}
+
+	ai := openaigo.NewClient(opts.AIAPIKey)
+	ai.HTTPClient = httpClient
+	if opts.AIBaseURL != "" {
+		ai.BaseURL = opts.AIBaseURL
+	}
+
+	contributors := &ContributorsWindow{
+		Months:     cmp.Or(opts.ContributorsMonths, DefaultContributorsWindow.Months),
+		MinCommits: cmp.Or(opts.ContributorsMinCommits, DefaultContributorsWindow.MinCommits),
+	}
+	if contributors.Months < 0 || contributors.MinCommits < 0 {
+		return nil, fmt.Errorf("Invalid window of the active contributors: %d months, %d commits", contributors.Months, contributors.MinCommits)
+	}
+	bots, err := ParseBotFilter(opts.Bots)
+	if err != nil {
+		return nil, err
+	}
+
+	toolOutput := opts.ToolOutput
+	if toolOutput == nil {
+		toolOutput = os.Stderr
+	}
+
+	var publicData *url.URL
+	if opts.PublicDataURL != "" {
+		publicData, err = url.Parse(opts.PublicDataURL)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot parse PUBLIC_DATA_URL: %w", err)
+		}
+	}
+
+	executor := &Executor{
+		HTTP:   httpClient,
+		GitHub: client,
+		AI:     ai,
+		Forge:  forge,
+		GitHubStats: &GitHubAPICollector{
+			Client:        client,
+			HTTP:          httpClient,
+			PublicDataURL: publicData,
+			Anonymous:     anonymous,
+			Contributors:  contributors,
+			Bots:          bots,
+			ToolOutput:    toolOutput,
+		},
+		ScoreCard:    &ScorecardCLICollector{GitHubToken: token, GitHubApp: app, GitLabToken: opts.GitLabToken, Forge: forge, Retry: retry, docker: opts.limits.docker},
+		Analyzer:     analyzer,
+		Contributors: contributors,
+		Bots:         bots,
+		Timeouts:     opts.Timeouts,
+		Workers:      max(opts.Workers, 1),
+		forgeLimit:   opts.limits.forge(forge),
+		cacheDir:     cacheDir,
+		offline:      opts.Offline,
+		ToolOutput:   toolOutput,
+		options:      opts,
+	}
+	// The results are not cached when the responses are recorded or replayed
+	if !opts.NoCache && opts.CacheTTL > 0 && opts.HTTPRecord == "" && !replay {
+		executor.results = &resultCache{Dir: filepath.Join(cacheDir, "results"), TTL: opts.CacheTTL}
+	}
+	switch forge.Kind {
+	case ForgeGitLab:
+		executor.GitHubStats = &GitLabCollector{URL: forge.URL, Token: opts.GitLabToken, HTTP: httpClient, Contributors: contributors, Bots: bots}
+	case ForgeGitea:
+		executor.GitHubStats = &GiteaCollector{URL: forge.URL, Token: opts.GiteaToken, HTTP: httpClient, Contributors: contributors, Bots: bots}
+	case ForgeBitbucket:
+		if !strings.EqualFold(forge.URL.Host, "bitbucket.org") {
+			return nil, fmt.Errorf("Cannot use %s, only Bitbucket Cloud is supported", forge.URL)
+		}
+		executor.GitHubStats = &BitbucketCollector{Username: opts.BitbucketUsername, Token: opts.BitbucketToken, HTTP: httpClient, Contributors: contributors, Bots: bots}
+	case ForgeGit:
+		executor.GitHubStats = &GitHistoryCollector{Forge: forge, Contributors: contributors, Bots: bots, ToolOutput: toolOutput}
+	}
+	if opts.Advisories && forge.Kind == ForgeGitHub {
+		executor.Advisories = &GitHubAdvisoriesCollector{Client: client}
+	} else if opts.Advisories {
+		slog.Warn("the security advisories are only collected on GitHub", "forge", forge.URL.Host)
+	}
+	if analyzer == "lite" {
+		executor.Sonar = &LiteCollector{CacheDir: cacheDir, Forge: forge, ToolOutput: toolOutput}
+	} else {
+		sonarHTTP := httpClient
+		tlsConfig, err := sonarqubeTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		caCert := opts.SonarqubeCACert
+		if caCert != "" {
+			// The file is mounted in the container of the scanner
+			if caCert, err = filepath.Abs(caCert); err != nil {
+				return nil, fmt.Errorf("Cannot find SONARQUBE_CA_CERT: %w", err)
+			}
+		}
+		if tlsConfig != nil {
+			if opts.SonarqubeInsecure {
+				slog.Warn("the certificate of the Sonarqube server is not verified", "url", u)
+			}
+			if sonarHTTP, err = newHTTPClient(opts.HTTPRecord, opts.HTTPReplay, tlsConfig, retry); err != nil {
+				return nil, err
+			}
+		}
+		executor.Sonar = &SonarqubeCollector{
+			URL:         u,
+			Token:       sonarToken,
+			HTTP:        sonarHTTP,
+			CACert:      caCert,
+			Fallback:    &LiteCollector{CacheDir: cacheDir, Forge: forge, ToolOutput: toolOutput},
+			Forge:       forge,
+			ScanTimeout: opts.Timeouts.SonarScan,
+			PollTimeout: opts.Timeouts.SonarPoll,
+			ToolOutput:  toolOutput,
+			docker:      opts.limits.docker,
+		}
+	}
+	return executor, nil
+}
+
+// ForProject returns the executor for a project, given in the owner/repo
+// format or by the URL of its repository, with its owner and name. For a
+// URL on another forge, a new executor is created with the same settings,
+// but without the credentials of the forge, which are only sent to the
+// forge they are configured for.
+func (e *Executor) ForProject(project string) (*Executor, string, string, error) {
+	if !strings.Contains(project, "://") {
+		owner, repo, err := e.Forge.ParseProject(project)
+		return e, owner, repo, err
+	}
+	forge, owner, repo, err := ParseForgeURL(project, e.Forge)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if forge.sameAs(e.Forge) {
+		return e, owner, repo, nil
+	}
+	if e.options == nil {
+		return nil, "", "", fmt.Errorf("Cannot evaluate %s, the executor is for %s", project, e.Forge.orDefault().URL)
+	}
+	opts := *e.options
+	opts.Forge, opts.ForgeURL = forge.Kind, forge.URL.String()
+	// The API URLs are the ones of the GitHub forge of the options
+	opts.GitHubAPIURL, opts.GitHubUploadURL = "", ""
+	opts.GitHubToken, opts.GitHubTokenFile = "", ""
+	opts.GitHubAppID, opts.GitHubAppInstallationID, opts.GitHubAppKey, opts.GitHubAppKeyFile = "", "", "", ""
+	opts.GitLabToken, opts.GiteaToken = "", ""
+	opts.BitbucketUsername, opts.BitbucketToken = "", ""
+	executor, err := NewExecutor(&opts)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("Cannot create the executor for %s: %w", forge.URL, err)
+	}
+	executor.Refs = e.Refs
+	executor.Subdir, executor.SubdirCommits = e.Subdir, e.SubdirCommits
+	executor.SetProgress(e.Progress)
+	return executor, owner, repo, nil
+}
+
+// GetProjectStats collects the stats of a project, in a span with the spans
+// of its phases.
+func (e *Executor) GetProjectStats(ctx context.Context, owner, repo string) (_ *ProjectStats, err error) {
+	ctx, end := startSpan(ctx, "collect", projectAttribute(owner, repo))
+	defer func() { end(err) }()
+	if e.offline {
+		return e.cachedStats(owner, repo)
+	}
+	release, err := e.forgeLimit.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// The results of the collectors are cached for the head commit
+	head := e.headCommit(ctx, owner, repo)
+	// The collectors are independent, they run concurrently, and the first
+	// failure cancels the others
+	var github *GitHubStats
+	var card *ScoreCardStats
+	var sonar *SonarStats
+	var noScorecard, noScorecardToken bool
+	group, groupCtx := errgroup.WithContext(ctx)
+	// The repository is cloned once, for the analyzer and the history of the
+	// commits, when the analyzer needs the sources
+	var clone func() (string, error)
+	if e.clonesSources() {
+		tmpDir, err := os.MkdirTemp("", componentName(owner, repo)+"-")
+		if err != nil {
+			return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		clone = sync.OnceValues(func() (string, error) {
+			return tmpDir, cloneRepository(groupCtx, e.ToolOutput, e.Forge.cloneURL(owner, repo), tmpDir)
+		})
+	}
+	group.Go(func() error {
+		var subdir string
+		if e.SubdirCommits {
+			subdir = e.Subdir
+		}
+		key := e.resultKey(owner, repo, head, PhaseGitHub, subdir)
+		phaseCtx, done := e.startPhase(groupCtx, owner, repo, PhaseGitHub)
+		if e.results.get(key, &github) {
+			done(nil)
+			return nil
+		}
+		err := withTimeout(phaseCtx, e.Timeouts.GitHub, "the community stats", "QSOS_GITHUB_TIMEOUT", func(ctx context.Context) error {
+			history, ok := e.GitHubStats.(historyCollector)
+			if !ok || clone == nil {
+				var err error
+				github, err = e.GitHubStats.GetGitHubStats(ctx, owner, repo)
+				return err
+			}
+			dir, err := clone()
+			if err != nil {
+				return err
+			}
+			github, err = history.GetGitHubStatsFromHistory(ctx, owner, repo, dir)
+			return err
+		})
+		done(err)
+		if err != nil {
+			return fmt.Errorf("GitHub: %w", err)
+		}
+		if e.Subdir != "" && e.SubdirCommits {
+			if err := e.filterSubdirCommits(groupCtx, clone, github); err != nil {
+				return fmt.Errorf("Git: %w", err)
+			}
+		}
+		e.results.put(key, github)
+		return nil
+	})
+	group.Go(func() error {
+		key := e.resultKey(owner, repo, head, PhaseScorecard, "")
+		phaseCtx, done := e.startPhase(groupCtx, owner, repo, PhaseScorecard)
+		if e.results.get(key, &card) {
+			done(nil)
+			return nil
+		}
+		err := withTimeout(phaseCtx, e.Timeouts.Scorecard, "the scorecard", "QSOS_SCORECARD_TIMEOUT", func(ctx context.Context) error {
+			var err error
+			card, err = e.ScoreCard.GetScoreCardStats(ctx, owner, repo)
+			return err
+		})
+		done(err)
+		noScorecard = errors.Is(err, ErrScorecardUnsupported)
+		noScorecardToken = errors.Is(err, ErrScorecardNoToken)
+		if noScorecard || noScorecardToken {
+			card = &ScoreCardStats{}
+		} else if err != nil {
+			return fmt.Errorf("ScoreCard: %w", err)
+		}
+		// The checks are sorted, for stable reports
+		slices.SortFunc(card.Checks, func(a, b ScoreCardCheck) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		if err == nil {
+			e.results.put(key, card)
+		}
+		return nil
+	})
+	group.Go(func() error {
+		key := e.resultKey(owner, repo, head, PhaseSonar, e.Subdir)
+		phaseCtx, done := e.startPhase(groupCtx, owner, repo, PhaseSonar)
+		if e.results.get(key, &sonar) {
+			done(nil)
+			return nil
+		}
+		var err error
+		if clone != nil {
+			sonar, err = e.analyzeClone(phaseCtx, owner, repo, clone)
+		} else {
+			sonar, err = e.Sonar.GetSonarStats(phaseCtx, owner, repo)
+		}
+		done(err)
+		if err != nil {
+			return fmt.Errorf("Sonar: %w", err)
+		}
+		// The measures still computed by Sonarqube are collected again
+		if !sonar.Incomplete {
+			e.results.put(key, sonar)
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	var metrics Metrics
+	collected := reportTime()
+	metrics.record("github", collected, github.metrics(collected))
+	metrics.record("scorecard", collected, card.metrics())
+	metrics.record("sonar", collected, sonar.metrics())
+	phaseCtx, done := e.startPhase(ctx, owner, repo, PhaseSummary)
+	summary, err := e.GetSummary(phaseCtx, owner, repo)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("Summary: %w", err)
+	}
+	stats := &ProjectStats{
+		GitHub:    github,
+		ScoreCard: card,
+		Sonar:     sonar,
+		Summary:   summary,
+		Metrics:   metrics,
+		Subdir:    e.Subdir,
+	}
+	if noScorecard {
+		stats.addWarning("scorecard-unsupported", "the scorecard does not support the forge of the project, the scorecard score is the lowest one")
+	}
+	if noScorecardToken {
+		stats.addWarning("scorecard-no-token", "the scorecard needs a token for the API of the forge, the scorecard score is the lowest one")
+	}
+	if c, ok := e.GitHubStats.(*GitHubAPICollector); ok && c.Anonymous {
+		stats.addWarning("github-anonymous", "the GitHub API was used without a token, the merged PRs by bots and the maintainers were not collected")
+	}
+	phaseCtx, done = e.startPhase(ctx, owner, repo, PhasePackages)
+	packages, err := e.GetPackagesStats(phaseCtx, owner, repo)
+	done(err)
+	if err != nil {
+		slog.Warn("cannot get the packages stats", "project", owner+"/"+repo, "err", err)
+		stats.addWarning("packages-unavailable", "the stats of the packages are not available, the popularity only uses GitHub data")
+	}
+	stats.Packages = packages
+	if packages != nil {
+		stats.Metrics.record("packages", reportTime(), packages.metrics())
+	}
+	if e.Advisories != nil {
+		phaseCtx, done = e.startPhase(ctx, owner, repo, PhaseAdvisories)
+		stats.Advisories, err = e.Advisories.GetAdvisoriesStats(phaseCtx, owner, repo)
+		done(err)
+		if err != nil {
+			slog.Warn("cannot get the security advisories", "project", owner+"/"+repo, "err", err)
+			stats.addWarning("advisories-unavailable", "the security advisories are not available, the security process is not scored")
+		} else {
+			stats.Metrics.record("advisories", reportTime(), stats.Advisories.metrics())
+		}
+	}
+	if len(e.Refs) > 0 {
+		phaseCtx, done = e.startPhase(ctx, owner, repo, PhaseRefs)
+		refs, err := e.GetRefsStats(phaseCtx, owner, repo, e.Refs)
+		done(err)
+		if err != nil {
+			return nil, fmt.Errorf("Refs: %w", err)
+		}
+		stats.Refs = refs
+	}
+	stats.checkWarnings()
+	e.cacheStats(owner, repo, stats)
+	return stats, nil
+}
+
+// readmeCollector is implemented by the collectors of the forges other than
+// GitHub, which give the README of the projects.
+type readmeCollector interface {
+	GetReadme(ctx context.Context, owner, repo string) (string, error)
+}
+
+func (e *Executor) GetSummary(ctx context.Context, owner, repo string) (string, error) {
+	content, err := e.getReadme(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	summary, err := e.summarize(ctx, content)
+	if err != nil {
+		return "", fmt.Errorf("summarize: %w", err)
+	}
+	return summary, nil
+}
+
+func (e *Executor) getReadme(ctx context.Context, owner, repo string) (string, error) {
+	if c, ok := e.GitHubStats.(readmeCollector); ok {
+		return c.GetReadme(ctx, owner, repo)
+	}
+	readme, _, err := e.GitHub.Repositories.GetReadme(ctx, owner, repo, nil)
+	if err != nil {
+		return "", err
+	}
+	return readme.GetContent()
+}
+
+const promptTLDR = `
+Tu es un agent dont le rôle est de créer une introduction en français pour un
+logiciel Open-Source. Cette introduction devra faire 4 ou 5 phrases. Voici le
+README du logiciel en question.
+`
+
+func (e *Executor) summarize(ctx context.Context, content string) (string, error) {
+	model := "gpt-oss-120b"
+	if m := os.Getenv("AI_MODEL"); m != "" {
+		model = m
+	}
+	request := openaigo.ChatRequest{
+		Model: model,
+		Messages: []openaigo.Message{
+			{Role: "system", Content: promptTLDR},
+			{Role: "user", Content: content},
+		},
+	}
+	response, err := e.AI.Chat(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("AI error: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("AI error: no response")
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// cloneRepository clones a repository in dir, with its history but without
+// the content of the files of the previous commits.
+func cloneRepository(ctx context.Context, toolOutput io.Writer, repoURL, dir string) error {
+	cmd := cloneCommand(ctx, repoURL, dir)
+	cmd.Stdout = toolOutput
+	cmd.Stderr = toolOutput
+	end := traceCommand(ctx, cmd)
+	err := cmd.Run()
+	end(err)
+	if err != nil {
+		return fmt.Errorf("Cannot clone git repository: %w", err)
+	}
+	return nil
+}
+
+func cloneCommand(ctx context.Context, repoURL, dir string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", "clone", "--filter=blob:none", repoURL, ".")
+	cmd.Dir = dir
+	return cmd
+}
@@ -0,0 +1,222 @@
+package qsos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otiai10/openaigo"
+)
+
+type fakeGitHub struct {
+	stats *GitHubStats
+	err   error
+}
+
+func (c *fakeGitHub) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	return c.stats, c.err
+}
+
+func (c *fakeGitHub) GetReadme(ctx context.Context, owner, repo string) (string, error) {
+	return "# " + repo, nil
+}
+
+type fakeSonar struct {
+	stats *SonarStats
+	err   error
+}
+
+func (c *fakeSonar) GetSonarStats(ctx context.Context, owner, repo string) (*SonarStats, error) {
+	return c.stats, c.err
+}
+
+func (c *fakeSonar) AnalyzeDir(ctx context.Context, dir, component string) (*SonarStats, error) {
+	return c.stats, c.err
+}
+
+type fakeScorecard struct {
+	stats *ScoreCardStats
+	err   error
+}
+
+func (c *fakeScorecard) GetScoreCardStats(ctx context.Context, owner, repo string) (*ScoreCardStats, error) {
+	return c.stats, c.err
+}
+
+func (c *fakeScorecard) AnalyzeDir(ctx context.Context, dir string) (*ScoreCardStats, error) {
+	return c.stats, c.err
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fakeHTTP answers the chat completions of the summaries, and 404 to the
+// other requests, like the lookup of the packages.
+var fakeHTTP = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+	response := &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}, Request: req}
+	if strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		response.StatusCode = http.StatusOK
+		response.Header.Set("Content-Type", "application/json")
+		response.Body = io.NopCloser(strings.NewReader(`{"choices":[{"message":{"role":"assistant","content":"A project."}}]}`))
+	}
+	return response, nil
+})}
+
+// fakeChecks returns all the checks of the scorecard, with the same score.
+func fakeChecks(score int64) *ScoreCardStats {
+	stats := &ScoreCardStats{}
+	for name := range DefaultConfig().Weights.ScoreCard {
+		stats.Checks = append(stats.Checks, ScoreCardCheck{Name: name, Score: score})
+	}
+	return stats
+}
+
+// newFakeExecutor returns an executor with the fake collectors, without any
+// request to the network.
+func newFakeExecutor(github GitHubCollector, sonar SonarCollector, scorecard ScorecardCollector) *Executor {
+	return &Executor{
+		HTTP:        fakeHTTP,
+		AI:          &openaigo.Client{BaseURL: "https://ai.example.com/v1", HTTPClient: fakeHTTP},
+		GitHubStats: github,
+		Sonar:       sonar,
+		ScoreCard:   scorecard,
+	}
+}
+
+func TestGetProjectStats(t *testing.T) {
+	now := time.Now()
+	active := &GitHubStats{
+		FirstCommitDate:     now.AddDate(-21, 0, 0),
+		LastCommitDate:      now.AddDate(0, 0, -2),
+		LastHumanCommitDate: now.AddDate(0, 0, -2),
+		Stars:               5000,
+		Forks:               500,
+		ActiveContributors:  30,
+		Issues:              40,
+		IssueResponseTime:   12 * time.Hour,
+		OpenIssues:          20,
+		ClosedIssues:        180,
+		License:             "Apache-2.0",
+		Releases: []Release{
+			{Name: "v2.1.0", Date: now.AddDate(0, -1, 0)},
+			{Name: "v2.0.0", Date: now.AddDate(0, -4, 0)},
+			{Name: "v1.9.0", Date: now.AddDate(0, -7, 0)},
+		},
+	}
+	archived := &GitHubStats{
+		FirstCommitDate:     now.AddDate(-10, 0, 0),
+		LastCommitDate:      now.AddDate(-3, 0, 0),
+		LastHumanCommitDate: now.AddDate(-3, 0, 0),
+		Archived:            true,
+	}
+	sonar := &SonarStats{LinesOfCode: 20000, Functions: 1000, CodeSmells: 50, CyclomaticComplexity: 3000, CognitiveComplexity: 2000, DuplicationDensity: 2}
+
+	tests := []struct {
+		name      string
+		github    *fakeGitHub
+		sonar     *fakeSonar
+		scorecard *fakeScorecard
+		err       string
+		warnings  []string
+		unknown   []string
+		check     func(t *testing.T, scores *ProjectScores)
+	}{
+		{
+			name:      "active",
+			github:    &fakeGitHub{stats: active},
+			sonar:     &fakeSonar{stats: sonar},
+			scorecard: &fakeScorecard{stats: fakeChecks(10)},
+			check: func(t *testing.T, scores *ProjectScores) {
+				if scores.Community.Maturity != 5 || scores.Community.Activity != 5 {
+					t.Errorf("maturity and activity = %d and %d, want 5", scores.Community.Maturity, scores.Community.Activity)
+				}
+				if scores.Security.ScoreCard != 5 {
+					t.Errorf("scorecard = %d, want 5", scores.Security.ScoreCard)
+				}
+				if scores.Licensing.Class != LicenseApproved {
+					t.Errorf("license class = %s, want %s", scores.Licensing.Class, LicenseApproved)
+				}
+			},
+		},
+		{
+			name:      "archived without issues",
+			github:    &fakeGitHub{stats: archived},
+			sonar:     &fakeSonar{stats: sonar},
+			scorecard: &fakeScorecard{stats: fakeChecks(0)},
+			warnings:  []string{"archived", "license-unknown", "responsiveness-unknown", "backlog-unknown", "releases-none"},
+			unknown:   []string{"community.backlog", "community.responsiveness"},
+			check: func(t *testing.T, scores *ProjectScores) {
+				if scores.Community.Activity != 1 {
+					t.Errorf("activity = %d, want 1", scores.Community.Activity)
+				}
+			},
+		},
+		{
+			name:      "scorecard unsupported",
+			github:    &fakeGitHub{stats: active},
+			sonar:     &fakeSonar{stats: sonar},
+			scorecard: &fakeScorecard{err: ErrScorecardUnsupported},
+			warnings:  []string{"scorecard-unsupported"},
+			check: func(t *testing.T, scores *ProjectScores) {
+				if scores.Security.ScoreCard != 1 {
+					t.Errorf("scorecard = %d, want 1", scores.Security.ScoreCard)
+				}
+			},
+		},
+		{
+			name:      "github failure",
+			github:    &fakeGitHub{err: errors.New("rate limit")},
+			sonar:     &fakeSonar{stats: sonar},
+			scorecard: &fakeScorecard{stats: fakeChecks(10)},
+			err:       "GitHub: rate limit",
+		},
+		{
+			name:      "sonar failure",
+			github:    &fakeGitHub{stats: active},
+			sonar:     &fakeSonar{err: errors.New("no analysis")},
+			scorecard: &fakeScorecard{stats: fakeChecks(10)},
+			err:       "Sonar: no analysis",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newFakeExecutor(test.github, test.sonar, test.scorecard)
+			stats, err := e.GetProjectStats(context.Background(), "owner", "repo")
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("error = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Summary != "A project." {
+				t.Errorf("summary = %q", stats.Summary)
+			}
+			var warnings []string
+			for _, warning := range stats.Warnings {
+				warnings = append(warnings, warning.Code)
+			}
+			if !slices.Equal(warnings, test.warnings) {
+				t.Errorf("warnings = %v, want %v", warnings, test.warnings)
+			}
+			scores, err := ComputeScores(stats, DefaultConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(scores.Unknown, test.unknown) {
+				t.Errorf("unknown criteria = %v, want %v", scores.Unknown, test.unknown)
+			}
+			test.check(t, scores)
+		})
+	}
+}
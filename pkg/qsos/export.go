@@ -0,0 +1,207 @@
+package qsos
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteMarkdownReport writes the evaluations in Markdown, with a table of the
+// scores of each project.
+func WriteMarkdownReport(w io.Writer, evaluations []*Evaluation, opts *ReportOptions) error {
+	lang := opts.language()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", lang.tr("QSOS report"))
+	for _, evaluation := range evaluations {
+		fmt.Fprintf(&b, "\n## %s\n\n", evaluation.Name())
+		for _, flag := range evaluation.RedFlags {
+			fmt.Fprintf(&b, "- **%s**: %s\n", lang.tr("Red flag"), flag.Message)
+		}
+		for _, warning := range evaluation.Stats.Warnings {
+			fmt.Fprintf(&b, "- %s: %s\n", lang.tr("Warning"), warning.Message)
+		}
+		if len(evaluation.RedFlags) > 0 || len(evaluation.Stats.Warnings) > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n|---|---|\n", lang.tr("Criterion"), lang.tr("Score"))
+		for _, c := range evaluation.Scores.Criteria() {
+			fmt.Fprintf(&b, "| %s | %d |\n", c.Name, *c.Score)
+		}
+		fmt.Fprintf(&b, "| **%s** | **%.2f** |\n", lang.tr("overall"), evaluation.Scores.Overall)
+		if licensing := evaluation.Scores.Licensing; licensing != nil {
+			fmt.Fprintf(&b, "\n%s: %s (%s, %s)\n", lang.tr("License"), cmp.Or(licensing.Expression, lang.tr("unknown")), licensing.Class, licensing.Rationale)
+		}
+		for _, msg := range evaluation.Denied {
+			fmt.Fprintf(&b, "\n%s: %s\n", lang.tr("Denied by the policy"), msg)
+		}
+		if evaluation.Stats.Summary != "" {
+			fmt.Fprintf(&b, "\n%s\n", evaluation.Stats.Summary)
+		}
+		if maintainers := evaluation.Stats.GitHub.Maintainers; len(maintainers) > 0 {
+			fmt.Fprintf(&b, "\n### %s\n\n| %s | %s | %s | %s |\n|---|---|---|---|\n",
+				lang.tr("Maintainers"), lang.tr("Login"), lang.tr("Identified by"), lang.tr("Commits (6 months)"), lang.tr("Last activity"))
+			for _, m := range maintainers {
+				fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", m.Login, strings.Join(m.Sources, ", "), m.Commits, formatLastActivity(lang, m.LastActivity))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSVReport writes the scores of the evaluations in CSV, with one line
+// per project, and the codes of its red flags.
+func WriteCSVReport(w io.Writer, evaluations []*Evaluation) error {
+	out := csv.NewWriter(w)
+	if len(evaluations) > 0 {
+		header := []string{"project"}
+		for _, c := range evaluations[0].Scores.Criteria() {
+			header = append(header, c.Name)
+		}
+		header = append(header, "overall", "red_flags")
+		out.Write(header)
+	}
+	for _, evaluation := range evaluations {
+		record := []string{evaluation.Name()}
+		for _, c := range evaluation.Scores.Criteria() {
+			record = append(record, fmt.Sprint(*c.Score))
+		}
+		var flags []string
+		for _, flag := range evaluation.RedFlags {
+			flags = append(flags, flag.Code)
+		}
+		record = append(record, fmt.Sprintf("%.2f", evaluation.Scores.Overall), strings.Join(flags, ";"))
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteHTMLReport writes the evaluations in a standalone HTML page, with the
+// heatmap of the projects and the radar chart of each project.
+func WriteHTMLReport(w io.Writer, evaluations []*Evaluation, opts *ReportOptions) error {
+	lang := opts.language()
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", lang.Code())
+	fmt.Fprintf(&b, "<title>%[1]s</title>\n</head>\n<body>\n<h1>%[1]s</h1>\n", lang.tr("QSOS report"))
+	if len(evaluations) > 1 {
+		if err := WriteHeatmapSVG(&b, evaluations); err != nil {
+			return err
+		}
+	}
+	if err := writeHTMLEvaluations(&b, lang, evaluations, true); err != nil {
+		return err
+	}
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteConfluenceReport writes the evaluations in the storage format of the
+// Confluence pages, the XHTML of the HTML report without its charts.
+func WriteConfluenceReport(w io.Writer, evaluations []*Evaluation, opts *ReportOptions) error {
+	lang := opts.language()
+	var b strings.Builder
+	if err := writeHTMLEvaluations(&b, lang, evaluations, false); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHTMLEvaluations writes the sections of the evaluations of the HTML
+// reports, with the radar charts if charts is set.
+func writeHTMLEvaluations(b *strings.Builder, lang *Language, evaluations []*Evaluation, charts bool) error {
+	for _, evaluation := range evaluations {
+		fmt.Fprintf(b, "<h2>%s</h2>\n", html.EscapeString(evaluation.Name()))
+		if len(evaluation.RedFlags) > 0 || len(evaluation.Stats.Warnings) > 0 {
+			b.WriteString("<ul>\n")
+			for _, flag := range evaluation.RedFlags {
+				fmt.Fprintf(b, "<li><strong>%s</strong>: %s</li>\n", lang.tr("Red flag"), html.EscapeString(flag.Message))
+			}
+			for _, warning := range evaluation.Stats.Warnings {
+				fmt.Fprintf(b, "<li>%s: %s</li>\n", lang.tr("Warning"), html.EscapeString(warning.Message))
+			}
+			b.WriteString("</ul>\n")
+		}
+		if charts {
+			if err := WriteRadarSVG(b, evaluation); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "<table>\n<tr><th>%s</th><th>%s</th></tr>\n", lang.tr("Criterion"), lang.tr("Score"))
+		for _, c := range evaluation.Scores.Criteria() {
+			fmt.Fprintf(b, "<tr><td>%s</td><td>%d</td></tr>\n", c.Name, *c.Score)
+		}
+		fmt.Fprintf(b, "<tr><th>%s</th><th>%.2f</th></tr>\n</table>\n", lang.tr("overall"), evaluation.Scores.Overall)
+		for _, msg := range evaluation.Denied {
+			fmt.Fprintf(b, "<p>%s: %s</p>\n", lang.tr("Denied by the policy"), html.EscapeString(msg))
+		}
+		if evaluation.Stats.Summary != "" {
+			fmt.Fprintf(b, "<p>%s</p>\n", html.EscapeString(evaluation.Stats.Summary))
+		}
+		if maintainers := evaluation.Stats.GitHub.Maintainers; len(maintainers) > 0 {
+			fmt.Fprintf(b, "<h3>%s</h3>\n<table>\n<tr><th>%s</th><th>%s</th><th>%s</th><th>%s</th></tr>\n",
+				lang.tr("Maintainers"), lang.tr("Login"), lang.tr("Identified by"), lang.tr("Commits (6 months)"), lang.tr("Last activity"))
+			for _, m := range maintainers {
+				fmt.Fprintf(b, "<tr><td>%s</td><td>%s</td><td>%d</td><td>%s</td></tr>\n",
+					html.EscapeString(m.Login), html.EscapeString(strings.Join(m.Sources, ", ")), m.Commits, formatLastActivity(lang, m.LastActivity))
+			}
+			b.WriteString("</table>\n")
+		}
+	}
+	return nil
+}
+
+// WriteReportBundle writes the report of the evaluations in all the formats
+// to a directory: qsos-report.json, .md, .html and .csv, and the
+// qsos-radar-<owner>-<repo>.svg and qsos-badge-<owner>-<repo>.json files of
+// each project. It returns the paths of the files.
+func WriteReportBundle(dir string, evaluations []*Evaluation, validate bool, opts *ReportOptions) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Cannot create the reports dir: %w", err)
+	}
+	type file struct {
+		name  string
+		write func(w io.Writer) error
+	}
+	files := []file{
+		{"qsos-report.json", func(w io.Writer) error { return WriteJSONReport(w, evaluations, validate) }},
+		{"qsos-report.md", func(w io.Writer) error { return WriteMarkdownReport(w, evaluations, opts) }},
+		{"qsos-report.html", func(w io.Writer) error { return WriteHTMLReport(w, evaluations, opts) }},
+		{"qsos-report.csv", func(w io.Writer) error { return WriteCSVReport(w, evaluations) }},
+	}
+	for _, evaluation := range evaluations {
+		name := fmt.Sprintf("qsos-radar-%s.svg", componentName(evaluation.Owner, evaluation.Repo))
+		files = append(files, file{name, func(w io.Writer) error { return WriteRadarSVG(w, evaluation) }})
+		name = fmt.Sprintf("qsos-badge-%s.json", componentName(evaluation.Owner, evaluation.Repo))
+		files = append(files, file{name, func(w io.Writer) error { return WriteBadge(w, evaluation) }})
+	}
+
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := writeFile(path, f.write); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Cannot create %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		return fmt.Errorf("Cannot write %s: %w", path, err)
+	}
+	return f.Close()
+}
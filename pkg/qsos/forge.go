@@ -0,0 +1,165 @@
+package qsos
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// The kinds of forges hosting the projects.
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	// ForgeGitea is for Gitea, Forgejo and Codeberg.
+	ForgeGitea = "gitea"
+	// ForgeBitbucket is for Bitbucket Cloud only.
+	ForgeBitbucket = "bitbucket"
+	// ForgeGit is for any git server, the stats are collected from the
+	// history of the repositories.
+	ForgeGit = "git"
+)
+
+// Forges are the kinds of forges for which the community stats can be
+// collected.
+var Forges = []string{ForgeGitHub, ForgeGitLab, ForgeGitea, ForgeBitbucket, ForgeGit}
+
+// Forge is the service hosting the repositories of the projects.
+type Forge struct {
+	// Kind is the API of the forge, one of Forges.
+	Kind string
+	// URL is the web URL of the forge, like https://gitlab.com.
+	URL *url.URL
+}
+
+// defaultForgeURLs are the URLs of the public instances of the forges.
+var defaultForgeURLs = map[string]string{
+	ForgeGitHub:    "https://github.com",
+	ForgeGitLab:    "https://gitlab.com",
+	ForgeGitea:     "https://codeberg.org",
+	ForgeBitbucket: "https://bitbucket.org",
+}
+
+// NewForge returns the forge of the given kind (github by default), on its
+// public instance or on the self-hosted one at rawURL. The git forge has no
+// public instance.
+func NewForge(kind, rawURL string) (*Forge, error) {
+	if kind == "" {
+		kind = ForgeGitHub
+	}
+	if !slices.Contains(Forges, kind) {
+		return nil, fmt.Errorf("Invalid forge %q. Must be one of %v", kind, Forges)
+	}
+	if kind == ForgeGit && rawURL == "" {
+		return nil, errors.New("The URL of the git server is required for the git forge")
+	}
+	if rawURL == "" {
+		rawURL = defaultForgeURLs[kind]
+	}
+	u, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("Invalid URL %q for the forge", rawURL)
+	}
+	return &Forge{Kind: kind, URL: u}, nil
+}
+
+// gitHub is the forge of the projects when none is given.
+var gitHub = &Forge{Kind: ForgeGitHub, URL: &url.URL{Scheme: "https", Host: "github.com"}}
+
+// orDefault returns the forge, or GitHub if it is nil.
+func (f *Forge) orDefault() *Forge {
+	if f == nil {
+		return gitHub
+	}
+	return f
+}
+
+// RepositoryURL returns the web URL of a repository.
+func (f *Forge) RepositoryURL(owner, repo string) string {
+	return f.orDefault().URL.JoinPath(owner, repo).String()
+}
+
+// cloneURL returns the URL for cloning a repository with git. On the git
+// forge, the name of the repository is the one of the URL, with its .git
+// suffix if any.
+func (f *Forge) cloneURL(owner, repo string) string {
+	if f.orDefault().Kind == ForgeGit {
+		return f.RepositoryURL(owner, repo)
+	}
+	return f.RepositoryURL(owner, repo) + ".git"
+}
+
+// sameAs returns true if both forges are the same instance.
+func (f *Forge) sameAs(other *Forge) bool {
+	f, other = f.orDefault(), other.orDefault()
+	return f.Kind == other.Kind && strings.EqualFold(f.URL.Host, other.URL.Host)
+}
+
+// ParseProject parses a project of the forge. On GitLab, the owner can be a
+// group with subgroups, like group/subgroup/repo, and on the git forge, it
+// is the path of the repository.
+func (f *Forge) ParseProject(project string) (string, string, error) {
+	if kind := f.orDefault().Kind; kind != ForgeGitLab && kind != ForgeGit {
+		return ParseProject(project)
+	}
+	i := strings.LastIndex(project, "/")
+	if i <= 0 || i == len(project)-1 || slices.Contains(strings.Split(project[:i], "/"), "") {
+		return "", "", fmt.Errorf("Invalid project format %q. Must be in the format: group/repo", project)
+	}
+	return project[:i], project[i+1:], nil
+}
+
+// ParseForgeURL parses the URL of a repository, like
+// https://gitlab.com/group/repo. The kind of the forge is the one of the
+// public instances (github.com, gitlab.com, codeberg.org, gitea.com and
+// bitbucket.org), or of defaultForge for its own host. The other hosts are
+// plain git servers, as their API cannot be known from their name.
+func ParseForgeURL(s string, defaultForge *Forge) (*Forge, string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return nil, "", "", fmt.Errorf("Invalid repository URL %q", s)
+	}
+	host := strings.ToLower(u.Host)
+	defaultForge = defaultForge.orDefault()
+	kind := ForgeGit
+	if strings.EqualFold(host, defaultForge.URL.Host) {
+		kind = defaultForge.Kind
+	}
+	switch host {
+	case "github.com", "www.github.com":
+		kind, host = ForgeGitHub, "github.com"
+	case "gitlab.com":
+		kind = ForgeGitLab
+	case "codeberg.org", "gitea.com":
+		kind = ForgeGitea
+	case "bitbucket.org", "www.bitbucket.org":
+		kind, host = ForgeBitbucket, "bitbucket.org"
+	}
+	forge, err := NewForge(kind, u.Scheme+"://"+host)
+	if err != nil {
+		return nil, "", "", err
+	}
+	path := strings.Trim(u.Path, "/")
+	if forge.Kind != ForgeGit {
+		path = strings.TrimSuffix(path, ".git")
+	}
+	// The GitLab URLs of the pages of a project have a /-/ separator
+	path, _, _ = strings.Cut(path, "/-/")
+	if forge.Kind != ForgeGitLab && forge.Kind != ForgeGit {
+		parts := strings.Split(path, "/")
+		path = strings.Join(parts[:min(len(parts), 2)], "/")
+	}
+	owner, repo, err := forge.ParseProject(path)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("Invalid repository URL %q: %w", s, err)
+	}
+	return forge, owner, repo, nil
+}
+
+// componentName returns a name for a project that can be used in the file
+// names and the keys of the analyzers, without the slashes of the GitLab
+// subgroups.
+func componentName(owner, repo string) string {
+	return strings.ReplaceAll(owner, "/", "-") + "-" + repo
+}
@@ -0,0 +1,86 @@
+package qsos
+
+import "testing"
+
+func TestParseForgeURL(t *testing.T) {
+	gitlab, err := NewForge(ForgeGitLab, "https://gitlab.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url          string
+		defaultForge *Forge
+		kind         string
+		host         string
+		owner, repo  string
+	}{
+		{"https://github.com/owner/repo", nil, ForgeGitHub, "github.com", "owner", "repo"},
+		{"https://www.github.com/owner/repo.git", nil, ForgeGitHub, "github.com", "owner", "repo"},
+		{"https://github.com/owner/repo/tree/main", nil, ForgeGitHub, "github.com", "owner", "repo"},
+		{"https://gitlab.com/group/subgroup/repo/-/tree/main", nil, ForgeGitLab, "gitlab.com", "group/subgroup", "repo"},
+		{"https://codeberg.org/forgejo/forgejo", nil, ForgeGitea, "codeberg.org", "forgejo", "forgejo"},
+		{"https://bitbucket.org/workspace/repo", nil, ForgeBitbucket, "bitbucket.org", "workspace", "repo"},
+		{"https://gitlab.example.com/group/repo", gitlab, ForgeGitLab, "gitlab.example.com", "group", "repo"},
+		// The kind of the other forges is not guessed from their host
+		{"https://gitlab.attacker.example/group/repo", nil, ForgeGit, "gitlab.attacker.example", "group", "repo"},
+		{"https://github.attacker.example/owner/repo", gitlab, ForgeGit, "github.attacker.example", "owner", "repo"},
+		{"https://gitea.attacker.example/owner/repo", nil, ForgeGit, "gitea.attacker.example", "owner", "repo"},
+		{"https://git.savannah.gnu.org/git/emacs.git", nil, ForgeGit, "git.savannah.gnu.org", "git", "emacs.git"},
+	}
+	for _, test := range tests {
+		forge, owner, repo, err := ParseForgeURL(test.url, test.defaultForge)
+		if err != nil {
+			t.Errorf("ParseForgeURL(%q): %v", test.url, err)
+			continue
+		}
+		if forge.Kind != test.kind || forge.URL.Host != test.host || owner != test.owner || repo != test.repo {
+			t.Errorf("ParseForgeURL(%q) = %s %s %s/%s, want %s %s %s/%s", test.url,
+				forge.Kind, forge.URL.Host, owner, repo, test.kind, test.host, test.owner, test.repo)
+		}
+	}
+	if _, _, _, err := ParseForgeURL("not a URL", nil); err == nil {
+		t.Error("ParseForgeURL(\"not a URL\") is valid")
+	}
+}
+
+// TestForProjectTokens checks that the tokens are only sent to the forge
+// they are configured for.
+func TestForProjectTokens(t *testing.T) {
+	e, err := NewExecutor(&ExecutorOptions{
+		Forge:       ForgeGitLab,
+		ForgeURL:    "https://gitlab.example.com",
+		GitLabToken: "secret",
+		GiteaToken:  "secret",
+		Local:       true,
+		CacheDir:    t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		project string
+		token   string
+	}{
+		{"group/repo", "secret"},
+		{"https://gitlab.example.com/group/repo", "secret"},
+		{"https://gitlab.com/group/repo", ""},
+		{"https://codeberg.org/owner/repo", ""},
+	}
+	for _, test := range tests {
+		executor, _, _, err := e.ForProject(test.project)
+		if err != nil {
+			t.Errorf("ForProject(%q): %v", test.project, err)
+			continue
+		}
+		var token string
+		switch collector := executor.GitHubStats.(type) {
+		case *GitLabCollector:
+			token = collector.Token
+		case *GiteaCollector:
+			token = collector.Token
+		}
+		if token != test.token {
+			t.Errorf("ForProject(%q): token = %q, want %q", test.project, token, test.token)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package qsos
+
+import (
+	"fmt"
+	"io"
+)
+
+// Violation is a score below the minimum required for gating.
+type Violation struct {
+	Project   string
+	Criterion string
+	Score     float64
+	Min       float64
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s: %g < %g", v.Project, v.Criterion, v.Score, v.Min)
+}
+
+// CheckMinScores returns the scores of the evaluations that are below the
+// minimal scores for the criteria, or below the minimal overall score (if
+// it is greater than 0).
+func CheckMinScores(evaluations []*Evaluation, minScores map[string]int64, minOverall float64) []Violation {
+	var violations []Violation
+	for _, evaluation := range evaluations {
+		for _, criterion := range evaluation.Scores.Criteria() {
+			if min, ok := minScores[criterion.Name]; ok && *criterion.Score < min {
+				violations = append(violations, Violation{
+					Project:   evaluation.Name(),
+					Criterion: criterion.Name,
+					Score:     float64(*criterion.Score),
+					Min:       float64(min),
+				})
+			}
+		}
+		if minOverall > 0 && evaluation.Scores.Overall < minOverall {
+			violations = append(violations, Violation{
+				Project:   evaluation.Name(),
+				Criterion: "overall",
+				Score:     evaluation.Scores.Overall,
+				Min:       minOverall,
+			})
+		}
+	}
+	return violations
+}
+
+func PrintViolations(w io.Writer, violations []Violation, opts *ReportOptions) {
+	lang := opts.language()
+	printSection(w, lang, "Gate")
+	if len(violations) == 0 {
+		fmt.Fprintf(w, "%s\n", lang.tr("Passed"))
+	}
+	for _, v := range violations {
+		fmt.Fprintf(w, "%s: %s\n", lang.tr("Failed"), v)
+	}
+}
@@ -0,0 +1,399 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GiteaCollector collects the community stats of the projects hosted on a
+// forge of the Gitea family (Gitea, Forgejo, and Codeberg) with its API. The
+// weekly commits and the maintainers are not collected.
+type GiteaCollector struct {
+	// URL is the URL of the instance, like https://codeberg.org.
+	URL *url.URL
+	// Token is optional for the public projects.
+	Token string
+	HTTP  *http.Client
+	// Progress is optional.
+	Progress ProgressFunc
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+}
+
+const (
+	// giteaPageSize is the default maximal size of the pages of Gitea.
+	giteaPageSize = 50
+	// maxGiteaCommitPages limits the number of requests for the commits of
+	// the last 6 months.
+	maxGiteaCommitPages = 40
+)
+
+// readmeNames are the names of the README files, in the order they are
+// looked for.
+var readmeNames = []string{"README.md", "README", "README.rst", "README.txt"}
+
+type giteaRepository struct {
+	StarsCount    int64     `json:"stars_count"`
+	ForksCount    int64     `json:"forks_count"`
+	Archived      bool      `json:"archived"`
+	DefaultBranch string    `json:"default_branch"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Licenses are the SPDX identifiers detected by Gitea, since 1.22.
+	Licenses []string `json:"licenses"`
+}
+
+type giteaCommit struct {
+	Commit struct {
+		Author struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// isBot returns true if the commit has been authored by a bot.
+func (c *giteaCommit) isBot(bots *BotFilter) bool {
+	return c.Author != nil && bots.isBot(c.Author.Login, "") || bots.isBotAuthor(c.Commit.Author.Name, c.Commit.Author.Email)
+}
+
+type giteaPullRequest struct {
+	Merged    bool       `json:"merged"`
+	MergedAt  *time.Time `json:"merged_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+type giteaRelease struct {
+	TagName     string     `json:"tag_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	Draft       bool       `json:"draft"`
+	Prerelease  bool       `json:"prerelease"`
+	Assets      []struct {
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+type giteaTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		// Created is the date of the commit, since Gitea 1.15.
+		Created time.Time `json:"created"`
+	} `json:"commit"`
+}
+
+// giteaCommitsQuery returns the query for listing the commits of a branch,
+// without their files and stats.
+func giteaCommitsQuery(branch string, limit, page int) url.Values {
+	return url.Values{
+		"sha":          {branch},
+		"limit":        {strconv.Itoa(limit)},
+		"page":         {strconv.Itoa(page)},
+		"stat":         {"false"},
+		"verification": {"false"},
+		"files":        {"false"},
+	}
+}
+
+func (c *GiteaCollector) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	stats := &GitHubStats{}
+	project := "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+
+	// 1. Get the repository info (stars, default branch)
+	var info giteaRepository
+	if _, err := c.get(ctx, project, nil, &info); err != nil {
+		return nil, err
+	}
+	stats.Stars = info.StarsCount
+	stats.Forks = info.ForksCount
+	stats.Archived = info.Archived
+	stats.License = strings.Join(info.Licenses, " AND ")
+
+	// 2. Get the date of the last commit, and of the last one not authored
+	// by a bot
+	var lastCommits []giteaCommit
+	res, err := c.get(ctx, project+"/commits", giteaCommitsQuery(info.DefaultBranch, giteaPageSize, 1), &lastCommits)
+	if err != nil {
+		return nil, err
+	}
+	if len(lastCommits) == 0 {
+		return nil, fmt.Errorf("could not find last commit date")
+	}
+	stats.LastCommitDate = lastCommits[0].Commit.Committer.Date.UTC()
+	for _, commit := range lastCommits {
+		// If the last commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.Commit.Committer.Date.UTC()
+		if !commit.isBot(c.Bots) {
+			break
+		}
+	}
+
+	// 3. Get the date of the first commit, the last one of the list
+	total, err := strconv.Atoi(res.Header.Get("X-Total-Count"))
+	if err != nil || total == 0 {
+		slog.Info("commits not counted, using the creation date of the repository", "project", owner+"/"+repo)
+		stats.FirstCommitDate = info.CreatedAt.UTC()
+	} else {
+		var firstCommit []giteaCommit
+		if _, err := c.get(ctx, project+"/commits", giteaCommitsQuery(info.DefaultBranch, 1, total), &firstCommit); err != nil {
+			return nil, err
+		}
+		if len(firstCommit) == 0 {
+			return nil, fmt.Errorf("could not find first commit date")
+		}
+		stats.FirstCommitDate = firstCommit[0].Commit.Committer.Date.UTC()
+	}
+
+	// 4. Get the number of active contributors, the authors of at least 4
+	// commits in the last 6 months by default
+	contribs, err := c.getContributions(ctx, owner, repo, info.DefaultBranch, c.Contributors.orDefault())
+	if err != nil {
+		return nil, err
+	}
+	stats.ActiveContributors = contribs.Active
+	stats.ContributorsWindow = c.Contributors.orDefault()
+	stats.BotCommits = contribs.BotCommits
+	stats.setOrganizations(contribs.Organizations)
+	stats.BotCommitShare = share(contribs.BotCommits, contribs.Commits)
+
+	sixMonthsAgo := reportTime().AddDate(0, -6, 0)
+	// 5. Get the share of the pull requests merged in the last 6 months
+	// that were opened by bots
+	stats.BotPullRequestShare, err = c.getBotPullRequestShare(ctx, owner, repo, sixMonthsAgo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. Get the platforms of the latest release
+	stats.ReleasePlatforms, err = c.getReleasePlatforms(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("platforms of the release not available", "project", owner+"/"+repo, "err", err)
+	}
+
+	// 7. Get the last releases and tags
+	stats.Releases, err = c.getReleases(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("releases not available", "project", owner+"/"+repo, "err", err)
+	}
+	return stats, nil
+}
+
+// getReleases returns the last releases and tags of a repository, on the
+// first page of each list. The date of a tag is the one of its commit.
+func (c *GiteaCollector) getReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	project := "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	query := url.Values{"limit": {strconv.Itoa(giteaPageSize)}}
+	var list []giteaRelease
+	if _, err := c.get(ctx, project+"/releases", query, &list); err != nil {
+		return nil, err
+	}
+	var releases []Release
+	for _, release := range list {
+		if release.Draft || release.PublishedAt == nil {
+			continue
+		}
+		if r, ok := newRelease(release.TagName, *release.PublishedAt, release.Prerelease); ok {
+			releases = append(releases, r)
+		}
+	}
+	var tagList []giteaTag
+	if _, err := c.get(ctx, project+"/tags", query, &tagList); err != nil {
+		return nil, err
+	}
+	var tags []Release
+	for _, tag := range tagList {
+		if tag.Commit.Created.IsZero() {
+			continue
+		}
+		if t, ok := newRelease(tag.Name, tag.Commit.Created, false); ok {
+			tags = append(tags, t)
+		}
+	}
+	return mergeReleases(releases, tags), nil
+}
+
+func (c *GiteaCollector) getContributions(ctx context.Context, owner, repo, branch string, window *ContributorsWindow) (*contributions, error) {
+	since := window.since()
+	result := &contributions{}
+	contributors := newIdentities()
+	for page := 1; page <= maxGiteaCommitPages; page++ {
+		query := giteaCommitsQuery(branch, giteaPageSize, page)
+		query.Set("since", since.UTC().Format(time.RFC3339))
+		var commits []giteaCommit
+		res, err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/commits", query, &commits)
+		if err != nil {
+			return nil, err
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page, Total: maxGiteaCommitPages})
+		for _, commit := range commits {
+			result.Commits++
+			if commit.isBot(c.Bots) {
+				result.BotCommits++
+				continue
+			}
+			var login string
+			if commit.Author != nil {
+				login = commit.Author.Login
+			}
+			contributors.add(commit.Commit.Author.Name, commit.Commit.Author.Email, login)
+		}
+		if res.Header.Get("X-HasMore") != "true" {
+			break
+		}
+	}
+	result.Active = contributors.active(window.MinCommits)
+	result.Organizations = contributors.organizationStats()
+	return result, nil
+}
+
+// getBotPullRequestShare returns the percentage of the pull requests merged
+// since the given date that have been opened by bots.
+func (c *GiteaCollector) getBotPullRequestShare(ctx context.Context, owner, repo string, since time.Time) (float64, error) {
+	var merged, bots int64
+	for page := 1; page <= maxPullRequestPages; page++ {
+		var pulls []giteaPullRequest
+		res, err := c.get(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/pulls", url.Values{
+			"state": {"closed"},
+			"sort":  {"recentupdate"},
+			"limit": {strconv.Itoa(giteaPageSize)},
+			"page":  {strconv.Itoa(page)},
+		}, &pulls)
+		if err != nil {
+			return 0, err
+		}
+		done := false
+		for _, pull := range pulls {
+			if pull.UpdatedAt.Before(since) {
+				done = true
+				break
+			}
+			if !pull.Merged || pull.MergedAt == nil || pull.MergedAt.Before(since) {
+				continue
+			}
+			merged++
+			if c.Bots.isBot(pull.User.Login, "") {
+				bots++
+			}
+		}
+		if done || res.Header.Get("X-HasMore") != "true" {
+			break
+		}
+	}
+	return share(bots, merged), nil
+}
+
+// getReleasePlatforms returns the platforms covered by the assets of the
+// latest release, or nil if the project has no release.
+func (c *GiteaCollector) getReleasePlatforms(ctx context.Context, owner, repo string) ([]string, error) {
+	var release giteaRelease
+	res, err := c.fetch(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/releases/latest", nil, &release)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	var platforms []string
+	for _, asset := range release.Assets {
+		platforms = append(platforms, assetPlatforms(asset.Name)...)
+	}
+	slices.Sort(platforms)
+	return slices.Compact(platforms), nil
+}
+
+// GetReadme returns the content of the README of a project.
+func (c *GiteaCollector) GetReadme(ctx context.Context, owner, repo string) (string, error) {
+	for _, name := range readmeNames {
+		req, err := c.newRequest(ctx, "repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/raw/"+name, nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := c.HTTP.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("Error on request: %w", err)
+		}
+		content, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("Gitea API %s: unexpected response: %d", name, res.StatusCode)
+		}
+		if err != nil {
+			return "", fmt.Errorf("Cannot read the README: %w", err)
+		}
+		return string(content), nil
+	}
+	return "", fmt.Errorf("no README in %s/%s", owner, repo)
+}
+
+// apiURL returns the URL of an endpoint of the Gitea API.
+func (c *GiteaCollector) apiURL(path string, query url.Values) string {
+	u := strings.TrimSuffix(c.URL.String(), "/") + "/api/v1/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *GiteaCollector) newRequest(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL(path, query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the request: %w", err)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+	return req, nil
+}
+
+// get decodes the JSON response of a request to the Gitea API. The response
+// is returned for its pagination headers.
+func (c *GiteaCollector) get(ctx context.Context, path string, query url.Values, data any) (*http.Response, error) {
+	res, err := c.fetch(ctx, path, query, data)
+	if err == nil && res == nil {
+		return nil, fmt.Errorf("Gitea API %s: not found", path)
+	}
+	return res, err
+}
+
+// fetch is like get, but it returns a nil response if the resource was not
+// found.
+func (c *GiteaCollector) fetch(ctx context.Context, path string, query url.Values, data any) (*http.Response, error) {
+	req, err := c.newRequest(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Gitea API %s: unexpected response: %d", path, res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return nil, fmt.Errorf("Gitea API %s: invalid response: %w", path, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package qsos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGiteaCollector(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1780272000")
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	commit := func(name, login string, days int) map[string]any {
+		c := map[string]any{"commit": map[string]any{
+			"author":    map[string]string{"name": name, "email": name + "@example.com"},
+			"committer": map[string]string{"date": ago(days)},
+		}}
+		if login != "" {
+			c["author"] = map[string]string{"login": login}
+		}
+		return c
+	}
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo":
+			serveJSON(w, map[string]any{
+				"stars_count": 12, "forks_count": 3, "default_branch": "main", "created_at": ago(900),
+				"licenses": []string{"MIT", "Apache-2.0"},
+			})
+		case "/api/v1/repos/owner/repo/commits":
+			switch {
+			case query.Get("since") != "" && query.Get("page") == "1":
+				// The commits of the last 6 months are on 2 pages
+				w.Header().Set("X-HasMore", "true")
+				serveJSON(w, []map[string]any{
+					commit("Jane Doe", "jane", 1), commit("Jane D.", "jane", 2), commit("renovate[bot]", "renovate-bot", 3),
+				})
+			case query.Get("since") != "":
+				serveJSON(w, []map[string]any{commit("Jane", "jane", 4), commit("jane", "jane", 5), commit("John", "", 6)})
+			default:
+				// The commits are not counted, like on big repositories
+				serveJSON(w, []map[string]any{commit("renovate[bot]", "renovate-bot", 1), commit("John", "", 3)})
+			}
+		case "/api/v1/repos/owner/repo/pulls":
+			serveJSON(w, []map[string]any{
+				{"merged": true, "merged_at": ago(2), "updated_at": ago(2), "user": map[string]string{"login": "renovate[bot]"}},
+				{"merged": false, "updated_at": ago(3), "user": map[string]string{"login": "renovate[bot]"}},
+				{"merged": true, "merged_at": ago(4), "updated_at": ago(4), "user": map[string]string{"login": "jane"}},
+				{"merged": true, "merged_at": ago(5), "updated_at": ago(5), "user": map[string]string{"login": "john"}},
+				{"merged": true, "merged_at": ago(300), "updated_at": ago(300), "user": map[string]string{"login": "renovate[bot]"}},
+			})
+		case "/api/v1/repos/owner/repo/releases":
+			serveJSON(w, []map[string]any{
+				{"tag_name": "v2.0.0", "draft": true},
+				{"tag_name": "v2.0.0-rc1", "published_at": ago(5), "prerelease": true},
+				{"tag_name": "v1.2.0", "published_at": ago(30)},
+			})
+		case "/api/v1/repos/owner/repo/tags":
+			serveJSON(w, []map[string]any{
+				{"name": "v2.0.0-rc1", "commit": map[string]string{"created": ago(6)}},
+				{"name": "v1.1.0", "commit": map[string]string{"created": ago(60)}},
+				{"name": "v1.0.0", "commit": map[string]any{}},
+			})
+		case "/api/v1/repos/owner/repo/raw/README":
+			w.Write([]byte("# Repo\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	collector := &GiteaCollector{URL: u, Token: "secret", HTTP: server.Client()}
+	stats, err := collector.GetGitHubStats(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stars != 12 || stats.Forks != 3 || stats.License != "MIT AND Apache-2.0" {
+		t.Errorf("stars, forks and license = %d, %d and %s, want 12, 3 and MIT AND Apache-2.0", stats.Stars, stats.Forks, stats.License)
+	}
+	if want := now.AddDate(0, 0, -900); !stats.FirstCommitDate.Equal(want) {
+		t.Errorf("first commit = %v, want the creation date %v", stats.FirstCommitDate, want)
+	}
+	if want := now.AddDate(0, 0, -3); !stats.LastHumanCommitDate.Equal(want) {
+		t.Errorf("last human commit = %v, want %v", stats.LastHumanCommitDate, want)
+	}
+	if stats.ActiveContributors != 1 || stats.BotCommits != 1 || stats.BotCommitShare != 16.67 {
+		t.Errorf("active contributors, bot commits and bot share = %d, %d and %v, want 1, 1 and 16.67",
+			stats.ActiveContributors, stats.BotCommits, stats.BotCommitShare)
+	}
+	if stats.BotPullRequestShare != 33.33 {
+		t.Errorf("bot share of the pull requests = %v, want 33.33", stats.BotPullRequestShare)
+	}
+	if stats.ReleasePlatforms != nil {
+		t.Errorf("release platforms = %v, want none without a latest release", stats.ReleasePlatforms)
+	}
+	var releases []string
+	for _, release := range stats.Releases {
+		releases = append(releases, release.Name)
+	}
+	if want := []string{"v2.0.0-rc1", "v1.2.0", "v1.1.0"}; !slices.Equal(releases, want) {
+		t.Errorf("releases = %v, want %v", releases, want)
+	}
+	if i := slices.IndexFunc(tokens, func(token string) bool { return token != "token secret" }); i >= 0 {
+		t.Errorf("request %d has the authorization %q, want token secret", i, tokens[i])
+	}
+
+	readme, err := collector.GetReadme(context.Background(), "owner", "repo")
+	if err != nil || readme != "# Repo\n" {
+		t.Errorf("GetReadme = %q, %v, want the README file", readme, err)
+	}
+}
@@ -0,0 +1,230 @@
+package qsos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHistoryCollector collects the community stats of the projects from the
+// history of their git repository, for the forges without a supported API
+// (sourcehut, Savannah, or plain git servers). The releases are the tags of
+// the repository. The stars, the forks, the pull requests and the
+// maintainers are not collected.
+type GitHistoryCollector struct {
+	Forge *Forge
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+	// ToolOutput receives the output of git, discarded when it is nil.
+	ToolOutput io.Writer
+}
+
+// GetGitHubStatsFromHistory computes the community stats from the clone of
+// the repository in dir.
+func (c *GitHistoryCollector) GetGitHubStatsFromHistory(ctx context.Context, owner, repo, dir string) (*GitHubStats, error) {
+	return gitHistoryStats(ctx, c.ToolOutput, dir, "", c.Contributors, c.Bots)
+}
+
+func (c *GitHistoryCollector) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	tmpDir, err := os.MkdirTemp("", componentName(owner, repo)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	// The history is cloned without the content of the files
+	if err := git(ctx, c.ToolOutput, tmpDir, "clone", "--quiet", "--bare", "--filter=blob:none", c.Forge.cloneURL(owner, repo), "."); err != nil {
+		return nil, fmt.Errorf("Cannot clone git repository: %w", err)
+	}
+	return gitHistoryStats(ctx, c.ToolOutput, tmpDir, "", c.Contributors, c.Bots)
+}
+
+// gitHistoryStats computes the community stats from the history of the git
+// repository in dir. If subdir is not empty, only the commits touching this
+// sub-directory are counted. The window defines the active contributors,
+// and bots identifies the bots, the default ones when they are nil. The first
+// commit and the contributors of the shallow clones, like the checkouts of
+// the CI, are the ones of their partial history, and ShallowHistory is set.
+func gitHistoryStats(ctx context.Context, toolOutput io.Writer, dir, subdir string, window *ContributorsWindow, bots *BotFilter) (*GitHubStats, error) {
+	stats := &GitHubStats{NoStars: true}
+	var paths []string
+	if subdir != "" {
+		paths = []string{"--", subdir}
+	}
+	shallow, err := isShallowRepository(ctx, toolOutput, dir)
+	if err != nil {
+		return nil, err
+	}
+	stats.ShallowHistory = shallow
+	if stats.Releases, err = gitTags(ctx, toolOutput, dir); err != nil {
+		return nil, err
+	}
+
+	// 1. Get the date of the last commit, and of the last one not authored
+	// by a bot
+	lastCommits, err := gitLog(ctx, toolOutput, dir, append([]string{"--max-count=100"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(lastCommits) == 0 {
+		return nil, fmt.Errorf("could not find last commit date")
+	}
+	stats.LastCommitDate = lastCommits[0].date
+	for _, commit := range lastCommits {
+		// If the last 100 commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.date
+		if !bots.isBotAuthor(commit.name, commit.email) {
+			break
+		}
+	}
+
+	// 2. Get the date of the first commit, the oldest root commit or the
+	// oldest commit touching the sub-directory
+	first := []string{"--max-parents=0"}
+	if subdir != "" {
+		first = paths
+	}
+	firstCommits, err := gitLog(ctx, toolOutput, dir, first...)
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range firstCommits {
+		if stats.FirstCommitDate.IsZero() || commit.date.Before(stats.FirstCommitDate) {
+			stats.FirstCommitDate = commit.date
+		}
+	}
+
+	// 3. Get the number of commits for each week of the last year, and the
+	// number of active contributors of the window
+	now := reportTime()
+	window = window.orDefault()
+	start := window.since()
+	since := now.AddDate(0, 0, -7*participationWeeks)
+	if start.Before(since) {
+		since = start
+	}
+	commits, err := gitLog(ctx, toolOutput, dir, append([]string{"--since=" + since.UTC().Format(time.RFC3339)}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	stats.WeeklyCommits = weeklyCommits(commits, now, bots)
+	contribs := &contributions{}
+	contributors := newIdentities()
+	for _, commit := range commits {
+		if commit.date.Before(start) {
+			continue
+		}
+		contribs.Commits++
+		if bots.isBotAuthor(commit.name, commit.email) {
+			contribs.BotCommits++
+			continue
+		}
+		contributors.add(commit.name, commit.email, "")
+	}
+	stats.ActiveContributors = contributors.active(window.MinCommits)
+	stats.ContributorsWindow = window
+	stats.BotCommits = contribs.BotCommits
+	stats.setOrganizations(contributors.organizationStats())
+	stats.BotCommitShare = share(contribs.BotCommits, contribs.Commits)
+	return stats, nil
+}
+
+// GetReadme returns the content of the README of a project, from a shallow
+// clone of its repository.
+func (c *GitHistoryCollector) GetReadme(ctx context.Context, owner, repo string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", componentName(owner, repo)+"-")
+	if err != nil {
+		return "", fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := git(ctx, c.ToolOutput, tmpDir, "clone", "--quiet", "--bare", "--depth=1", c.Forge.cloneURL(owner, repo), "."); err != nil {
+		return "", fmt.Errorf("Cannot clone git repository: %w", err)
+	}
+	for _, name := range readmeNames {
+		cmd := exec.CommandContext(ctx, "git", "show", "HEAD:"+name)
+		cmd.Dir = tmpDir
+		end := traceCommand(ctx, cmd)
+		content, err := cmd.Output()
+		end(err)
+		if err == nil {
+			return string(content), nil
+		}
+	}
+	return "", fmt.Errorf("no README in %s/%s", owner, repo)
+}
+
+// participationWeeks is the number of weeks of the weekly commits.
+const participationWeeks = 52
+
+// weeklyCommits returns the number of commits for each of the weeks of the
+// last year, from the oldest to the most recent, like the participation
+// statistics of GitHub, without the commits of the bots.
+func weeklyCommits(commits []gitCommit, now time.Time, bots *BotFilter) []int64 {
+	weeks := make([]int64, participationWeeks)
+	for _, commit := range commits {
+		if bots.isBotAuthor(commit.name, commit.email) {
+			continue
+		}
+		week := int(now.Sub(commit.date) / (7 * 24 * time.Hour))
+		if week >= 0 && week < len(weeks) {
+			weeks[len(weeks)-1-week]++
+		}
+	}
+	return weeks
+}
+
+// isShallowRepository tells if the git repository in dir is a shallow clone,
+// without the oldest commits.
+func isShallowRepository(ctx context.Context, toolOutput io.Writer, dir string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--is-shallow-repository")
+	cmd.Dir = dir
+	cmd.Stderr = toolOutput
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err != nil {
+		return false, fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+type gitCommit struct {
+	date        time.Time
+	name, email string
+}
+
+// gitLog returns the commits of the default branch given by git log with the
+// given options, from the most recent one. The names and the emails of the
+// authors are mapped by the .mailmap file of the repository.
+func gitLog(ctx context.Context, toolOutput io.Writer, dir string, args ...string) ([]gitCommit, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"log", "--format=%ct%x00%aN%x00%aE"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stderr = toolOutput
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+	var commits []gitCommit
+	for line := range bytes.Lines(output) {
+		fields := strings.Split(strings.TrimSpace(string(line)), "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot parse the date of a commit: %w", err)
+		}
+		commits = append(commits, gitCommit{date: time.Unix(timestamp, 0).UTC(), name: fields[1], email: fields[2]})
+	}
+	return commits, nil
+}
@@ -0,0 +1,87 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// gitCommitAt commits in the repository in dir, with the given author and
+// date.
+func gitCommitAt(t *testing.T, dir, author string, date time.Time) {
+	t.Helper()
+	cmd := exec.Command("git", "commit", "--quiet", "--allow-empty", "-m", "commit")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL="+author+"@example.com",
+		"GIT_COMMITTER_NAME="+author, "GIT_COMMITTER_EMAIL="+author+"@example.com",
+		fmt.Sprintf("GIT_AUTHOR_DATE=@%d +0000", date.Unix()), fmt.Sprintf("GIT_COMMITTER_DATE=@%d +0000", date.Unix()))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v: %s", err, output)
+	}
+}
+
+func TestGitHistoryCollector(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1780272000")
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "group", "repo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := git(context.Background(), nil, dir, "init", "--quiet", "--initial-branch=main"); err != nil {
+		t.Fatal(err)
+	}
+	commits := []struct {
+		author string
+		days   int
+	}{
+		{"jane", 1000}, {"jane", 30}, {"jane", 20}, {"john", 15}, {"jane", 10}, {"jane", 3}, {"dependabot[bot]", 2},
+	}
+	for _, commit := range commits {
+		gitCommitAt(t, dir, commit.author, ago(commit.days))
+		if commit.days == 20 {
+			if err := git(context.Background(), nil, dir, "tag", "v1.0.0"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	collector := &GitHistoryCollector{Forge: &Forge{Kind: ForgeGit, URL: &url.URL{Scheme: "file", Path: root}}}
+	stats, err := collector.GetGitHubStats(context.Background(), "group", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.NoStars || stats.ShallowHistory {
+		t.Errorf("no stars and shallow history = %v and %v, want true and false", stats.NoStars, stats.ShallowHistory)
+	}
+	if !stats.FirstCommitDate.Equal(ago(1000)) || !stats.LastCommitDate.Equal(ago(2)) || !stats.LastHumanCommitDate.Equal(ago(3)) {
+		t.Errorf("first, last and last human commits = %v, %v and %v, want %v, %v and %v",
+			stats.FirstCommitDate, stats.LastCommitDate, stats.LastHumanCommitDate, ago(1000), ago(2), ago(3))
+	}
+	if stats.ActiveContributors != 1 || stats.BotCommits != 1 || stats.BotCommitShare != 16.67 {
+		t.Errorf("active contributors, bot commits and bot share = %d, %d and %v, want 1, 1 and 16.67",
+			stats.ActiveContributors, stats.BotCommits, stats.BotCommitShare)
+	}
+	// The date of a lightweight tag is the one of its commit
+	if len(stats.Releases) != 1 || stats.Releases[0].Name != "v1.0.0" || !stats.Releases[0].Date.Equal(ago(20)) {
+		t.Errorf("releases = %v, want v1.0.0 on %v", stats.Releases, ago(20))
+	}
+	var weekly int64
+	for _, commits := range stats.WeeklyCommits {
+		weekly += commits
+	}
+	if len(stats.WeeklyCommits) != participationWeeks || weekly != 5 {
+		t.Errorf("weekly commits = %v, want 5 commits in %d weeks", stats.WeeklyCommits, participationWeeks)
+	}
+}
@@ -0,0 +1,148 @@
+package qsos
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// GitHubApp authenticates the requests to the GitHub API as an installation
+// of a GitHub App, instead of a personal access token. The installation
+// tokens expire after one hour, they are renewed when needed.
+type GitHubApp struct {
+	AppID int64
+	// InstallationID is the installation of the app used for the requests.
+	// When it is 0, the app must have a single installation.
+	InstallationID int64
+	Key            *rsa.PrivateKey
+	// APIURL is the base URL of the GitHub API, with a trailing slash.
+	APIURL *url.URL
+	HTTP   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// ParseGitHubAppKey parses the PEM private key of a GitHub App.
+func ParseGitHubAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("Invalid private key of the GitHub App: no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid private key of the GitHub App: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid private key of the GitHub App: not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// Token returns an installation token, renewed 5 minutes before its
+// expiration.
+func (a *GitHubApp) Token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expires.Add(-5*time.Minute)) {
+		return a.token, nil
+	}
+	if a.InstallationID == 0 {
+		var installations []struct {
+			ID int64 `json:"id"`
+		}
+		if err := a.request(http.MethodGet, "app/installations", &installations); err != nil {
+			return "", err
+		}
+		if len(installations) != 1 {
+			return "", fmt.Errorf("The GitHub App has %d installations, set GITHUB_APP_INSTALLATION_ID", len(installations))
+		}
+		a.InstallationID = installations[0].ID
+	}
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := a.request(http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", a.InstallationID), &token); err != nil {
+		return "", err
+	}
+	a.token, a.expires = token.Token, token.ExpiresAt
+	return a.token, nil
+}
+
+// request sends a request authenticated as the app, with a JWT.
+func (a *GitHubApp) request(method, path string, data any) error {
+	jwt, err := a.jwt()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, a.APIURL.JoinPath(path).String(), nil)
+	if err != nil {
+		return fmt.Errorf("Cannot create the request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	res, err := a.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("GitHub App %s: unexpected response: %d", path, res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return fmt.Errorf("GitHub App %s: invalid response: %w", path, err)
+	}
+	return nil
+}
+
+// jwt returns a JSON Web Token of the app, valid for 9 minutes. It is issued
+// one minute in the past, for the clock drift.
+func (a *GitHubApp) jwt() (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(a.AppID, 10),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.Key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("Cannot sign the JWT of the GitHub App: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// githubAppTransport adds an installation token of the app to the requests.
+type githubAppTransport struct {
+	App  *GitHubApp
+	Next http.RoundTripper
+}
+
+func (t *githubAppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.App.Token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.Next.RoundTrip(req)
+}
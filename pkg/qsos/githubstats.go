@@ -0,0 +1,497 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// GitHub computes the statistics in background jobs, and answers with a 202
+// status while they are not ready. The requests are retried a few times,
+// with these delays.
+var gitHubStatsRetryDelays = []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}
+
+// errStatsNotComputed is returned when GitHub has not computed the
+// statistics of a repository after the retries.
+var errStatsNotComputed = errors.New("GitHub statistics are not computed yet")
+
+// withStatsRetry calls fn until GitHub has computed the statistics, and
+// returns errStatsNotComputed if it has not computed them after the retries.
+func (c *GitHubAPICollector) withStatsRetry(ctx context.Context, owner, repo string, fn func() error) error {
+	for i, delay := range gitHubStatsRetryDelays {
+		err := fn()
+		var accepted *github.AcceptedError
+		if !errors.As(err, &accepted) {
+			return err
+		}
+		slog.Debug("GitHub statistics are being computed", "project", owner+"/"+repo, "retry_in", delay)
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepRetry, Count: i + 1, Total: len(gitHubStatsRetryDelays)})
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+	err := fn()
+	var accepted *github.AcceptedError
+	if errors.As(err, &accepted) {
+		return errStatsNotComputed
+	}
+	return err
+}
+
+// GitHubAPICollector collects the community stats with the GitHub API, or
+// from a mirror of public data.
+type GitHubAPICollector struct {
+	Client *github.Client
+	HTTP   *http.Client
+	// PublicDataURL is optional.
+	PublicDataURL *url.URL
+	// Anonymous is set when the client has no token: the merged pull
+	// requests and the maintainers, which need many requests, are not
+	// collected.
+	Anonymous bool
+	// Progress is optional.
+	Progress ProgressFunc
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+	// ToolOutput receives the errors of git in GetGitHubStatsFromHistory,
+	// discarded when it is nil.
+	ToolOutput io.Writer
+}
+
+func (c *GitHubAPICollector) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	if stats, err := c.getPublicStats(ctx, owner, repo); stats != nil || err != nil {
+		return stats, err
+	}
+
+	stats := &GitHubStats{}
+
+	// 1. Get the info of the repository and the dates of its first and last
+	// commits, with the GraphQL API which needs a token
+	var defaultBranch string
+	var err error
+	if c.Anonymous {
+		defaultBranch, err = c.getRepositoryREST(ctx, owner, repo, stats)
+	} else {
+		defaultBranch, err = c.getRepositoryGraphQL(ctx, owner, repo, stats)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Get the number of active contributors, the authors of at least 4
+	// commits in the last 6 months by default. The commits are listed only
+	// when GitHub has not computed the statistics, it needs thousands of
+	// requests on the big repositories
+	window := c.Contributors.orDefault()
+	contribs, err := c.getContributionsFromStats(ctx, owner, repo, window)
+	if errors.Is(err, errStatsNotComputed) {
+		slog.Info("contributors statistics not available, listing the commits", "project", owner+"/"+repo)
+		contribs, err = c.getContributionsFromCommits(ctx, owner, repo, defaultBranch, window)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if contribs.Organizations.ElephantFactor == 0 && !c.Anonymous {
+		// The statistics have no emails
+		contribs.Organizations = c.getOrganizationsFromProfiles(ctx, owner, repo, contribs.Committers)
+	}
+	stats.ActiveContributors = contribs.Active
+	stats.ContributorsWindow = window
+	stats.BotCommits = contribs.BotCommits
+	stats.setOrganizations(contribs.Organizations)
+	stats.BotCommitShare = share(contribs.BotCommits, contribs.Commits)
+	if err := c.getActivityStats(ctx, owner, repo, stats, contribs.Committers); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetGitHubStatsFromHistory computes the dates of the commits and the
+// contributors from the clone of the repository in dir, instead of listing
+// the commits with the API, and the other stats with the API.
+func (c *GitHubAPICollector) GetGitHubStatsFromHistory(ctx context.Context, owner, repo, dir string) (*GitHubStats, error) {
+	if stats, err := c.getPublicStats(ctx, owner, repo); stats != nil || err != nil {
+		return stats, err
+	}
+
+	// 1. Get the dates of the commits and the contributors from the history
+	stats, err := gitHistoryStats(ctx, c.ToolOutput, dir, "", c.Contributors, c.Bots)
+	if err != nil {
+		return nil, err
+	}
+	stats.NoStars = false
+
+	// 2. Get the info of the repository
+	repository, _, err := c.Client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("Repositories.Get failed: %w", err)
+	}
+	setRepositoryInfo(stats, repository)
+
+	// 3. Get the logins of the committers, for the maintainers
+	var committers []*Maintainer
+	if !c.Anonymous {
+		contribs, err := c.getContributionsFromStats(ctx, owner, repo, c.Contributors.orDefault())
+		if err != nil {
+			slog.Warn("contributors statistics not available", "project", owner+"/"+repo, "err", err)
+		} else {
+			committers = contribs.Committers
+		}
+	}
+	if err := c.getActivityStats(ctx, owner, repo, stats, committers); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// getPublicStats returns the stats of the mirror of public data, or nil
+// without a mirror or when the project is not in it.
+func (c *GitHubAPICollector) getPublicStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	if c.PublicDataURL == nil {
+		return nil, nil
+	}
+	stats, err := c.getPublicDataStats(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("public data: %w", err)
+	}
+	if stats == nil {
+		slog.Info("not found in public data, using the GitHub API", "project", owner+"/"+repo)
+	}
+	return stats, nil
+}
+
+// getActivityStats collects the share of the pull requests of bots, the
+// weekly commits if they are not known yet, the platforms of the latest
+// release, and the maintainers among the committers.
+func (c *GitHubAPICollector) getActivityStats(ctx context.Context, owner, repo string, stats *GitHubStats, committers []*Maintainer) error {
+	var err error
+	// 1. Get the share of the pull requests merged in the last 6 months
+	// that were opened by bots
+	if !c.Anonymous {
+		stats.BotPullRequestShare, err = c.getBotPullRequestShare(ctx, owner, repo, reportTime().AddDate(0, -6, 0))
+		if err != nil {
+			return err
+		}
+	}
+
+	// 2. Get the time to the first response of the maintainers to the
+	// issues opened in the last 6 months
+	if !c.Anonymous {
+		responsiveness, err := c.getResponsiveness(ctx, owner, repo, reportTime().AddDate(0, -6, 0))
+		if err != nil {
+			slog.Warn("responsiveness not available", "project", owner+"/"+repo, "err", err)
+		} else {
+			stats.Issues = responsiveness.Issues
+			stats.IssueResponseTime = responsiveness.Median
+		}
+	}
+
+	// 3. Get the numbers of open and closed issues
+	backlog, err := c.getIssueBacklog(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("issues not available", "project", owner+"/"+repo, "err", err)
+	} else {
+		stats.setIssueBacklog(backlog)
+	}
+
+	// 4. Get the number of commits per week in the last year, with the ones
+	// of the bots
+	if stats.WeeklyCommits == nil {
+		participation, err := c.getParticipation(ctx, owner, repo)
+		if err != nil {
+			slog.Warn("participation statistics not available", "project", owner+"/"+repo, "err", err)
+		} else {
+			stats.WeeklyCommits = participation
+			stats.WeeklyCommitsWithBots = true
+		}
+	}
+
+	// 5. Get the last releases, merged with the tags of the clone
+	releases, err := c.getReleases(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("releases not available", "project", owner+"/"+repo, "err", err)
+	} else {
+		stats.Releases = mergeReleases(releases, stats.Releases)
+	}
+
+	// 6. Get the platforms of the latest release
+	stats.ReleasePlatforms, err = c.getReleasePlatforms(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("platforms of the release not available", "project", owner+"/"+repo, "err", err)
+	}
+
+	// 7. Identify the maintainers
+	if !c.Anonymous {
+		stats.Maintainers, err = c.getMaintainers(ctx, owner, repo, committers)
+		if err != nil {
+			slog.Warn("maintainers not available", "project", owner+"/"+repo, "err", err)
+		}
+	}
+	return nil
+}
+
+// getRepositoryREST collects the info of the repository and the dates of its
+// first and last commits with the REST API, which allows anonymous requests.
+// It returns the default branch.
+func (c *GitHubAPICollector) getRepositoryREST(ctx context.Context, owner, repo string, stats *GitHubStats) (string, error) {
+	// 1. Get Project Info (Stars, Default Branch)
+	repository, _, err := c.Client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", fmt.Errorf("Repositories.Get failed: %w", err)
+	}
+
+	setRepositoryInfo(stats, repository)
+	defaultBranch := repository.GetDefaultBranch()
+
+	// 2. Get Date of the Last Commit, and of the last one not authored by a
+	// bot (reverse chronological by default)
+	lastCommits, _, err := c.Client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		SHA:         defaultBranch,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return "", fmt.Errorf("ListCommits for last commit failed: %w", err)
+	}
+	if len(lastCommits) > 0 && lastCommits[0].Commit.Committer.Date != nil {
+		stats.LastCommitDate = lastCommits[0].Commit.Committer.Date.UTC()
+	} else {
+		return "", fmt.Errorf("could not find last commit date")
+	}
+	for _, commit := range lastCommits {
+		// If the last 100 commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.GetCommit().GetCommitter().GetDate().UTC()
+		if !c.Bots.isBotCommit(commit) {
+			break
+		}
+	}
+
+	// 3. Get Date of the First Commit (by fetching the last page of commits)
+	_, resp, err := c.Client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		SHA:         defaultBranch,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return "", fmt.Errorf("ListCommits for first commit page count failed: %w", err)
+	}
+	firstCommitPage := resp.LastPage
+	firstCommit, _, err := c.Client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		SHA:         defaultBranch,
+		ListOptions: github.ListOptions{PerPage: 1, Page: firstCommitPage},
+	})
+	if err != nil {
+		return "", fmt.Errorf("ListCommits for first commit failed: %w", err)
+	}
+	if len(firstCommit) > 0 && firstCommit[0].Commit.Committer.Date != nil {
+		stats.FirstCommitDate = firstCommit[0].Commit.Committer.Date.UTC()
+	} else {
+		return "", fmt.Errorf("could not find first commit date")
+	}
+	return defaultBranch, nil
+}
+
+// setRepositoryInfo sets the stars, the forks, the archival and the license
+// of a repository.
+func setRepositoryInfo(stats *GitHubStats, repository *github.Repository) {
+	if repository.StargazersCount != nil {
+		stats.Stars = int64(*repository.StargazersCount)
+	}
+	stats.Forks = int64(repository.GetForksCount())
+	stats.Archived = repository.GetArchived()
+	stats.License = repository.GetLicense().GetSPDXID()
+}
+
+func (c *GitHubAPICollector) getContributionsFromCommits(ctx context.Context, owner, repo, branch string, window *ContributorsWindow) (*contributions, error) {
+	result := &contributions{}
+	contributors := newIdentities()
+	committers := map[string]*Maintainer{}
+	opts := &github.CommitsListOptions{
+		Since: window.since(),
+		SHA:   branch,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for page := 1; ; page++ {
+		commits, resp, err := c.Client.Repositories.ListCommits(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("ListCommits for contributors failed: %w", err)
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page, Total: resp.LastPage})
+		for _, commit := range commits {
+			result.Commits++
+			if c.Bots.isBotCommit(commit) {
+				result.BotCommits++
+				continue
+			}
+			contributors.add(commit.GetCommit().GetAuthor().GetName(), commit.GetCommit().GetAuthor().GetEmail(), commit.GetAuthor().GetLogin())
+			if login := commit.GetAuthor().GetLogin(); login != "" {
+				committer, ok := committers[login]
+				if !ok {
+					committer = &Maintainer{Login: login}
+					committers[login] = committer
+					result.Committers = append(result.Committers, committer)
+				}
+				committer.Commits++
+				if date := commit.GetCommit().GetCommitter().GetDate().UTC(); date.After(committer.LastActivity) {
+					committer.LastActivity = date
+				}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	result.Active = contributors.active(window.MinCommits)
+	result.Organizations = contributors.organizationStats()
+	return result, nil
+}
+
+// contributions are the commits made since a date.
+type contributions struct {
+	// Active is the number of human contributors with the minimal number of
+	// commits of the window.
+	Active     int64
+	Commits    int64
+	BotCommits int64
+	// Organizations are the organizations of the human commits, if the
+	// emails of their authors are known.
+	Organizations organizationStats
+	// Committers are the human authors of the commits, with their number of
+	// commits and the date of their last one.
+	Committers []*Maintainer
+}
+
+// getContributionsFromStats counts the commits of the window, with the
+// statistics endpoint of GitHub. It is much cheaper than listing the
+// commits, but the statistics are limited to the 100 top contributors.
+func (c *GitHubAPICollector) getContributionsFromStats(ctx context.Context, owner, repo string, window *ContributorsWindow) (*contributions, error) {
+	var contributors []*github.ContributorStats
+	err := c.withStatsRetry(ctx, owner, repo, func() error {
+		var err error
+		contributors, _, err = c.Client.Repositories.ListContributorsStats(ctx, owner, repo)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListContributorsStats failed: %w", err)
+	}
+	if len(contributors) == 0 {
+		// GitHub answers with a 204 status and no statistics for the empty
+		// repositories, and sometimes while computing them
+		return nil, errStatsNotComputed
+	}
+
+	result := &contributions{}
+	since := window.since()
+	for _, contributor := range contributors {
+		var commits int64
+		for _, week := range contributor.Weeks {
+			if week.Week != nil && !week.Week.Before(since) {
+				commits += int64(week.GetCommits())
+			}
+		}
+		result.Commits += commits
+		author := contributor.GetAuthor()
+		if c.Bots.isBot(author.GetLogin(), author.GetType()) {
+			result.BotCommits += commits
+			continue
+		}
+		if commits >= window.MinCommits {
+			result.Active++
+		}
+		if commits > 0 {
+			committer := &Maintainer{Login: author.GetLogin(), Commits: commits}
+			for _, week := range contributor.Weeks {
+				if week.GetCommits() > 0 && week.Week != nil {
+					committer.LastActivity = week.Week.UTC()
+				}
+			}
+			result.Committers = append(result.Committers, committer)
+		}
+	}
+	return result, nil
+}
+
+// maxProfileLookups limits the number of profiles of the committers read for
+// their organization.
+const maxProfileLookups = 10
+
+// getOrganizationsFromProfiles returns the organizations of the commits from
+// the company of the profiles of the top committers, until they author half
+// of the commits. The other committers, and the ones without a company, are
+// independent. The errors are logged, the organizations are then unknown.
+func (c *GitHubAPICollector) getOrganizationsFromProfiles(ctx context.Context, owner, repo string, committers []*Maintainer) organizationStats {
+	committers = slices.Clone(committers)
+	slices.SortFunc(committers, func(a, b *Maintainer) int { return cmp.Compare(b.Commits, a.Commits) })
+	var total, looked int64
+	for _, committer := range committers {
+		total += committer.Commits
+	}
+	organizations := map[string]int64{}
+	var independent []int64
+	for i, committer := range committers {
+		if i >= maxProfileLookups || 2*looked >= total {
+			independent = append(independent, committer.Commits)
+			continue
+		}
+		user, _, err := c.Client.Users.Get(ctx, committer.Login)
+		if err != nil {
+			slog.Warn("cannot get the organizations of the committers", "project", owner+"/"+repo, "err", err)
+			return organizationStats{}
+		}
+		looked += committer.Commits
+		if company := companyOrganization(user.GetCompany()); company != "" {
+			organizations[company] += committer.Commits
+		} else {
+			independent = append(independent, committer.Commits)
+		}
+	}
+	return computeOrganizationStats(organizations, independent)
+}
+
+// companyOrganization normalizes the company of a GitHub profile, "@Example,
+// Inc." is example. It returns an empty string for the independent
+// contributors.
+func companyOrganization(company string) string {
+	company = strings.ToLower(strings.TrimSpace(company))
+	company = strings.TrimPrefix(company, "@")
+	company = strings.TrimSuffix(strings.TrimSuffix(company, "."), ", inc")
+	company = strings.TrimSpace(company)
+	switch company {
+	case "freelance", "freelancer", "independent", "self-employed", "none", "n/a", "-":
+		return ""
+	}
+	return company
+}
+
+// getParticipation returns the number of commits for each week of the last
+// year, from the participation statistics of GitHub.
+func (c *GitHubAPICollector) getParticipation(ctx context.Context, owner, repo string) ([]int64, error) {
+	var participation *github.RepositoryParticipation
+	err := c.withStatsRetry(ctx, owner, repo, func() error {
+		var err error
+		participation, _, err = c.Client.Repositories.ListParticipation(ctx, owner, repo)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListParticipation failed: %w", err)
+	}
+	weeks := make([]int64, len(participation.All))
+	for i, nb := range participation.All {
+		weeks[i] = int64(nb)
+	}
+	return weeks, nil
+}
@@ -0,0 +1,384 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitLabCollector collects the community stats of the projects hosted on
+// GitLab (gitlab.com or a self-hosted instance) with its API. The weekly
+// commits and the maintainers are not collected.
+type GitLabCollector struct {
+	// URL is the URL of the instance, like https://gitlab.com.
+	URL *url.URL
+	// Token is optional for the public projects.
+	Token string
+	HTTP  *http.Client
+	// Progress is optional.
+	Progress ProgressFunc
+	// Contributors defines the active contributors,
+	// DefaultContributorsWindow when it is nil.
+	Contributors *ContributorsWindow
+	// Bots identifies the bots, DefaultBotFilter when it is nil.
+	Bots *BotFilter
+}
+
+// maxGitLabCommitPages limits the number of requests for the commits of the
+// last 6 months.
+const maxGitLabCommitPages = 20
+
+type gitLabProject struct {
+	StarCount     int64     `json:"star_count"`
+	ForksCount    int64     `json:"forks_count"`
+	Archived      bool      `json:"archived"`
+	DefaultBranch string    `json:"default_branch"`
+	CreatedAt     time.Time `json:"created_at"`
+	ReadmeURL     string    `json:"readme_url"`
+	License       *struct {
+		Key string `json:"key"`
+	} `json:"license"`
+}
+
+type gitLabCommit struct {
+	AuthorName    string    `json:"author_name"`
+	AuthorEmail   string    `json:"author_email"`
+	CommittedDate time.Time `json:"committed_date"`
+}
+
+type gitLabMergeRequest struct {
+	MergedAt *time.Time `json:"merged_at"`
+	Author   struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+type gitLabRelease struct {
+	TagName    string     `json:"tag_name"`
+	ReleasedAt *time.Time `json:"released_at"`
+	// UpcomingRelease is true for the releases with a date in the future.
+	UpcomingRelease bool `json:"upcoming_release"`
+	Assets          struct {
+		Links []struct {
+			Name string `json:"name"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+type gitLabTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		CommittedDate time.Time `json:"committed_date"`
+	} `json:"commit"`
+}
+
+// gitLabLicenses are the SPDX identifiers of the license keys of GitLab.
+var gitLabLicenses = map[string]string{
+	"agpl-3.0":     "AGPL-3.0",
+	"apache-2.0":   "Apache-2.0",
+	"bsd-2-clause": "BSD-2-Clause",
+	"bsd-3-clause": "BSD-3-Clause",
+	"epl-2.0":      "EPL-2.0",
+	"gpl-2.0":      "GPL-2.0",
+	"gpl-3.0":      "GPL-3.0",
+	"isc":          "ISC",
+	"lgpl-2.1":     "LGPL-2.1",
+	"lgpl-3.0":     "LGPL-3.0",
+	"mit":          "MIT",
+	"mpl-2.0":      "MPL-2.0",
+	"unlicense":    "Unlicense",
+}
+
+func (c *GitLabCollector) GetGitHubStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	stats := &GitHubStats{}
+	project := "projects/" + url.PathEscape(owner+"/"+repo)
+
+	// 1. Get the project info (stars, default branch)
+	var info gitLabProject
+	if _, err := c.get(ctx, project, url.Values{"license": {"true"}}, &info); err != nil {
+		return nil, err
+	}
+	stats.Stars = info.StarCount
+	stats.Forks = info.ForksCount
+	stats.Archived = info.Archived
+	if info.License != nil {
+		stats.License = gitLabLicenses[info.License.Key]
+	}
+
+	// 2. Get the date of the last commit, and of the last one not authored
+	// by a bot
+	var lastCommits []gitLabCommit
+	if _, err := c.get(ctx, project+"/repository/commits", url.Values{
+		"ref_name": {info.DefaultBranch},
+		"per_page": {"100"},
+	}, &lastCommits); err != nil {
+		return nil, err
+	}
+	if len(lastCommits) == 0 {
+		return nil, fmt.Errorf("could not find last commit date")
+	}
+	stats.LastCommitDate = lastCommits[0].CommittedDate.UTC()
+	for _, commit := range lastCommits {
+		// If the last 100 commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.CommittedDate.UTC()
+		if !c.Bots.isBotAuthor(commit.AuthorName, commit.AuthorEmail) {
+			break
+		}
+	}
+
+	// 3. Get the date of the first commit, on the last page of the commits.
+	// GitLab does not count the pages of the big projects, and their
+	// creation date is used instead.
+	res, err := c.get(ctx, project+"/repository/commits", url.Values{
+		"ref_name": {info.DefaultBranch},
+		"per_page": {"1"},
+	}, &[]gitLabCommit{})
+	if err != nil {
+		return nil, err
+	}
+	if pages := res.Header.Get("X-Total-Pages"); pages != "" {
+		var firstCommit []gitLabCommit
+		if _, err := c.get(ctx, project+"/repository/commits", url.Values{
+			"ref_name": {info.DefaultBranch},
+			"per_page": {"1"},
+			"page":     {pages},
+		}, &firstCommit); err != nil {
+			return nil, err
+		}
+		if len(firstCommit) == 0 {
+			return nil, fmt.Errorf("could not find first commit date")
+		}
+		stats.FirstCommitDate = firstCommit[0].CommittedDate.UTC()
+	} else {
+		slog.Info("too many commits to find the first one, using the creation date of the project", "project", owner+"/"+repo)
+		stats.FirstCommitDate = info.CreatedAt.UTC()
+	}
+
+	// 4. Get the number of active contributors, the authors of at least 4
+	// commits in the last 6 months by default
+	contribs, err := c.getContributions(ctx, owner, repo, info.DefaultBranch, c.Contributors.orDefault())
+	if err != nil {
+		return nil, err
+	}
+	stats.ActiveContributors = contribs.Active
+	stats.ContributorsWindow = c.Contributors.orDefault()
+	stats.BotCommits = contribs.BotCommits
+	stats.setOrganizations(contribs.Organizations)
+	stats.BotCommitShare = share(contribs.BotCommits, contribs.Commits)
+
+	sixMonthsAgo := reportTime().AddDate(0, -6, 0)
+	// 5. Get the share of the merge requests merged in the last 6 months
+	// that were opened by bots
+	stats.BotPullRequestShare, err = c.getBotMergeRequestShare(ctx, owner, repo, sixMonthsAgo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. Get the platforms of the latest release
+	stats.ReleasePlatforms, err = c.getReleasePlatforms(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("platforms of the release not available", "project", owner+"/"+repo, "err", err)
+	}
+
+	// 7. Get the last releases and tags
+	stats.Releases, err = c.getReleases(ctx, owner, repo)
+	if err != nil {
+		slog.Warn("releases not available", "project", owner+"/"+repo, "err", err)
+	}
+	return stats, nil
+}
+
+// getReleases returns the last releases and tags of a project. The date of a
+// tag is the one of its commit.
+func (c *GitLabCollector) getReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	project := "projects/" + url.PathEscape(owner+"/"+repo)
+	var list []gitLabRelease
+	if _, err := c.get(ctx, project+"/releases", url.Values{"per_page": {strconv.Itoa(maxReleases)}}, &list); err != nil {
+		return nil, err
+	}
+	var releases []Release
+	for _, release := range list {
+		if release.UpcomingRelease || release.ReleasedAt == nil {
+			continue
+		}
+		if r, ok := newRelease(release.TagName, *release.ReleasedAt, false); ok {
+			releases = append(releases, r)
+		}
+	}
+	var tagList []gitLabTag
+	if _, err := c.get(ctx, project+"/repository/tags", url.Values{"order_by": {"updated"}, "per_page": {strconv.Itoa(maxReleases)}}, &tagList); err != nil {
+		return nil, err
+	}
+	var tags []Release
+	for _, tag := range tagList {
+		if t, ok := newRelease(tag.Name, tag.Commit.CommittedDate, false); ok {
+			tags = append(tags, t)
+		}
+	}
+	return mergeReleases(releases, tags), nil
+}
+
+func (c *GitLabCollector) getContributions(ctx context.Context, owner, repo, branch string, window *ContributorsWindow) (*contributions, error) {
+	since := window.since()
+	result := &contributions{}
+	contributors := newIdentities()
+	for page := 1; page <= maxGitLabCommitPages; page++ {
+		var commits []gitLabCommit
+		res, err := c.get(ctx, "projects/"+url.PathEscape(owner+"/"+repo)+"/repository/commits", url.Values{
+			"ref_name": {branch},
+			"since":    {since.UTC().Format(time.RFC3339)},
+			"per_page": {"100"},
+			"page":     {strconv.Itoa(page)},
+		}, &commits)
+		if err != nil {
+			return nil, err
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page, Total: maxGitLabCommitPages})
+		for _, commit := range commits {
+			result.Commits++
+			if c.Bots.isBotAuthor(commit.AuthorName, commit.AuthorEmail) {
+				result.BotCommits++
+				continue
+			}
+			contributors.add(commit.AuthorName, commit.AuthorEmail, "")
+		}
+		if res.Header.Get("X-Next-Page") == "" {
+			break
+		}
+	}
+	result.Active = contributors.active(window.MinCommits)
+	result.Organizations = contributors.organizationStats()
+	return result, nil
+}
+
+// getBotMergeRequestShare returns the percentage of the merge requests
+// merged since the given date that have been opened by bots.
+func (c *GitLabCollector) getBotMergeRequestShare(ctx context.Context, owner, repo string, since time.Time) (float64, error) {
+	var merged, bots int64
+	for page := 1; page <= maxPullRequestPages; page++ {
+		var requests []gitLabMergeRequest
+		res, err := c.get(ctx, "projects/"+url.PathEscape(owner+"/"+repo)+"/merge_requests", url.Values{
+			"state":         {"merged"},
+			"updated_after": {since.UTC().Format(time.RFC3339)},
+			"per_page":      {"100"},
+			"page":          {strconv.Itoa(page)},
+		}, &requests)
+		if err != nil {
+			return 0, err
+		}
+		for _, request := range requests {
+			if request.MergedAt == nil || request.MergedAt.Before(since) {
+				continue
+			}
+			merged++
+			if c.Bots.isBot(request.Author.Username, "") {
+				bots++
+			}
+		}
+		if res.Header.Get("X-Next-Page") == "" {
+			break
+		}
+	}
+	return share(bots, merged), nil
+}
+
+// getReleasePlatforms returns the platforms covered by the asset links of
+// the latest release, or nil if the project has no release.
+func (c *GitLabCollector) getReleasePlatforms(ctx context.Context, owner, repo string) ([]string, error) {
+	var releases []gitLabRelease
+	if _, err := c.get(ctx, "projects/"+url.PathEscape(owner+"/"+repo)+"/releases", url.Values{"per_page": {"1"}}, &releases); err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	var platforms []string
+	for _, link := range releases[0].Assets.Links {
+		platforms = append(platforms, assetPlatforms(link.Name)...)
+	}
+	slices.Sort(platforms)
+	return slices.Compact(platforms), nil
+}
+
+// GetReadme returns the content of the README of a project.
+func (c *GitLabCollector) GetReadme(ctx context.Context, owner, repo string) (string, error) {
+	project := "projects/" + url.PathEscape(owner+"/"+repo)
+	var info gitLabProject
+	if _, err := c.get(ctx, project, nil, &info); err != nil {
+		return "", err
+	}
+	// The README URL is like <project>/-/blob/<branch>/README.md
+	_, file, ok := strings.Cut(info.ReadmeURL, "/-/blob/"+info.DefaultBranch+"/")
+	if !ok {
+		return "", fmt.Errorf("no README in %s/%s", owner, repo)
+	}
+	req, err := c.newRequest(ctx, project+"/repository/files/"+url.PathEscape(file)+"/raw", url.Values{"ref": {info.DefaultBranch}})
+	if err != nil {
+		return "", err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitLab API %s: unexpected response: %d", file, res.StatusCode)
+	}
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("Cannot read the README: %w", err)
+	}
+	return string(content), nil
+}
+
+// apiURL returns the URL of an endpoint of the GitLab API.
+func (c *GitLabCollector) apiURL(path string, query url.Values) string {
+	u := strings.TrimSuffix(c.URL.String(), "/") + "/api/v4/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *GitLabCollector) newRequest(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL(path, query), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the request: %w", err)
+	}
+	if c.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	}
+	return req, nil
+}
+
+// get decodes the JSON response of a request to the GitLab API. The
+// response is returned for its pagination headers.
+func (c *GitLabCollector) get(ctx context.Context, path string, query url.Values, data any) (*http.Response, error) {
+	req, err := c.newRequest(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("GitLab API %s: not found", path)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitLab API %s: unexpected response: %d", path, res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return nil, fmt.Errorf("GitLab API %s: invalid response: %w", path, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+// serveJSON writes a JSON response of a fake forge.
+func serveJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func TestGitLabCollector(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1780272000")
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+	commit := func(name string, days int) map[string]any {
+		return map[string]any{"author_name": name, "author_email": name + "@example.com", "committed_date": ago(days)}
+	}
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("PRIVATE-TOKEN"))
+		query := r.URL.Query()
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Frepo":
+			serveJSON(w, map[string]any{
+				"star_count": 42, "forks_count": 7, "default_branch": "main", "created_at": ago(2000),
+				"license": map[string]string{"key": "apache-2.0"},
+			})
+		case "/api/v4/projects/group%2Fsub%2Frepo/repository/commits":
+			switch {
+			case query.Get("since") != "":
+				serveJSON(w, []map[string]any{
+					commit("jane", 1), commit("jane", 2), commit("jane", 3), commit("jane", 4),
+					commit("john", 5), commit("renovate[bot]", 6),
+				})
+			case query.Get("per_page") == "100":
+				serveJSON(w, []map[string]any{commit("renovate[bot]", 1), commit("jane", 2)})
+			case query.Get("page") == "3":
+				serveJSON(w, []map[string]any{commit("jane", 1500)})
+			default:
+				w.Header().Set("X-Total-Pages", "3")
+				serveJSON(w, []map[string]any{commit("renovate[bot]", 1)})
+			}
+		case "/api/v4/projects/group%2Fsub%2Frepo/merge_requests":
+			serveJSON(w, []map[string]any{
+				{"merged_at": ago(10), "author": map[string]string{"username": "renovate[bot]"}},
+				{"merged_at": ago(20), "author": map[string]string{"username": "jane"}},
+				{"merged_at": ago(400), "author": map[string]string{"username": "renovate[bot]"}},
+			})
+		case "/api/v4/projects/group%2Fsub%2Frepo/releases":
+			serveJSON(w, []map[string]any{
+				{"tag_name": "v1.1.0", "released_at": ago(10), "assets": map[string]any{"links": []map[string]string{
+					{"name": "app-linux-amd64.tar.gz"}, {"name": "app-darwin-arm64.tar.gz"},
+				}}},
+				{"tag_name": "v2.0.0", "released_at": now.AddDate(0, 1, 0).Format(time.RFC3339), "upcoming_release": true},
+			})
+		case "/api/v4/projects/group%2Fsub%2Frepo/repository/tags":
+			serveJSON(w, []map[string]any{
+				{"name": "v1.1.0", "commit": map[string]string{"committed_date": ago(12)}},
+				{"name": "v1.0.0", "commit": map[string]string{"committed_date": ago(100)}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	collector := &GitLabCollector{URL: u, Token: "secret", HTTP: server.Client()}
+	stats, err := collector.GetGitHubStats(context.Background(), "group/sub", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stars != 42 || stats.Forks != 7 || stats.License != "Apache-2.0" {
+		t.Errorf("stars, forks and license = %d, %d and %s, want 42, 7 and Apache-2.0", stats.Stars, stats.Forks, stats.License)
+	}
+	if want := now.AddDate(0, 0, -1500); !stats.FirstCommitDate.Equal(want) {
+		t.Errorf("first commit = %v, want %v", stats.FirstCommitDate, want)
+	}
+	if want := now.AddDate(0, 0, -2); !stats.LastHumanCommitDate.Equal(want) {
+		t.Errorf("last human commit = %v, want %v", stats.LastHumanCommitDate, want)
+	}
+	if stats.ActiveContributors != 1 || stats.BotCommits != 1 || stats.BotCommitShare != 16.67 {
+		t.Errorf("active contributors, bot commits and bot share = %d, %d and %v, want 1, 1 and 16.67",
+			stats.ActiveContributors, stats.BotCommits, stats.BotCommitShare)
+	}
+	if stats.BotPullRequestShare != 50 {
+		t.Errorf("bot share of the merge requests = %v, want 50", stats.BotPullRequestShare)
+	}
+	if want := []string{"darwin/arm64", "linux/amd64"}; !slices.Equal(stats.ReleasePlatforms, want) {
+		t.Errorf("release platforms = %v, want %v", stats.ReleasePlatforms, want)
+	}
+	var releases []string
+	for _, release := range stats.Releases {
+		releases = append(releases, release.Name)
+	}
+	if want := []string{"v1.1.0", "v1.0.0"}; !slices.Equal(releases, want) {
+		t.Errorf("releases = %v, want %v", releases, want)
+	}
+	if i := slices.IndexFunc(tokens, func(token string) bool { return token != "secret" }); i >= 0 {
+		t.Errorf("request %d has the token %q, want secret", i, tokens[i])
+	}
+}
@@ -0,0 +1,185 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// githubRepositoryQuery gets the info of a repository and the last commits of
+// its default branch, in one request.
+const githubRepositoryQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    stargazerCount
+    forkCount
+    isArchived
+    licenseInfo { spdxId }
+    defaultBranchRef {
+      name
+      target {
+        ... on Commit {
+          oid
+          history(first: 100) {
+            totalCount
+            nodes { committedDate author { name email user { login } } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// githubFirstCommitQuery gets the first commit of a branch, with a cursor
+// after all the other commits. The cursors of the history are like
+// "<oid of the head> <offset>".
+const githubFirstCommitQuery = `query($owner: String!, $name: String!, $oid: GitObjectID!, $after: String!) {
+  repository(owner: $owner, name: $name) {
+    object(oid: $oid) {
+      ... on Commit {
+        history(first: 1, after: $after) {
+          nodes { committedDate author { name email user { login } } }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLCommit struct {
+	CommittedDate time.Time `json:"committedDate"`
+	Author        struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		User  *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"author"`
+}
+
+// isBot returns true if the commit has been authored by a bot.
+func (c *graphQLCommit) isBot(bots *BotFilter) bool {
+	if c.Author.User != nil && bots.isBot(c.Author.User.Login, "") {
+		return true
+	}
+	return bots.isBotAuthor(c.Author.Name, c.Author.Email)
+}
+
+type graphQLHistory struct {
+	TotalCount int64           `json:"totalCount"`
+	Nodes      []graphQLCommit `json:"nodes"`
+}
+
+type graphQLRepository struct {
+	StargazerCount int64 `json:"stargazerCount"`
+	ForkCount      int64 `json:"forkCount"`
+	IsArchived     bool  `json:"isArchived"`
+	LicenseInfo    *struct {
+		SPDXID string `json:"spdxId"`
+	} `json:"licenseInfo"`
+	DefaultBranchRef *struct {
+		Name   string `json:"name"`
+		Target struct {
+			OID     string         `json:"oid"`
+			History graphQLHistory `json:"history"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+	Object *struct {
+		History graphQLHistory `json:"history"`
+	} `json:"object"`
+	Issues   *graphQLIssues   `json:"issues"`
+	Releases *graphQLReleases `json:"releases"`
+	Refs     *graphQLTags     `json:"refs"`
+}
+
+// getRepositoryGraphQL collects the info of the repository and the dates of
+// its first and last commits with the GraphQL API, in one request, or two
+// for the repositories with more than 100 commits. It returns the default
+// branch.
+func (c *GitHubAPICollector) getRepositoryGraphQL(ctx context.Context, owner, repo string, stats *GitHubStats) (string, error) {
+	// 1. Get the info of the repository, and its last commits
+	var repository graphQLRepository
+	if err := c.graphQL(ctx, githubRepositoryQuery, map[string]any{"owner": owner, "name": repo}, &repository); err != nil {
+		return "", err
+	}
+	stats.Stars = repository.StargazerCount
+	stats.Forks = repository.ForkCount
+	stats.Archived = repository.IsArchived
+	if repository.LicenseInfo != nil {
+		stats.License = repository.LicenseInfo.SPDXID
+	}
+	branch := repository.DefaultBranchRef
+	if branch == nil || len(branch.Target.History.Nodes) == 0 {
+		return "", fmt.Errorf("could not find last commit date")
+	}
+
+	// 2. Get the date of the last commit, and of the last one not authored
+	// by a bot
+	commits := branch.Target.History.Nodes
+	stats.LastCommitDate = commits[0].CommittedDate.UTC()
+	for _, commit := range commits {
+		// If the last 100 commits are from bots, the oldest one is used
+		stats.LastHumanCommitDate = commit.CommittedDate.UTC()
+		if !commit.isBot(c.Bots) {
+			break
+		}
+	}
+
+	// 3. Get the date of the first commit, in the last commits for the small
+	// repositories
+	total := branch.Target.History.TotalCount
+	if total <= int64(len(commits)) {
+		stats.FirstCommitDate = commits[len(commits)-1].CommittedDate.UTC()
+		return branch.Name, nil
+	}
+	var first graphQLRepository
+	variables := map[string]any{"owner": owner, "name": repo, "oid": branch.Target.OID, "after": fmt.Sprintf("%s %d", branch.Target.OID, total-2)}
+	if err := c.graphQL(ctx, githubFirstCommitQuery, variables, &first); err != nil {
+		return "", err
+	}
+	if first.Object == nil || len(first.Object.History.Nodes) == 0 {
+		return "", fmt.Errorf("could not find first commit date")
+	}
+	stats.FirstCommitDate = first.Object.History.Nodes[0].CommittedDate.UTC()
+	return branch.Name, nil
+}
+
+// graphQL sends a query to the GraphQL API, and decodes the repository of
+// the response.
+func (c *GitHubAPICollector) graphQL(ctx context.Context, query string, variables map[string]any, repository *graphQLRepository) error {
+	req, err := c.Client.NewRequest(http.MethodPost, c.graphQLURL(), map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("Cannot create the GraphQL request: %w", err)
+	}
+	var response struct {
+		Data struct {
+			Repository *graphQLRepository `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.Client.Do(ctx, req, &response); err != nil {
+		return fmt.Errorf("GraphQL query failed: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("GraphQL query failed: %s", response.Errors[0].Message)
+	}
+	if response.Data.Repository == nil {
+		return fmt.Errorf("GraphQL query failed: repository not found")
+	}
+	*repository = *response.Data.Repository
+	return nil
+}
+
+// graphQLURL returns the URL of the GraphQL API: /graphql on github.com, and
+// /api/graphql on GitHub Enterprise Server.
+func (c *GitHubAPICollector) graphQLURL() string {
+	u := *c.Client.BaseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path += "graphql"
+	}
+	return u.String()
+}
@@ -0,0 +1,129 @@
+package qsos
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// heatmapColors are the colors used for the scores, from 1 (red) to 5 (green).
+var heatmapColors = [5]string{"#d73027", "#fc8d59", "#fee08b", "#91cf60", "#1a9850"}
+
+const (
+	heatmapLabelWidth   = 220
+	heatmapCellWidth    = 90
+	heatmapCellHeight   = 28
+	heatmapHeaderHeight = 110
+)
+
+// WriteHeatmapFile writes the heatmap of the evaluations (projects ×
+// criteria) to a file. The format is HTML if the file has the .html
+// extension, and SVG otherwise.
+func WriteHeatmapFile(path string, evaluations []*Evaluation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Cannot create heatmap file: %w", err)
+	}
+	defer f.Close()
+	if ext := filepath.Ext(path); ext == ".html" || ext == ".htm" {
+		err = WriteHeatmapHTML(f, evaluations)
+	} else {
+		err = WriteHeatmapSVG(f, evaluations)
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot write heatmap: %w", err)
+	}
+	return f.Close()
+}
+
+func WriteHeatmapHTML(w io.Writer, evaluations []*Evaluation) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>QSOS portfolio</title>\n</head>\n<body>\n")
+	b.WriteString("<h1>QSOS portfolio</h1>\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	if err := WriteHeatmapSVG(w, evaluations); err != nil {
+		return err
+	}
+
+	b.Reset()
+	for _, evaluation := range evaluations {
+		if len(evaluation.RedFlags) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "<h2>Red flags for %s</h2>\n<ul>\n", html.EscapeString(evaluation.Name()))
+		for _, flag := range evaluation.RedFlags {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(flag.Message))
+		}
+		b.WriteString("</ul>\n")
+	}
+	for _, evaluation := range evaluations {
+		if len(evaluation.Stats.Warnings) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "<h2>Warnings for %s</h2>\n<ul>\n", html.EscapeString(evaluation.Name()))
+		for _, warning := range evaluation.Stats.Warnings {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(warning.Message))
+		}
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func WriteHeatmapSVG(w io.Writer, evaluations []*Evaluation) error {
+	var criteria []string
+	if len(evaluations) > 0 {
+		for _, c := range evaluations[0].Scores.Criteria() {
+			criteria = append(criteria, c.Name)
+		}
+	}
+	width := heatmapLabelWidth + len(criteria)*heatmapCellWidth
+	height := heatmapHeaderHeight + len(evaluations)*heatmapCellHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, height)
+	for i, name := range criteria {
+		x := heatmapLabelWidth + i*heatmapCellWidth + heatmapCellWidth/2
+		y := heatmapHeaderHeight - 8
+		fmt.Fprintf(&b, `<text x="%d" y="%d" transform="rotate(-40 %d %d)">%s</text>`+"\n", x, y, x, y, html.EscapeString(name))
+	}
+	for j, evaluation := range evaluations {
+		y := heatmapHeaderHeight + j*heatmapCellHeight
+		label := html.EscapeString(evaluation.Name())
+		if flags := evaluation.RedFlags; len(flags) > 0 {
+			var titles []string
+			for _, flag := range flags {
+				titles = append(titles, html.EscapeString(flag.Message))
+			}
+			label = fmt.Sprintf(`%s <tspan fill="#d73027">⚑<title>%s</title></tspan>`, label, strings.Join(titles, "\n"))
+		}
+		if warnings := evaluation.Stats.Warnings; len(warnings) > 0 {
+			var titles []string
+			for _, warning := range warnings {
+				titles = append(titles, html.EscapeString(warning.Message))
+			}
+			label = fmt.Sprintf(`%s <tspan fill="#d73027">⚠<title>%s</title></tspan>`, label, strings.Join(titles, "\n"))
+		}
+		fmt.Fprintf(&b, `<text x="4" y="%d">%s</text>`+"\n", y+heatmapCellHeight*2/3, label)
+		for i, c := range evaluation.Scores.Criteria() {
+			x := heatmapLabelWidth + i*heatmapCellWidth
+			color := "#cccccc"
+			if *c.Score >= 1 && *c.Score <= 5 {
+				color = heatmapColors[*c.Score-1]
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#ffffff"><title>%s %s: %d</title></rect>`+"\n",
+				x, y, heatmapCellWidth, heatmapCellHeight, color, html.EscapeString(evaluation.Name()), c.Name, *c.Score)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n", x+heatmapCellWidth/2, y+heatmapCellHeight*2/3, *c.Score)
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
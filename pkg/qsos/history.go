@@ -0,0 +1,238 @@
+package qsos
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotInHistory is returned when an evaluation is not in the history.
+var ErrNotInHistory = errors.New("no such evaluation in history")
+
+// History is a store of the past evaluations, with one JSON file per
+// evaluation in a directory, or in a database. The intake requests and the
+// schedules are always in the directory.
+type History struct {
+	Dir string
+	// Store has the evaluations, the files of Dir if it is nil.
+	Store HistoryStore
+}
+
+// HistoryStore is the storage backend of the evaluations of the history:
+// DirStore, or SQLStore for SQLite and PostgreSQL.
+type HistoryStore interface {
+	// Put creates or replaces a record.
+	Put(record *HistoryRecord) error
+	// Get returns a record, or an error wrapping ErrNotInHistory.
+	Get(id string) (*HistoryRecord, error)
+	// Search returns the records matching the filter, in any order. The
+	// filter can be only partially applied, History.Search applies it.
+	Search(filter *HistoryFilter) ([]*HistoryRecord, error)
+}
+
+// HistoryRecord is an evaluation stored in the history.
+type HistoryRecord struct {
+	ID         string
+	Date       time.Time
+	Tags       []string
+	Evaluation *Evaluation
+}
+
+// HistoryFilter is used to search the history. The zero values match all the
+// records.
+type HistoryFilter struct {
+	Owner string
+	Repo  string
+	// Tags are the tags that the records must all have.
+	Tags  []string
+	Since time.Time
+	Until time.Time
+	// MinScores and MaxScores are the bounds for the criteria scores.
+	MinScores map[string]int64
+	MaxScores map[string]int64
+}
+
+func OpenHistory(dir string) (*History, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Cannot create history dir: %w", err)
+	}
+	return &History{Dir: dir}, nil
+}
+
+// OpenHistoryFromEnv opens the history in the directory given by the
+// QSOS_HISTORY_DIR env variable, or in the database given by QSOS_HISTORY_DB
+// (an SQLite file, or a postgres:// URL). It returns nil if none is set.
+func OpenHistoryFromEnv() (*History, error) {
+	dir := os.Getenv("QSOS_HISTORY_DIR")
+	if db := os.Getenv("QSOS_HISTORY_DB"); db != "" {
+		return OpenHistoryDB(db, dir)
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	return OpenHistory(dir)
+}
+
+func (h *History) store() HistoryStore {
+	if h.Store == nil {
+		return DirStore(h.Dir)
+	}
+	return h.Store
+}
+
+// Save records a new evaluation in the history. The ID of the record has a
+// random suffix, so that concurrent evaluations of a project do not
+// overwrite each other.
+func (h *History) Save(evaluation *Evaluation, tags []string) (*HistoryRecord, error) {
+	now := time.Now().UTC()
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	record := &HistoryRecord{
+		ID:         fmt.Sprintf("%s-%s-%s", now.Format("20060102T150405.000Z"), componentName(evaluation.Owner, evaluation.Repo), hex.EncodeToString(suffix)),
+		Date:       now,
+		Tags:       normalizeTags(tags),
+		Evaluation: evaluation,
+	}
+	if err := h.store().Put(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Get returns the record with the given ID.
+func (h *History) Get(id string) (*HistoryRecord, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("%w: invalid ID %q", ErrNotInHistory, id)
+	}
+	return h.store().Get(id)
+}
+
+// Tag adds tags to a record.
+func (h *History) Tag(id string, tags ...string) (*HistoryRecord, error) {
+	record, err := h.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	record.Tags = normalizeTags(append(record.Tags, tags...))
+	if err := h.store().Put(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Search returns the records matching the filter, from the oldest to the
+// newest.
+func (h *History) Search(filter *HistoryFilter) ([]*HistoryRecord, error) {
+	found, err := h.store().Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	var records []*HistoryRecord
+	for _, record := range found {
+		if filter.Match(record) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Date.Before(records[j].Date)
+	})
+	return records, nil
+}
+
+// DirStore stores the records in a directory, one JSON file per record.
+type DirStore string
+
+func (dir DirStore) Get(id string) (*HistoryRecord, error) {
+	data, err := os.ReadFile(filepath.Join(string(dir), id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotInHistory, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read history: %w", err)
+	}
+	var record HistoryRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("Invalid history record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+func (dir DirStore) Search(filter *HistoryFilter) ([]*HistoryRecord, error) {
+	entries, err := os.ReadDir(string(dir))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read history: %w", err)
+	}
+	var records []*HistoryRecord
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		record, err := dir.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (dir DirStore) Put(record *HistoryRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode history record: %w", err)
+	}
+	path := filepath.Join(string(dir), record.ID+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("Cannot write history: %w", err)
+	}
+	return nil
+}
+
+func (f *HistoryFilter) Match(record *HistoryRecord) bool {
+	evaluation := record.Evaluation
+	if f.Owner != "" && !strings.EqualFold(f.Owner, evaluation.Owner) {
+		return false
+	}
+	if f.Repo != "" && !strings.EqualFold(f.Repo, evaluation.Repo) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(record.Tags, tag) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && record.Date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Date.After(f.Until) {
+		return false
+	}
+	for _, criterion := range evaluation.Scores.Criteria() {
+		if min, ok := f.MinScores[criterion.Name]; ok && *criterion.Score < min {
+			return false
+		}
+		if max, ok := f.MaxScores[criterion.Name]; ok && *criterion.Score > max {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
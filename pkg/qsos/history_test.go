@@ -0,0 +1,22 @@
+package qsos
+
+import "testing"
+
+func TestHistorySave(t *testing.T) {
+	h := &History{Dir: t.TempDir()}
+	evaluation := &Evaluation{Owner: "owner", Repo: "repo", Stats: &ProjectStats{}, Scores: &ProjectScores{}}
+	ids := map[string]bool{}
+	for range 10 {
+		record, err := h.Save(evaluation, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids[record.ID] {
+			t.Fatalf("the ID %s is used by 2 records", record.ID)
+		}
+		ids[record.ID] = true
+		if _, err := h.Get(record.ID); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachingTransport caches the responses of the GET requests on disk, and
+// revalidates them with conditional requests (If-None-Match and
+// If-Modified-Since). The GitHub API answers them with a 304 status, which
+// does not count in the rate limit, if the response has not changed.
+type cachingTransport struct {
+	Dir  string
+	Next http.RoundTripper
+	// Offline answers from the cache only, without revalidation, and fails
+	// with ErrOffline on the misses.
+	Offline bool
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if t.Offline {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), ErrOffline)
+		}
+		return t.Next.RoundTrip(req)
+	}
+	// The credentials are not in the key, the installation tokens of the
+	// GitHub Apps change every hour
+	path := filepath.Join(t.Dir, recordKey(req, nil)+".json")
+	var cached *recordedResponse
+	if data, err := os.ReadFile(path); err == nil {
+		cached = &recordedResponse{}
+		if err := json.Unmarshal(data, cached); err != nil {
+			cached = nil
+		}
+	}
+	if t.Offline {
+		if cached == nil {
+			return nil, fmt.Errorf("%s: %w", req.URL.Redacted(), ErrOffline)
+		}
+		return cached.response(req), nil
+	}
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	res, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		// The rate limit is the one of the new response
+		for name, values := range res.Header {
+			if strings.HasPrefix(name, "X-Ratelimit-") {
+				cached.Header[name] = values
+			}
+		}
+		slog.Debug("GitHub response not modified", "url", req.URL.Redacted())
+		return cached.response(req), nil
+	}
+	if res.StatusCode != http.StatusOK || (res.Header.Get("ETag") == "" && res.Header.Get("Last-Modified") == "") {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	recorded := recordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+	}
+	if recorded.Body, err = io.ReadAll(res.Body); err != nil {
+		return nil, err
+	}
+	recorded.Header.Del("Set-Cookie")
+	if err := writeCachedResponse(path, &recorded); err != nil {
+		slog.Warn("cannot cache the response", "url", req.URL.Redacted(), "err", err)
+	}
+	return recorded.response(req), nil
+}
+
+// writeCachedResponse writes a response in the cache.
+func writeCachedResponse(path string, recorded *recordedResponse) error {
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
@@ -0,0 +1,159 @@
+package qsos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recordedResponse is an HTTP response saved on disk by the recorder.
+type recordedResponse struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// recordingTransport saves the HTTP responses in a directory (record mode),
+// or answers the requests with the responses saved previously (replay mode),
+// without using the network. It allows to run the tool on fixtures, for
+// deterministic tests and offline demos.
+type recordingTransport struct {
+	Dir    string
+	Replay bool
+	// Next is the transport used for the requests in record mode.
+	Next http.RoundTripper
+}
+
+// newHTTPClient returns the HTTP client used for all the calls to the APIs,
+// traced and logged. The responses are recorded in the record dir, or
+// replayed from the replay dir, if they are set. tlsConfig is optional. The
+// transient failures are retried with the retry policy, except in replay
+// mode.
+func newHTTPClient(record, replay string, tlsConfig *tls.Config, retry RetryPolicy) (*http.Client, error) {
+	transport := NewTransport()
+	if tlsConfig != nil {
+		transport.(*http.Transport).TLSClientConfig = tlsConfig
+	}
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("QSOS_HTTP_RECORD and QSOS_HTTP_REPLAY cannot be used together")
+	case record != "":
+		if err := os.MkdirAll(record, 0o755); err != nil {
+			return nil, fmt.Errorf("Cannot create the record dir: %w", err)
+		}
+		return &http.Client{Transport: &tracingTransport{&loggingTransport{&retryTransport{Policy: retry, Next: &recordingTransport{Dir: record, Next: transport}}}}}, nil
+	case replay != "":
+		return &http.Client{Transport: &tracingTransport{&loggingTransport{&recordingTransport{Dir: replay, Replay: true}}}}, nil
+	}
+	return &http.Client{Transport: &tracingTransport{&loggingTransport{&retryTransport{Policy: retry, Next: transport}}}}, nil
+}
+
+// LevelTrace is the level of the logs of the HTTP requests, below the debug
+// level.
+const LevelTrace = slog.LevelDebug - 4
+
+// loggingTransport logs the HTTP requests, at the trace level, and counts
+// them for APIRequestCounts.
+type loggingTransport struct {
+	Next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.Next.RoundTrip(req)
+	countAPIRequest(req, res, err)
+	if !slog.Default().Enabled(req.Context(), LevelTrace) {
+		return res, err
+	}
+	if err != nil {
+		slog.Log(req.Context(), LevelTrace, "HTTP request", "method", req.Method, "url", req.URL.Redacted(), "err", err)
+		return nil, err
+	}
+	slog.Log(req.Context(), LevelTrace, "HTTP request", "method", req.Method, "url", req.URL.Redacted(),
+		"status", res.StatusCode, "duration", time.Since(start))
+	return res, nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := filepath.Join(t.Dir, recordKey(req, body)+".json")
+
+	if t.Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("no recorded response for %s %s: %w", req.Method, req.URL, err)
+		}
+		var recorded recordedResponse
+		if err := json.Unmarshal(data, &recorded); err != nil {
+			return nil, fmt.Errorf("invalid recorded response %s: %w", path, err)
+		}
+		return recorded.response(req), nil
+	}
+
+	res, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	recorded := recordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+	}
+	if recorded.Body, err = io.ReadAll(res.Body); err != nil {
+		return nil, err
+	}
+	recorded.Header.Del("Set-Cookie")
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("Cannot record the response: %w", err)
+	}
+	return recorded.response(req), nil
+}
+
+// recordKey identifies a request by its method, URL and body. The headers
+// are ignored, so the credentials are not needed to replay the responses.
+func recordKey(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+func (r *recordedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
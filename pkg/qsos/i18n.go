@@ -0,0 +1,91 @@
+package qsos
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Languages are the languages of the reports. The messages of the reports
+// are written in English in the code, and translated with the catalogs of
+// the locales directory, like locales/fr.json.
+var Languages = []string{"en", "fr"}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Language is a language of the text, Markdown and HTML reports, and of the
+// browser. The messages of the red flags and of the warnings are not
+// translated, as they are part of the stats. A nil Language is English.
+type Language struct {
+	code string
+	// catalog maps the English messages to the language.
+	catalog map[string]string
+}
+
+// NewLanguage loads the catalog of a language of Languages.
+func NewLanguage(code string) (*Language, error) {
+	if !slices.Contains(Languages, code) {
+		return nil, fmt.Errorf("Unknown language %q, must be one of %v", code, Languages)
+	}
+	if code == "en" {
+		return nil, nil
+	}
+	data, err := locales.ReadFile("locales/" + code + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the %s catalog: %w", code, err)
+	}
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("Invalid %s catalog: %w", code, err)
+	}
+	return &Language{code: code, catalog: messages}, nil
+}
+
+// Code returns the code of the language, like "fr".
+func (l *Language) Code() string {
+	if l == nil {
+		return "en"
+	}
+	return l.code
+}
+
+// Translate returns an English message of the reports in the language, or
+// the message itself if it has no translation.
+func (l *Language) Translate(msg string) string {
+	if l == nil {
+		return msg
+	}
+	if translated, ok := l.catalog[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+func (l *Language) tr(msg string) string {
+	return l.Translate(msg)
+}
+
+// trf formats a message in the language.
+func (l *Language) trf(format string, args ...any) string {
+	return fmt.Sprintf(l.Translate(format), args...)
+}
+
+// ReportOptions are the options of the text, Markdown and HTML reports.
+type ReportOptions struct {
+	// Language is the language of the reports. When nil, they are in English.
+	Language *Language
+	// Colors enables the ANSI colors in the text reports: the scores are
+	// green, yellow or red, and the red flags and warnings are highlighted.
+	// It should only be set when the output is a terminal.
+	Colors bool
+}
+
+// language returns the language of the options, which may be nil.
+func (o *ReportOptions) language() *Language {
+	if o == nil {
+		return nil
+	}
+	return o.Language
+}
@@ -0,0 +1,56 @@
+package qsos
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	fr, err := NewLanguage("fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	en, err := NewLanguage("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLanguage("de"); err == nil {
+		t.Error("de is a language")
+	}
+	tests := []struct {
+		lang *Language
+		code string
+		msg  string
+	}{
+		{en, "en", "QSOS report"},
+		{fr, "fr", "Rapport QSOS"},
+		{nil, "en", "QSOS report"},
+	}
+	for _, test := range tests {
+		if code := test.lang.Code(); code != test.code {
+			t.Errorf("Code() = %s, want %s", code, test.code)
+		}
+		if msg := test.lang.Translate("QSOS report"); msg != test.msg {
+			t.Errorf("%s: Translate(\"QSOS report\") = %q, want %q", test.code, msg, test.msg)
+		}
+		if msg := test.lang.Translate("Not a message"); msg != "Not a message" {
+			t.Errorf("%s: Translate(\"Not a message\") = %q, want the message", test.code, msg)
+		}
+	}
+
+	// The languages of the reports are independent
+	var html, markdown bytes.Buffer
+	if err := WriteHTMLReport(&html, nil, &ReportOptions{Language: fr}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMarkdownReport(&markdown, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<html lang="fr">`) || !strings.Contains(html.String(), "Rapport QSOS") {
+		t.Error("the HTML report is not in French")
+	}
+	if !strings.Contains(markdown.String(), "# QSOS report") {
+		t.Error("the Markdown report is not in English")
+	}
+}
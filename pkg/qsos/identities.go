@@ -0,0 +1,224 @@
+package qsos
+
+import (
+	"cmp"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ContributorsWindow defines the active contributors: the human authors of
+// at least MinCommits commits in the last Months months.
+type ContributorsWindow struct {
+	Months     int
+	MinCommits int64
+}
+
+// DefaultContributorsWindow counts the authors of at least 4 commits in the
+// last 6 months.
+var DefaultContributorsWindow = ContributorsWindow{Months: 6, MinCommits: 4}
+
+// orDefault returns the window, or the default one if it is nil.
+func (w *ContributorsWindow) orDefault() *ContributorsWindow {
+	if w == nil {
+		return &DefaultContributorsWindow
+	}
+	return w
+}
+
+// since returns the start of the window, before the time of the collection.
+func (w *ContributorsWindow) since() time.Time {
+	return reportTime().AddDate(0, -w.orDefault().Months, 0)
+}
+
+// noreplyEmail matches the private emails of the GitHub accounts, like
+// 12345+login@users.noreply.github.com, and of the GitLab accounts, like
+// 12345-login@users.noreply.gitlab.com.
+var noreplyEmail = regexp.MustCompile(`^(?:\d+[+-])?([^@]+)@users\.noreply\.git(?:hub|lab)\.com$`)
+
+// identities counts the commits of the human contributors, merging the
+// identities of a same person: the commits with a same email, a same login
+// on the forge (also read from the noreply emails), or a same full name are
+// counted for a single contributor.
+type identities struct {
+	// parent links each identity to another one of the same contributor,
+	// up to the root identity of the contributor.
+	parent  map[string]string
+	commits map[string]int64
+	// organizations are the commits of each organization, by email domain,
+	// and independent the commits of the contributors with a personal
+	// email, by identity.
+	organizations map[string]int64
+	independent   map[string]int64
+}
+
+func newIdentities() *identities {
+	return &identities{
+		parent:        map[string]string{},
+		commits:       map[string]int64{},
+		organizations: map[string]int64{},
+		independent:   map[string]int64{},
+	}
+}
+
+// add counts a commit of an author. The login is optional.
+func (ids *identities) add(name, email, login string) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if match := noreplyEmail.FindStringSubmatch(email); match != nil {
+		login, email = cmp.Or(login, match[1]), ""
+	}
+	var keys []string
+	if login != "" {
+		keys = append(keys, "login:"+strings.ToLower(login))
+	}
+	if email != "" {
+		keys = append(keys, "email:"+email)
+	}
+	// A single word, like "root" or "admin", is too common to identify a
+	// person
+	if words := strings.Fields(strings.ToLower(name)); len(words) > 1 {
+		keys = append(keys, "name:"+strings.Join(words, " "))
+	}
+	if len(keys) == 0 {
+		keys = append(keys, "name:"+strings.ToLower(strings.TrimSpace(name)))
+	}
+	root := ids.root(keys[0])
+	for _, key := range keys[1:] {
+		if other := ids.root(key); other != root {
+			ids.parent[other] = root
+			ids.commits[root] += ids.commits[other]
+			delete(ids.commits, other)
+		}
+	}
+	ids.commits[root]++
+	if organization := emailOrganization(email); organization != "" {
+		ids.organizations[organization]++
+	} else {
+		ids.independent[keys[0]]++
+	}
+}
+
+// root returns the root identity of the contributor of an identity.
+func (ids *identities) root(key string) string {
+	parent, ok := ids.parent[key]
+	if !ok {
+		ids.parent[key] = key
+		return key
+	}
+	if parent == key {
+		return key
+	}
+	root := ids.root(parent)
+	ids.parent[key] = root
+	return root
+}
+
+// active returns the number of contributors with at least minCommits
+// commits.
+func (ids *identities) active(minCommits int64) int64 {
+	var active int64
+	for _, commits := range ids.commits {
+		if commits >= minCommits {
+			active++
+		}
+	}
+	return active
+}
+
+// personalDomains are the domains of the email providers, and of the
+// foundations giving an email to their members, which do not tell the
+// employer of a contributor.
+var personalDomains = []string{
+	"163.com", "aol.com", "fastmail.com", "gmail.com", "gmx.com", "gmx.de",
+	"googlemail.com", "hotmail.com", "icloud.com", "live.com", "mail.ru",
+	"me.com", "outlook.com", "pm.me", "proton.me", "protonmail.com", "qq.com",
+	"yahoo.com", "yandex.ru",
+	"apache.org", "debian.org", "eclipse.org", "fedoraproject.org",
+	"freebsd.org", "gnome.org", "gnu.org", "kde.org", "kernel.org",
+	"localhost",
+}
+
+// emailOrganization returns the organization of an email, its registered
+// domain like example.com for dev@eu.example.com, or an empty string for the
+// personal emails.
+func emailOrganization(email string) string {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok || !strings.Contains(domain, ".") {
+		return ""
+	}
+	if registered, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+		domain = registered
+	}
+	if slices.Contains(personalDomains, domain) {
+		return ""
+	}
+	return domain
+}
+
+// organizationStats are the organizations authoring the commits of the
+// contributors.
+type organizationStats struct {
+	// ElephantFactor is the smallest number of organizations authoring at
+	// least half of the commits, 0 without commits.
+	ElephantFactor int64
+	// Top is the organization with the most commits, if it is not an
+	// independent contributor, and TopShare the percentage of its commits.
+	Top      string
+	TopShare float64
+}
+
+// setOrganizations sets the organizations of the commits of the stats.
+func (s *GitHubStats) setOrganizations(organizations organizationStats) {
+	s.ElephantFactor = organizations.ElephantFactor
+	s.TopOrganization = organizations.Top
+	s.TopOrganizationShare = organizations.TopShare
+}
+
+// organizationStats returns the organizations of the commits. Each
+// independent contributor counts as an organization.
+func (ids *identities) organizationStats() organizationStats {
+	independent := map[string]int64{}
+	for key, commits := range ids.independent {
+		independent[ids.root(key)] += commits
+	}
+	return computeOrganizationStats(ids.organizations, slices.Collect(maps.Values(independent)))
+}
+
+// computeOrganizationStats returns the stats of the commits of the
+// organizations, and of the independent contributors.
+func computeOrganizationStats(organizations map[string]int64, independent []int64) organizationStats {
+	type organization struct {
+		name    string
+		commits int64
+	}
+	var all []organization
+	var total int64
+	for name, commits := range organizations {
+		all = append(all, organization{name, commits})
+		total += commits
+	}
+	for _, commits := range independent {
+		all = append(all, organization{"", commits})
+		total += commits
+	}
+	slices.SortFunc(all, func(a, b organization) int {
+		return cmp.Or(cmp.Compare(b.commits, a.commits), strings.Compare(b.name, a.name))
+	})
+	var result organizationStats
+	var commits int64
+	for _, organization := range all {
+		if 2*commits >= total {
+			break
+		}
+		if result.ElephantFactor == 0 {
+			result.Top, result.TopShare = organization.name, share(organization.commits, total)
+		}
+		result.ElephantFactor++
+		commits += organization.commits
+	}
+	return result
+}
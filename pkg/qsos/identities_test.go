@@ -0,0 +1,128 @@
+package qsos
+
+import "testing"
+
+func TestIdentities(t *testing.T) {
+	type commit struct{ name, email, login string }
+	tests := []struct {
+		name    string
+		commits []commit
+		// contributors are the ones with at least one commit, and active
+		// the ones with at least 2 commits.
+		contributors, active int64
+	}{
+		{
+			name: "same email",
+			commits: []commit{
+				{"Jane Doe", "jane@example.com", ""},
+				{"jdoe", "JANE@example.com ", ""},
+			},
+			contributors: 1, active: 1,
+		},
+		{
+			name: "noreply email and login",
+			commits: []commit{
+				{"Jane Doe", "12345+jdoe@users.noreply.github.com", ""},
+				{"J. Doe", "jane@corp.example", "JDoe"},
+			},
+			contributors: 1, active: 1,
+		},
+		{
+			name: "same full name",
+			commits: []commit{
+				{"Jane  Doe", "jane@home.example", ""},
+				{"jane doe", "jane@work.example", ""},
+			},
+			contributors: 1, active: 1,
+		},
+		{
+			name: "common single word name",
+			commits: []commit{
+				{"root", "a@one.example", ""},
+				{"root", "b@two.example", ""},
+			},
+			contributors: 2, active: 0,
+		},
+		{
+			name: "transitive",
+			commits: []commit{
+				{"Jane Doe", "a@example.com", ""},
+				{"John Roe", "b@example.com", ""},
+				{"Jane Doe", "b@example.com", ""},
+				{"Max Poe", "c@example.com", ""},
+			},
+			contributors: 2, active: 1,
+		},
+	}
+	for _, test := range tests {
+		ids := newIdentities()
+		for _, c := range test.commits {
+			ids.add(c.name, c.email, c.login)
+		}
+		if contributors := ids.active(1); contributors != test.contributors {
+			t.Errorf("%s: contributors = %d, want %d", test.name, contributors, test.contributors)
+		}
+		if active := ids.active(2); active != test.active {
+			t.Errorf("%s: active contributors = %d, want %d", test.name, active, test.active)
+		}
+	}
+}
+
+func TestEmailOrganization(t *testing.T) {
+	tests := []struct {
+		email, organization string
+	}{
+		{"dev@example.com", "example.com"},
+		{"dev@eu.example.com", "example.com"},
+		{"dev@example.co.uk", "example.co.uk"},
+		{"dev@gmail.com", ""},
+		{"dev@apache.org", ""},
+		{"dev@localhost", ""},
+		{"dev", ""},
+	}
+	for _, test := range tests {
+		if organization := emailOrganization(test.email); organization != test.organization {
+			t.Errorf("emailOrganization(%q) = %q, want %q", test.email, organization, test.organization)
+		}
+	}
+}
+
+func TestOrganizationStats(t *testing.T) {
+	tests := []struct {
+		name          string
+		organizations map[string]int64
+		independent   []int64
+		want          organizationStats
+	}{
+		{name: "no commits"},
+		{
+			name:          "one organization",
+			organizations: map[string]int64{"example.com": 60},
+			independent:   []int64{20, 20},
+			want:          organizationStats{ElephantFactor: 1, Top: "example.com", TopShare: 60},
+		},
+		{
+			name:          "independent contributors",
+			organizations: map[string]int64{"example.com": 10},
+			independent:   []int64{40, 30, 20},
+			want:          organizationStats{ElephantFactor: 2, Top: "", TopShare: 40},
+		},
+		{
+			name:          "half exactly",
+			organizations: map[string]int64{"a.example": 50, "b.example": 50},
+			want:          organizationStats{ElephantFactor: 1, Top: "b.example", TopShare: 50},
+		},
+	}
+	for _, test := range tests {
+		if got := computeOrganizationStats(test.organizations, test.independent); got != test.want {
+			t.Errorf("%s: organizations = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+	ids := newIdentities()
+	ids.add("Jane Doe", "jane@gmail.com", "")
+	ids.add("Jane Doe", "jane@proton.me", "")
+	ids.add("John Roe", "john@example.com", "")
+	if got, want := ids.organizationStats(), (organizationStats{ElephantFactor: 1, Top: "", TopShare: 66.67}); got != want {
+		t.Errorf("organizations of the identities = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package qsos
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoSuchRequest is returned when an evaluation request is not in the
+// history.
+var ErrNoSuchRequest = errors.New("no such evaluation request")
+
+// The statuses of the evaluation requests.
+const (
+	RequestQueued  = "queued"
+	RequestRunning = "running"
+	RequestDone    = "done"
+	RequestFailed  = "failed"
+)
+
+// IntakeRequest is a request for the evaluation of a project, queued until
+// the evaluation is done.
+type IntakeRequest struct {
+	ID      string
+	Project string
+	// Profile is the name of the configuration used for the evaluation, or
+	// empty for the default configuration.
+	Profile string
+	// Email is the address notified when the evaluation is done, if any.
+	Email string `json:",omitempty"`
+	// Tags are the tags of the evaluation saved in the history, "requested"
+	// if there is none.
+	Tags      []string `json:",omitempty"`
+	Status    string
+	CreatedAt time.Time
+	// RecordID is the ID of the evaluation in the history, when it is done.
+	RecordID   string    `json:",omitempty"`
+	FinishedAt time.Time `json:",omitzero"`
+	Error      string    `json:",omitempty"`
+}
+
+// ParseRepositoryURL returns the project of a GitHub repository URL, like
+// https://github.com/minio/minio or github.com/minio/minio.git. The projects
+// in the owner/repo format are also accepted.
+func ParseRepositoryURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "://") && strings.HasPrefix(s, "github.com/") {
+		s = "https://" + s
+	}
+	project := s
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil || u.Host != "github.com" && u.Host != "www.github.com" {
+			return "", fmt.Errorf("Invalid repository URL %q. Must be a GitHub URL", s)
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 2 {
+			return "", fmt.Errorf("Invalid repository URL %q. Must be like https://github.com/owner/repo", s)
+		}
+		project = parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+	}
+	if _, _, err := ParseProject(project); err != nil {
+		return "", err
+	}
+	return project, nil
+}
+
+// NewIntakeRequest returns a queued request for the evaluation of the
+// repository at the given URL.
+func NewIntakeRequest(repositoryURL, profile, email string) (*IntakeRequest, error) {
+	project, err := ParseRepositoryURL(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" && !profileNameRe.MatchString(profile) {
+		return nil, fmt.Errorf("Invalid profile name %q", profile)
+	}
+	if email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return nil, fmt.Errorf("Invalid email address %q", email)
+		}
+	}
+	return &IntakeRequest{
+		Project:   project,
+		Profile:   profile,
+		Email:     email,
+		Status:    RequestQueued,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func (h *History) requestsDir() string {
+	return filepath.Join(h.Dir, "requests")
+}
+
+// SaveRequest creates or updates an evaluation request. An ID is generated
+// for a new request.
+func (h *History) SaveRequest(request *IntakeRequest) error {
+	if request.ID == "" {
+		id := make([]byte, 8)
+		rand.Read(id)
+		request.ID = hex.EncodeToString(id)
+	}
+	if err := os.MkdirAll(h.requestsDir(), 0o755); err != nil {
+		return fmt.Errorf("Cannot create requests dir: %w", err)
+	}
+	data, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode request: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(h.requestsDir(), request.ID+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("Cannot write request: %w", err)
+	}
+	return nil
+}
+
+// GetRequest returns the evaluation request with the given ID.
+func (h *History) GetRequest(id string) (*IntakeRequest, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("%w: invalid ID %q", ErrNoSuchRequest, id)
+	}
+	data, err := os.ReadFile(filepath.Join(h.requestsDir(), id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchRequest, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read request: %w", err)
+	}
+	var request IntakeRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("Invalid request %s: %w", id, err)
+	}
+	return &request, nil
+}
+
+// ListRequests returns all the evaluation requests, from the oldest to the
+// newest.
+func (h *History) ListRequests() ([]*IntakeRequest, error) {
+	entries, err := os.ReadDir(h.requestsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return []*IntakeRequest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read requests: %w", err)
+	}
+	requests := []*IntakeRequest{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		request, err := h.GetRequest(id)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests, nil
+}
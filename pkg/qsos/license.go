@@ -0,0 +1,258 @@
+package qsos
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// The classes of the license policy, from the best to the worst.
+const (
+	LicenseApproved       = "approved"
+	LicenseReviewRequired = "review-required"
+	LicenseForbidden      = "forbidden"
+)
+
+var licenseClassRank = map[string]int{
+	LicenseApproved:       0,
+	LicenseReviewRequired: 1,
+	LicenseForbidden:      2,
+}
+
+// LicensePolicy classifies the licenses, by their SPDX identifiers. A
+// license with an exception (like "GPL-2.0-only WITH Classpath-exception-2.0")
+// can be listed as is, else it has the worst class of the license without the
+// exception and of the exception, if it is listed. The licenses not listed
+// require a review.
+type LicensePolicy struct {
+	Approved       []string
+	ReviewRequired []string
+	Forbidden      []string
+}
+
+// LicenseClassification is the class of the license of a project, with the
+// rationale for it.
+type LicenseClassification struct {
+	Expression string
+	Class      string
+	Rationale  string
+}
+
+// ClassifyLicense parses an SPDX license expression, and classifies it with
+// the policy. With OR, the best alternative is kept, as the adopter can
+// choose it. With AND, all the licenses apply, so the worst one is kept.
+func (p *LicensePolicy) ClassifyLicense(expression string) *LicenseClassification {
+	result := &LicenseClassification{Expression: expression}
+	if expression == "" || expression == "NOASSERTION" || expression == "NONE" {
+		result.Class = LicenseReviewRequired
+		result.Rationale = "the license is unknown"
+		return result
+	}
+	expr, err := parseSPDX(expression)
+	if err != nil {
+		result.Class = LicenseReviewRequired
+		result.Rationale = fmt.Sprintf("cannot parse the license expression: %s", err)
+		return result
+	}
+	result.Class, result.Rationale = p.classify(expr)
+	if expr.Op != "" {
+		result.Rationale += " (OR keeps the best alternative, AND the worst license)"
+	}
+	return result
+}
+
+func (p *LicensePolicy) classify(expr *spdxExpr) (string, string) {
+	switch expr.Op {
+	case "OR", "AND":
+		left, leftWhy := p.classify(expr.Left)
+		right, rightWhy := p.classify(expr.Right)
+		keepLeft := licenseClassRank[left] <= licenseClassRank[right]
+		if expr.Op == "AND" {
+			keepLeft = licenseClassRank[left] >= licenseClassRank[right]
+		}
+		if keepLeft {
+			return left, leftWhy
+		}
+		return right, rightWhy
+	}
+
+	id := expr.String()
+	if class := p.classOf(id); class != "" {
+		return class, fmt.Sprintf("%s is classified as %s by the policy", id, class)
+	}
+	if expr.Exception == "" {
+		return LicenseReviewRequired, fmt.Sprintf("%s is not in the policy", id)
+	}
+	// An exception listed in the policy, like Commons-Clause, can restrict
+	// the license: the worst class is kept
+	class, why := LicenseReviewRequired, fmt.Sprintf("%s is not in the policy", expr.License)
+	if licenseClass := p.classOf(expr.License); licenseClass != "" {
+		class, why = licenseClass, fmt.Sprintf("%s is classified as %s by the policy", expr.License, licenseClass)
+	}
+	if exceptionClass := p.classOf(expr.Exception); licenseClassRank[exceptionClass] > licenseClassRank[class] {
+		class, why = exceptionClass, fmt.Sprintf("%s is classified as %s by the policy", expr.Exception, exceptionClass)
+	}
+	return class, fmt.Sprintf("%s (with the %s exception)", why, expr.Exception)
+}
+
+// classOf returns the class of a license, or "" if it is not in the policy.
+// The identifiers are compared case-insensitively, as in the SPDX spec.
+func (p *LicensePolicy) classOf(id string) string {
+	if p == nil {
+		return ""
+	}
+	match := func(list []string) bool {
+		return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, id) })
+	}
+	switch {
+	case match(p.Forbidden):
+		return LicenseForbidden
+	case match(p.ReviewRequired):
+		return LicenseReviewRequired
+	case match(p.Approved):
+		return LicenseApproved
+	}
+	return ""
+}
+
+// spdxExpr is a node of a parsed SPDX expression: either a compound
+// expression (Op is AND or OR), or a license with an optional exception.
+type spdxExpr struct {
+	Op          string
+	Left, Right *spdxExpr
+	License     string
+	Exception   string
+}
+
+func (e *spdxExpr) String() string {
+	switch {
+	case e.Op != "":
+		return "(" + e.Left.String() + " " + e.Op + " " + e.Right.String() + ")"
+	case e.Exception != "":
+		return e.License + " WITH " + e.Exception
+	}
+	return e.License
+}
+
+// parseSPDX parses an SPDX license expression, like
+// "(MIT OR Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0". AND
+// has a higher precedence than OR.
+func parseSPDX(expression string) (*spdxExpr, error) {
+	p := &spdxParser{tokens: tokenizeSPDX(expression)}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeSPDX(expression string) []string {
+	var tokens []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type spdxParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *spdxParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *spdxParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *spdxParser) parseOr() (*spdxExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &spdxExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *spdxParser) parseAnd() (*spdxExpr, error) {
+	left, err := p.parseLicense()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.next()
+		right, err := p.parseLicense()
+		if err != nil {
+			return nil, err
+		}
+		left = &spdxExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *spdxParser) parseLicense() (*spdxExpr, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return expr, nil
+	case token == ")" || isSPDXOperator(token):
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	expr := &spdxExpr{License: token}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.next()
+		exception := p.next()
+		if exception == "" || exception == "(" || exception == ")" || isSPDXOperator(exception) {
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+		expr.Exception = exception
+	}
+	return expr, nil
+}
+
+func isSPDXOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
@@ -0,0 +1,83 @@
+package qsos
+
+import "testing"
+
+func TestClassifyLicense(t *testing.T) {
+	policy := &LicensePolicy{
+		Approved:       []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		ReviewRequired: []string{"GPL-2.0-only", "LGPL-2.1-or-later"},
+		Forbidden:      []string{"AGPL-3.0-only", "Commons-Clause"},
+	}
+	tests := []struct {
+		expression string
+		class      string
+	}{
+		{"MIT", LicenseApproved},
+		{"mit", LicenseApproved},
+		{"", LicenseReviewRequired},
+		{"NOASSERTION", LicenseReviewRequired},
+		{"BSD-3-Clause", LicenseReviewRequired},
+		{"AGPL-3.0-only", LicenseForbidden},
+		{"MIT OR AGPL-3.0-only", LicenseApproved},
+		{"MIT AND AGPL-3.0-only", LicenseForbidden},
+		{"MIT AND (Apache-2.0 OR AGPL-3.0-only)", LicenseApproved},
+		{"(MIT OR GPL-2.0-only) AND AGPL-3.0-only", LicenseForbidden},
+		{"MIT AND Apache-2.0 OR AGPL-3.0-only", LicenseApproved},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", LicenseApproved},
+		{"LGPL-2.1-or-later WITH GCC-exception-3.1", LicenseReviewRequired},
+		{"MIT WITH GCC-exception-3.1", LicenseApproved},
+		{"Apache-2.0 WITH Commons-Clause", LicenseForbidden},
+		{"BSD-3-Clause WITH Commons-Clause", LicenseForbidden},
+		{"MIT OR Apache-2.0 WITH Commons-Clause", LicenseApproved},
+		{"MIT AND", LicenseReviewRequired},
+		{"(MIT", LicenseReviewRequired},
+		{"MIT Apache-2.0", LicenseReviewRequired},
+	}
+	for _, test := range tests {
+		if got := policy.ClassifyLicense(test.expression); got.Class != test.class {
+			t.Errorf("ClassifyLicense(%q) = %s (%s), want %s", test.expression, got.Class, got.Rationale, test.class)
+		}
+	}
+}
+
+func TestParseSPDX(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"MIT", "MIT"},
+		{"MIT OR Apache-2.0", "(MIT OR Apache-2.0)"},
+		{"MIT AND Apache-2.0 OR BSD-3-Clause", "((MIT AND Apache-2.0) OR BSD-3-Clause)"},
+		{"MIT AND (Apache-2.0 OR BSD-3-Clause)", "(MIT AND (Apache-2.0 OR BSD-3-Clause))"},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+	}
+	for _, test := range tests {
+		expr, err := parseSPDX(test.expression)
+		if err != nil {
+			t.Errorf("parseSPDX(%q): %v", test.expression, err)
+			continue
+		}
+		if got := expr.String(); got != test.want {
+			t.Errorf("parseSPDX(%q) = %s, want %s", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestDefaultLicensePolicy(t *testing.T) {
+	tests := []struct {
+		expression string
+		class      string
+	}{
+		{"Apache-2.0", LicenseApproved},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", LicenseApproved},
+		{"EUPL-1.2", LicenseReviewRequired},
+		{"BUSL-1.1", LicenseForbidden},
+		{"Apache-2.0 WITH Commons-Clause", LicenseForbidden},
+	}
+	policy := DefaultConfig().Licenses
+	for _, test := range tests {
+		if got := policy.ClassifyLicense(test.expression); got.Class != test.class {
+			t.Errorf("ClassifyLicense(%q) = %s (%s), want %s", test.expression, got.Class, got.Rationale, test.class)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package qsos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The lite analyzer is a built-in backend for the tech stats, that doesn't
+// need Sonarqube. It uses heuristics on the source files, so its measures are
+// only an approximation of the ones from Sonarqube.
+
+type liteLanguage struct {
+	Name         string
+	LineComments []string
+	BlockComment [2]string
+	Function     *regexp.Regexp
+	Decisions    *regexp.Regexp
+}
+
+var (
+	cDecisions = regexp.MustCompile(`\b(if|for|while|case|catch)\b|&&|\|\||\?`)
+	cLike      = [2]string{"/*", "*/"}
+)
+
+var liteLanguages = map[string]*liteLanguage{
+	".go": {
+		Name:         "go",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`^\s*func\b`),
+		Decisions:    regexp.MustCompile(`\b(if|for|case|select)\b|&&|\|\|`),
+	},
+	".js":  jsLanguage,
+	".jsx": jsLanguage,
+	".mjs": jsLanguage,
+	".ts":  jsLanguage,
+	".tsx": jsLanguage,
+	".java": {
+		Name:         "java",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     cFunction,
+		Decisions:    cDecisions,
+	},
+	".c":   cLanguage,
+	".h":   cLanguage,
+	".cc":  cLanguage,
+	".cpp": cLanguage,
+	".hpp": cLanguage,
+	".cs": {
+		Name:         "cs",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     cFunction,
+		Decisions:    cDecisions,
+	},
+	".py": {
+		Name:         "python",
+		LineComments: []string{"#"},
+		Function:     regexp.MustCompile(`^\s*(async\s+)?def\s`),
+		Decisions:    regexp.MustCompile(`\b(if|elif|for|while|except|and|or)\b`),
+	},
+	".rb": {
+		Name:         "ruby",
+		LineComments: []string{"#"},
+		Function:     regexp.MustCompile(`^\s*def\s`),
+		Decisions:    regexp.MustCompile(`\b(if|elsif|unless|for|while|until|when|rescue)\b|&&|\|\|`),
+	},
+	".php": {
+		Name:         "php",
+		LineComments: []string{"//", "#"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`\bfunction\b`),
+		Decisions:    regexp.MustCompile(`\b(if|elseif|for|foreach|while|case|catch)\b|&&|\|\||\?`),
+	},
+	".rs": {
+		Name:         "rust",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?(async\s+)?(unsafe\s+)?fn\s`),
+		Decisions:    regexp.MustCompile(`\b(if|for|while|loop|match)\b|=>|&&|\|\||\?`),
+	},
+	".kt": {
+		Name:         "kotlin",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`\bfun\s`),
+		Decisions:    regexp.MustCompile(`\b(if|for|while|when|catch)\b|&&|\|\||\?:`),
+	},
+	".scala": {
+		Name:         "scala",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`\bdef\s`),
+		Decisions:    regexp.MustCompile(`\b(if|for|while|case|catch)\b|&&|\|\|`),
+	},
+	".swift": {
+		Name:         "swift",
+		LineComments: []string{"//"},
+		BlockComment: cLike,
+		Function:     regexp.MustCompile(`\bfunc\s`),
+		Decisions:    regexp.MustCompile(`\b(if|guard|for|while|case|catch)\b|&&|\|\||\?\?`),
+	},
+}
+
+var jsLanguage = &liteLanguage{
+	Name:         "javascript",
+	LineComments: []string{"//"},
+	BlockComment: cLike,
+	Function:     regexp.MustCompile(`\bfunction\b|=>\s*\{`),
+	Decisions:    cDecisions,
+}
+
+var cFunction = regexp.MustCompile(`^\s*[\w<>\[\],*&:\s]+\s[*&]?(\w+)\s*\([^;]*\)\s*(const\s*)?(throws [\w.,\s]+)?\{?\s*$`)
+
+var cLanguage = &liteLanguage{
+	Name:         "c",
+	LineComments: []string{"//"},
+	BlockComment: cLike,
+	Function:     cFunction,
+	Decisions:    cDecisions,
+}
+
+var controlKeywords = regexp.MustCompile(`^\s*(\}\s*)?(if|else|for|while|switch|catch|return|do|new|throw)\b`)
+
+const (
+	// liteCacheVersion must be incremented when the heuristics change, to
+	// invalidate the cached metrics.
+	liteCacheVersion = 1
+	// liteBlockSize is the number of lines for a duplicated block.
+	liteBlockSize = 10
+	// liteBrainOverload is the cognitive complexity above which a function
+	// is considered as a brain overload, like in Sonarqube.
+	liteBrainOverload = 15
+	liteLongLine      = 160
+)
+
+// liteFileMetrics are the metrics of a source file.
+type liteFileMetrics struct {
+	Lines         int64
+	Functions     int64
+	Complexity    int64
+	Cognitive     int64
+	BrainOverload int64
+	CodeSmells    int64
+	// Blocks are the hashes of the windows of liteBlockSize code lines,
+	// indexed by their first line, for detecting the duplications.
+	Blocks []uint64
+}
+
+// LiteCollector computes the tech stats with the built-in analyzer.
+type LiteCollector struct {
+	// CacheDir is the directory where the metrics of the files are cached.
+	CacheDir string
+	// Forge hosts the repositories, GitHub when it is nil.
+	Forge *Forge
+	// ToolOutput receives the output of the clones, discarded when it is nil.
+	ToolOutput io.Writer
+}
+
+// clonesSources is always true, the built-in analyzer reads the clones of the
+// repositories.
+func (c *LiteCollector) clonesSources() bool {
+	return true
+}
+
+func (c *LiteCollector) GetSonarStats(ctx context.Context, owner, repo string) (*SonarStats, error) {
+	component := componentName(owner, repo)
+	tmpDir, err := os.MkdirTemp("", component+"-")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := cloneRepository(ctx, c.ToolOutput, c.Forge.cloneURL(owner, repo), tmpDir); err != nil {
+		return nil, err
+	}
+	return c.AnalyzeDir(ctx, tmpDir, component)
+}
+
+// AnalyzeDir computes the tech stats of a git working copy. The metrics of
+// the files are cached by their git blob hash, so only the files that have
+// changed since a previous analysis are analyzed again.
+func (c *LiteCollector) AnalyzeDir(ctx context.Context, dir, component string) (*SonarStats, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--stage", "-z")
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot list git files: %w", err)
+	}
+
+	stats := &SonarStats{}
+	var files []*liteFileMetrics
+	blocks := map[uint64]int{}
+	cached := 0
+	for _, entry := range bytes.Split(output, []byte{0}) {
+		// <mode> <hash> <stage>\t<path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[0] != "100644" && fields[0] != "100755" {
+			continue
+		}
+		lang := liteLanguages[strings.ToLower(filepath.Ext(path))]
+		if lang == nil || isVendored(path) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		metrics, hit, err := c.liteFileMetrics(dir, path, fields[1], lang)
+		if err != nil {
+			return nil, err
+		}
+		if hit {
+			cached++
+		}
+		files = append(files, metrics)
+		for _, block := range metrics.Blocks {
+			blocks[block]++
+		}
+	}
+	slog.Debug("lite analyzer", "component", component, "files", len(files), "cached", cached)
+
+	var duplicated int64
+	for _, metrics := range files {
+		stats.LinesOfCode += metrics.Lines
+		stats.Functions += metrics.Functions
+		stats.CyclomaticComplexity += metrics.Complexity
+		stats.CognitiveComplexity += metrics.Cognitive
+		stats.BrainOverload += metrics.BrainOverload
+		stats.CodeSmells += metrics.CodeSmells
+		duplicated += duplicatedLines(metrics.Blocks, metrics.Lines, blocks)
+	}
+	if stats.LinesOfCode > 0 {
+		stats.DuplicationDensity = roundFloat(100*float64(duplicated)/float64(stats.LinesOfCode), 1)
+	}
+	return stats, nil
+}
+
+func isVendored(path string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(path), "/") {
+		if dir == "vendor" || dir == "node_modules" || dir == "third_party" {
+			return true
+		}
+	}
+	return strings.Contains(path, ".min.")
+}
+
+// liteFileMetrics returns the metrics of a file, from the cache if possible.
+// The boolean is true if the metrics were in the cache.
+func (c *LiteCollector) liteFileMetrics(dir, path, hash string, lang *liteLanguage) (*liteFileMetrics, bool, error) {
+	cachePath := filepath.Join(c.CacheDir, "lite", fmt.Sprintf("v%d", liteCacheVersion), hash[:2], hash+"-"+lang.Name+".json")
+	if data, err := os.ReadFile(cachePath); err == nil {
+		var metrics liteFileMetrics
+		if err := json.Unmarshal(data, &metrics); err == nil {
+			return &metrics, true, nil
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		return nil, false, fmt.Errorf("Cannot read %s: %w", path, err)
+	}
+	metrics := analyzeLiteFile(lang, string(content))
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return nil, false, fmt.Errorf("Cannot encode metrics: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return nil, false, fmt.Errorf("Cannot create cache dir: %w", err)
+	}
+	if err := os.WriteFile(cachePath, data, 0o644); err != nil {
+		return nil, false, fmt.Errorf("Cannot write cache: %w", err)
+	}
+	return metrics, false, nil
+}
+
+func analyzeLiteFile(lang *liteLanguage, content string) *liteFileMetrics {
+	metrics := &liteFileMetrics{}
+	lines := strings.Split(content, "\n")
+	unit := indentationUnit(lines)
+
+	var code []string
+	inBlockComment := false
+	inFunction := false
+	var functionIndent, cognitive int64
+	endFunction := func() {
+		if inFunction && cognitive > liteBrainOverload {
+			metrics.BrainOverload++
+			metrics.CodeSmells++
+		}
+		metrics.Cognitive += cognitive
+		cognitive = 0
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		comment := inBlockComment
+		if inBlockComment {
+			if strings.Contains(trimmed, lang.BlockComment[1]) {
+				inBlockComment = false
+			}
+		} else if lang.BlockComment[0] != "" && strings.HasPrefix(trimmed, lang.BlockComment[0]) {
+			comment = true
+			inBlockComment = !strings.Contains(trimmed[len(lang.BlockComment[0]):], lang.BlockComment[1])
+		}
+		for _, prefix := range lang.LineComments {
+			if strings.HasPrefix(trimmed, prefix) {
+				comment = true
+			}
+		}
+		if comment {
+			if strings.Contains(trimmed, "TODO") || strings.Contains(trimmed, "FIXME") {
+				metrics.CodeSmells++
+			}
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+
+		metrics.Lines++
+		code = append(code, strings.Join(strings.Fields(trimmed), " "))
+		if len(line) > liteLongLine {
+			metrics.CodeSmells++
+		}
+
+		indent := indentation(line) / unit
+		if lang.Function.MatchString(line) && !controlKeywords.MatchString(line) {
+			endFunction()
+			inFunction = true
+			functionIndent = indent
+			metrics.Functions++
+			metrics.Complexity++
+			continue
+		}
+		decisions := int64(len(lang.Decisions.FindAllStringIndex(trimmed, -1)))
+		metrics.Complexity += decisions
+		nesting := max(0, indent-functionIndent-1)
+		cognitive += decisions * (1 + nesting)
+	}
+	endFunction()
+
+	for i := 0; i+liteBlockSize <= len(code); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(code[i:i+liteBlockSize], "\n")))
+		metrics.Blocks = append(metrics.Blocks, h.Sum64())
+	}
+	return metrics
+}
+
+// duplicatedLines returns the number of lines of a file that are in a block
+// that appears several times in the project.
+func duplicatedLines(fileBlocks []uint64, lines int64, counts map[uint64]int) int64 {
+	duplicated := make([]bool, lines)
+	for i, block := range fileBlocks {
+		if counts[block] < 2 {
+			continue
+		}
+		for j := i; j < i+liteBlockSize && j < len(duplicated); j++ {
+			duplicated[j] = true
+		}
+	}
+	var nb int64
+	for _, d := range duplicated {
+		if d {
+			nb++
+		}
+	}
+	return nb
+}
+
+// indentation returns the width of the leading whitespaces of a line, with a
+// tab counting as 4 spaces.
+func indentation(line string) int64 {
+	var width int64
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// indentationUnit guesses the width of one level of indentation in a file.
+func indentationUnit(lines []string) int64 {
+	unit := int64(0)
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if width := indentation(line); width >= 2 && (unit == 0 || width < unit) {
+			unit = width
+		}
+	}
+	if unit == 0 {
+		return 4
+	}
+	return unit
+}
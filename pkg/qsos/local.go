@@ -0,0 +1,119 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// LocalOwner is the owner of the local working copies in the evaluations.
+const LocalOwner = "local"
+
+// EvaluateLocal collects the stats of a local git working copy and computes
+// its scores, like Evaluate. The project is named local/<name of the dir>.
+func EvaluateLocal(ctx context.Context, executor *Executor, config *Config, dir, policy string) (*Evaluation, error) {
+	stats, err := executor.GetLocalStats(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve local statistics: %w", err)
+	}
+	return ScoreStats(config, LocalOwner, localName(dir), stats, policy)
+}
+
+// localName returns the name of the project of a local working copy.
+func localName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Base(dir)
+	}
+	return filepath.Base(abs)
+}
+
+// GetLocalStats collects the stats of a local git working copy, without any
+// forge, for the air-gapped environments. The community stats come from the
+// git history, the security ones from the local checks of the scorecard, and
+// the tech ones from the analyzer. The packages and the advisories are not
+// collected.
+func (e *Executor) GetLocalStats(ctx context.Context, dir string) (_ *ProjectStats, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot find the local dir: %w", err)
+	}
+	owner, repo := LocalOwner, filepath.Base(dir)
+	ctx, end := startSpan(ctx, "collect", projectAttribute(owner, repo))
+	defer func() { end(err) }()
+	var metrics Metrics
+
+	// 1. Get the community stats from the history
+	phaseCtx, done := e.startPhase(ctx, owner, repo, PhaseGitHub)
+	subdir := ""
+	if e.SubdirCommits {
+		subdir = e.Subdir
+	}
+	github, err := gitHistoryStats(phaseCtx, e.ToolOutput, dir, subdir, e.Contributors, e.Bots)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("Git: %w", err)
+	}
+	collected := reportTime()
+	metrics.record("github", collected, github.metrics(collected))
+
+	// 2. Run the local checks of the scorecard
+	phaseCtx, done = e.startPhase(ctx, owner, repo, PhaseScorecard)
+	card, err := e.ScoreCard.AnalyzeDir(phaseCtx, dir)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("ScoreCard: %w", err)
+	}
+	metrics.record("scorecard", reportTime(), card.metrics())
+
+	// 3. Analyze the sources of the working copy
+	phaseCtx, done = e.startPhase(ctx, owner, repo, PhaseSonar)
+	sources, err := e.subdirPath(dir)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+	sonar, err := e.Sonar.AnalyzeDir(phaseCtx, sources, e.component(owner, repo))
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("Sonar: %w", err)
+	}
+	metrics.record("sonar", reportTime(), sonar.metrics())
+
+	stats := &ProjectStats{
+		GitHub:    github,
+		ScoreCard: card,
+		Sonar:     sonar,
+		Metrics:   metrics,
+		Subdir:    e.Subdir,
+	}
+	stats.addWarning("scorecard-local", "the scorecard checks needing the API of a forge were not run on the local working copy")
+
+	// 4. Summarize the README, if the AI service can be reached
+	phaseCtx, done = e.startPhase(ctx, owner, repo, PhaseSummary)
+	stats.Summary, err = e.getLocalSummary(phaseCtx, dir)
+	done(err)
+	if err != nil {
+		slog.Warn("cannot summarize the README", "dir", dir, "err", err)
+		stats.addWarning("summary-unavailable", "the README could not be summarized")
+	}
+	stats.checkWarnings()
+	return stats, nil
+}
+
+func (e *Executor) getLocalSummary(ctx context.Context, dir string) (string, error) {
+	for _, name := range readmeNames {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		summary, err := e.summarize(ctx, string(content))
+		if err != nil {
+			return "", fmt.Errorf("summarize: %w", err)
+		}
+		return summary, nil
+	}
+	return "", fmt.Errorf("no README in %s", dir)
+}
@@ -0,0 +1,160 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// Maintainer is a person the project depends on: an owner of the code in
+// CODEOWNERS, a member of an owner team, or a top committer.
+type Maintainer struct {
+	Login string
+	// Sources tell how the maintainer has been identified: "codeowners",
+	// "team:<org>/<slug>" or "commits".
+	Sources []string
+	// Commits is the number of commits of the last 6 months, if the
+	// maintainer is a top committer.
+	Commits int64
+	// LastActivity is the date of the last commit, if it is known.
+	LastActivity time.Time `json:",omitzero"`
+}
+
+const (
+	// maxTopCommitters is the number of top committers of the last 6
+	// months added to the maintainers.
+	maxTopCommitters = 5
+	// maxMaintainers limits the number of maintainers, and of the requests
+	// for their last activity.
+	maxMaintainers = 15
+)
+
+// codeownersPaths are the locations of the CODEOWNERS file, in the order
+// GitHub looks for it.
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// getMaintainers identifies the maintainers from the CODEOWNERS file, the
+// members of the owner teams (when they are visible with the token), and the
+// top committers, sorted by commits and last activity.
+func (c *GitHubAPICollector) getMaintainers(ctx context.Context, owner, repo string, committers []*Maintainer) ([]*Maintainer, error) {
+	var maintainers []*Maintainer
+	add := func(login, source string) *Maintainer {
+		i := slices.IndexFunc(maintainers, func(m *Maintainer) bool { return strings.EqualFold(m.Login, login) })
+		if i < 0 {
+			maintainers = append(maintainers, &Maintainer{Login: login})
+			i = len(maintainers) - 1
+		}
+		if !slices.Contains(maintainers[i].Sources, source) {
+			maintainers[i].Sources = append(maintainers[i].Sources, source)
+		}
+		return maintainers[i]
+	}
+
+	users, teams, err := c.getCodeowners(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		add(user, "codeowners")
+	}
+	for _, team := range teams {
+		org, slug, _ := strings.Cut(team, "/")
+		members, _, err := c.Client.Teams.ListTeamMembersBySlug(ctx, org, slug, &github.TeamListTeamMembersOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		})
+		if err != nil {
+			slog.Debug("members of the team not visible", "project", owner+"/"+repo, "team", team, "err", err)
+			continue
+		}
+		for _, member := range members {
+			add(member.GetLogin(), "team:"+team)
+		}
+	}
+	slices.SortFunc(committers, func(a, b *Maintainer) int { return cmp.Compare(b.Commits, a.Commits) })
+	for _, committer := range committers[:min(len(committers), maxTopCommitters)] {
+		m := add(committer.Login, "commits")
+		m.Commits = committer.Commits
+		m.LastActivity = committer.LastActivity
+	}
+
+	slices.SortStableFunc(maintainers, func(a, b *Maintainer) int {
+		return cmp.Or(cmp.Compare(b.Commits, a.Commits), b.LastActivity.Compare(a.LastActivity))
+	})
+	maintainers = maintainers[:min(len(maintainers), maxMaintainers)]
+	for _, m := range maintainers {
+		if !m.LastActivity.IsZero() {
+			continue
+		}
+		commits, _, err := c.Client.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+			Author:      m.Login,
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ListCommits for the last activity of %s failed: %w", m.Login, err)
+		}
+		if len(commits) > 0 {
+			m.LastActivity = commits[0].GetCommit().GetCommitter().GetDate().UTC()
+		}
+	}
+	slices.SortStableFunc(maintainers, func(a, b *Maintainer) int {
+		return cmp.Or(cmp.Compare(b.Commits, a.Commits), b.LastActivity.Compare(a.LastActivity))
+	})
+	return maintainers, nil
+}
+
+// getCodeowners returns the users and the teams (as org/slug) of the
+// CODEOWNERS file, if the project has one.
+func (c *GitHubAPICollector) getCodeowners(ctx context.Context, owner, repo string) (users, teams []string, err error) {
+	for _, path := range codeownersPaths {
+		file, _, resp, err := c.Client.Repositories.GetContents(ctx, owner, repo, path, nil)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("GetContents for %s failed: %w", path, err)
+		}
+		if file == nil {
+			continue
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, nil, fmt.Errorf("Cannot decode %s: %w", path, err)
+		}
+		users, teams = parseCodeowners(content)
+		return users, teams, nil
+	}
+	return nil, nil, nil
+}
+
+// parseCodeowners returns the users and the teams of a CODEOWNERS file. The
+// owners given by email are ignored, as they have no login.
+func parseCodeowners(content string) (users, teams []string) {
+	for _, line := range strings.Split(content, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, owner := range fields[1:] {
+			name, ok := strings.CutPrefix(owner, "@")
+			if !ok {
+				continue
+			}
+			if strings.Contains(name, "/") {
+				if !slices.Contains(teams, name) {
+					teams = append(teams, name)
+				}
+			} else if !slices.Contains(users, name) {
+				users = append(users, name)
+			}
+		}
+	}
+	return users, teams
+}
@@ -0,0 +1,68 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+const (
+	// gitHubAPIVersion is the version of the REST API used by go-github.
+	gitHubAPIVersion  = "2022-11-28"
+	sonarScannerImage = "sonarsource/sonar-scanner-cli"
+	scorecardImage    = "gcr.io/openssf/scorecard:stable"
+)
+
+// CollectionMetadata describes how the stats of a project have been
+// collected, for archiving them.
+type CollectionMetadata struct {
+	StartedAt        time.Time
+	FinishedAt       time.Time
+	ToolVersion      string
+	GitHubClient     string
+	GitHubAPIVersion string
+	Analyzer         string
+	SonarqubeVersion string `json:",omitempty"`
+	SonarScanner     string `json:",omitempty"`
+	ScorecardImage   string
+	ScorecardVersion string
+}
+
+// Collect collects the stats of a project, with the metadata of the
+// collection.
+func (e *Executor) Collect(ctx context.Context, owner, repo string) (*RawStats, error) {
+	started := time.Now().UTC()
+	stats, err := e.GetProjectStats(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &CollectionMetadata{
+		StartedAt:        started,
+		FinishedAt:       time.Now().UTC(),
+		ToolVersion:      toolVersion(),
+		GitHubClient:     "go-github " + github.Version,
+		GitHubAPIVersion: gitHubAPIVersion,
+		Analyzer:         e.Analyzer,
+		ScorecardImage:   scorecardImage,
+		ScorecardVersion: stats.ScoreCard.Scorecard.Version,
+	}
+	if sonar, ok := e.Sonar.(*SonarqubeCollector); ok {
+		metadata.SonarScanner = sonarScannerImage
+		metadata.SonarqubeVersion, err = sonar.getSonarqubeVersion(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Sonar: %w", err)
+		}
+	}
+	return &RawStats{Owner: owner, Repo: repo, Stats: stats, Metadata: metadata}, nil
+}
+
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return info.Main.Version
+}
@@ -0,0 +1,274 @@
+package qsos
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Metric is a raw value collected for a project, like the number of stars
+// on GitHub. The scores are computed from the metrics, not from the stats of
+// the collectors.
+type Metric struct {
+	// Name is the name of the metric, in the "source.metric" form (like
+	// "github.stars" or "sonar.ncloc").
+	Name string
+	Unit string
+	// Source is the collector of the metric: "github", "sonar",
+	// "scorecard", "packages" or "advisories".
+	Source string
+	Value  float64
+	// CollectedAt is when the collector has returned the metric.
+	CollectedAt time.Time `json:",omitzero"`
+}
+
+// The units of the metrics. The dates are given in seconds since the Unix
+// epoch.
+const (
+	UnitCount   = "count"
+	UnitLines   = "lines"
+	UnitPercent = "percent"
+	UnitDate    = "date"
+	UnitScore   = "score"
+	UnitSeconds = "seconds"
+)
+
+// Metrics is the registry of the metrics of a project, sorted by name. A
+// metric is missing when its data is not available.
+type Metrics []Metric
+
+// Set adds a metric to the registry, or replaces the metric with the same
+// name.
+func (m *Metrics) Set(metric Metric) {
+	i, found := slices.BinarySearchFunc(*m, metric.Name, func(a Metric, name string) int {
+		return strings.Compare(a.Name, name)
+	})
+	if found {
+		(*m)[i] = metric
+	} else {
+		*m = slices.Insert(*m, i, metric)
+	}
+}
+
+// Get returns the metric with the given name, if it is in the registry.
+func (m Metrics) Get(name string) (Metric, bool) {
+	i, found := slices.BinarySearchFunc(m, name, func(a Metric, name string) int {
+		return strings.Compare(a.Name, name)
+	})
+	if !found {
+		return Metric{}, false
+	}
+	return m[i], true
+}
+
+// Value returns the value of a metric, or 0 if it is missing.
+func (m Metrics) Value(name string) float64 {
+	metric, _ := m.Get(name)
+	return metric.Value
+}
+
+func (m Metrics) int(name string) int64 {
+	return int64(m.Value(name))
+}
+
+// date returns the value of a date metric, or the zero time if it is
+// missing.
+func (m Metrics) date(name string) time.Time {
+	metric, ok := m.Get(name)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(metric.Value), 0).UTC()
+}
+
+// record adds the metrics of a collector to the registry.
+func (m *Metrics) record(source string, at time.Time, values []Metric) {
+	for _, metric := range values {
+		metric.Name = source + "." + metric.Name
+		metric.Source = source
+		metric.CollectedAt = at
+		m.Set(metric)
+	}
+}
+
+func count(name string, value int64) Metric {
+	return Metric{Name: name, Unit: UnitCount, Value: float64(value)}
+}
+
+// metrics returns the metrics of the stats collected at the given time, from
+// which the age of the releases is computed.
+func (s *GitHubStats) metrics(at time.Time) []Metric {
+	metrics := []Metric{
+		count("active_contributors", s.ActiveContributors),
+		count("bot_commits", s.BotCommits),
+		{Name: "bot_commit_share", Unit: UnitPercent, Value: s.BotCommitShare},
+		{Name: "bot_pr_share", Unit: UnitPercent, Value: s.BotPullRequestShare},
+	}
+	if !s.NoStars {
+		metrics = append(metrics, count("stars", s.Stars), count("forks", s.Forks))
+	}
+	if s.ElephantFactor > 0 {
+		metrics = append(metrics, count("elephant_factor", s.ElephantFactor))
+	}
+	if s.Issues > 0 {
+		metrics = append(metrics,
+			count("issues", s.Issues),
+			Metric{Name: "issue_response_time", Unit: UnitSeconds, Value: s.IssueResponseTime.Seconds()})
+	}
+	if len(s.Releases) > 0 {
+		cadence := computeReleaseCadence(s.Releases, at)
+		metrics = append(metrics, count("releases", cadence.Releases))
+		if cadence.Interval > 0 {
+			metrics = append(metrics, Metric{Name: "release_interval", Unit: UnitSeconds, Value: cadence.Interval.Seconds()})
+		}
+		if cadence.Irregularity >= 0 {
+			metrics = append(metrics, Metric{Name: "release_irregularity", Unit: UnitPercent, Value: cadence.Irregularity})
+		}
+		latest, _ := latestRelease(s.Releases)
+		metrics = append(metrics, Metric{Name: "latest_release", Unit: UnitDate, Value: float64(latest.Date.Unix())})
+		if major := majorVersion(s.Releases); major >= 0 {
+			metrics = append(metrics, count("major_version", major))
+		}
+		versioning := computeVersioning(s.Releases, at)
+		metrics = append(metrics, Metric{Name: "semver_share", Unit: UnitPercent, Value: versioning.SemverShare})
+		if versioning.MajorBumps >= 0 {
+			metrics = append(metrics, count("major_bumps", versioning.MajorBumps))
+		}
+	}
+	if s.OpenIssues+s.ClosedIssues > 0 {
+		metrics = append(metrics,
+			count("open_issues", s.OpenIssues),
+			count("closed_issues", s.ClosedIssues),
+			Metric{Name: "open_issue_share", Unit: UnitPercent, Value: s.openIssueShare()},
+			Metric{Name: "backlog_growth", Unit: UnitPercent, Value: s.backlogGrowth()})
+	}
+	if len(s.WeeklyCommits) > 0 && !s.WeeklyCommitsWithBots {
+		var weeks int64
+		for _, nb := range s.WeeklyCommits {
+			if nb > 0 {
+				weeks++
+			}
+		}
+		metrics = append(metrics, count("active_weeks", weeks))
+	}
+	// The unknown dates are missing metrics
+	for name, date := range map[string]time.Time{
+		"first_commit":      s.FirstCommitDate,
+		"last_commit":       s.LastCommitDate,
+		"last_human_commit": s.LastHumanCommitDate,
+	} {
+		if !date.IsZero() {
+			metrics = append(metrics, Metric{Name: name, Unit: UnitDate, Value: float64(date.Unix())})
+		}
+	}
+	return metrics
+}
+
+func (s *SonarStats) metrics() []Metric {
+	return []Metric{
+		{Name: "ncloc", Unit: UnitLines, Value: float64(s.LinesOfCode)},
+		count("functions", s.Functions),
+		count("code_smells", s.CodeSmells),
+		count("brain_overload", s.BrainOverload),
+		count("complexity", s.CyclomaticComplexity),
+		count("cognitive_complexity", s.CognitiveComplexity),
+		{Name: "duplicated_lines_density", Unit: UnitPercent, Value: s.DuplicationDensity},
+	}
+}
+
+func (s *ScoreCardStats) metrics() []Metric {
+	var metrics []Metric
+	for _, check := range s.Checks {
+		metrics = append(metrics, Metric{Name: check.Name, Unit: UnitScore, Value: float64(check.Score)})
+	}
+	return metrics
+}
+
+// metrics returns the popularity sources of the packages, without the ones
+// with no data.
+func (s *PackagesStats) metrics() []Metric {
+	var metrics []Metric
+	for _, metric := range []Metric{
+		count("downloads", s.Downloads),
+		count("dependents", s.Dependents),
+		count("container_pulls", s.ContainerPulls),
+	} {
+		if metric.Value > 0 {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+func (s *AdvisoriesStats) metrics() []Metric {
+	return []Metric{
+		count("published", s.Published),
+		count("with_credits", s.WithCredits),
+		count("with_cvss", s.WithCVSS),
+		count("with_remediation", s.WithRemediation),
+		count("postmortems", int64(len(s.Postmortems))),
+	}
+}
+
+// metrics returns the registry of the metrics of a project. The raw stats
+// saved before the registry was added have no metrics: they are built from
+// the stats of the collectors, without the collection dates.
+func (s *ProjectStats) metrics() Metrics {
+	if len(s.Metrics) > 0 {
+		return s.Metrics
+	}
+	var metrics Metrics
+	if s.GitHub != nil {
+		metrics.record("github", time.Time{}, s.GitHub.metrics(s.collectedAt()))
+	}
+	if s.Sonar != nil {
+		metrics.record("sonar", time.Time{}, s.Sonar.metrics())
+	}
+	if s.ScoreCard != nil {
+		metrics.record("scorecard", time.Time{}, s.ScoreCard.metrics())
+	}
+	if s.Packages != nil {
+		metrics.record("packages", time.Time{}, s.Packages.metrics())
+	}
+	if s.Advisories != nil {
+		metrics.record("advisories", time.Time{}, s.Advisories.metrics())
+	}
+	return metrics
+}
+
+// collectedAt returns when the stats were collected: the time of the raw
+// stats they were read from, the time of their last metric, or now for the
+// stats saved without their metrics.
+func (s *ProjectStats) collectedAt() time.Time {
+	if !s.collected.IsZero() {
+		return s.collected
+	}
+	var at time.Time
+	for _, metric := range s.Metrics {
+		if metric.CollectedAt.After(at) {
+			at = metric.CollectedAt
+		}
+	}
+	if at.IsZero() {
+		return time.Now()
+	}
+	return at
+}
+
+// withSonar returns a copy of the registry with the tech metrics of another
+// git ref.
+func (m Metrics) withSonar(sonar *SonarStats) Metrics {
+	at := time.Time{}
+	if metric, ok := m.Get("sonar.ncloc"); ok {
+		at = metric.CollectedAt
+	}
+	metrics := slices.Clone(m)
+	metrics.record("sonar", at, sonar.metrics())
+	return metrics
+}
+
+// RawMetrics returns the registry of the metrics of a project, built from
+// the stats of the collectors for the stats saved without it.
+func (s *ProjectStats) RawMetrics() Metrics {
+	return s.metrics()
+}
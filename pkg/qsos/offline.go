@@ -0,0 +1,86 @@
+package qsos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrOffline is returned in offline mode for the data that is not in the
+// cache.
+var ErrOffline = errors.New("not in the cache, evaluate it online first")
+
+// statsCachePath returns the file of the cached stats of a project, by forge
+// and by sub-directory.
+func (e *Executor) statsCachePath(owner, repo string) string {
+	name := repo
+	if e.Subdir != "" {
+		name += "@" + url.PathEscape(e.Subdir)
+	}
+	return filepath.Join(e.cacheDir, "stats", e.Forge.orDefault().URL.Host, owner, name+".json")
+}
+
+// cacheStats saves the stats of a project, for the offline evaluations.
+func (e *Executor) cacheStats(owner, repo string, stats *ProjectStats) {
+	if e.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(&RawStats{Owner: owner, Repo: repo, Stats: stats})
+	if err == nil {
+		err = writeFileAtomic(e.statsCachePath(owner, repo), data)
+	}
+	if err != nil {
+		slog.Warn("cannot cache the stats", "project", owner+"/"+repo, "err", err)
+	}
+}
+
+// cachedStats returns the stats of a project saved by its last online
+// evaluation.
+func (e *Executor) cachedStats(owner, repo string) (*ProjectStats, error) {
+	path := e.statsCachePath(owner, repo)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("Cannot evaluate %s/%s offline, its stats are %w", owner, repo, ErrOffline)
+	} else if err != nil {
+		return nil, fmt.Errorf("Cannot read the cached stats: %w", err)
+	}
+	var raw RawStats
+	if err := json.Unmarshal(data, &raw); err != nil || raw.Stats == nil {
+		return nil, fmt.Errorf("Invalid cached stats in %s: %v", path, err)
+	}
+	for _, ref := range e.Refs {
+		if raw.Stats.Refs[ref] == nil {
+			return nil, fmt.Errorf("Cannot evaluate the ref %s of %s/%s offline, its stats are %w", ref, owner, repo, ErrOffline)
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		slog.Info("using the cached stats", "project", owner+"/"+repo, "collected", info.ModTime().Format(time.DateTime))
+	}
+	return raw.Stats, nil
+}
+
+// writeFileAtomic writes a file in the cache, with a rename so that the
+// concurrent evaluations never read a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("Cannot create the cache dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("Cannot write the cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Cannot write the cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Cannot write the cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,79 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// ListOrgProjects returns the repositories of a GitHub organization, in the
+// owner/repo format. The archived repositories are skipped.
+func (e *Executor) ListOrgProjects(ctx context.Context, org string) ([]string, error) {
+	opts := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var projects []string
+	for {
+		repositories, resp, err := e.GitHub.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Repositories.ListByOrg failed: %w", err)
+		}
+		for _, repository := range repositories {
+			if repository.GetArchived() {
+				continue
+			}
+			projects = append(projects, org+"/"+repository.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return projects, nil
+}
+
+// FilterProjects keeps the projects whose repository name matches one of the
+// include patterns (all of them if there are no include patterns), and none
+// of the exclude patterns. The patterns are comma-separated lists of globs,
+// like "qsos-*,twake-*".
+func FilterProjects(projects []string, include, exclude string) ([]string, error) {
+	includes := SplitList(include)
+	excludes := SplitList(exclude)
+	var filtered []string
+	for _, project := range projects {
+		name := path.Base(project)
+		included := len(includes) == 0
+		for _, pattern := range includes {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid pattern %q: %w", pattern, err)
+			}
+			included = included || ok
+		}
+		for _, pattern := range excludes {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid pattern %q: %w", pattern, err)
+			}
+			included = included && !ok
+		}
+		if included {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered, nil
+}
+
+// SplitList splits a comma-separated list, and ignores the empty items.
+func SplitList(patterns string) []string {
+	var list []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			list = append(list, pattern)
+		}
+	}
+	return list
+}
@@ -0,0 +1,159 @@
+package qsos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// The OS and architectures are detected in the names of the release assets,
+// like "tool_1.2.0_linux_x86_64.tar.gz" or "tool-aarch64-apple-darwin.zip".
+var (
+	assetOSPatterns = []struct {
+		OS      string
+		Pattern *regexp.Regexp
+	}{
+		{"linux", regexp.MustCompile(`linux`)},
+		{"darwin", regexp.MustCompile(`darwin|macos|osx|apple`)},
+		{"windows", regexp.MustCompile(`windows|win32|win64|\.exe$|\.msi$`)},
+		{"freebsd", regexp.MustCompile(`freebsd`)},
+		{"android", regexp.MustCompile(`android`)},
+	}
+	assetArchPatterns = []struct {
+		Arch    string
+		Pattern *regexp.Regexp
+	}{
+		{"amd64", regexp.MustCompile(`amd64|x86[_-]64|x64`)},
+		{"arm64", regexp.MustCompile(`arm64|aarch64`)},
+		{"arm", regexp.MustCompile(`armv[67]|armhf|arm32|(^|[^a-z0-9])arm([^a-z0-9]|$)`)},
+		{"386", regexp.MustCompile(`(^|[^a-z0-9])(x86|386|i[36]86)([^a-z0-9]|$)|win32`)},
+		{"riscv64", regexp.MustCompile(`riscv64`)},
+		{"ppc64le", regexp.MustCompile(`ppc64le`)},
+		{"s390x", regexp.MustCompile(`s390x`)},
+	}
+	universalPattern = regexp.MustCompile(`universal`)
+	x8664Pattern     = regexp.MustCompile(`x86[_-]64`)
+)
+
+// assetPlatforms returns the platforms (like "linux/amd64") of a release
+// asset, from its name. When the architecture is not in the name, amd64 is
+// assumed.
+func assetPlatforms(name string) []string {
+	name = strings.ToLower(name)
+	var platforms []string
+	for _, system := range assetOSPatterns {
+		if !system.Pattern.MatchString(name) {
+			continue
+		}
+		var archs []string
+		for _, arch := range assetArchPatterns {
+			if arch.Arch == "386" && x8664Pattern.MatchString(name) {
+				continue
+			}
+			if arch.Pattern.MatchString(name) {
+				archs = append(archs, arch.Arch)
+			}
+		}
+		if system.OS == "darwin" && universalPattern.MatchString(name) {
+			archs = append(archs, "amd64", "arm64")
+		}
+		if len(archs) == 0 {
+			archs = []string{"amd64"}
+		}
+		for _, arch := range archs {
+			platforms = append(platforms, system.OS+"/"+arch)
+		}
+	}
+	return platforms
+}
+
+// getReleasePlatforms returns the platforms covered by the assets of the
+// latest release, or nil if the project has no release.
+func (c *GitHubAPICollector) getReleasePlatforms(ctx context.Context, owner, repo string) ([]string, error) {
+	release, _, err := c.Client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if err != nil {
+		var errResponse *github.ErrorResponse
+		if errors.As(err, &errResponse) && errResponse.Response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("GetLatestRelease failed: %w", err)
+	}
+	var platforms []string
+	for _, asset := range release.Assets {
+		platforms = append(platforms, assetPlatforms(asset.GetName())...)
+	}
+	slices.Sort(platforms)
+	return slices.Compact(platforms), nil
+}
+
+type dockerHubTags struct {
+	Results []struct {
+		Images []struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"images"`
+	} `json:"results"`
+}
+
+// getContainerPlatforms returns the platforms of the manifest of the last
+// pushed tag of the Docker Hub image.
+func (e *Executor) getContainerPlatforms(ctx context.Context, owner, repo string) ([]string, error) {
+	u := dockerHubURL + url.PathEscape(owner) + "/" + url.PathEscape(repo) + "/tags?page_size=1&ordering=last_updated"
+	var tags dockerHubTags
+	found, err := e.getJSON(ctx, u, &tags)
+	if err != nil || !found || len(tags.Results) == 0 {
+		return nil, err
+	}
+	var platforms []string
+	for _, image := range tags.Results[0].Images {
+		if image.OS == "" || image.Architecture == "" || image.OS == "unknown" {
+			continue
+		}
+		platform := image.OS + "/" + image.Architecture
+		if image.Architecture == "arm" && image.Variant != "" {
+			platform += "/" + image.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+	slices.Sort(platforms)
+	return slices.Compact(platforms), nil
+}
+
+// coveredPlatforms returns all the platforms covered by the releases and the
+// container images of a project.
+func coveredPlatforms(stats *ProjectStats) []string {
+	all := slices.Clone(stats.GitHub.ReleasePlatforms)
+	if stats.Packages != nil {
+		all = append(all, stats.Packages.ContainerPlatforms...)
+	}
+	slices.Sort(all)
+	return slices.Compact(all)
+}
+
+// computePlatformsScore scores the percentage of the target platforms of
+// the adopter that are covered by the project. An "arm" target is covered
+// by any variant of arm.
+func computePlatformsScore(stats *ProjectStats, config *Config) int64 {
+	if len(config.TargetPlatforms) == 0 {
+		return 5
+	}
+	covered := coveredPlatforms(stats)
+	var nb int64
+	for _, target := range config.TargetPlatforms {
+		if slices.ContainsFunc(covered, func(p string) bool {
+			return p == target || strings.HasPrefix(p, target+"/")
+		}) {
+			nb++
+		}
+	}
+	coverage := 100 * nb / int64(len(config.TargetPlatforms))
+	return computeScore(coverage, config.Thresholds.Adoption.Platforms, BiggerIsBetter)
+}
@@ -0,0 +1,142 @@
+package qsos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+)
+
+// PolicyInput is the document given as input to the policies.
+type PolicyInput struct {
+	Stats    *ProjectStats
+	Scores   *ProjectScores
+	RedFlags []RedFlag
+}
+
+// PolicyOutput is the part of the policy result that is not a configuration
+// override: the list of reasons for denying the project.
+type PolicyOutput struct {
+	Deny []string
+}
+
+// ApplyPolicy evaluates a policy written in OPA Rego (.rego) or CUE (.cue).
+// The policy can override the thresholds and weights of the configuration
+// (with the same structure as the configuration file), and it can deny the
+// project by giving a list of messages in a deny field, for example when a
+// red flag has been raised. The policy is first evaluated to get the
+// configuration overrides, and then a second time with the new scores to get
+// the deny messages. The given configuration is not modified.
+func ApplyPolicy(path string, stats *ProjectStats, config *Config) (*ProjectScores, []string, error) {
+	config, err := config.Clone()
+	if err != nil {
+		return nil, nil, err
+	}
+	scores, err := ComputeScores(stats, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := evalPolicy(path, &PolicyInput{Stats: stats, Scores: scores, RedFlags: CheckRedFlags(stats, config)})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(output, config); err != nil {
+		return nil, nil, fmt.Errorf("Invalid configuration in policy: %w", err)
+	}
+
+	scores, err = ComputeScores(stats, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err = evalPolicy(path, &PolicyInput{Stats: stats, Scores: scores, RedFlags: CheckRedFlags(stats, config)})
+	if err != nil {
+		return nil, nil, err
+	}
+	var result PolicyOutput
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, nil, fmt.Errorf("Invalid deny in policy: %w", err)
+	}
+	return scores, result.Deny, nil
+}
+
+func evalPolicy(path string, input *PolicyInput) ([]byte, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot encode policy input: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case ".rego":
+		return evalRego(path, data)
+	case ".cue":
+		return evalCUE(path, data)
+	default:
+		return nil, fmt.Errorf("Unsupported policy format: %s", path)
+	}
+}
+
+type opaEvalResult struct {
+	Result []struct {
+		Expressions []struct {
+			Value json.RawMessage
+		}
+	}
+}
+
+// evalRego evaluates the data.qsos document of a Rego policy with OPA.
+func evalRego(path string, input []byte) ([]byte, error) {
+	cmd := exec.Command("opa", "eval", "--format=json", "--stdin-input", "--data", path, "data.qsos")
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot run opa: %w", err)
+	}
+	var result opaEvalResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("Unexpected output from opa: %w", err)
+	}
+	if len(result.Result) == 0 || len(result.Result[0].Expressions) == 0 {
+		return nil, fmt.Errorf("No qsos package in policy %s", path)
+	}
+	return result.Result[0].Expressions[0].Value, nil
+}
+
+var cuePackageRegexp = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+
+// evalCUE exports a CUE policy, unified with the input placed in an input
+// field.
+func evalCUE(path string, input []byte) ([]byte, error) {
+	policy, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read policy: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp("", "qsos-policy-")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// JSON is valid CUE, but the input file must be in the same package as
+	// the policy.
+	var content bytes.Buffer
+	if m := cuePackageRegexp.FindSubmatch(policy); m != nil {
+		fmt.Fprintf(&content, "package %s\n\n", m[1])
+	}
+	content.WriteString("input: ")
+	content.Write(input)
+	inputPath := filepath.Join(tmpDir, "input.cue")
+	if err := os.WriteFile(inputPath, content.Bytes(), 0o600); err != nil {
+		return nil, fmt.Errorf("Cannot write policy input: %w", err)
+	}
+
+	cmd := exec.Command("cue", "export", "--out=json", path, inputPath)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot run cue: %w", err)
+	}
+	return output, nil
+}
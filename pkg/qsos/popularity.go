@@ -0,0 +1,122 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// PackagesStats are the stats of the packages published from a repository,
+// used for the popularity. A value of 0 means that the data is not
+// available.
+type PackagesStats struct {
+	// Downloads is the number of downloads on the registries, for the last
+	// period given by the registries (usually the last month).
+	Downloads int64
+	// Dependents is the number of repositories depending on the packages.
+	Dependents int64
+	// ContainerPulls is the number of pulls of the Docker Hub image with the
+	// same name as the repository.
+	ContainerPulls int64
+	// ContainerPlatforms are the platforms of the Docker Hub image.
+	ContainerPlatforms []string
+}
+
+const (
+	packagesLookupURL = "https://packages.ecosyste.ms/api/v1/packages/lookup"
+	dockerHubURL      = "https://hub.docker.com/v2/repositories/"
+)
+
+type ecosystemsPackage struct {
+	Downloads           int64 `json:"downloads"`
+	DependentReposCount int64 `json:"dependent_repos_count"`
+}
+
+type dockerHubRepository struct {
+	PullCount int64 `json:"pull_count"`
+}
+
+func (e *Executor) GetPackagesStats(ctx context.Context, owner, repo string) (*PackagesStats, error) {
+	stats := &PackagesStats{}
+
+	lookup := packagesLookupURL + "?" + url.Values{
+		"repository_url": []string{e.Forge.RepositoryURL(owner, repo)},
+	}.Encode()
+	var packages []ecosystemsPackage
+	found, err := e.getJSON(ctx, lookup, &packages)
+	if err != nil {
+		return nil, fmt.Errorf("packages lookup: %w", err)
+	}
+	if found {
+		for _, pkg := range packages {
+			stats.Downloads += pkg.Downloads
+			stats.Dependents += pkg.DependentReposCount
+		}
+	}
+
+	var image dockerHubRepository
+	found, err = e.getJSON(ctx, dockerHubURL+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/", &image)
+	if err != nil {
+		return nil, fmt.Errorf("Docker Hub: %w", err)
+	}
+	if found {
+		stats.ContainerPulls = image.PullCount
+		stats.ContainerPlatforms, err = e.getContainerPlatforms(ctx, owner, repo)
+		if err != nil {
+			slog.Warn("platforms of the image not available", "project", owner+"/"+repo, "err", err)
+		}
+	}
+	return stats, nil
+}
+
+// getJSON decodes the JSON response of a GET request. It returns false if
+// the resource was not found.
+func (e *Executor) getJSON(ctx context.Context, u string, data any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, fmt.Errorf("Cannot create request: %w", err)
+	}
+	res, err := e.HTTP.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return false, fmt.Errorf("invalid response: %w", err)
+	}
+	return true, nil
+}
+
+// popularityMetrics are the metrics of the popularity sources.
+var popularityMetrics = map[string]string{
+	"stars":      "github.stars",
+	"forks":      "github.forks",
+	"downloads":  "packages.downloads",
+	"dependents": "packages.dependents",
+	"pulls":      "packages.container_pulls",
+}
+
+// popularitySources returns the values of the popularity sources for which
+// there is some data.
+func popularitySources(stats *ProjectStats) map[string]int64 {
+	return popularityValues(stats.metrics())
+}
+
+func popularityValues(metrics Metrics) map[string]int64 {
+	sources := map[string]int64{}
+	for name, metric := range popularityMetrics {
+		if _, ok := metrics.Get(metric); ok {
+			sources[name] = metrics.int(metric)
+		}
+	}
+	return sources
+}
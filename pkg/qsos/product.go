@@ -0,0 +1,148 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Product is a product spanning several repositories, like a server, its
+// clients and its documentation, evaluated as a whole.
+type Product struct {
+	Name  string
+	Repos []ProductRepo
+}
+
+// ProductRepo is a repository of a product. Its weight in the scores of the
+// product is 1 by default.
+type ProductRepo struct {
+	// Project is in the owner/repo format, or the URL of the repository.
+	Project string
+	Weight  int64
+}
+
+// ReadProduct reads the JSON definition of a product. The lines starting
+// with // are comments.
+func ReadProduct(path string) (*Product, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read product file: %w", err)
+	}
+	product := &Product{}
+	if err := json.Unmarshal(stripComments(data), product); err != nil {
+		return nil, fmt.Errorf("Invalid product file: %w", err)
+	}
+	if product.Name == "" {
+		return nil, fmt.Errorf("Invalid product file: the name is required")
+	}
+	if len(product.Repos) == 0 {
+		return nil, fmt.Errorf("Invalid product file: no repository")
+	}
+	for i := range product.Repos {
+		repo := &product.Repos[i]
+		if repo.Project == "" {
+			return nil, fmt.Errorf("Invalid product file: the project of the repository %d is required", i+1)
+		}
+		if repo.Weight < 0 {
+			return nil, fmt.Errorf("Invalid product file: negative weight for %s", repo.Project)
+		}
+		if repo.Weight == 0 {
+			repo.Weight = 1
+		}
+	}
+	return product, nil
+}
+
+// ProductEvaluation has the scores of the repositories of a product, and the
+// scores of the product.
+type ProductEvaluation struct {
+	Name  string
+	Repos []*ProductRepoScores
+	// Scores are the weighted averages of the scores of the evaluated
+	// repositories, by criterion, and for the overall score.
+	Scores map[string]float64
+	// RedFlags are the red flags of the repositories, by project.
+	RedFlags map[string][]RedFlag `json:",omitempty"`
+}
+
+type ProductRepoScores struct {
+	Project string
+	Weight  int64
+	// Scores are the scores of the criteria and the overall score, nil if
+	// the repository could not be evaluated.
+	Scores map[string]float64
+}
+
+// AggregateProduct computes the scores of a product from the evaluations of
+// its repositories, given in the same order. The repositories without an
+// evaluation (nil) are not counted.
+func AggregateProduct(product *Product, evaluations []*Evaluation) *ProductEvaluation {
+	result := &ProductEvaluation{Name: product.Name, Scores: map[string]float64{}, RedFlags: map[string][]RedFlag{}}
+	sums := map[string]float64{}
+	var totalWeight int64
+	for i, repo := range product.Repos {
+		scores := &ProductRepoScores{Project: repo.Project, Weight: repo.Weight}
+		result.Repos = append(result.Repos, scores)
+		if i >= len(evaluations) || evaluations[i] == nil {
+			continue
+		}
+		scores.Scores = evaluationScores(evaluations[i])
+		for name, score := range scores.Scores {
+			sums[name] += score * float64(repo.Weight)
+		}
+		totalWeight += repo.Weight
+		if len(evaluations[i].RedFlags) > 0 {
+			result.RedFlags[repo.Project] = evaluations[i].RedFlags
+		}
+	}
+	if totalWeight == 0 {
+		return result
+	}
+	for name, sum := range sums {
+		result.Scores[name] = roundFloat(sum/float64(totalWeight), 2)
+	}
+	return result
+}
+
+// PrintProduct prints the scores of the repositories of a product side by
+// side, with the scores of the product in the last column.
+func PrintProduct(w io.Writer, product *ProductEvaluation, opts *ReportOptions) {
+	lang := opts.language()
+	fmt.Fprintf(w, "\n=== %s ===\n", opts.colorize(colorBold, product.Name))
+	for _, repo := range product.Repos {
+		for _, flag := range product.RedFlags[repo.Project] {
+			fmt.Fprintf(w, "%s: %s: %s\n", opts.colorize(colorRed, lang.tr("RED FLAG")), repo.Project, flag.Message)
+		}
+	}
+	header := []string{"criterion"}
+	for _, repo := range product.Repos {
+		header = append(header, fmt.Sprintf("%s (x%d)", repo.Project, repo.Weight))
+	}
+	table := newTextTable(opts, append(header, "product")...)
+	for i := range len(product.Repos) + 1 {
+		table.alignRight(i + 1)
+	}
+	empty := &ProjectScores{Community: &CommunityScores{}, Tech: &TechScores{}, Security: &SecurityScores{}}
+	for _, criterion := range append(criteriaNames(empty), "overall") {
+		cells := []string{criterion}
+		for _, repo := range product.Repos {
+			score, ok := repo.Scores[criterion]
+			switch {
+			case !ok:
+				cells = append(cells, "-")
+			case criterion == "overall":
+				cells = append(cells, opts.formatOverall(score))
+			default:
+				cells = append(cells, opts.formatScore(int64(score)))
+			}
+		}
+		if score, ok := product.Scores[criterion]; ok {
+			cells = append(cells, opts.formatOverall(score))
+		} else {
+			cells = append(cells, "-")
+		}
+		table.add(cells...)
+	}
+	table.print(w)
+}
@@ -0,0 +1,115 @@
+package qsos
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// The phases of the evaluation of a project.
+const (
+	PhaseGitHub    = "github"
+	PhaseScorecard = "scorecard"
+	PhaseSonar     = "sonar"
+	PhaseSummary   = "summary"
+	PhasePackages  = "packages"
+	PhaseRefs      = "refs"
+	// PhaseAdvisories is only run with an advisories collector.
+	PhaseAdvisories = "advisories"
+)
+
+// The steps of the phases. All the phases have a start and a done step, the
+// others are sent by the long phases.
+const (
+	// StepPage is sent for each page of commits or pull requests fetched.
+	StepPage = "page"
+	// StepRetry is sent while GitHub computes its statistics.
+	StepRetry = "retry"
+	// StepScan is sent periodically while sonar-scanner-cli runs.
+	StepScan = "scan"
+	// StepPoll is sent for each attempt to get the Sonarqube measures.
+	StepPoll  = "poll"
+	StepStart = "start"
+	StepDone  = "done"
+)
+
+// scanProgressInterval is the interval between the scan steps.
+const scanProgressInterval = 5 * time.Second
+
+// ProgressEvent is a step of the evaluation of a project.
+type ProgressEvent struct {
+	// Project is owner/repo, or the component (owner-repo, and the ref if
+	// any) in the scan and poll steps.
+	Project string
+	Phase   string
+	Step    string
+	// Count is the number of pages fetched, or of attempts.
+	Count int `json:",omitempty"`
+	// Total is the maximal count, if it is known.
+	Total int `json:",omitempty"`
+	// Elapsed is the duration since the start of the phase, for the done
+	// steps, or since the start of the scan.
+	Elapsed time.Duration `json:",omitempty"`
+}
+
+// ProgressFunc receives the progress of the evaluations. The GitHub, scorecard
+// and sonar phases run concurrently, it must be safe for concurrent use.
+type ProgressFunc func(event *ProgressEvent)
+
+// IsFor tells if the event is a step of the evaluation of a project, with
+// the component of the project in the scan and poll steps.
+func (e *ProgressEvent) IsFor(owner, repo string) bool {
+	component := componentName(owner, repo)
+	return e.Project == owner+"/"+repo || e.Project == component || strings.HasPrefix(e.Project, component+"-")
+}
+
+func (fn ProgressFunc) report(event ProgressEvent) {
+	if fn != nil {
+		fn(&event)
+	}
+}
+
+// start reports the start of a phase, and returns the function reporting
+// its end.
+func (fn ProgressFunc) start(owner, repo, phase string) func() {
+	if fn == nil {
+		return func() {}
+	}
+	project := owner + "/" + repo
+	start := time.Now()
+	fn.report(ProgressEvent{Project: project, Phase: phase, Step: StepStart})
+	return func() {
+		fn.report(ProgressEvent{Project: project, Phase: phase, Step: StepDone, Elapsed: time.Since(start)})
+	}
+}
+
+// startPhase reports the start of a phase of the evaluation of a project, and
+// starts its span. The returned function ends both, with the error of the
+// phase.
+func (e *Executor) startPhase(ctx context.Context, owner, repo, phase string) (context.Context, func(error)) {
+	done := e.Progress.start(owner, repo, phase)
+	ctx, end := startSpan(ctx, phase, projectAttribute(owner, repo))
+	return ctx, func(err error) {
+		done()
+		end(err)
+	}
+}
+
+// SetProgress sets the function receiving the progress of the evaluations,
+// for the executor and its collectors.
+func (e *Executor) SetProgress(fn ProgressFunc) {
+	e.Progress = fn
+	switch c := e.GitHubStats.(type) {
+	case *GitHubAPICollector:
+		c.Progress = fn
+	case *GitLabCollector:
+		c.Progress = fn
+	case *GiteaCollector:
+		c.Progress = fn
+	case *BitbucketCollector:
+		c.Progress = fn
+	}
+	if c, ok := e.Sonar.(*SonarqubeCollector); ok {
+		c.Progress = fn
+	}
+}
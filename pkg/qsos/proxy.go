@@ -0,0 +1,97 @@
+package qsos
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxyConfig returns the proxies given by the env variables, like
+// http.ProxyFromEnvironment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY), with
+// ALL_PROXY when the others are not set, like curl and git. The proxies can be
+// HTTP or SOCKS5 proxies.
+func proxyConfig() *httpproxy.Config {
+	config := httpproxy.FromEnvironment()
+	all := cmp.Or(os.Getenv("ALL_PROXY"), os.Getenv("all_proxy"))
+	config.HTTPProxy = cmp.Or(config.HTTPProxy, all)
+	config.HTTPSProxy = cmp.Or(config.HTTPSProxy, all)
+	return config
+}
+
+// NewTransport returns the transport of the HTTP clients, with the proxies
+// of the env variables.
+func NewTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxy := proxyConfig().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+	return transport
+}
+
+// containerProxyEnv returns the docker run arguments giving the proxies to a
+// container, and the env variables of the docker command with their values.
+// The values are not in the arguments, since they may have credentials.
+func containerProxyEnv() (args, env []string) {
+	config := proxyConfig()
+	for _, v := range []struct{ name, value string }{
+		{"HTTPS_PROXY", config.HTTPSProxy},
+		{"HTTP_PROXY", config.HTTPProxy},
+		{"NO_PROXY", config.NoProxy},
+	} {
+		if v.value != "" {
+			args = append(args, "-e", v.name)
+			env = append(env, v.name+"="+v.value)
+		}
+	}
+	return args, env
+}
+
+// javaProxyOptions returns the options of the JVM for the proxies, for the
+// Sonarqube scanner which ignores the env variables. The credentials of the
+// proxies are not supported.
+func javaProxyOptions() string {
+	config := proxyConfig()
+	var options []string
+	socks := false
+	for _, p := range []struct{ prefix, value string }{
+		{"https", config.HTTPSProxy},
+		{"http", config.HTTPProxy},
+	} {
+		u, err := url.Parse(p.value)
+		if p.value == "" || err != nil || u.Hostname() == "" {
+			continue
+		}
+		if strings.HasPrefix(u.Scheme, "socks") {
+			if socks {
+				continue
+			}
+			socks = true
+			options = append(options, "-DsocksProxyHost="+u.Hostname(), "-DsocksProxyPort="+cmp.Or(u.Port(), "1080"))
+			continue
+		}
+		options = append(options, "-D"+p.prefix+".proxyHost="+u.Hostname(), "-D"+p.prefix+".proxyPort="+cmp.Or(u.Port(), "80"))
+	}
+	if config.NoProxy != "" && len(options) > 0 {
+		var hosts []string
+		for _, host := range strings.Split(config.NoProxy, ",") {
+			host = strings.TrimSpace(host)
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if strings.HasPrefix(host, ".") {
+				host = "*" + host
+			}
+			if host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+		options = append(options, "-Dhttp.nonProxyHosts="+strings.Join(hosts, "|"))
+	}
+	return strings.Join(options, " ")
+}
@@ -0,0 +1,40 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// getPublicDataStats reads the community stats of a project from a mirror of
+// precomputed public data (like the ones derived from GH Archive or the
+// OpenSSF BigQuery exports). The mirror serves one JSON document per project,
+// at <base URL>/<owner>/<repo>.json, with the same fields as GitHubStats. It
+// returns nil if the project is not in the mirror.
+func (c *GitHubAPICollector) getPublicDataStats(ctx context.Context, owner, repo string) (*GitHubStats, error) {
+	cloned := *c.PublicDataURL
+	cloned.Path = path.Join(cloned.Path, owner, repo+".json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloned.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create request: %w", err)
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+
+	var stats GitHubStats
+	if err := json.NewDecoder(res.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,70 @@
+// Package qsos evaluates open source projects, with the QSOS method: it
+// collects stats about the community, the code and the security of a
+// project, and computes scores from 1 to 5 for several criteria.
+//
+// Collect and Score are the entry points for embedding the evaluation in
+// other Go programs. Score does not need the network, so the stats can be
+// collected once, and scored again later with other configurations.
+package qsos
+
+import "context"
+
+// CollectOptions are the options for collecting the stats of a project.
+type CollectOptions struct {
+	// Executor collects the stats. When nil, it is created from the env
+	// variables (see NewExecutorFromEnv).
+	Executor *Executor
+	// Refs are other git refs for which the tech stats are also collected.
+	Refs []string
+	// Subdir restricts the tech stats to a sub-directory of the repository,
+	// and with SubdirCommits the commits of the community stats.
+	Subdir        string
+	SubdirCommits bool
+}
+
+// Collect collects the stats of a GitHub project, with the metadata of the
+// collection. The collection stops when the context is done.
+func Collect(ctx context.Context, owner, repo string, opts *CollectOptions) (*RawStats, error) {
+	if opts == nil {
+		opts = &CollectOptions{}
+	}
+	executor := opts.Executor
+	if executor == nil {
+		var err error
+		if executor, err = NewExecutorFromEnv(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Refs != nil || opts.Subdir != "" {
+		cloned := *executor
+		if opts.Refs != nil {
+			cloned.Refs = opts.Refs
+		}
+		if opts.Subdir != "" {
+			cloned.Subdir, cloned.SubdirCommits = opts.Subdir, opts.SubdirCommits
+		}
+		executor = &cloned
+	}
+	return executor.Collect(ctx, owner, repo)
+}
+
+// ScoreOptions are the options for computing the scores of a project.
+type ScoreOptions struct {
+	// Config has the thresholds and weights. When nil, the default
+	// configuration is used.
+	Config *Config
+	// Policy is the path of an optional Rego or CUE policy.
+	Policy string
+}
+
+// Score computes the scores of a project from its stats.
+func Score(owner, repo string, stats *ProjectStats, opts *ScoreOptions) (*Evaluation, error) {
+	if opts == nil {
+		opts = &ScoreOptions{}
+	}
+	config := opts.Config
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return ScoreStats(config, owner, repo, stats, opts.Policy)
+}
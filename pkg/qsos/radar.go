@@ -0,0 +1,63 @@
+package qsos
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// The radar is wider than high, for the labels of the criteria.
+const (
+	radarWidth  = 720
+	radarHeight = 420
+	radarRadius = 150
+)
+
+// WriteRadarSVG writes a radar chart of the scores of the criteria of an
+// evaluation, with one axis per criterion, from 0 at the center to 5.
+func WriteRadarSVG(w io.Writer, evaluation *Evaluation) error {
+	criteria := evaluation.Scores.Criteria()
+	cx, cy := float64(radarWidth)/2, float64(radarHeight)/2+10
+	point := func(i int, value float64) (float64, float64) {
+		angle := 2*math.Pi*float64(i)/float64(len(criteria)) - math.Pi/2
+		r := radarRadius * value / 5
+		return cx + r*math.Cos(angle), cy + r*math.Sin(angle)
+	}
+	polygon := func(value func(i int) float64) string {
+		var points []string
+		for i := range criteria {
+			x, y := point(i, value(i))
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		return strings.Join(points, " ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n", radarWidth, radarHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="20" text-anchor="middle" font-size="14">%s (%.2f)</text>`+"\n",
+		radarWidth/2, html.EscapeString(evaluation.Name()), evaluation.Scores.Overall)
+	for level := 1; level <= 5; level++ {
+		fmt.Fprintf(&b, `<polygon points="%s" fill="none" stroke="#cccccc"/>`+"\n",
+			polygon(func(int) float64 { return float64(level) }))
+	}
+	for i, c := range criteria {
+		x, y := point(i, 5)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#cccccc"/>`+"\n", cx, cy, x, y)
+		anchor := "middle"
+		switch {
+		case x > cx+1:
+			anchor = "start"
+		case x < cx-1:
+			anchor = "end"
+		}
+		lx, ly := point(i, 5.6)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="%s">%s</text>`+"\n", lx, ly+4, anchor, html.EscapeString(c.Name))
+	}
+	fmt.Fprintf(&b, `<polygon points="%s" fill="#1a9850" fill-opacity="0.3" stroke="#1a9850" stroke-width="2"/>`+"\n",
+		polygon(func(i int) float64 { return float64(*criteria[i].Score) }))
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
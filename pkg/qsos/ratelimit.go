@@ -0,0 +1,141 @@
+package qsos
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitTransport paces the requests to the GitHub API with the rate limit
+// headers of the responses: when the remaining requests of a resource (core,
+// search or graphql) are exhausted, the next requests wait for the reset of
+// the rate limit, instead of failing.
+type rateLimitTransport struct {
+	Next http.RoundTripper
+
+	mu     sync.Mutex
+	limits map[string]*rateLimit
+}
+
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+	// warned is set when the low budget has been logged, once by reset.
+	warned bool
+}
+
+// wait returns how long the requests must wait for the reset, 0 if there are
+// enough remaining requests. A reserve of 1% of the limit is kept for the
+// concurrent requests, and the last request is kept since go-github rejects
+// the requests itself once the rate limit is exhausted.
+func (l *rateLimit) wait() time.Duration {
+	if l.remaining > max(l.limit/100, 1) {
+		return 0
+	}
+	return max(time.Until(l.reset)+time.Second, 0)
+}
+
+// githubResource returns the rate limit resource of a request, before its
+// response gives it.
+func githubResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := githubResource(req)
+	if err := t.pace(req, resource); err != nil {
+		return nil, err
+	}
+	res, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	exhausted := t.update(res, resource)
+
+	// Retry once after the reset if the rate limit is exceeded anyway, by
+	// other clients of the same token
+	if !exhausted || (req.Body != nil && req.GetBody == nil) {
+		return res, nil
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if err := t.pace(req, resource); err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	res, err = t.Next.RoundTrip(retry)
+	if err != nil {
+		return nil, err
+	}
+	t.update(res, resource)
+	return res, nil
+}
+
+// pace waits for the reset of the rate limit of a resource if needed.
+func (t *rateLimitTransport) pace(req *http.Request, resource string) error {
+	t.mu.Lock()
+	var wait time.Duration
+	var limit rateLimit
+	if l := t.limits[resource]; l != nil {
+		wait, limit = l.wait(), *l
+	}
+	t.mu.Unlock()
+	if wait == 0 {
+		return nil
+	}
+	slog.Warn("GitHub rate limit exhausted, waiting for its reset", "resource", resource, "limit", limit.limit, "wait", wait.Round(time.Second), "reset", limit.reset.Local().Format(time.TimeOnly))
+	return sleep(req.Context(), wait)
+}
+
+// update records the rate limit of a response. It returns true if the
+// request has been rejected by the rate limit.
+func (t *rateLimitTransport) update(res *http.Response, resource string) bool {
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	limit, _ := strconv.Atoi(res.Header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if r := res.Header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limits == nil {
+		t.limits = map[string]*rateLimit{}
+	}
+	l := t.limits[resource]
+	if l == nil {
+		l = &rateLimit{}
+		t.limits[resource] = l
+	}
+	resetTime := time.Unix(reset, 0)
+	if !resetTime.Equal(l.reset) {
+		l.warned = false
+	}
+	l.limit, l.remaining, l.reset = limit, remaining, resetTime
+	// Warn once when 10% of the rate limit remains, with the longest wait
+	// if the evaluations need more requests
+	if !l.warned && remaining < limit/10 {
+		l.warned = true
+		slog.Warn("GitHub rate limit is low", "resource", resource, "remaining", remaining, "limit", limit, "reset", resetTime.Local().Format(time.TimeOnly), "max_wait", max(time.Until(resetTime), 0).Round(time.Second))
+	}
+	return remaining == 0 && (res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests)
+}
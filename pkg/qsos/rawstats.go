@@ -0,0 +1,79 @@
+package qsos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RawStats are the stats collected for a project, that can be saved and
+// scored again later, with other thresholds and weights.
+type RawStats struct {
+	Owner string
+	Repo  string
+	Stats *ProjectStats
+	// Metadata is only set by the collect subcommand.
+	Metadata *CollectionMetadata `json:",omitempty"`
+}
+
+// WriteRawStats saves the raw stats of the evaluations to a JSON file.
+func WriteRawStats(path string, evaluations []*Evaluation) error {
+	var raw []*RawStats
+	for _, evaluation := range evaluations {
+		raw = append(raw, &RawStats{Owner: evaluation.Owner, Repo: evaluation.Repo, Stats: evaluation.Stats})
+	}
+	return WriteRawStatsFile(path, raw)
+}
+
+// WriteRawStatsFile writes raw stats to a JSON file, or to the standard
+// output if path is "-".
+func WriteRawStatsFile(path string, raw []*RawStats) error {
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode raw stats: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot write raw stats: %w", err)
+	}
+	return nil
+}
+
+// ReadRawStats reads a file of raw stats. The JSON reports can also be read,
+// as they have the raw stats of the projects.
+func ReadRawStats(path string) ([]*RawStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read raw stats: %w", err)
+	}
+	// The raw stats are either a list, or a JSON report with the evaluations.
+	var raw []*RawStats
+	var generated time.Time
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var report struct {
+			GeneratedAt time.Time
+			Evaluations []*RawStats
+		}
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("Invalid raw stats: %w", err)
+		}
+		raw, generated = report.Evaluations, report.GeneratedAt
+	}
+	for _, r := range raw {
+		if r.Stats == nil || r.Stats.GitHub == nil || r.Stats.Sonar == nil || r.Stats.ScoreCard == nil {
+			return nil, fmt.Errorf("Invalid raw stats: missing stats for %s/%s", r.Owner, r.Repo)
+		}
+		// The stats are scored at the time of their collection
+		r.Stats.collected = generated
+		if r.Metadata != nil && !r.Metadata.FinishedAt.IsZero() {
+			r.Stats.collected = r.Metadata.FinishedAt
+		}
+	}
+	return raw, nil
+}
@@ -0,0 +1,147 @@
+package qsos
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RedFlag is a serious issue of a project, raised by a rule independently of
+// the scores, like a project without any license.
+type RedFlag struct {
+	// Code is the stable identifier of the rule, like "inactive".
+	Code    string
+	Message string
+}
+
+// RedFlagRules are the settings of the rules raising the red flags.
+type RedFlagRules struct {
+	// Inactivity is the duration without commits (in nanoseconds, like the
+	// thresholds) after which a project is inactive.
+	Inactivity int64
+	// MinContributors is the minimal number of active contributors; a
+	// project with less active contributors depends on a single maintainer.
+	MinContributors int64
+	// MaxOrganizationShare is the maximal percentage of the commits authored
+	// by a single organization; 0 disables the rule.
+	MaxOrganizationShare float64
+	// MaxVulnerabilities is the maximal number of known vulnerabilities not
+	// fixed, as reported by the Vulnerabilities check of scorecard.
+	MaxVulnerabilities int64
+	// Disabled are the codes of the rules that are not checked.
+	Disabled []string
+}
+
+type redFlagCheck struct {
+	Code string
+	// Check returns the message of the red flag, or an empty string if the
+	// project is not flagged.
+	Check func(stats *ProjectStats, config *Config) string
+}
+
+var redFlagChecks = []redFlagCheck{
+	{"archived", checkArchived},
+	{"inactive", checkInactive},
+	{"single-maintainer", checkSingleMaintainer},
+	{"single-vendor", checkSingleVendor},
+	{"no-license", checkNoLicense},
+	{"forbidden-license", checkForbiddenLicense},
+	{"known-vulnerabilities", checkKnownVulnerabilities},
+}
+
+// CheckRedFlags runs the rules that are not disabled in the configuration on
+// the stats of a project.
+func CheckRedFlags(stats *ProjectStats, config *Config) []RedFlag {
+	if config.RedFlags == nil {
+		// Configuration from a policy or a file without the rules
+		withDefaults := *config
+		withDefaults.RedFlags = DefaultConfig().RedFlags
+		config = &withDefaults
+	}
+	flags := []RedFlag{}
+	for _, rule := range redFlagChecks {
+		if slices.Contains(config.RedFlags.Disabled, rule.Code) {
+			continue
+		}
+		if msg := rule.Check(stats, config); msg != "" {
+			flags = append(flags, RedFlag{Code: rule.Code, Message: msg})
+		}
+	}
+	return flags
+}
+
+func checkArchived(stats *ProjectStats, config *Config) string {
+	if stats.GitHub.Archived {
+		return "the repository has been archived, the project is no longer maintained"
+	}
+	return ""
+}
+
+func checkInactive(stats *ProjectStats, config *Config) string {
+	last := stats.GitHub.LastHumanCommitDate
+	if last.IsZero() {
+		last = stats.GitHub.LastCommitDate
+	}
+	if last.IsZero() || config.RedFlags.Inactivity <= 0 {
+		return ""
+	}
+	if elapsed := stats.collectedAt().Sub(last); elapsed.Nanoseconds() > config.RedFlags.Inactivity {
+		return fmt.Sprintf("no commit since %s", last.Format(time.DateOnly))
+	}
+	return ""
+}
+
+func checkSingleMaintainer(stats *ProjectStats, config *Config) string {
+	if stats.GitHub.ActiveContributors < config.RedFlags.MinContributors {
+		return fmt.Sprintf("%d active contributors, the project depends on a single maintainer", stats.GitHub.ActiveContributors)
+	}
+	return ""
+}
+
+// checkSingleVendor flags the projects driven by a company, which can
+// relicense or abandon them. The independent contributors are not a vendor.
+func checkSingleVendor(stats *ProjectStats, config *Config) string {
+	github := stats.GitHub
+	if github.TopOrganization == "" || config.RedFlags.MaxOrganizationShare <= 0 {
+		return ""
+	}
+	if github.TopOrganizationShare > config.RedFlags.MaxOrganizationShare {
+		return fmt.Sprintf("%.0f%% of the commits are authored by %s, the project depends on a single vendor", github.TopOrganizationShare, github.TopOrganization)
+	}
+	return ""
+}
+
+func checkNoLicense(stats *ProjectStats, config *Config) string {
+	if stats.GitHub.License == "" || stats.GitHub.License == "NOASSERTION" {
+		return "no license has been detected"
+	}
+	return ""
+}
+
+func checkForbiddenLicense(stats *ProjectStats, config *Config) string {
+	if config.Licenses == nil || stats.GitHub.License == "" {
+		return ""
+	}
+	if classification := config.Licenses.ClassifyLicense(stats.GitHub.License); classification.Class == LicenseForbidden {
+		return fmt.Sprintf("the license %s is forbidden: %s", classification.Expression, classification.Rationale)
+	}
+	return ""
+}
+
+// checkKnownVulnerabilities uses the Vulnerabilities check of scorecard,
+// which scores 10 minus the number of open vulnerabilities from OSV. Their
+// severity is not known.
+func checkKnownVulnerabilities(stats *ProjectStats, config *Config) string {
+	for _, check := range stats.ScoreCard.Checks {
+		if check.Name != "Vulnerabilities" || check.Score < 0 {
+			continue
+		}
+		if nb := 10 - check.Score; nb > config.RedFlags.MaxVulnerabilities {
+			if check.Score == 0 {
+				return "at least 10 known vulnerabilities are not fixed"
+			}
+			return fmt.Sprintf("%d known vulnerabilities are not fixed", nb)
+		}
+	}
+	return ""
+}
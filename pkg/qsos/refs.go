@@ -0,0 +1,70 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+)
+
+var unsafeRefChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// GetRefsStats collects the tech stats for several git refs (branches or
+// tags) of a repository. The refs are fetched in the same local repository,
+// one after the other.
+func (e *Executor) GetRefsStats(ctx context.Context, owner, repo string, refs []string) (map[string]*SonarStats, error) {
+	component := e.component(owner, repo)
+	tmpDir, err := os.MkdirTemp("", componentName(owner, repo)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	remote := e.Forge.cloneURL(owner, repo)
+	if err := git(ctx, e.ToolOutput, tmpDir, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if err := git(ctx, e.ToolOutput, tmpDir, "remote", "add", "origin", remote); err != nil {
+		return nil, err
+	}
+
+	stats := map[string]*SonarStats{}
+	for _, ref := range refs {
+		if err := git(ctx, e.ToolOutput, tmpDir, "fetch", "--depth=1", "origin", ref); err != nil {
+			return nil, fmt.Errorf("Cannot fetch %s: %w", ref, err)
+		}
+		if err := git(ctx, e.ToolOutput, tmpDir, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+			return nil, fmt.Errorf("Cannot checkout %s: %w", ref, err)
+		}
+		if err := git(ctx, e.ToolOutput, tmpDir, "clean", "--quiet", "-fdx"); err != nil {
+			return nil, err
+		}
+
+		dir, err := e.subdirPath(tmpDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		refComponent := component + "-" + unsafeRefChars.ReplaceAllString(ref, "_")
+		stats[ref], err = e.Sonar.AnalyzeDir(ctx, dir, refComponent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+	}
+	return stats, nil
+}
+
+func git(ctx context.Context, toolOutput io.Writer, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = toolOutput
+	cmd.Stderr = toolOutput
+	end := traceCommand(ctx, cmd)
+	err := cmd.Run()
+	end(err)
+	if err != nil {
+		return fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return nil
+}
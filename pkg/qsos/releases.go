@@ -0,0 +1,336 @@
+package qsos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+)
+
+// Release is a version of a project: a release published on the forge, or a
+// tag of the repository.
+type Release struct {
+	Name string
+	Date time.Time
+	// Prerelease is true for the releases flagged as such on the forge, and
+	// for the versions like 2.0.0-rc.1.
+	Prerelease bool `json:",omitempty"`
+}
+
+// maxReleases is the number of the most recent releases and tags collected.
+const maxReleases = 100
+
+// prereleaseVersion matches the versions of the alpha, beta, release
+// candidate and development builds.
+var prereleaseVersion = regexp.MustCompile(`(?i)[-.+_]?(alpha|beta|rc|pre|preview|dev|snapshot|nightly|canary)([-.]?\d+)*$`)
+
+// newRelease returns the release of a tag, or false if the tag is not a
+// version, without any digit.
+func newRelease(name string, date time.Time, prerelease bool) (Release, bool) {
+	if !strings.ContainsAny(name, "0123456789") {
+		return Release{}, false
+	}
+	return Release{Name: name, Date: date.UTC(), Prerelease: prerelease || prereleaseVersion.MatchString(name)}, true
+}
+
+// mergeReleases returns the releases of the forge and the other tags, the
+// most recent first. The releases of the forge have the precedence over the
+// tags with the same name.
+func mergeReleases(releases, tags []Release) []Release {
+	merged := slices.Clone(releases)
+	for _, tag := range tags {
+		if !slices.ContainsFunc(releases, func(r Release) bool { return r.Name == tag.Name }) {
+			merged = append(merged, tag)
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b Release) int { return b.Date.Compare(a.Date) })
+	return merged[:min(len(merged), maxReleases)]
+}
+
+// githubReleasesQuery gets the last releases and tags of a repository, in one
+// request. The date of an annotated tag is the one of the tag, and the one of
+// the commit for the lightweight tags.
+const githubReleasesQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    releases(first: 100, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { tagName publishedAt isPrerelease isDraft }
+    }
+    refs(refPrefix: "refs/tags/", first: 100, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+      nodes {
+        name
+        target {
+          ... on Commit { committedDate }
+          ... on Tag { tagger { date } }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLReleases struct {
+	Nodes []struct {
+		TagName      string     `json:"tagName"`
+		PublishedAt  *time.Time `json:"publishedAt"`
+		IsPrerelease bool       `json:"isPrerelease"`
+		IsDraft      bool       `json:"isDraft"`
+	} `json:"nodes"`
+}
+
+type graphQLTags struct {
+	Nodes []struct {
+		Name   string `json:"name"`
+		Target struct {
+			CommittedDate *time.Time `json:"committedDate"`
+			Tagger        *struct {
+				Date time.Time `json:"date"`
+			} `json:"tagger"`
+		} `json:"target"`
+	} `json:"nodes"`
+}
+
+// getReleases returns the last releases and tags of a repository, with the
+// GraphQL API, or only the releases with the REST API for the anonymous
+// requests.
+func (c *GitHubAPICollector) getReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	if c.Anonymous {
+		return c.getReleasesREST(ctx, owner, repo)
+	}
+	var repository graphQLRepository
+	if err := c.graphQL(ctx, githubReleasesQuery, map[string]any{"owner": owner, "name": repo}, &repository); err != nil {
+		return nil, err
+	}
+	var releases, tags []Release
+	if repository.Releases != nil {
+		for _, node := range repository.Releases.Nodes {
+			if node.IsDraft || node.PublishedAt == nil {
+				continue
+			}
+			if release, ok := newRelease(node.TagName, *node.PublishedAt, node.IsPrerelease); ok {
+				releases = append(releases, release)
+			}
+		}
+	}
+	if repository.Refs != nil {
+		for _, node := range repository.Refs.Nodes {
+			date := node.Target.CommittedDate
+			if node.Target.Tagger != nil {
+				date = &node.Target.Tagger.Date
+			}
+			if date == nil {
+				continue
+			}
+			if tag, ok := newRelease(node.Name, *date, false); ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return mergeReleases(releases, tags), nil
+}
+
+// getReleasesREST returns the last releases of a repository. The tags are
+// not listed, as the REST API does not give their dates.
+func (c *GitHubAPICollector) getReleasesREST(ctx context.Context, owner, repo string) ([]Release, error) {
+	list, _, err := c.Client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: maxReleases})
+	if err != nil {
+		return nil, fmt.Errorf("ListReleases failed: %w", err)
+	}
+	var releases []Release
+	for _, release := range list {
+		if release.GetDraft() || release.PublishedAt == nil {
+			continue
+		}
+		if r, ok := newRelease(release.GetTagName(), release.GetPublishedAt().Time, release.GetPrerelease()); ok {
+			releases = append(releases, r)
+		}
+	}
+	return mergeReleases(releases, nil), nil
+}
+
+// gitTags returns the last tags of the repository in dir. The date of an
+// annotated tag is the one of the tag, and the one of the commit for the
+// lightweight tags.
+func gitTags(ctx context.Context, toolOutput io.Writer, dir string) ([]Release, error) {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--sort=-creatordate", fmt.Sprintf("--count=%d", maxReleases),
+		"--format=%(refname:short)%00%(creatordate:unix)", "refs/tags")
+	cmd.Dir = dir
+	cmd.Stderr = toolOutput
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref failed: %w", err)
+	}
+	var tags []Release
+	for line := range bytes.Lines(output) {
+		name, timestamp, _ := strings.Cut(strings.TrimSpace(string(line)), "\x00")
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		if tag, ok := newRelease(name, time.Unix(seconds, 0), false); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return mergeReleases(nil, tags), nil
+}
+
+// releaseCadenceYears is the period of the releases of the cadence.
+const releaseCadenceYears = 2
+
+// releaseCadence is the frequency and the regularity of the releases.
+type releaseCadence struct {
+	// Releases is the number of stable releases of the period, the ones of
+	// a same day counting once.
+	Releases int64
+	// Interval is the median time between 2 releases, 0 with less than 2
+	// releases.
+	Interval time.Duration
+	// Irregularity is the coefficient of variation of the times between the
+	// releases, in percent, -1 with less than 3 releases.
+	Irregularity float64
+}
+
+// computeReleaseCadence returns the cadence of the stable releases of the
+// last 2 years.
+func computeReleaseCadence(releases []Release, now time.Time) releaseCadence {
+	since := now.AddDate(-releaseCadenceYears, 0, 0)
+	var days []time.Time
+	for _, release := range releases {
+		if release.Prerelease || release.Date.Before(since) {
+			continue
+		}
+		days = append(days, release.Date.Truncate(24*time.Hour))
+	}
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	days = slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+	cadence := releaseCadence{Releases: int64(len(days)), Irregularity: -1}
+	if len(days) < 2 {
+		return cadence
+	}
+	intervals := make([]time.Duration, 0, len(days)-1)
+	var sum float64
+	for i := 1; i < len(days); i++ {
+		interval := days[i].Sub(days[i-1])
+		intervals = append(intervals, interval)
+		sum += interval.Hours()
+	}
+	slices.Sort(intervals)
+	cadence.Interval = intervals[len(intervals)/2]
+	if len(intervals) < 2 {
+		return cadence
+	}
+	mean := sum / float64(len(intervals))
+	var variance float64
+	for _, interval := range intervals {
+		variance += math.Pow(interval.Hours()-mean, 2)
+	}
+	variance /= float64(len(intervals))
+	cadence.Irregularity = roundFloat(100*math.Sqrt(variance)/mean, 2)
+	return cadence
+}
+
+// latestRelease returns the most recent stable release, or the most recent
+// prerelease if there are only prereleases.
+func latestRelease(releases []Release) (Release, bool) {
+	if len(releases) == 0 {
+		return Release{}, false
+	}
+	for _, release := range releases {
+		if !release.Prerelease {
+			return release, true
+		}
+	}
+	return releases[0], true
+}
+
+// firstNumber matches the first number of a version, like 2 for v2.1.0 or
+// release-2.1.
+var firstNumber = regexp.MustCompile(`\d+`)
+
+// majorVersion returns the highest major version of the stable releases, or
+// -1 without a stable release.
+func majorVersion(releases []Release) int64 {
+	major := int64(-1)
+	for _, release := range releases {
+		if release.Prerelease {
+			continue
+		}
+		if nb, err := strconv.ParseInt(firstNumber.FindString(release.Name), 10, 64); err == nil {
+			major = max(major, nb)
+		}
+	}
+	return major
+}
+
+// semanticVersion matches the versions of the semantic versioning 2.0.0
+// specification, with an optional v prefix.
+var semanticVersion = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*)?` +
+	`(?:\+[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*)?$`)
+
+// semanticMajor returns the major version of a release, or false if its name
+// is not a semantic version. The prefix of the tags of the modules of a
+// monorepo, like api/ for api/v1.2.3, is ignored.
+func semanticMajor(name string) (int64, bool) {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	match := semanticVersion.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	major, err := strconv.ParseInt(match[1], 10, 64)
+	return major, err == nil
+}
+
+// versioning tells if the versions of the releases follow the semantic
+// versioning.
+type versioning struct {
+	// SemverShare is the percentage of the releases with a semantic
+	// version.
+	SemverShare float64
+	// MajorBumps is the number of new major versions from 1.0 released in
+	// the last 2 years, each one breaking the compatibility, or -1 without a
+	// stable semantic version in this period.
+	MajorBumps int64
+}
+
+// computeVersioning returns the adherence of the releases to the semantic
+// versioning. The releases of 0.x versions, which can break the
+// compatibility with any minor version, and the first release of 1.0 are not
+// major bumps.
+func computeVersioning(releases []Release, now time.Time) versioning {
+	since := now.AddDate(-releaseCadenceYears, 0, 0)
+	var semver int64
+	result := versioning{MajorBumps: -1}
+	highest := int64(-1)
+	// Oldest first, to find the releases of the new major versions
+	for _, release := range slices.Backward(releases) {
+		major, ok := semanticMajor(release.Name)
+		if !ok {
+			continue
+		}
+		semver++
+		if release.Prerelease {
+			continue
+		}
+		if !release.Date.Before(since) {
+			result.MajorBumps = max(result.MajorBumps, 0)
+			if major > highest && highest >= 1 {
+				result.MajorBumps++
+			}
+		}
+		highest = max(highest, major)
+	}
+	result.SemverShare = share(semver, int64(len(releases)))
+	return result
+}
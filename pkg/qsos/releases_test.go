@@ -0,0 +1,170 @@
+package qsos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		prerelease bool
+		ok         bool
+	}{
+		{"v1.0.0", false, true},
+		{"v2.0.0-rc1", true, true},
+		{"2.0.0.beta.2", true, true},
+		{"v1.8.0-SNAPSHOT", true, true},
+		{"docs", false, false},
+		{"latest", false, false},
+	}
+	for _, test := range tests {
+		release, ok := newRelease(test.name, time.Now(), false)
+		if ok != test.ok || release.Prerelease != test.prerelease {
+			t.Errorf("newRelease(%q) = %+v, %v, want prerelease %v, %v", test.name, release, ok, test.prerelease, test.ok)
+		}
+	}
+}
+
+// testReleases are the releases of the tests of the release metrics, by
+// case.
+func testReleases(now time.Time) map[string][]Release {
+	ago := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	return map[string][]Release{
+		"none": nil,
+		"regular": {
+			{Name: "v3.0.0", Date: ago(10)},
+			{Name: "v2.1.0", Date: ago(40)},
+			{Name: "v2.0.0", Date: ago(70)},
+			{Name: "v1.0.0", Date: ago(100)},
+		},
+		"prereleases and old releases": {
+			{Name: "v2.0.0-rc1", Date: ago(5), Prerelease: true},
+			{Name: "v1.1.0", Date: ago(30)},
+			{Name: "nightly-42", Date: ago(60)},
+			{Name: "v1.0.0", Date: ago(1000)},
+		},
+		"zero versions": {
+			{Name: "v0.3.0", Date: ago(10)},
+			{Name: "v0.2.0", Date: ago(20)},
+		},
+		"only prereleases": {
+			{Name: "v1.0.0-beta.2", Date: ago(10), Prerelease: true},
+			{Name: "v1.0.0-beta.1", Date: ago(20), Prerelease: true},
+		},
+	}
+}
+
+func TestReleaseCadence(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		cadence releaseCadence
+	}{
+		{"none", releaseCadence{Irregularity: -1}},
+		{"regular", releaseCadence{Releases: 4, Interval: 30 * 24 * time.Hour, Irregularity: 0}},
+		{"prereleases and old releases", releaseCadence{Releases: 2, Interval: 30 * 24 * time.Hour, Irregularity: -1}},
+		{"zero versions", releaseCadence{Releases: 2, Interval: 10 * 24 * time.Hour, Irregularity: -1}},
+		{"only prereleases", releaseCadence{Irregularity: -1}},
+	}
+	releases := testReleases(now)
+	for _, test := range tests {
+		if cadence := computeReleaseCadence(releases[test.name], now); cadence != test.cadence {
+			t.Errorf("%s: cadence = %+v, want %+v", test.name, cadence, test.cadence)
+		}
+	}
+}
+
+func TestMergeReleases(t *testing.T) {
+	now := time.Now()
+	releases := []Release{{Name: "v1.1.0", Date: now.AddDate(0, 0, -1)}}
+	tags := []Release{
+		{Name: "v1.1.0", Date: now.AddDate(0, 0, -3)},
+		{Name: "v1.2.0", Date: now},
+		{Name: "v1.0.0", Date: now.AddDate(0, 0, -10)},
+	}
+	merged := mergeReleases(releases, tags)
+	want := []string{"v1.2.0", "v1.1.0", "v1.0.0"}
+	if len(merged) != len(want) {
+		t.Fatalf("merged = %+v, want %v", merged, want)
+	}
+	for i, release := range merged {
+		if release.Name != want[i] {
+			t.Errorf("merged[%d] = %s, want %s", i, release.Name, want[i])
+		}
+	}
+	if !merged[1].Date.Equal(releases[0].Date) {
+		t.Errorf("the date of the release of the forge is %v, want %v", merged[1].Date, releases[0].Date)
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		latest string
+		major  int64
+	}{
+		{"none", "", -1},
+		{"regular", "v3.0.0", 3},
+		{"prereleases and old releases", "v1.1.0", 42},
+		{"zero versions", "v0.3.0", 0},
+		{"only prereleases", "v1.0.0-beta.2", -1},
+	}
+	releases := testReleases(now)
+	for _, test := range tests {
+		if latest, _ := latestRelease(releases[test.name]); latest.Name != test.latest {
+			t.Errorf("%s: latest release = %q, want %q", test.name, latest.Name, test.latest)
+		}
+		if major := majorVersion(releases[test.name]); major != test.major {
+			t.Errorf("%s: major version = %d, want %d", test.name, major, test.major)
+		}
+	}
+}
+
+func TestSemanticMajor(t *testing.T) {
+	tests := []struct {
+		name  string
+		major int64
+		ok    bool
+	}{
+		{"1.2.3", 1, true},
+		{"v2.0.0", 2, true},
+		{"v0.9.1", 0, true},
+		{"v3.0.0-rc.1", 3, true},
+		{"v1.0.0+build.5", 1, true},
+		{"api/v4.1.0", 4, true},
+		{"v1.2", 0, false},
+		{"v01.2.3", 0, false},
+		{"release-2.1.0", 0, false},
+		{"2024.1.15", 2024, true},
+		{"2024.01.15", 0, false},
+		{"v1.2.3-", 0, false},
+	}
+	for _, test := range tests {
+		major, ok := semanticMajor(test.name)
+		if major != test.major || ok != test.ok {
+			t.Errorf("semanticMajor(%q) = %d, %v, want %d, %v", test.name, major, ok, test.major, test.ok)
+		}
+	}
+}
+
+func TestVersioning(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		version versioning
+	}{
+		{"none", versioning{MajorBumps: -1}},
+		{"regular", versioning{SemverShare: 100, MajorBumps: 2}},
+		{"prereleases and old releases", versioning{SemverShare: 75, MajorBumps: 0}},
+		{"zero versions", versioning{SemverShare: 100, MajorBumps: 0}},
+		{"only prereleases", versioning{SemverShare: 100, MajorBumps: -1}},
+	}
+	releases := testReleases(now)
+	for _, test := range tests {
+		if version := computeVersioning(releases[test.name], now); version != test.version {
+			t.Errorf("%s: versioning = %+v, want %+v", test.name, version, test.version)
+		}
+	}
+}
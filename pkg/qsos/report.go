@@ -0,0 +1,434 @@
+package qsos
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrintReport prints the stats and the scores of an evaluation in a human
+// readable format.
+func PrintReport(w io.Writer, evaluation *Evaluation, opts *ReportOptions) {
+	lang := opts.language()
+	stats, scores := evaluation.Stats, evaluation.Scores
+	name := evaluation.Name()
+	if stats.Subdir != "" {
+		name += " (" + stats.Subdir + ")"
+	}
+	fmt.Fprintf(w, "\n=== %s ===\n", opts.colorize(colorBold, name))
+	if len(evaluation.RedFlags) > 0 {
+		printSection(w, lang, "Red flags")
+		for _, flag := range evaluation.RedFlags {
+			fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorRed, lang.tr("RED FLAG")), flag.Message)
+		}
+	}
+	if len(stats.Warnings) > 0 {
+		printSection(w, lang, "Warnings")
+		for _, warning := range stats.Warnings {
+			fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorYellow, lang.tr("WARNING")), warning.Message)
+		}
+	}
+	github := [][2]string{
+		{"Date of the First Commit", stats.GitHub.FirstCommitDate.Format("2006-01-02 15:04:05 MST")},
+		{"Date of the Last Commit", stats.GitHub.LastCommitDate.Format("2006-01-02 15:04:05 MST")},
+		{"Number of Stars", formatStarsCount(lang, stats.GitHub, stats.GitHub.Stars)},
+		{"Number of Forks", formatStarsCount(lang, stats.GitHub, stats.GitHub.Forks)},
+		{"Active contributors", formatActiveContributors(lang, stats.GitHub)},
+		{"Commits by bots", formatBotCommits(lang, stats.GitHub)},
+		{"Elephant factor", formatElephantFactor(lang, stats.GitHub)},
+		{"Issue response time", formatIssueResponseTime(lang, stats.GitHub)},
+		{"Issues", formatIssueBacklog(lang, stats.GitHub)},
+		{"Release cadence", formatReleaseCadence(lang, stats.GitHub, stats.collectedAt())},
+		{"Latest release", formatLatestRelease(lang, stats.GitHub, stats.collectedAt())},
+		{"Versioning", formatVersioning(lang, stats.GitHub, stats.collectedAt())},
+		{"Merged PRs by bots", fmt.Sprintf("%.0f%%", stats.GitHub.BotPullRequestShare)},
+	}
+	if len(stats.GitHub.WeeklyCommits) > 0 {
+		var commits int64
+		for _, nb := range stats.GitHub.WeeklyCommits {
+			commits += nb
+		}
+		github = append(github, [2]string{"Commits in the last year", fmt.Sprint(commits)})
+	}
+	if platforms := coveredPlatforms(stats); len(platforms) > 0 {
+		github = append(github, [2]string{"Platforms", strings.Join(platforms, ", ")})
+	}
+	printFields(w, lang, "GitHub Project Statistics", github)
+	if stats.Packages != nil {
+		printFields(w, lang, "Packages Statistics", [][2]string{
+			{"Downloads", fmt.Sprint(stats.Packages.Downloads)},
+			{"Dependents", fmt.Sprint(stats.Packages.Dependents)},
+			{"Container pulls", fmt.Sprint(stats.Packages.ContainerPulls)},
+		})
+	}
+	functions := fmt.Sprint(stats.Sonar.Functions)
+	if stats.Sonar.FunctionsEstimated {
+		functions += " " + lang.tr("(estimated)")
+	}
+	printFields(w, lang, "Sonarqube Statistics", [][2]string{
+		{"Number of lines of code", fmt.Sprint(stats.Sonar.LinesOfCode)},
+		{"Number of functions", functions},
+		{"Cyclomatic complexity", fmt.Sprint(stats.Sonar.CyclomaticComplexity)},
+		{"Cognitive complexity", fmt.Sprint(stats.Sonar.CognitiveComplexity)},
+		{"Brain-overload issues", fmt.Sprint(stats.Sonar.BrainOverload)},
+		{"Number of code smells", fmt.Sprint(stats.Sonar.CodeSmells)},
+		{"Duplication density", fmt.Sprintf("%.1f", stats.Sonar.DuplicationDensity)},
+	})
+	if advisories := stats.Advisories; advisories != nil {
+		fields := [][2]string{
+			{"Published", fmt.Sprint(advisories.Published)},
+			{"With credits", fmt.Sprint(advisories.WithCredits)},
+			{"With CVSS", fmt.Sprint(advisories.WithCVSS)},
+			{"With remediation", fmt.Sprint(advisories.WithRemediation)},
+		}
+		for _, link := range advisories.Postmortems {
+			fields = append(fields, [2]string{"Postmortem", link})
+		}
+		printFields(w, lang, "Security advisories", fields)
+	}
+	printSection(w, lang, "ScoreCard checks")
+	checks := newTextTable(opts, "Check", "Score").alignRight(1)
+	for _, check := range stats.ScoreCard.Checks {
+		score := fmt.Sprint(check.Score)
+		if check.Score >= 0 {
+			// The checks are scored from 0 to 10
+			score = opts.colorize(scoreColor(1+float64(check.Score)*0.4), score)
+		}
+		checks.add(check.Name, score)
+	}
+	checks.print(w)
+
+	printSection(w, lang, "Scores")
+	table := newTextTable(opts, "Axis", "Criterion", "Score").alignRight(2)
+	table.add(lang.tr("Community"), lang.tr("Maturity"), opts.formatScore(scores.Community.Maturity))
+	table.add("", lang.tr("Activity"), opts.formatScore(scores.Community.Activity))
+	table.add("", lang.tr("Popularity"), opts.formatScore(scores.Community.Popularity))
+	for _, name := range slices.Sorted(maps.Keys(scores.Community.PopularitySources)) {
+		table.add("", "  - "+name, opts.formatScore(scores.Community.PopularitySources[name]))
+	}
+	table.add("", lang.tr("Contributors"), opts.formatScore(scores.Community.Contributors))
+	table.add("", lang.tr("Responsiveness"), opts.formatScore(scores.Community.Responsiveness))
+	table.add("", lang.tr("Backlog"), opts.formatScore(scores.Community.Backlog))
+	table.add(lang.tr("Tech"), lang.tr("Code size"), opts.formatScore(scores.Tech.Size))
+	table.add("", lang.tr("Cyclomatic complexity"), opts.formatScore(scores.Tech.CyclomaticComplexity))
+	table.add("", lang.tr("Cognitive complexity"), opts.formatScore(scores.Tech.CognitiveComplexity))
+	table.add("", lang.tr("Duplication"), opts.formatScore(scores.Tech.Duplication))
+	table.add("", lang.tr("Code smells"), opts.formatScore(scores.Tech.CodeSmells))
+	table.add(lang.tr("Security"), lang.tr("Scorecard"), opts.formatScore(scores.Security.ScoreCard))
+	if scores.Security.Process != nil {
+		table.add("", lang.tr("Process"), opts.formatScore(*scores.Security.Process))
+	}
+	table.add(lang.tr("Industrialization"), lang.tr("Release cadence"), opts.formatScore(scores.Industrialization.ReleaseCadence))
+	table.add("", lang.tr("Release freshness"), opts.formatScore(scores.Industrialization.ReleaseFreshness))
+	table.add("", lang.tr("Versioning"), opts.formatScore(scores.Industrialization.Versioning))
+	table.add(lang.tr("Adoption"), lang.tr("Platforms"), opts.formatScore(scores.Adoption.Platforms))
+	table.add(lang.tr("Overall"), "", opts.formatOverall(scores.Overall))
+	table.print(w)
+	if licensing := scores.Licensing; licensing != nil {
+		printFields(w, lang, "Licensing", [][2]string{
+			{"License", cmp.Or(licensing.Expression, lang.tr("unknown"))},
+			{"Class", licensing.Class},
+			{"Rationale", licensing.Rationale},
+		})
+	}
+	if len(scores.Refs) > 0 {
+		printRefs(w, opts, stats, scores)
+	}
+
+	printSection(w, lang, "Summary")
+	fmt.Fprintf(w, "%s\n", stats.Summary)
+
+	if evaluation.Denied != nil {
+		printSection(w, lang, "Policy")
+		if len(evaluation.Denied) == 0 {
+			fmt.Fprintf(w, "%s\n", opts.colorize(colorGreen, lang.tr("Passed")))
+		}
+		for _, msg := range evaluation.Denied {
+			fmt.Fprintf(w, "%s: %s\n", opts.colorize(colorRed, lang.tr("Denied")), msg)
+		}
+	}
+
+	if len(stats.GitHub.Maintainers) > 0 {
+		printSection(w, lang, "Maintainers")
+		table := newTextTable(opts, "Login", "Identified by", "Commits (6 months)", "Last activity").alignRight(2)
+		for _, m := range stats.GitHub.Maintainers {
+			activity := formatLastActivity(lang, m.LastActivity)
+			if !m.LastActivity.IsZero() {
+				activity += " " + lang.trf("(%d days ago)", int(stats.collectedAt().Sub(m.LastActivity).Hours()/24))
+			}
+			table.add(m.Login, strings.Join(m.Sources, ", "), fmt.Sprint(m.Commits), activity)
+		}
+		table.print(w)
+	}
+}
+
+// formatLastActivity formats the date of the last activity of a maintainer.
+func formatLastActivity(lang *Language, date time.Time) string {
+	if date.IsZero() {
+		return lang.tr("unknown")
+	}
+	return date.Format(time.DateOnly)
+}
+
+// formatStarsCount formats the number of stars or of forks, unknown on the
+// forges without them.
+func formatStarsCount(lang *Language, stats *GitHubStats, nb int64) string {
+	if stats.NoStars {
+		return lang.tr("unknown")
+	}
+	return fmt.Sprint(nb)
+}
+
+// formatActiveContributors formats the number of active contributors, with
+// their window when it is known.
+func formatActiveContributors(lang *Language, stats *GitHubStats) string {
+	if stats.ContributorsWindow == nil {
+		return fmt.Sprint(stats.ActiveContributors)
+	}
+	return lang.trf("%d (at least %d commits in %d months)", stats.ActiveContributors, stats.ContributorsWindow.MinCommits, stats.ContributorsWindow.Months)
+}
+
+// formatBotCommits formats the share of the commits authored by bots, with
+// their number when it is known.
+func formatBotCommits(lang *Language, stats *GitHubStats) string {
+	if stats.BotCommits == 0 {
+		return fmt.Sprintf("%.0f%%", stats.BotCommitShare)
+	}
+	return lang.trf("%.0f%% (%d commits excluded)", stats.BotCommitShare, stats.BotCommits)
+}
+
+// formatElephantFactor formats the number of organizations authoring half
+// of the commits, with the top one when it is not an independent contributor.
+func formatElephantFactor(lang *Language, stats *GitHubStats) string {
+	switch {
+	case stats.ElephantFactor == 0:
+		return lang.tr("unknown")
+	case stats.TopOrganization == "":
+		return fmt.Sprint(stats.ElephantFactor)
+	}
+	return lang.trf("%d (%s: %.0f%% of the commits)", stats.ElephantFactor, stats.TopOrganization, stats.TopOrganizationShare)
+}
+
+// formatIssueResponseTime formats the median time to the first response to
+// the issues, with their number.
+func formatIssueResponseTime(lang *Language, stats *GitHubStats) string {
+	if stats.Issues == 0 {
+		return lang.tr("unknown")
+	}
+	return lang.trf("%s (median of %d issues)", formatDuration(stats.IssueResponseTime), stats.Issues)
+}
+
+// formatIssueBacklog formats the open and closed issues, with the growth of
+// the open issues in the last year.
+func formatIssueBacklog(lang *Language, stats *GitHubStats) string {
+	if stats.OpenIssues+stats.ClosedIssues == 0 {
+		return lang.tr("unknown")
+	}
+	growth := stats.IssuesOpenedLastYear - stats.IssuesClosedLastYear
+	return lang.trf("%d open, %d closed (%.0f%% open), %+d open in the last year", stats.OpenIssues, stats.ClosedIssues, stats.openIssueShare(), growth)
+}
+
+// formatReleaseCadence formats the number of stable releases of the 2 years
+// before the collection of the stats, with the median time between them.
+func formatReleaseCadence(lang *Language, stats *GitHubStats, at time.Time) string {
+	if len(stats.Releases) == 0 {
+		return lang.tr("no release")
+	}
+	cadence := computeReleaseCadence(stats.Releases, at)
+	if cadence.Interval == 0 {
+		return lang.trf("%d releases in 2 years", cadence.Releases)
+	}
+	return lang.trf("%d releases in 2 years, every %s (median)", cadence.Releases, formatDuration(cadence.Interval))
+}
+
+// formatLatestRelease formats the latest release with its age, telling if
+// the project has never released a stable 1.0 version.
+func formatLatestRelease(lang *Language, stats *GitHubStats, at time.Time) string {
+	latest, ok := latestRelease(stats.Releases)
+	if !ok {
+		return lang.tr("no release")
+	}
+	age := formatDuration(at.Sub(latest.Date))
+	if majorVersion(stats.Releases) < 1 {
+		return lang.trf("%s, %s ago, no stable 1.0 version", latest.Name, age)
+	}
+	return lang.trf("%s, %s ago", latest.Name, age)
+}
+
+// formatVersioning formats the share of the semantic versions, with the
+// number of new major versions of the last 2 years.
+func formatVersioning(lang *Language, stats *GitHubStats, at time.Time) string {
+	if len(stats.Releases) == 0 {
+		return lang.tr("no release")
+	}
+	versioning := computeVersioning(stats.Releases, at)
+	if versioning.MajorBumps < 0 {
+		return lang.trf("%.0f%% semantic versions", versioning.SemverShare)
+	}
+	return lang.trf("%.0f%% semantic versions, %d new major versions in 2 years", versioning.SemverShare, versioning.MajorBumps)
+}
+
+// formatDuration formats a duration in hours, or in days from 2 days.
+func formatDuration(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dd", d/(24*time.Hour))
+}
+
+// printRefs prints the tech stats and scores of the refs side by side.
+func printRefs(w io.Writer, opts *ReportOptions, stats *ProjectStats, scores *ProjectScores) {
+	lang := opts.language()
+	refs := slices.Sorted(maps.Keys(stats.Refs))
+	rows := []struct {
+		label string
+		value func(sonar *SonarStats, tech *TechScores) string
+	}{
+		{"Lines of code", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.LinesOfCode) }},
+		{"Functions", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.Functions) }},
+		{"Cyclomatic complexity", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.CyclomaticComplexity) }},
+		{"Cognitive complexity", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.CognitiveComplexity) }},
+		{"Brain-overload issues", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.BrainOverload) }},
+		{"Code smells", func(s *SonarStats, _ *TechScores) string { return fmt.Sprint(s.CodeSmells) }},
+		{"Duplication density", func(s *SonarStats, _ *TechScores) string { return fmt.Sprintf("%.1f", s.DuplicationDensity) }},
+		{"Score: code size", func(_ *SonarStats, t *TechScores) string { return opts.formatScore(t.Size) }},
+		{"Score: cyclomatic", func(_ *SonarStats, t *TechScores) string { return opts.formatScore(t.CyclomaticComplexity) }},
+		{"Score: cognitive", func(_ *SonarStats, t *TechScores) string { return opts.formatScore(t.CognitiveComplexity) }},
+		{"Score: duplication", func(_ *SonarStats, t *TechScores) string { return opts.formatScore(t.Duplication) }},
+		{"Score: code smells", func(_ *SonarStats, t *TechScores) string { return opts.formatScore(t.CodeSmells) }},
+	}
+
+	printSection(w, lang, "Tech by ref")
+	table := newTextTable(opts, append([]string{""}, refs...)...)
+	for i := range refs {
+		table.alignRight(i + 1)
+	}
+	for _, row := range rows {
+		cells := []string{lang.tr(row.label)}
+		for _, ref := range refs {
+			cells = append(cells, row.value(stats.Refs[ref], scores.Refs[ref]))
+		}
+		table.add(cells...)
+	}
+	table.print(w)
+}
+
+// PrintSummaryTable prints a table with the scores of several evaluations,
+// one line per project.
+func PrintSummaryTable(w io.Writer, evaluations []*Evaluation, opts *ReportOptions) {
+	lang := opts.language()
+	if len(evaluations) == 0 {
+		return
+	}
+	header := []string{"Project"}
+	for _, c := range evaluations[0].Scores.Criteria() {
+		header = append(header, c.Name)
+	}
+	table := newTextTable(opts, append(header, "overall")...)
+	for i := 1; i < len(header)+1; i++ {
+		table.alignRight(i)
+	}
+	for _, evaluation := range evaluations {
+		cells := []string{evaluation.Name()}
+		for _, c := range evaluation.Scores.Criteria() {
+			cells = append(cells, opts.formatScore(*c.Score))
+		}
+		table.add(append(cells, opts.formatOverall(evaluation.Scores.Overall))...)
+	}
+	printSection(w, lang, "Scores")
+	table.print(w)
+}
+
+// PrintScores prints one line per evaluation, with the overall score and the
+// scores of the criteria, like "owner/repo 3.45 community.maturity=4 ...".
+func PrintScores(w io.Writer, evaluations []*Evaluation) {
+	for _, evaluation := range evaluations {
+		fmt.Fprintf(w, "%s %.2f", evaluation.Name(), evaluation.Scores.Overall)
+		for _, c := range evaluation.Scores.Criteria() {
+			fmt.Fprintf(w, " %s=%d", c.Name, *c.Score)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Rank sorts the evaluations by overall score, from the best to the worst.
+func Rank(evaluations []*Evaluation) []*Evaluation {
+	ranked := slices.Clone(evaluations)
+	slices.SortStableFunc(ranked, func(a, b *Evaluation) int {
+		return cmp.Compare(b.Scores.Overall, a.Scores.Overall)
+	})
+	return ranked
+}
+
+// PrintRanking prints the leaderboard of the evaluations, sorted by overall
+// score.
+func PrintRanking(w io.Writer, evaluations []*Evaluation, opts *ReportOptions) {
+	lang := opts.language()
+	printSection(w, lang, "Ranking")
+	table := newTextTable(opts, "Rank", "Project", "Overall").alignRight(0, 2)
+	for i, evaluation := range Rank(evaluations) {
+		table.add(fmt.Sprint(i+1), evaluation.Name(), opts.formatOverall(evaluation.Scores.Overall))
+	}
+	table.print(w)
+}
+
+// Report is the JSON report of evaluations, described by the schema in
+// schema/report.schema.json.
+type Report struct {
+	SchemaVersion string
+	GeneratedAt   time.Time
+	Evaluations   []*Evaluation
+}
+
+func NewReport(evaluations []*Evaluation) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   reportTime(),
+		Evaluations:   evaluations,
+	}
+}
+
+// reportTime returns the date of the reports and of the collection of the
+// metrics, in UTC and to the second. It is
+// SOURCE_DATE_EPOCH (https://reproducible-builds.org/specs/source-date-epoch/)
+// if it is set, for reports that do not change between the runs.
+func reportTime() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// WriteJSONReport writes the evaluations in JSON. When validate is set, the
+// report is checked against the schema before being written.
+func WriteJSONReport(w io.Writer, evaluations []*Evaluation, validate bool) error {
+	data, err := json.MarshalIndent(NewReport(evaluations), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode the report: %w", err)
+	}
+	if validate {
+		if err := ValidateReport(data); err != nil {
+			return fmt.Errorf("Invalid report: %w", err)
+		}
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// PrintHistory prints a list of evaluations from the history, one per line.
+func PrintHistory(w io.Writer, records []*HistoryRecord) {
+	for _, record := range records {
+		fmt.Fprintf(w, "%s  %s  %-32s  %s\n",
+			record.ID,
+			record.Date.Format("2006-01-02 15:04"),
+			record.Evaluation.Name(),
+			strings.Join(record.Tags, ", "))
+	}
+}
@@ -0,0 +1,141 @@
+package qsos
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// githubIssuesQuery gets the issues of a repository, the most recent first,
+// with their first comments.
+const githubIssuesQuery = `query($owner: String!, $name: String!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    issues(first: 100, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        createdAt
+        closedAt
+        authorAssociation
+        author { login }
+        comments(first: 10) {
+          nodes { createdAt authorAssociation author { login } }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLIssues struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []graphQLIssue `json:"nodes"`
+}
+
+type graphQLIssue struct {
+	CreatedAt         time.Time      `json:"createdAt"`
+	ClosedAt          *time.Time     `json:"closedAt"`
+	AuthorAssociation string         `json:"authorAssociation"`
+	Author            *graphQLAuthor `json:"author"`
+	Comments          struct {
+		Nodes []graphQLComment `json:"nodes"`
+	} `json:"comments"`
+}
+
+type graphQLComment struct {
+	CreatedAt         time.Time      `json:"createdAt"`
+	AuthorAssociation string         `json:"authorAssociation"`
+	Author            *graphQLAuthor `json:"author"`
+}
+
+type graphQLAuthor struct {
+	Login string `json:"login"`
+}
+
+// login returns the login of the author, empty for a deleted account.
+func (a *graphQLAuthor) login() string {
+	if a == nil {
+		return ""
+	}
+	return a.Login
+}
+
+// isMaintainerAssociation returns true if the association of an author with
+// the repository gives them the write access.
+func isMaintainerAssociation(association string) bool {
+	return association == "OWNER" || association == "MEMBER" || association == "COLLABORATOR"
+}
+
+// maxIssuePages limits the number of requests for the responsiveness, as a
+// busy project can receive thousands of issues in 6 months.
+const maxIssuePages = 5
+
+// responsiveness is the time to the first response of the maintainers to the
+// issues.
+type responsiveness struct {
+	// Issues is the number of issues opened by the users.
+	Issues int64
+	// Median is the median of the times to the first response.
+	Median time.Duration
+}
+
+// getResponsiveness returns the median time to the first response of a
+// maintainer to the issues opened since the given date by the users. The
+// issues opened by the maintainers and the bots are ignored. The closing of
+// an issue is a response, and the issues without a response count for the
+// time since they have been opened.
+func (c *GitHubAPICollector) getResponsiveness(ctx context.Context, owner, repo string, since time.Time) (*responsiveness, error) {
+	now := reportTime()
+	var times []time.Duration
+	variables := map[string]any{"owner": owner, "name": repo, "after": nil}
+	for page := range maxIssuePages {
+		var repository graphQLRepository
+		if err := c.graphQL(ctx, githubIssuesQuery, variables, &repository); err != nil {
+			return nil, err
+		}
+		c.Progress.report(ProgressEvent{Project: owner + "/" + repo, Phase: PhaseGitHub, Step: StepPage, Count: page + 1, Total: maxIssuePages})
+		if repository.Issues == nil {
+			break
+		}
+		done := false
+		for _, issue := range repository.Issues.Nodes {
+			if issue.CreatedAt.Before(since) {
+				done = true
+				break
+			}
+			if isMaintainerAssociation(issue.AuthorAssociation) || c.Bots.isBot(issue.Author.login(), "") {
+				continue
+			}
+			times = append(times, issue.responseTime(c.Bots, now))
+		}
+		if done || !repository.Issues.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = repository.Issues.PageInfo.EndCursor
+	}
+	result := &responsiveness{Issues: int64(len(times))}
+	if len(times) > 0 {
+		slices.Sort(times)
+		result.Median = times[len(times)/2]
+	}
+	return result, nil
+}
+
+// responseTime returns the time to the first response of a maintainer to the
+// issue, or to its closing.
+func (i *graphQLIssue) responseTime(bots *BotFilter, now time.Time) time.Duration {
+	response := now
+	if i.ClosedAt != nil {
+		response = *i.ClosedAt
+	}
+	for _, comment := range i.Comments.Nodes {
+		if isMaintainerAssociation(comment.AuthorAssociation) && !bots.isBot(comment.Author.login(), "") {
+			if comment.CreatedAt.Before(response) {
+				response = comment.CreatedAt
+			}
+			break
+		}
+	}
+	return response.Sub(i.CreatedAt)
+}
@@ -0,0 +1,106 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCacheTTL is the lifetime of the cached results of the collectors.
+const defaultCacheTTL = 24 * time.Hour
+
+// resultCache caches the results of the collectors on disk, by repository
+// and by commit: a result is reused while the head of the default branch is
+// the same commit, until its TTL. A nil cache is disabled.
+type resultCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// get reads a cached result in v. It returns false if there is none, or if
+// it has expired.
+func (c *resultCache) get(key string, v any) bool {
+	if c == nil || key == "" {
+		return false
+	}
+	path := filepath.Join(c.Dir, key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if time.Since(info.ModTime()) > c.TTL {
+		os.Remove(path)
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, v) != nil {
+		return false
+	}
+	slog.Debug("using the cached result", "key", key, "age", time.Since(info.ModTime()).Round(time.Second))
+	return true
+}
+
+// put caches a result.
+func (c *resultCache) put(key string, v any) {
+	if c == nil || key == "" {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(c.Dir, key), data)
+	}
+	if err != nil {
+		slog.Warn("cannot cache the result", "key", key, "err", err)
+	}
+}
+
+// resultKey returns the key of the result of a phase for a commit, with the
+// sub-directory of the repository if the result depends on it. It is empty
+// without a commit.
+func (e *Executor) resultKey(owner, repo, head, phase, subdir string) string {
+	if head == "" {
+		return ""
+	}
+	name := phase
+	if subdir != "" {
+		name += "@" + url.PathEscape(subdir)
+	}
+	// The active contributors depend on their window and on the bots
+	if window := e.Contributors.orDefault(); phase == PhaseGitHub && *window != DefaultContributorsWindow {
+		name += fmt.Sprintf("-%dm%dc", window.Months, window.MinCommits)
+	}
+	if bots := e.Bots.cacheKey(); phase == PhaseGitHub && bots != "" {
+		name += "-bots" + bots
+	}
+	return filepath.Join(e.Forge.orDefault().URL.Host, owner, repo, head, name+".json")
+}
+
+// headCommit returns the SHA of the head of the default branch of a
+// repository, or an empty string if the result cache is disabled or if it
+// cannot be read.
+func (e *Executor) headCommit(ctx context.Context, owner, repo string) string {
+	if e.results == nil {
+		return ""
+	}
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", e.Forge.cloneURL(owner, repo), "HEAD")
+	cmd.Stderr = e.ToolOutput
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err == nil {
+		sha, _, _ := strings.Cut(string(output), "\t")
+		if len(sha) >= 40 {
+			return sha
+		}
+		err = fmt.Errorf("no HEAD in %q", output)
+	}
+	slog.Debug("cannot read the head commit, the results are not cached", "project", owner+"/"+repo, "err", err)
+	return ""
+}
@@ -0,0 +1,148 @@
+package qsos
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries    = 3
+	defaultRetryDelay = time.Second
+	// maxRetryDelay caps the exponential backoff, and the Retry-After
+	// headers: the longer waits are not retried.
+	maxRetryDelay = 5 * time.Minute
+)
+
+// RetryPolicy retries the transient failures, with an exponential backoff:
+// the delay doubles after each attempt, starting at Delay.
+type RetryPolicy struct {
+	// Retries is the number of retries after the first attempt, 0 disables
+	// them.
+	Retries int
+	Delay   time.Duration
+}
+
+// backoff returns the delay before a retry, from 0, with a jitter so that
+// the concurrent evaluations do not retry together.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := min(cmp.Or(p.Delay, defaultRetryDelay)<<retry, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleep waits for a delay, or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport retries the requests failing with a network error, a 5xx
+// status, or a secondary rate limit of GitHub. Only the requests without side
+// effects are retried: GET, HEAD and the GraphQL queries.
+type retryTransport struct {
+	Policy RetryPolicy
+	Next   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		(strings.HasSuffix(req.URL.Path, "/graphql") && (req.Body == nil || req.GetBody != nil))
+	if !idempotent || t.Policy.Retries <= 0 {
+		return t.Next.RoundTrip(req)
+	}
+	for retry := 0; ; retry++ {
+		attempt := req
+		if retry > 0 && req.GetBody != nil {
+			attempt = req.Clone(req.Context())
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		res, err := t.Next.RoundTrip(attempt)
+		delay, retryable := t.Policy.backoff(retry), false
+		switch {
+		case err != nil:
+			retryable = req.Context().Err() == nil
+		case res.StatusCode >= 500 && res.StatusCode != http.StatusNotImplemented:
+			retryable = true
+		case res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests:
+			var after time.Duration
+			after, retryable = secondaryRateLimit(res)
+			delay = max(delay, after)
+		}
+		if !retryable || retry >= t.Policy.Retries || delay > maxRetryDelay {
+			return res, err
+		}
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = res.Status
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		slog.Warn("request failed, retrying", "url", req.URL.Redacted(), "reason", reason, "retry", retry+1, "retry_in", delay.Round(time.Millisecond))
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// secondaryRateLimit returns true if a response is rejected by a secondary
+// rate limit of GitHub, with the delay given by its Retry-After header. The
+// primary rate limit, with no remaining request, is paced by
+// rateLimitTransport.
+func secondaryRateLimit(res *http.Response) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	// The body is read to tell the secondary rate limits from the
+	// forbidden requests, and given back to the caller
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0, false
+	}
+	if bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) {
+		// GitHub asks to wait for at least one minute
+		return time.Minute, true
+	}
+	return 0, res.StatusCode == http.StatusTooManyRequests
+}
+
+// do calls fn until it succeeds, with the retries of the policy. The errors
+// of the commands that cannot be run, like a missing docker, are not retried.
+func (p RetryPolicy) do(ctx context.Context, what string, fn func() error) error {
+	for retry := 0; ; retry++ {
+		err := fn()
+		if err == nil || retry >= p.Retries || errors.Is(err, exec.ErrNotFound) || ctx.Err() != nil {
+			return err
+		}
+		delay := p.backoff(retry)
+		slog.Warn(what+" failed, retrying", "err", err, "retry", retry+1, "retry_in", delay.Round(time.Millisecond))
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
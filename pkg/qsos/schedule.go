@@ -0,0 +1,142 @@
+package qsos
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoSuchSchedule is returned when a schedule is not in the history.
+var ErrNoSuchSchedule = errors.New("no such schedule")
+
+// Schedule is a periodic evaluation of a project.
+type Schedule struct {
+	ID      string
+	Project string
+	// Cron is the cron expression of the schedule, like "0 3 * * 1".
+	Cron string
+	// Profile is the name of the configuration used for the evaluation, or
+	// empty for the default configuration.
+	Profile string
+	// Targets are the URLs where the evaluations are posted, in JSON.
+	Targets   []string
+	NextRun   time.Time
+	LastRun   time.Time `json:",omitzero"`
+	LastError string    `json:",omitempty"`
+}
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks the schedule, and computes its next run after now.
+func (s *Schedule) Validate(now time.Time) error {
+	if _, _, err := ParseProject(s.Project); err != nil {
+		return err
+	}
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	if s.Profile != "" && !profileNameRe.MatchString(s.Profile) {
+		return fmt.Errorf("Invalid profile name %q", s.Profile)
+	}
+	for _, target := range s.Targets {
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			return fmt.Errorf("Invalid target %q: must be an HTTP URL", target)
+		}
+	}
+	s.NextRun = cron.Next(now)
+	if s.NextRun.IsZero() {
+		return fmt.Errorf("Invalid cron expression %q: it never matches", s.Cron)
+	}
+	return nil
+}
+
+func (h *History) schedulesDir() string {
+	return filepath.Join(h.Dir, "schedules")
+}
+
+// SaveSchedule creates or updates a schedule. An ID is generated for a new
+// schedule.
+func (h *History) SaveSchedule(schedule *Schedule) error {
+	if schedule.ID == "" {
+		id := make([]byte, 8)
+		rand.Read(id)
+		schedule.ID = hex.EncodeToString(id)
+	}
+	if err := os.MkdirAll(h.schedulesDir(), 0o755); err != nil {
+		return fmt.Errorf("Cannot create schedules dir: %w", err)
+	}
+	data, err := json.MarshalIndent(schedule, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Cannot encode schedule: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(h.schedulesDir(), schedule.ID+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("Cannot write schedule: %w", err)
+	}
+	return nil
+}
+
+// GetSchedule returns the schedule with the given ID.
+func (h *History) GetSchedule(id string) (*Schedule, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("%w: invalid ID %q", ErrNoSuchSchedule, id)
+	}
+	data, err := os.ReadFile(filepath.Join(h.schedulesDir(), id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchSchedule, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read schedule: %w", err)
+	}
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("Invalid schedule %s: %w", id, err)
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule removes a schedule.
+func (h *History) DeleteSchedule(id string) error {
+	if _, err := h.GetSchedule(id); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(h.schedulesDir(), id+".json")); err != nil {
+		return fmt.Errorf("Cannot delete schedule: %w", err)
+	}
+	return nil
+}
+
+// ListSchedules returns all the schedules, by their next run.
+func (h *History) ListSchedules() ([]*Schedule, error) {
+	entries, err := os.ReadDir(h.schedulesDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Schedule{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read schedules: %w", err)
+	}
+	schedules := []*Schedule{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		schedule, err := h.GetSchedule(id)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRun.Before(schedules[j].NextRun)
+	})
+	return schedules, nil
+}
@@ -0,0 +1,166 @@
+package qsos
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON schema of the reports. The minor
+// version is incremented when fields are added, and the major version when
+// fields are changed or removed.
+const SchemaVersion = "1.17"
+
+//go:embed schema/report.schema.json
+var ReportSchema []byte
+
+// ValidateReport checks that a JSON report is valid for the embedded schema.
+// The validator only supports the keywords used by this schema: type,
+// properties, required, additionalProperties, items, enum, minimum, maximum
+// and local $ref.
+func ValidateReport(report []byte) error {
+	var schema, doc any
+	if err := json.Unmarshal(ReportSchema, &schema); err != nil {
+		return fmt.Errorf("Cannot parse the schema: %w", err)
+	}
+	if err := json.Unmarshal(report, &doc); err != nil {
+		return fmt.Errorf("Cannot parse the report: %w", err)
+	}
+	v := &schemaValidator{root: schema.(map[string]any)}
+	return v.validate(v.root, doc, "")
+}
+
+type schemaValidator struct {
+	root map[string]any
+}
+
+func (v *schemaValidator) validate(schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return v.validate(resolved, value, path)
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return fmt.Errorf("%s: expected type %v, got %s", pathOrRoot(path), types, jsonType(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", pathOrRoot(path), value, enum)
+	}
+	if nb, ok := value.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && nb < min {
+			return fmt.Errorf("%s: %v is less than %v", pathOrRoot(path), nb, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && nb > max {
+			return fmt.Errorf("%s: %v is greater than %v", pathOrRoot(path), nb, max)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required field %s", pathOrRoot(path), name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, field := range value {
+			sub, ok := properties[name].(map[string]any)
+			if !ok {
+				switch additional := schema["additionalProperties"].(type) {
+				case bool:
+					if !additional {
+						return fmt.Errorf("%s: unexpected field %s", pathOrRoot(path), name)
+					}
+					continue
+				case map[string]any:
+					sub = additional
+				default:
+					continue
+				}
+			}
+			if err := v.validate(sub, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) resolve(ref string) (map[string]any, error) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %s", ref)
+	}
+	defs, _ := v.root["$defs"].(map[string]any)
+	schema, ok := defs[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unknown $ref %s", ref)
+	}
+	return schema, nil
+}
+
+func matchesType(types, value any) bool {
+	switch types := types.(type) {
+	case string:
+		return matchesOneType(types, value)
+	case []any:
+		for _, t := range types {
+			if s, ok := t.(string); ok && matchesOneType(s, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesOneType(t string, value any) bool {
+	actual := jsonType(value)
+	if t == "integer" {
+		nb, ok := value.(float64)
+		return ok && nb == math.Trunc(nb)
+	}
+	return t == actual || t == "number" && actual == "integer"
+}
+
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "report"
+	}
+	return strings.TrimPrefix(path, ".")
+}
@@ -0,0 +1,96 @@
+package qsos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	var root map[string]any
+	schema := `{
+		"type": "object",
+		"required": ["Name"],
+		"additionalProperties": false,
+		"properties": {
+			"Name": {"type": "string"},
+			"Score": {"type": "integer", "minimum": 0, "maximum": 5},
+			"Ratio": {"type": ["number", "null"]},
+			"Class": {"enum": ["approved", "forbidden"]},
+			"Tags": {"type": "array", "items": {"type": "string"}},
+			"Child": {"$ref": "#/$defs/Child"},
+			"Extra": {"type": "object", "additionalProperties": {"type": "integer"}}
+		},
+		"$defs": {
+			"Child": {"type": "object", "required": ["ID"]}
+		}
+	}`
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{`{"Name": "a", "Score": 3, "Ratio": 0.5, "Class": "approved", "Tags": ["x"], "Child": {"ID": 1}, "Extra": {"a": 1}}`, ""},
+		{`{"Name": "a", "Ratio": null}`, ""},
+		{`{"Name": "a", "Score": 3.0}`, ""},
+		{`{}`, "report: missing required field Name"},
+		{`[]`, "report: expected type object, got array"},
+		{`{"Name": 1}`, "Name: expected type string, got integer"},
+		{`{"Name": "a", "Score": 2.5}`, "Score: expected type integer, got number"},
+		{`{"Name": "a", "Score": 6}`, "Score: 6 is greater than 5"},
+		{`{"Name": "a", "Score": -1}`, "Score: -1 is less than 0"},
+		{`{"Name": "a", "Class": "other"}`, "Class: other is not one of [approved forbidden]"},
+		{`{"Name": "a", "Tags": ["x", 2]}`, "Tags[1]: expected type string, got integer"},
+		{`{"Name": "a", "Child": {}}`, "Child: missing required field ID"},
+		{`{"Name": "a", "Extra": {"a": "b"}}`, "Extra.a: expected type integer, got string"},
+		{`{"Name": "a", "Other": 1}`, "report: unexpected field Other"},
+	}
+	v := &schemaValidator{root: root}
+	for _, test := range tests {
+		var value any
+		if err := json.Unmarshal([]byte(test.value), &value); err != nil {
+			t.Fatal(err)
+		}
+		err := v.validate(root, value, "")
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("validate(%s) = %v, want %q", test.value, err, test.err)
+		}
+	}
+}
+
+// TestValidateReport checks that the reports of the evaluations are valid for
+// the schema, which must be updated with the fields of the stats and the
+// scores.
+func TestValidateReport(t *testing.T) {
+	now := time.Now()
+	github := &fakeGitHub{stats: &GitHubStats{
+		FirstCommitDate:     now.AddDate(-3, 0, 0),
+		LastCommitDate:      now.AddDate(0, 0, -1),
+		LastHumanCommitDate: now.AddDate(0, 0, -1),
+		Issues:              10,
+		IssueResponseTime:   time.Hour,
+		OpenIssues:          5,
+		ClosedIssues:        50,
+		WeeklyCommits:       []int64{1, 0, 3},
+		License:             "MIT",
+		Releases:            []Release{{Name: "v1.0.0", Date: now.AddDate(0, -2, 0)}},
+		Maintainers:         []*Maintainer{{Login: "dev"}},
+	}}
+	e := newFakeExecutor(github, &fakeSonar{stats: &SonarStats{LinesOfCode: 1000}}, &fakeScorecard{stats: fakeChecks(7)})
+	evaluation, err := Evaluate(context.Background(), e, DefaultConfig(), "owner", "repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report bytes.Buffer
+	if err := WriteJSONReport(&report, []*Evaluation{evaluation}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateReport([]byte(strings.Replace(report.String(), `"SchemaVersion": "`+SchemaVersion+`"`, `"SchemaVersion": "0.1"`, 1))); err == nil {
+		t.Error("a report with an unknown schema version is valid")
+	}
+}
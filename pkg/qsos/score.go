@@ -0,0 +1,552 @@
+package qsos
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+type Thresholds struct {
+	Community *CommunityThreshold
+	Tech      *TechThreshold
+	// Industrialization are the thresholds of the release process.
+	Industrialization *IndustrializationThreshold
+	Adoption          *AdoptionThreshold
+}
+
+type CommunityThreshold struct {
+	Maturity [4]int64
+	Activity [4]int64
+	// ActiveWeeks are the thresholds for the number of weeks of the last
+	// year with commits.
+	ActiveWeeks [4]int64
+	// Popularity has the thresholds for each popularity source (stars,
+	// forks, downloads, dependents, pulls).
+	Popularity   map[string][4]int64
+	Contributors [4]int64
+	// ElephantFactor are the thresholds for the smallest number of
+	// organizations authoring half of the commits.
+	ElephantFactor [4]int64
+	// Responsiveness are the thresholds for the median time to the first
+	// response to the issues.
+	Responsiveness [4]int64
+	// OpenIssues are the thresholds for the percentage of the issues that
+	// are open, and BacklogGrowth for the growth of the open issues in the
+	// last year, in percent.
+	OpenIssues    [4]int64
+	BacklogGrowth [4]int64
+}
+
+type TechThreshold struct {
+	Size                 [4]int64
+	CyclomaticComplexity [4]int64
+	CognitiveComplexity  [4]int64
+	Duplication          [4]int64
+	CodeSmells           [4]int64
+}
+
+type IndustrializationThreshold struct {
+	// ReleaseInterval are the thresholds for the median time between 2
+	// releases, and ReleaseIrregularity for the coefficient of variation of
+	// the times between the releases, in percent.
+	ReleaseInterval     [4]int64
+	ReleaseIrregularity [4]int64
+	// ReleaseAge are the thresholds for the time since the latest release.
+	ReleaseAge [4]int64
+	// SemverShare are the thresholds for the percentage of the releases
+	// with a semantic version, and MajorBumps for the number of new major
+	// versions of the last 2 years.
+	SemverShare [4]int64
+	MajorBumps  [4]int64
+}
+
+type AdoptionThreshold struct {
+	// Platforms are the thresholds for the percentage of the target
+	// platforms covered by the project.
+	Platforms [4]int64
+}
+
+type Weights struct {
+	ScoreCard map[string]int64
+	// Popularity are the weights of the popularity sources.
+	Popularity map[string]int64
+	// Criteria are the weights of the criteria for the overall score.
+	Criteria map[string]int64
+}
+
+type ProjectScores struct {
+	Community *CommunityScores
+	Tech      *TechScores
+	Security  *SecurityScores
+	// Industrialization is nil in the scores computed before it was added.
+	Industrialization *IndustrializationScores `json:",omitempty"`
+	Adoption          *AdoptionScores
+	// Refs has the tech scores for other git refs, if they were asked.
+	Refs map[string]*TechScores
+	// Overall is the weighted average of the scores of the criteria.
+	Overall float64
+	// Unknown are the criteria whose data could not be collected. They have
+	// the lowest score, but are left out of the overall score.
+	Unknown []string `json:",omitempty"`
+	// Licensing is the classification of the license with the policy.
+	Licensing *LicenseClassification
+}
+
+type CommunityScores struct {
+	Maturity     int64
+	Activity     int64
+	Popularity   int64
+	Contributors int64
+	// Responsiveness is the score of the median time to the first response
+	// of the maintainers to the issues, 1 when it is unknown.
+	Responsiveness int64
+	// Backlog is the score of the share of the open issues, averaged with
+	// the score of the growth of the backlog, 1 when they are unknown.
+	Backlog int64
+	// PopularitySources is the breakdown of the popularity score, with the
+	// score of each source.
+	PopularitySources map[string]int64
+}
+
+type TechScores struct {
+	Size                 int64
+	CyclomaticComplexity int64
+	CognitiveComplexity  int64
+	Duplication          int64
+	CodeSmells           int64
+}
+
+type SecurityScores struct {
+	ScoreCard int64
+	// Process is the maturity of the handling of the security incidents,
+	// when the advisories have been collected. It is not a criterion of the
+	// overall score.
+	Process *int64 `json:",omitempty"`
+}
+
+// IndustrializationScores tell if the project has a real release process.
+type IndustrializationScores struct {
+	// ReleaseCadence is the score of the frequency of the releases of the
+	// last 2 years, averaged with the score of their regularity.
+	ReleaseCadence int64
+	// ReleaseFreshness is the score of the time since the latest release,
+	// at most 3 for the projects without a stable 1.0 version.
+	ReleaseFreshness int64
+	// Versioning is the score of the share of the semantic versions,
+	// averaged with the score of the number of new major versions.
+	Versioning int64
+}
+
+// AdoptionScores tell if the project fits the needs of the adopter.
+type AdoptionScores struct {
+	Platforms int64
+}
+
+// CriterionScore is the score of a criterion, with its name in the
+// "axis.criterion" form (like "tech.codesmells").
+type CriterionScore struct {
+	Name  string
+	Score *int64
+}
+
+// Criteria returns the scores of all the criteria, in a stable order.
+func (s *ProjectScores) Criteria() []CriterionScore {
+	if s.Adoption == nil {
+		// Scores computed before the adoption criteria were added
+		s.Adoption = &AdoptionScores{}
+	}
+	if s.Industrialization == nil {
+		s.Industrialization = &IndustrializationScores{}
+	}
+	return []CriterionScore{
+		{"community.maturity", &s.Community.Maturity},
+		{"community.activity", &s.Community.Activity},
+		{"community.popularity", &s.Community.Popularity},
+		{"community.contributors", &s.Community.Contributors},
+		{"community.responsiveness", &s.Community.Responsiveness},
+		{"community.backlog", &s.Community.Backlog},
+		{"tech.size", &s.Tech.Size},
+		{"tech.cyclomaticcomplexity", &s.Tech.CyclomaticComplexity},
+		{"tech.cognitivecomplexity", &s.Tech.CognitiveComplexity},
+		{"tech.duplication", &s.Tech.Duplication},
+		{"tech.codesmells", &s.Tech.CodeSmells},
+		{"security.scorecard", &s.Security.ScoreCard},
+		{"industrialization.releasecadence", &s.Industrialization.ReleaseCadence},
+		{"industrialization.releasefreshness", &s.Industrialization.ReleaseFreshness},
+		{"industrialization.versioning", &s.Industrialization.Versioning},
+		{"adoption.platforms", &s.Adoption.Platforms},
+	}
+}
+
+// IsCriterion returns true if name is the name of a criterion.
+func IsCriterion(name string) bool {
+	empty := &ProjectScores{Community: &CommunityScores{}, Tech: &TechScores{}, Security: &SecurityScores{}}
+	for _, criterion := range empty.Criteria() {
+		if criterion.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeScores computes the scores of a project from the registry of its
+// metrics. The external scorers, the platforms and the license still read
+// the stats. The ages, like the one of the last commit, are computed at the
+// time of the collection of the stats, so that the same stats always have
+// the same scores.
+func ComputeScores(stats *ProjectStats, config *Config) (*ProjectScores, error) {
+	thresholds, weights := config.Thresholds, config.Weights
+	metrics := stats.metrics()
+	at := stats.collectedAt()
+	scorecard, err := computeScoreCardScore(metrics, weights, stats.ScoreCard != nil && stats.ScoreCard.Local)
+	if err != nil {
+		return nil, err
+	}
+	scores := &ProjectScores{
+		Community: &CommunityScores{
+			Maturity:          computeMaturityScore(metrics, thresholds, at),
+			Activity:          computeActivityScore(metrics, thresholds, at),
+			Popularity:        computePopularityScore(metrics, thresholds, weights),
+			Contributors:      computeContributorsScore(metrics, thresholds),
+			Responsiveness:    computeResponsivenessScore(metrics, thresholds),
+			Backlog:           computeBacklogScore(metrics, thresholds),
+			PopularitySources: computePopularitySourcesScores(metrics, thresholds, weights),
+		},
+		Tech: computeTechScores(metrics, thresholds),
+		Security: &SecurityScores{
+			ScoreCard: scorecard,
+			Process:   computeSecurityProcessScore(metrics),
+		},
+		Industrialization: &IndustrializationScores{
+			ReleaseCadence:   computeReleaseCadenceScore(metrics, thresholds),
+			ReleaseFreshness: computeReleaseFreshnessScore(metrics, thresholds, at),
+			Versioning:       computeVersioningScore(metrics, thresholds),
+		},
+		Adoption: &AdoptionScores{
+			Platforms: computePlatformsScore(stats, config),
+		},
+		Unknown: unknownCriteria(metrics),
+	}
+
+	for name, path := range config.Scorers {
+		found := false
+		for _, criterion := range scores.Criteria() {
+			if criterion.Name != name {
+				continue
+			}
+			found = true
+			score, err := runScorer(path, name, stats)
+			if err != nil {
+				return nil, err
+			}
+			*criterion.Score = score
+			scores.Unknown = slices.DeleteFunc(scores.Unknown, func(unknown string) bool { return unknown == name })
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown criterion %s for scorer", name)
+		}
+	}
+	scores.Overall = computeOverallScore(scores, weights)
+	scores.Licensing = config.Licenses.ClassifyLicense(stats.GitHub.License)
+
+	if len(stats.Refs) > 0 {
+		scores.Refs = map[string]*TechScores{}
+		for ref, sonar := range stats.Refs {
+			scores.Refs[ref] = computeTechScores(metrics.withSonar(sonar), thresholds)
+		}
+	}
+	return scores, nil
+}
+
+func computeTechScores(metrics Metrics, thresholds *Thresholds) *TechScores {
+	return &TechScores{
+		Size:                 computeSizeScore(metrics, thresholds),
+		CyclomaticComplexity: computeCyclomaticComplexityScore(metrics, thresholds),
+		CognitiveComplexity:  computeCognitiveComplexityScore(metrics, thresholds),
+		Duplication:          computeDuplicationScore(metrics, thresholds),
+		CodeSmells:           computeCodeSmellsScore(metrics, thresholds),
+	}
+}
+
+func computeOverallScore(scores *ProjectScores, weights *Weights) float64 {
+	var sum, divisor int64
+	for _, criterion := range scores.Criteria() {
+		if slices.Contains(scores.Unknown, criterion.Name) {
+			continue
+		}
+		weight, ok := weights.Criteria[criterion.Name]
+		if !ok {
+			weight = 1
+		}
+		sum += *criterion.Score * weight
+		divisor += weight
+	}
+	if divisor == 0 {
+		return 0
+	}
+	return roundFloat(float64(sum)/float64(divisor), 2)
+}
+
+// criterionMetrics are the metrics without which a criterion is unknown: they
+// are not collected on all the forges, nor without a token.
+var criterionMetrics = map[string]string{
+	"community.responsiveness": "github.issue_response_time",
+	"community.backlog":        "github.open_issue_share",
+}
+
+// unknownCriteria returns the criteria without their metrics.
+func unknownCriteria(metrics Metrics) []string {
+	var unknown []string
+	for _, criterion := range slices.Sorted(maps.Keys(criterionMetrics)) {
+		if _, ok := metrics.Get(criterionMetrics[criterion]); !ok {
+			unknown = append(unknown, criterion)
+		}
+	}
+	return unknown
+}
+
+// roundFloat rounds x to the given number of decimals, so that the reports
+// do not change with the floating-point noise.
+func roundFloat(x float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	return math.Round(x*p) / p
+}
+
+func computeMaturityScore(metrics Metrics, thresholds *Thresholds, at time.Time) int64 {
+	elapsed := at.Sub(metrics.date("github.first_commit")).Nanoseconds()
+	return computeScore(elapsed, thresholds.Community.Maturity, BiggerIsBetter)
+}
+
+// computeActivityScore uses the date of the last commit not authored by a
+// bot, as a project can look active with only dependency updates. When the
+// weekly commits of the last year are known, it is averaged with the score of
+// the number of weeks with commits, so that a single drive-by commit does not
+// make a project look active.
+func computeActivityScore(metrics Metrics, thresholds *Thresholds, at time.Time) int64 {
+	last := metrics.date("github.last_human_commit")
+	if last.IsZero() {
+		// Stats collected before the bots were detected
+		last = metrics.date("github.last_commit")
+	}
+	elapsed := at.Sub(last).Nanoseconds()
+	score := computeScore(elapsed, thresholds.Community.Activity, SmallerIsBetter)
+	if weeks, ok := metrics.Get("github.active_weeks"); ok {
+		// Rounded to the nearest integer, up for the halves
+		score = (score + computeScore(int64(weeks.Value), thresholds.Community.ActiveWeeks, BiggerIsBetter) + 1) / 2
+	}
+	return score
+}
+
+func computePopularitySourcesScores(metrics Metrics, thresholds *Thresholds, weights *Weights) map[string]int64 {
+	scores := map[string]int64{}
+	for name, nb := range popularityValues(metrics) {
+		t, ok := thresholds.Community.Popularity[name]
+		if !ok || weights.Popularity[name] == 0 {
+			continue
+		}
+		scores[name] = computeScore(nb, t, BiggerIsBetter)
+	}
+	return scores
+}
+
+func computePopularityScore(metrics Metrics, thresholds *Thresholds, weights *Weights) int64 {
+	// Weighted average of the sources, rounded to the nearest integer
+	var sum, divisor int64
+	for name, score := range computePopularitySourcesScores(metrics, thresholds, weights) {
+		sum += score * weights.Popularity[name]
+		divisor += weights.Popularity[name]
+	}
+	if divisor == 0 {
+		return 1
+	}
+	return (2*sum + divisor) / (2 * divisor)
+}
+
+// computeContributorsScore averages the score of the active contributors
+// with the score of the elephant factor, when the organizations of the
+// commits are known, so that a project driven by a single vendor scores
+// lower than one with as many contributors from several organizations.
+func computeContributorsScore(metrics Metrics, thresholds *Thresholds) int64 {
+	nb := metrics.int("github.active_contributors")
+	score := computeScore(nb, thresholds.Community.Contributors, BiggerIsBetter)
+	if factor, ok := metrics.Get("github.elephant_factor"); ok {
+		score = (score + computeScore(int64(factor.Value), thresholds.Community.ElephantFactor, BiggerIsBetter) + 1) / 2
+	}
+	return score
+}
+
+// computeResponsivenessScore uses the median time to the first response to
+// the issues. The projects without issues, or on the forges where it is not
+// collected, have the lowest score, and the criterion is unknown.
+func computeResponsivenessScore(metrics Metrics, thresholds *Thresholds) int64 {
+	median, ok := metrics.Get("github.issue_response_time")
+	if !ok {
+		return 1
+	}
+	elapsed := (time.Duration(median.Value) * time.Second).Nanoseconds()
+	return computeScore(elapsed, thresholds.Community.Responsiveness, SmallerIsBetter)
+}
+
+// computeBacklogScore averages the score of the share of the open issues
+// with the score of the growth of the backlog in the last year, so that an
+// old backlog being cleaned up scores better than a growing one. Without the
+// issues, the criterion is unknown.
+func computeBacklogScore(metrics Metrics, thresholds *Thresholds) int64 {
+	open, ok := metrics.Get("github.open_issue_share")
+	if !ok {
+		return 1
+	}
+	growth, _ := metrics.Get("github.backlog_growth")
+	score := computeScore(int64(open.Value), thresholds.Community.OpenIssues, SmallerIsBetter)
+	return (score + computeScore(int64(growth.Value), thresholds.Community.BacklogGrowth, SmallerIsBetter) + 1) / 2
+}
+
+// computeReleaseCadenceScore averages the score of the median time between
+// the releases of the last 2 years with the score of their regularity, when
+// there are at least 3 releases. The projects with less than 2 releases have
+// the lowest score.
+func computeReleaseCadenceScore(metrics Metrics, thresholds *Thresholds) int64 {
+	interval, ok := metrics.Get("github.release_interval")
+	if !ok {
+		return 1
+	}
+	elapsed := (time.Duration(interval.Value) * time.Second).Nanoseconds()
+	score := computeScore(elapsed, thresholds.Industrialization.ReleaseInterval, SmallerIsBetter)
+	if irregularity, ok := metrics.Get("github.release_irregularity"); ok {
+		score = (score + computeScore(int64(irregularity.Value), thresholds.Industrialization.ReleaseIrregularity, SmallerIsBetter) + 1) / 2
+	}
+	return score
+}
+
+// preStableMaxScore is the highest release freshness score of the projects
+// which have never released a stable 1.0 version: their API can still change
+// with any release.
+const preStableMaxScore = 3
+
+// computeReleaseFreshnessScore scores the time since the latest release. The
+// projects without any release have the lowest score.
+func computeReleaseFreshnessScore(metrics Metrics, thresholds *Thresholds, at time.Time) int64 {
+	latest := metrics.date("github.latest_release")
+	if latest.IsZero() {
+		return 1
+	}
+	score := computeScore(at.Sub(latest).Nanoseconds(), thresholds.Industrialization.ReleaseAge, SmallerIsBetter)
+	if metrics.int("github.major_version") < 1 {
+		score = min(score, preStableMaxScore)
+	}
+	return score
+}
+
+// computeVersioningScore averages the score of the share of the releases with
+// a semantic version with the score of the major versions released in the
+// last 2 years, when there are stable semantic versions in this period. The
+// projects without any release have the lowest score.
+func computeVersioningScore(metrics Metrics, thresholds *Thresholds) int64 {
+	semver, ok := metrics.Get("github.semver_share")
+	if !ok {
+		return 1
+	}
+	score := computeScore(int64(semver.Value), thresholds.Industrialization.SemverShare, BiggerIsBetter)
+	if bumps, ok := metrics.Get("github.major_bumps"); ok {
+		score = (score + computeScore(int64(bumps.Value), thresholds.Industrialization.MajorBumps, SmallerIsBetter) + 1) / 2
+	}
+	return score
+}
+
+func computeSizeScore(metrics Metrics, thresholds *Thresholds) int64 {
+	nb := metrics.int("sonar.ncloc")
+	return computeScore(nb, thresholds.Tech.Size, SmallerIsBetter)
+}
+
+func computeCyclomaticComplexityScore(metrics Metrics, thresholds *Thresholds) int64 {
+	functions := metrics.int("sonar.functions")
+	if functions == 0 {
+		return 1
+	}
+	// What is the percentage of functions with high complexity?
+	pct := 100 * metrics.int("sonar.brain_overload") / functions
+	return computeScore(pct, thresholds.Tech.CyclomaticComplexity, SmallerIsBetter)
+}
+
+func computeCognitiveComplexityScore(metrics Metrics, thresholds *Thresholds) int64 {
+	functions := metrics.int("sonar.functions")
+	if functions == 0 {
+		return 1
+	}
+	// What is the average cognitive complexity per function?
+	nb := metrics.int("sonar.cognitive_complexity") / functions
+	return computeScore(nb, thresholds.Tech.CognitiveComplexity, SmallerIsBetter)
+}
+
+func computeDuplicationScore(metrics Metrics, thresholds *Thresholds) int64 {
+	nb := metrics.int("sonar.duplicated_lines_density")
+	return computeScore(nb, thresholds.Tech.Duplication, SmallerIsBetter)
+}
+
+func computeCodeSmellsScore(metrics Metrics, thresholds *Thresholds) int64 {
+	// What is the average number of lines between 2 code smells?
+	smells := metrics.int("sonar.code_smells")
+	if smells == 0 {
+		return computeScore(math.MaxInt64, thresholds.Tech.CodeSmells, BiggerIsBetter)
+	}
+	nb := metrics.int("sonar.ncloc") / smells
+	return computeScore(nb, thresholds.Tech.CodeSmells, BiggerIsBetter)
+}
+
+type Direction bool
+
+const (
+	BiggerIsBetter  Direction = true
+	SmallerIsBetter Direction = false
+)
+
+func computeScore(nb int64, thresholds [4]int64, direction Direction) int64 {
+	scale := [5]int64{1, 2, 3, 4, 5}
+	if direction == SmallerIsBetter {
+		scale = [5]int64{5, 4, 3, 2, 1}
+	}
+	switch {
+	case nb > thresholds[3]:
+		return scale[4]
+	case nb > thresholds[2]:
+		return scale[3]
+	case nb > thresholds[1]:
+		return scale[2]
+	case nb > thresholds[0]:
+		return scale[1]
+	default:
+		return scale[0]
+	}
+}
+
+// computeScoreCardScore computes the weighted average of the checks. When the
+// scorecard has been run on a local working copy, the missing checks are
+// ignored.
+func computeScoreCardScore(metrics Metrics, weights *Weights, local bool) (int64, error) {
+	if !slices.ContainsFunc(metrics, func(m Metric) bool { return m.Source == "scorecard" }) {
+		// The scorecard does not support all the forges
+		return 1, nil
+	}
+	var sum, divisor int64
+	for name, weight := range weights.ScoreCard {
+		check, found := metrics.Get("scorecard." + name)
+		if !found && local {
+			continue
+		} else if !found {
+			return 0, fmt.Errorf("Check %s not found in scorecard scores", name)
+		}
+		score := int64(check.Value)
+		if score == -1 { // -1 means that it doesn't apply
+			continue
+		}
+		sum += score * weight
+		divisor += weight
+	}
+	if divisor == 0 {
+		return 0, errors.New("No scorecard check applies to the project")
+	}
+	return (sum + 1) / divisor / 2, nil
+}
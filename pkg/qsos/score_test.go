@@ -0,0 +1,29 @@
+package qsos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCodeSmellsScore(t *testing.T) {
+	thresholds := DefaultConfig().Thresholds
+	tests := []struct {
+		lines, smells int64
+		score         int64
+	}{
+		{10_000, 500, 1},
+		{10_000, 20, 3},
+		{10_000, 1, 5},
+		// Without code smells, the score is the best one instead of a
+		// division by zero
+		{10_000, 0, 5},
+		{0, 0, 5},
+	}
+	for _, test := range tests {
+		var metrics Metrics
+		metrics.record("sonar", time.Time{}, (&SonarStats{LinesOfCode: test.lines, CodeSmells: test.smells}).metrics())
+		if score := computeCodeSmellsScore(metrics, thresholds); score != test.score {
+			t.Errorf("%d lines, %d code smells: score %d, want %d", test.lines, test.smells, score, test.score)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package qsos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ErrScorecardUnsupported is returned by the scorecard collectors for the
+// projects of the forges that the scorecard does not support.
+var ErrScorecardUnsupported = errors.New("the scorecard does not support this forge")
+
+// ErrScorecardNoToken is returned by the scorecard collectors when there is
+// no token for the API of the forge, which the scorecard requires.
+var ErrScorecardNoToken = errors.New("the scorecard needs a token for the API of the forge")
+
+// ScorecardCLICollector runs the OpenSSF scorecard CLI, in a container. It
+// supports the projects hosted on GitHub and GitLab.
+type ScorecardCLICollector struct {
+	GitHubToken string
+	// GitHubApp gives the GitHub token instead of GitHubToken, if it is set.
+	GitHubApp   *GitHubApp
+	GitLabToken string
+	// Forge hosts the repositories, GitHub when it is nil.
+	Forge *Forge
+	// Retry retries the failed runs, like on the errors of the API of the
+	// forge.
+	Retry RetryPolicy
+	// docker limits the concurrent containers.
+	docker limiter
+}
+
+func (c *ScorecardCLICollector) GetScoreCardStats(ctx context.Context, owner, repo string) (*ScoreCardStats, error) {
+	if kind := c.Forge.orDefault().Kind; kind != ForgeGitHub && kind != ForgeGitLab {
+		return nil, ErrScorecardUnsupported
+	}
+	if c.Forge.orDefault().Kind == ForgeGitHub && c.GitHubApp != nil {
+		token, err := c.GitHubApp.Token()
+		if err != nil {
+			return nil, err
+		}
+		cloned := *c
+		cloned.GitHubToken = token
+		c = &cloned
+	}
+	if c.Forge.orDefault().Kind == ForgeGitHub && c.GitHubToken == "" {
+		return nil, ErrScorecardNoToken
+	}
+	var card *ScoreCardStats
+	err := c.Retry.do(ctx, "scorecard", func() error {
+		release, err := c.docker.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		card, err = runScorecard(ctx, c.command(ctx, owner, repo))
+		return err
+	})
+	return card, err
+}
+
+// AnalyzeDir runs the scorecard with --local on the working copy in dir,
+// mounted in the container. The checks that need the API of the forge are
+// missing.
+func (c *ScorecardCLICollector) AnalyzeDir(ctx context.Context, dir string) (*ScoreCardStats, error) {
+	release, err := c.docker.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	card, err := runScorecard(ctx, c.localCommand(ctx, dir))
+	if err != nil {
+		return nil, err
+	}
+	card.Local = true
+	return card, nil
+}
+
+func runScorecard(ctx context.Context, cmd *exec.Cmd) (*ScoreCardStats, error) {
+	cmd.Stderr = os.Stderr
+	end := traceCommand(ctx, cmd)
+	output, err := cmd.Output()
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot run scorecard: %w", err)
+	}
+
+	var card ScoreCardStats
+	if err := json.Unmarshal(output, &card); err != nil {
+		return nil, fmt.Errorf("Unexpected output from scorecard: %w", err)
+	}
+	return &card, nil
+}
+
+func (c *ScorecardCLICollector) localCommand(ctx context.Context, dir string) *exec.Cmd {
+	return dockerRun(ctx,
+		"-v", dir+":/src:ro",
+		scorecardImage,
+		"--local=/src",
+		"--format=json",
+	)
+}
+
+func (c *ScorecardCLICollector) command(ctx context.Context, owner, repo string) *exec.Cmd {
+	// TODO make the command configurable
+	args := []string{"--net=host"}
+	// The tokens are given by the env, not in the arguments of the processes
+	var env []string
+	forge := c.Forge.orDefault()
+	if forge.Kind == ForgeGitLab {
+		args = append(args, "-e", "GITLAB_AUTH_TOKEN")
+		env = append(env, "GITLAB_AUTH_TOKEN="+c.GitLabToken)
+		if forge.URL.Host != "gitlab.com" {
+			args = append(args, "-e", fmt.Sprintf(`GL_HOST=%s`, forge.URL.Host))
+		}
+	} else {
+		args = append(args, "-e", "GITHUB_AUTH_TOKEN")
+		env = append(env, "GITHUB_AUTH_TOKEN="+c.GitHubToken)
+		if forge.URL.Host != "github.com" {
+			args = append(args, "-e", fmt.Sprintf(`GH_HOST=%s`, forge.URL.Host))
+		}
+	}
+	proxyArgs, proxyEnv := containerProxyEnv()
+	args, env = append(args, proxyArgs...), append(env, proxyEnv...)
+	cmd := dockerRun(ctx, append(args,
+		scorecardImage,
+		"--repo="+forge.RepositoryURL(owner, repo),
+		"--format=json",
+	)...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
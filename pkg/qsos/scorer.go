@@ -0,0 +1,50 @@
+package qsos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// runScorer computes the score of a criterion with an external scorer. The
+// scorer receives the name of the criterion as its first argument and the
+// raw stats, encoded in JSON, on its standard input. It must print the score
+// (from 1 to 5) on its standard output.
+//
+// A scorer can be an executable, or a WASM module (with the .wasm extension)
+// that is run with a WASI runtime. The runtime is wasmtime by default, and it
+// can be changed with the QSOS_WASM_RUNTIME env variable.
+func runScorer(path, criterion string, stats *ProjectStats) (int64, error) {
+	input, err := json.Marshal(stats)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot encode stats: %w", err)
+	}
+
+	cmd := exec.Command(path, criterion)
+	if strings.HasSuffix(path, ".wasm") {
+		runtime := "wasmtime"
+		if r := os.Getenv("QSOS_WASM_RUNTIME"); r != "" {
+			runtime = r
+		}
+		cmd = exec.Command(runtime, "run", path, criterion)
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("Cannot run scorer %s: %w", path, err)
+	}
+
+	score, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Unexpected output from scorer %s: %w", path, err)
+	}
+	if score < 1 || score > 5 {
+		return 0, fmt.Errorf("Invalid score from scorer %s: %d", path, score)
+	}
+	return score, nil
+}
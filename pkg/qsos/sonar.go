@@ -0,0 +1,467 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sonarCloudURL is the default Sonarqube server, without a token.
+const sonarCloudURL = "https://sonarcloud.io"
+
+// SonarqubeCollector collects the tech stats with sonar-scanner-cli and a
+// Sonarqube server.
+type SonarqubeCollector struct {
+	URL *url.URL
+	// Token is optional. Without it, the projects are not analyzed, and the
+	// measures of their public analyses are read.
+	Token string
+	HTTP  *http.Client
+	// CACert is the optional PEM bundle of the CAs of the server, given to
+	// the scanner.
+	CACert string
+	// Fallback counts the functions when Sonarqube doesn't report them, for
+	// the languages it has not analyzed (or not in its community edition).
+	// Its count is a heuristic, by regular expressions on the lines.
+	Fallback *LiteCollector
+	// Forge hosts the repositories, GitHub when it is nil.
+	Forge *Forge
+	// Progress is optional.
+	Progress ProgressFunc
+	// ScanTimeout limits the runs of the scanner, 0 is no limit.
+	ScanTimeout time.Duration
+	// PollTimeout is the maximal wait for the measures after a scan, 100
+	// seconds when it is 0.
+	PollTimeout time.Duration
+	// ToolOutput receives the output of git and of the scanner, discarded
+	// when it is nil.
+	ToolOutput io.Writer
+	// docker limits the concurrent containers.
+	docker limiter
+}
+
+type SonarMeasuresResponse struct {
+	Component struct {
+		Measures []struct {
+			Metric string
+			Value  string
+		}
+	}
+}
+
+// skipScanner tells if SKIP_SONAR_SCANNER is set, to read the analyses of a
+// previous run instead of running sonar-scanner-cli.
+func skipScanner() (bool, error) {
+	skip := os.Getenv("SKIP_SONAR_SCANNER")
+	if skip == "" {
+		return false, nil
+	}
+	skipped, err := strconv.ParseBool(skip)
+	if err != nil {
+		return false, fmt.Errorf("Invalid value for SKIP_SONAR_SCANNER: %w", err)
+	}
+	return skipped, nil
+}
+
+// clonesSources is true when sonar-scanner-cli analyzes the clones of the
+// repositories.
+func (c *SonarqubeCollector) clonesSources() bool {
+	skipped, err := skipScanner()
+	return c.Token != "" && !skipped && err == nil
+}
+
+func (c *SonarqubeCollector) GetSonarStats(ctx context.Context, owner, repo string) (*SonarStats, error) {
+	skipped, err := skipScanner()
+	if err != nil {
+		return nil, err
+	}
+	component := componentName(owner, repo)
+	if c.Token == "" {
+		return c.getPublicSonarStats(ctx, owner, repo)
+	}
+	if skipped {
+		return c.waitSonarStats(ctx, component)
+	}
+	tmpDir, err := os.MkdirTemp("", component+"-")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create a temporary dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := cloneRepository(ctx, c.ToolOutput, c.Forge.cloneURL(owner, repo), tmpDir); err != nil {
+		return nil, err
+	}
+	return c.AnalyzeDir(ctx, tmpDir, component)
+}
+
+// AnalyzeDir runs sonar-scanner-cli on the sources in dir, and returns the
+// stats of the Sonarqube component.
+func (c *SonarqubeCollector) AnalyzeDir(ctx context.Context, dir, component string) (*SonarStats, error) {
+	if c.Token == "" {
+		if c.Fallback == nil {
+			return nil, errors.New("Cannot analyze the sources without SONARQUBE_TOKEN")
+		}
+		return c.Fallback.AnalyzeDir(ctx, dir, component)
+	}
+	// The project created by an interrupted first analysis is deleted
+	_, err := c.getSonarMeasures(ctx, component)
+	created := errors.Is(err, errNoSonarComponent)
+	err = withTimeout(ctx, c.ScanTimeout, "sonar-scanner-cli", "QSOS_SONAR_SCAN_TIMEOUT", func(ctx context.Context) error {
+		return c.runSonarScanner(ctx, dir, component)
+	})
+	if err != nil {
+		if created && (ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded)) {
+			c.deleteProject(component)
+		}
+		return nil, err
+	}
+	stats, err := c.waitSonarStats(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Functions == 0 && stats.LinesOfCode > 0 && c.Fallback != nil {
+		lite, err := c.Fallback.AnalyzeDir(ctx, dir, component)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot count the functions: %w", err)
+		}
+		slog.Info("no functions in Sonarqube, counted by the lite analyzer", "component", component, "functions", lite.Functions)
+		stats.Functions = lite.Functions
+		stats.FunctionsEstimated = true
+	}
+	return stats, nil
+}
+
+// errNoSonarComponent is returned when a component is not in Sonarqube.
+var errNoSonarComponent = errors.New("no such component")
+
+// publicSonarComponents returns the keys of the components of a project: the
+// one of the analyses by qsos, and the one of the analyses imported from
+// GitHub by SonarCloud.
+func publicSonarComponents(owner, repo string) []string {
+	return []string{owner + "-" + repo, owner + "_" + repo}
+}
+
+// getPublicSonarStats returns the stats of the existing analysis of a
+// project, without a token. If there is no analysis, the fallback analyzes
+// the project.
+func (c *SonarqubeCollector) getPublicSonarStats(ctx context.Context, owner, repo string) (*SonarStats, error) {
+	for _, component := range publicSonarComponents(owner, repo) {
+		stats, err := c.getSonarStats(ctx, component)
+		if errors.Is(err, errNoSonarComponent) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stats.LinesOfCode > 0 {
+			return stats, nil
+		}
+	}
+	if c.Fallback == nil {
+		return nil, fmt.Errorf("No public analysis of %s/%s in %s, set SONARQUBE_TOKEN to analyze it", owner, repo, c.URL)
+	}
+	slog.Info("no public analysis in Sonarqube, using the lite analyzer", "project", owner+"/"+repo, "url", c.URL)
+	return c.Fallback.GetSonarStats(ctx, owner, repo)
+}
+
+// sonarPollInterval is the delay between the attempts to get the measures.
+const sonarPollInterval = time.Second
+
+// waitSonarStats returns the stats of a Sonarqube component, after waiting
+// for the measures to be available.
+func (c *SonarqubeCollector) waitSonarStats(ctx context.Context, component string) (*SonarStats, error) {
+	// XXX Sonarqube takes some time to build the measures after the scanner
+	// has sent its result...
+	attempts := max(int(cmp.Or(c.PollTimeout, DefaultTimeouts.SonarPoll)/sonarPollInterval), 1)
+	for i := 0; i < attempts; i++ {
+		stats, err := c.getSonarStats(ctx, component)
+		if err != nil {
+			return nil, err
+		}
+		if stats.LinesOfCode > 0 && stats.BrainOverload > 0 {
+			return stats, nil
+		}
+		slog.Debug("measures not yet available in Sonarqube", "component", component, "attempt", i+1)
+		c.Progress.report(ProgressEvent{Project: component, Phase: PhaseSonar, Step: StepPoll, Count: i + 1, Total: attempts})
+		if err := sleep(ctx, sonarPollInterval); err != nil {
+			return nil, err
+		}
+	}
+	stats, err := c.getSonarStats(ctx, component)
+	if err != nil {
+		return nil, err
+	}
+	stats.Incomplete = stats.LinesOfCode == 0 || stats.BrainOverload == 0
+	if stats.Incomplete {
+		slog.Warn("measures not available in Sonarqube, they are incomplete", "component", component, "timeout", time.Duration(attempts)*sonarPollInterval, "variable", "QSOS_SONAR_POLL_TIMEOUT")
+	}
+	return stats, nil
+}
+
+// runSonarScanner runs sonar-scanner-cli on the sources in dir, and sends
+// the results to the given Sonarqube component.
+func (c *SonarqubeCollector) runSonarScanner(ctx context.Context, dir, component string) error {
+	release, err := c.docker.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	cmd := c.scannerCommand(ctx, dir, component)
+	cmd.Stdout = c.ToolOutput
+	cmd.Stderr = c.ToolOutput
+	end := traceCommand(ctx, cmd)
+	if err := cmd.Start(); err != nil {
+		end(err)
+		return fmt.Errorf("Cannot run sonar-scanner-cli: %w", err)
+	}
+	if c.Progress != nil {
+		// The scan can take many minutes, its elapsed time is reported
+		// until it ends
+		ended := make(chan struct{})
+		defer close(ended)
+		go func() {
+			start := time.Now()
+			ticker := time.NewTicker(scanProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ended:
+					return
+				case <-ticker.C:
+					c.Progress.report(ProgressEvent{Project: component, Phase: PhaseSonar, Step: StepScan, Elapsed: time.Since(start)})
+				}
+			}
+		}()
+	}
+	err = cmd.Wait()
+	end(err)
+	if err != nil {
+		return fmt.Errorf("Cannot run sonar-scanner-cli: %w", err)
+	}
+	return nil
+}
+
+func (c *SonarqubeCollector) scannerCommand(ctx context.Context, dir, component string) *exec.Cmd {
+	// TODO make the command configurable
+	args := []string{
+		"--net=host",
+		"-e", fmt.Sprintf(`SONAR_HOST_URL=%s`, c.URL),
+		// The token is given by the env, not in the arguments of the
+		// processes
+		"-e", "SONAR_TOKEN",
+	}
+	env := []string{"SONAR_TOKEN=" + c.Token}
+	proxyArgs, proxyEnv := containerProxyEnv()
+	args, env = append(args, proxyArgs...), append(env, proxyEnv...)
+	if options := javaProxyOptions(); options != "" {
+		args = append(args, "-e", "SONAR_SCANNER_OPTS="+options)
+	}
+	if c.CACert != "" {
+		// The image imports the certificates of /tmp/cacerts in the
+		// truststore of the scanner
+		args = append(args, "-v", c.CACert+":/tmp/cacerts/qsos-ca.pem:ro")
+	}
+	cmd := dockerRun(ctx, append(args,
+		"-v", fmt.Sprintf(`%s:/usr/src`, dir),
+		sonarScannerImage,
+		fmt.Sprintf(`-Dsonar.projectKey=%s`, component),
+		"-Dsonar.sources=.",
+		// The blame of the files of the partial clones would fetch the
+		// content of all their previous versions
+		"-Dsonar.scm.disabled=true",
+	)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = dir
+	return cmd
+}
+
+func (c *SonarqubeCollector) getSonarStats(ctx context.Context, component string) (*SonarStats, error) {
+	stats, err := c.getSonarMeasures(ctx, component)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get sonar stats: %w", err)
+	}
+	if stats.LinesOfCode == 0 {
+		return stats, nil
+	}
+	nb, err := c.getSonarBrainOverloadIssues(ctx, component)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get sonar issues: %w", err)
+	}
+	stats.BrainOverload = nb
+	return stats, nil
+}
+
+func (c *SonarqubeCollector) getSonarMeasures(ctx context.Context, component string) (*SonarStats, error) {
+	cloned := *c.URL
+	cloned.Path = "/api/measures/component"
+	cloned.RawQuery = url.Values{
+		"component":  []string{component},
+		"metricKeys": []string{"ncloc,functions,code_smells,complexity,cognitive_complexity,duplicated_lines_density"},
+	}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloned.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create request: %w", err)
+	}
+	c.authorize(req)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error on request: %w", err)
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: %s", errNoSonarComponent, component)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+	defer res.Body.Close()
+
+	stats := &SonarStats{}
+	var data SonarMeasuresResponse
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	for _, measure := range data.Component.Measures {
+		switch measure.Metric {
+		case "ncloc":
+			nb, err := strconv.ParseInt(measure.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ncloc value: %w", err)
+			}
+			stats.LinesOfCode = nb
+		case "functions":
+			nb, err := strconv.ParseInt(measure.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid functions value: %w", err)
+			}
+			stats.Functions = nb
+		case "code_smells":
+			nb, err := strconv.ParseInt(measure.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid code_smells value: %w", err)
+			}
+			stats.CodeSmells = nb
+		case "complexity":
+			nb, err := strconv.ParseInt(measure.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid complexity value: %w", err)
+			}
+			stats.CyclomaticComplexity = nb
+		case "cognitive_complexity":
+			nb, err := strconv.ParseInt(measure.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cognitive_complexity value: %w", err)
+			}
+			stats.CognitiveComplexity = nb
+		case "duplicated_lines_density":
+			nb, err := strconv.ParseFloat(measure.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duplicated_lines_density value: %w", err)
+			}
+			stats.DuplicationDensity = nb
+		}
+	}
+
+	return stats, nil
+}
+
+type SonarIssues struct {
+	Total int64
+}
+
+func (c *SonarqubeCollector) getSonarBrainOverloadIssues(ctx context.Context, component string) (int64, error) {
+	cloned := *c.URL
+	cloned.Path = "/api/issues/search"
+	cloned.RawQuery = url.Values{
+		"components": []string{component},
+		"tags":       []string{"brain-overload"},
+	}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloned.String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot create request: %w", err)
+	}
+	c.authorize(req)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("Error on request: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+	defer res.Body.Close()
+
+	var data SonarIssues
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return 0, fmt.Errorf("invalid response: %w", err)
+	}
+	return data.Total, nil
+}
+
+// deleteProject deletes a Sonarqube project, which needs the permission to
+// administer it. The errors are only logged.
+func (c *SonarqubeCollector) deleteProject(component string) {
+	// The context of the evaluation is done, the deletion has its own
+	// timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cloned := *c.URL
+	cloned.Path = "/api/projects/delete"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cloned.String(), strings.NewReader(url.Values{"project": {component}}.Encode()))
+	if err != nil {
+		slog.Warn("cannot delete the Sonarqube project", "component", component, "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.authorize(req)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		slog.Warn("cannot delete the Sonarqube project", "component", component, "err", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		slog.Warn("cannot delete the Sonarqube project", "component", component, "status", res.StatusCode)
+		return
+	}
+	slog.Info("Sonarqube project of the interrupted analysis deleted", "component", component)
+}
+
+// authorize adds the token, if any, to a request.
+func (c *SonarqubeCollector) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Add("Authorization", "Bearer "+c.Token)
+	}
+}
+
+func (c *SonarqubeCollector) getSonarqubeVersion(ctx context.Context) (string, error) {
+	cloned := *c.URL
+	cloned.Path = "/api/server/version"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloned.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("Cannot create request: %w", err)
+	}
+	c.authorize(req)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error on request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+	version, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("invalid response: %w", err)
+	}
+	return strings.TrimSpace(string(version)), nil
+}
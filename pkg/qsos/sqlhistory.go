@@ -0,0 +1,217 @@
+package qsos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDateFormat is the format of the dates in SQLite, with a fixed width
+// so that they are sorted as strings.
+const sqliteDateFormat = "2006-01-02T15:04:05.000000000Z"
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS evaluations (
+  id TEXT PRIMARY KEY,
+  date TEXT NOT NULL,
+  owner TEXT NOT NULL COLLATE NOCASE,
+  repo TEXT NOT NULL COLLATE NOCASE,
+  tags TEXT NOT NULL,
+  record TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS evaluations_project ON evaluations (owner, repo, date);`
+
+const postgresSchema = `CREATE TABLE IF NOT EXISTS evaluations (
+  id TEXT PRIMARY KEY,
+  date TIMESTAMPTZ NOT NULL,
+  owner TEXT NOT NULL,
+  repo TEXT NOT NULL,
+  tags JSONB NOT NULL,
+  record JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS evaluations_project ON evaluations (lower(owner), lower(repo), date);`
+
+// SQLStore stores the records of the history in an SQLite or a PostgreSQL
+// database, with their raw stats and their scores in JSON.
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// OpenHistoryDB opens the history in a database, created if it does not
+// exist: PostgreSQL for a postgres:// URL, or else an SQLite file. The
+// intake requests and the schedules are in dir, by default the directory of
+// the SQLite file.
+func OpenHistoryDB(db, dir string) (*History, error) {
+	var store *SQLStore
+	var err error
+	if strings.HasPrefix(db, "postgres://") || strings.HasPrefix(db, "postgresql://") {
+		if dir == "" {
+			return nil, errors.New("QSOS_HISTORY_DIR environment variable is not set, it is needed with PostgreSQL")
+		}
+		store, err = OpenPostgresStore(db)
+	} else {
+		if dir == "" {
+			dir = filepath.Dir(db)
+		}
+		store, err = OpenSQLiteStore(db)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Cannot create history dir: %w", err)
+	}
+	return &History{Dir: dir, Store: store}, nil
+}
+
+// OpenSQLiteStore opens an SQLite database, created if it does not exist.
+func OpenSQLiteStore(path string) (*SQLStore, error) {
+	// The concurrent evaluations wait for the lock of the database
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open the history database: %w", err)
+	}
+	return newSQLStore(db, false)
+}
+
+// OpenPostgresStore opens a PostgreSQL database, shared by the instances of
+// a team, given by its URL.
+func OpenPostgresStore(url string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open the history database: %w", err)
+	}
+	return newSQLStore(db, true)
+}
+
+func newSQLStore(db *sql.DB, postgres bool) (*SQLStore, error) {
+	schema := sqliteSchema
+	if postgres {
+		schema = postgresSchema
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Cannot create the history database: %w", err)
+	}
+	return &SQLStore{db: db, postgres: postgres}, nil
+}
+
+// Close closes the database.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// query returns a query with the placeholders of the database: ? for
+// SQLite, $1, $2... for PostgreSQL.
+func (s *SQLStore) query(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// date returns a date for the database, a string in SQLite.
+func (s *SQLStore) date(t time.Time) any {
+	if s.postgres {
+		return t.UTC()
+	}
+	return t.UTC().Format(sqliteDateFormat)
+}
+
+func (s *SQLStore) Put(record *HistoryRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("Cannot encode history record: %w", err)
+	}
+	tags, err := json.Marshal(record.Tags)
+	if err != nil {
+		return fmt.Errorf("Cannot encode history record: %w", err)
+	}
+	_, err = s.db.Exec(s.query(`INSERT INTO evaluations (id, date, owner, repo, tags, record) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET tags = excluded.tags, record = excluded.record`),
+		record.ID, s.date(record.Date), record.Evaluation.Owner, record.Evaluation.Repo, string(tags), string(data))
+	if err != nil {
+		return fmt.Errorf("Cannot write history: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Get(id string) (*HistoryRecord, error) {
+	var data []byte
+	err := s.db.QueryRow(s.query(`SELECT record FROM evaluations WHERE id = ?`), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotInHistory, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read history: %w", err)
+	}
+	var record HistoryRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("Invalid history record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// Search selects the records by project and by date, the tags and the
+// scores are filtered by History.Search.
+func (s *SQLStore) Search(filter *HistoryFilter) ([]*HistoryRecord, error) {
+	var where []string
+	var args []any
+	if filter.Owner != "" {
+		where, args = append(where, "lower(owner) = lower(?)"), append(args, filter.Owner)
+	}
+	if filter.Repo != "" {
+		where, args = append(where, "lower(repo) = lower(?)"), append(args, filter.Repo)
+	}
+	if !filter.Since.IsZero() {
+		where, args = append(where, "date >= ?"), append(args, s.date(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		where, args = append(where, "date <= ?"), append(args, s.date(filter.Until))
+	}
+	query := `SELECT id, record FROM evaluations`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := s.db.Query(s.query(query+" ORDER BY date"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read history: %w", err)
+	}
+	defer rows.Close()
+	var records []*HistoryRecord
+	for rows.Next() {
+		var id string
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("Cannot read history: %w", err)
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("Invalid history record %s: %w", id, err)
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot read history: %w", err)
+	}
+	return records, nil
+}
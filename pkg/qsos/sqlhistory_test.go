@@ -0,0 +1,91 @@
+package qsos
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestHistoryStores checks that the stores of the history find the same
+// records.
+func TestHistoryStores(t *testing.T) {
+	sqlite, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+	stores := map[string]HistoryStore{
+		"dir":    DirStore(t.TempDir()),
+		"sqlite": sqlite,
+	}
+	scores, err := ComputeScores(&ProjectStats{GitHub: &GitHubStats{}, Sonar: &SonarStats{}, ScoreCard: &ScoreCardStats{}}, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	records := []*HistoryRecord{
+		{ID: "1", Date: date.AddDate(0, 0, -20), Tags: []string{"candidate"}, Evaluation: &Evaluation{Owner: "Owner", Repo: "repo", Scores: scores}},
+		{ID: "2", Date: date.AddDate(0, 0, -10), Tags: []string{}, Evaluation: &Evaluation{Owner: "owner", Repo: "Repo", Scores: scores}},
+		{ID: "3", Date: date, Tags: []string{"candidate"}, Evaluation: &Evaluation{Owner: "other", Repo: "repo", Scores: scores}},
+	}
+	tests := []struct {
+		filter HistoryFilter
+		ids    []string
+	}{
+		{HistoryFilter{}, []string{"1", "2", "3"}},
+		{HistoryFilter{Owner: "OWNER", Repo: "repo"}, []string{"1", "2"}},
+		{HistoryFilter{Tags: []string{"candidate"}}, []string{"1", "3"}},
+		{HistoryFilter{Since: date.AddDate(0, 0, -15)}, []string{"2", "3"}},
+		{HistoryFilter{Until: date.AddDate(0, 0, -10)}, []string{"1", "2"}},
+		{HistoryFilter{Owner: "owner", Tags: []string{"other"}}, nil},
+	}
+	for name, store := range stores {
+		h := &History{Dir: t.TempDir(), Store: store}
+		for _, record := range records {
+			if err := store.Put(record); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		for _, test := range tests {
+			found, err := h.Search(&test.filter)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			var ids []string
+			for _, record := range found {
+				ids = append(ids, record.ID)
+			}
+			if !slices.Equal(ids, test.ids) {
+				t.Errorf("%s: Search(%+v) = %v, want %v", name, test.filter, ids, test.ids)
+			}
+		}
+
+		// The tags are updated in place
+		if _, err := h.Tag("2", "candidate"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		record, err := h.Get("2")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(record.Tags, []string{"candidate"}) || !record.Date.Equal(records[1].Date) {
+			t.Errorf("%s: record 2 = %+v, want the candidate tag", name, record)
+		}
+		if _, err := h.Get("4"); !errors.Is(err, ErrNotInHistory) {
+			t.Errorf("%s: Get(\"4\") = %v, want %v", name, err, ErrNotInHistory)
+		}
+	}
+}
+
+func TestSQLStoreQuery(t *testing.T) {
+	query := `SELECT record FROM evaluations WHERE owner = ? AND date >= ?`
+	if got := (&SQLStore{}).query(query); got != query {
+		t.Errorf("SQLite query = %s, want %s", got, query)
+	}
+	want := `SELECT record FROM evaluations WHERE owner = $1 AND date >= $2`
+	if got := (&SQLStore{postgres: true}).query(query); got != want {
+		t.Errorf("PostgreSQL query = %s, want %s", got, want)
+	}
+}
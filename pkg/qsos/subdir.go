@@ -0,0 +1,77 @@
+package qsos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// component returns the name of the tech stats of a project in the
+// analyzers, with the sub-directory if any.
+func (e *Executor) component(owner, repo string) string {
+	if e.Subdir == "" {
+		return componentName(owner, repo)
+	}
+	return componentName(owner, repo) + "-" + unsafeRefChars.ReplaceAllString(filepath.ToSlash(filepath.Clean(e.Subdir)), "_")
+}
+
+// subdirPath returns the path of the sub-directory in a working copy.
+func (e *Executor) subdirPath(dir string) (string, error) {
+	path := filepath.Join(dir, e.Subdir)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("Cannot find the sub-directory %s in the repository", e.Subdir)
+	}
+	return path, nil
+}
+
+// clonesSources tells if the repositories are cloned for the analyzer: the
+// sub-directories are always analyzed, as the public analyses cover the
+// whole repositories.
+func (e *Executor) clonesSources() bool {
+	c, ok := e.Sonar.(sourcesCollector)
+	return e.Subdir != "" || ok && c.clonesSources()
+}
+
+// analyzeClone computes the tech stats of the clone of a repository, or of
+// its sub-directory.
+func (e *Executor) analyzeClone(ctx context.Context, owner, repo string, clone func() (string, error)) (*SonarStats, error) {
+	dir, err := clone()
+	if err != nil {
+		return nil, err
+	}
+	if e.Subdir != "" {
+		if dir, err = e.subdirPath(dir); err != nil {
+			return nil, err
+		}
+	}
+	return e.Sonar.AnalyzeDir(ctx, dir, e.component(owner, repo))
+}
+
+// filterSubdirCommits replaces the stats of the commits with the ones of the
+// commits touching the sub-directory, from the history of the clone of the
+// repository.
+func (e *Executor) filterSubdirCommits(ctx context.Context, clone func() (string, error), stats *GitHubStats) error {
+	dir, err := clone()
+	if err != nil {
+		return err
+	}
+	history, err := gitHistoryStats(ctx, e.ToolOutput, dir, e.Subdir, e.Contributors, e.Bots)
+	if err != nil {
+		return err
+	}
+	stats.FirstCommitDate = history.FirstCommitDate
+	stats.LastCommitDate = history.LastCommitDate
+	stats.LastHumanCommitDate = history.LastHumanCommitDate
+	stats.ActiveContributors = history.ActiveContributors
+	stats.ContributorsWindow = history.ContributorsWindow
+	stats.BotCommits = history.BotCommits
+	stats.ElephantFactor = history.ElephantFactor
+	stats.TopOrganization = history.TopOrganization
+	stats.TopOrganizationShare = history.TopOrganizationShare
+	stats.BotCommitShare = history.BotCommitShare
+	stats.WeeklyCommits = history.WeeklyCommits
+	stats.WeeklyCommitsWithBots = history.WeeklyCommitsWithBots
+	stats.ShallowHistory = history.ShallowHistory
+	return nil
+}
@@ -0,0 +1,139 @@
+package qsos
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorBold   = "\033[1m"
+	colorReset  = "\033[0m"
+)
+
+// colorize wraps text in an ANSI color, if the colors are enabled.
+func (o *ReportOptions) colorize(color, text string) string {
+	if o == nil || !o.Colors || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// scoreColor returns the color of a score from 1 to 5: red up to 2, yellow
+// for 3, and green from 4.
+func scoreColor(score float64) string {
+	switch {
+	case score < 2.5:
+		return colorRed
+	case score < 3.5:
+		return colorYellow
+	}
+	return colorGreen
+}
+
+// formatScore formats a score of a criterion, colored by its value.
+func (o *ReportOptions) formatScore(score int64) string {
+	return o.colorize(scoreColor(float64(score)), fmt.Sprint(score))
+}
+
+// formatOverall formats an overall score, colored by its value.
+func (o *ReportOptions) formatOverall(score float64) string {
+	return o.colorize(scoreColor(score), fmt.Sprintf("%.2f", score))
+}
+
+var escapeRegexp = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// textWidth returns the width of text on a terminal, without the colors.
+func textWidth(text string) int {
+	return utf8.RuneCountInString(escapeRegexp.ReplaceAllString(text, ""))
+}
+
+// textTable is a table of the text reports, with aligned columns. The
+// cells can be colored.
+type textTable struct {
+	header []string
+	rows   [][]string
+	// numeric tells which columns are right-aligned.
+	numeric []bool
+	opts    *ReportOptions
+}
+
+// newTextTable returns a table with the given header, translated.
+func newTextTable(opts *ReportOptions, header ...string) *textTable {
+	translated := make([]string, len(header))
+	for i, h := range header {
+		translated[i] = opts.language().tr(h)
+	}
+	return &textTable{header: translated, numeric: make([]bool, len(header)), opts: opts}
+}
+
+// alignRight right-aligns the given columns.
+func (t *textTable) alignRight(columns ...int) *textTable {
+	for _, c := range columns {
+		t.numeric[c] = true
+	}
+	return t
+}
+
+func (t *textTable) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *textTable) print(w io.Writer) {
+	widths := make([]int, len(t.header))
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], textWidth(cell))
+		}
+	}
+	t.printRow(w, t.header, widths, colorBold)
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+	fmt.Fprintf(w, "%s\n", strings.Join(separators, "-+-"))
+	for _, row := range t.rows {
+		t.printRow(w, row, widths, "")
+	}
+}
+
+func (t *textTable) printRow(w io.Writer, row []string, widths []int, color string) {
+	cells := make([]string, len(widths))
+	for i := range widths {
+		var cell string
+		if i < len(row) {
+			cell = row[i]
+		}
+		padding := strings.Repeat(" ", widths[i]-textWidth(cell))
+		if t.numeric[i] {
+			cells[i] = padding + t.opts.colorize(color, cell)
+		} else {
+			cells[i] = t.opts.colorize(color, cell) + padding
+		}
+	}
+	fmt.Fprintf(w, "%s\n", strings.TrimRight(strings.Join(cells, " | "), " "))
+}
+
+// printSection prints the title of a section, translated.
+func printSection(w io.Writer, lang *Language, title string) {
+	fmt.Fprintf(w, "\n--- %s ---\n", lang.tr(title))
+}
+
+// printFields prints a section of labels and values, with the values
+// aligned. The title and the labels are translated.
+func printFields(w io.Writer, lang *Language, title string, fields [][2]string) {
+	printSection(w, lang, title)
+	width := 0
+	for _, field := range fields {
+		width = max(width, textWidth(lang.tr(field[0])))
+	}
+	for _, field := range fields {
+		label := lang.tr(field[0]) + ":"
+		fmt.Fprintf(w, "%s%s %s\n", label, strings.Repeat(" ", width+1-textWidth(label)), field[1])
+	}
+}
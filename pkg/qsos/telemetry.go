@@ -0,0 +1,49 @@
+package qsos
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// APIRequestCount is the number of requests sent to the API of a host, with
+// a status code.
+type APIRequestCount struct {
+	Host string
+	// Code is the status code of the responses, or "error" for the requests
+	// without a response.
+	Code  string
+	Count int64
+}
+
+var apiRequests = struct {
+	sync.Mutex
+	counts map[[2]string]int64
+}{counts: map[[2]string]int64{}}
+
+func countAPIRequest(req *http.Request, res *http.Response, err error) {
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	apiRequests.Lock()
+	defer apiRequests.Unlock()
+	apiRequests.counts[[2]string{req.URL.Host, code}]++
+}
+
+// APIRequestCounts returns the numbers of requests sent to the APIs since
+// the start, by host and by status code.
+func APIRequestCounts() []APIRequestCount {
+	apiRequests.Lock()
+	defer apiRequests.Unlock()
+	var counts []APIRequestCount
+	for key, count := range apiRequests.counts {
+		counts = append(counts, APIRequestCount{Host: key[0], Code: key[1], Count: count})
+	}
+	slices.SortFunc(counts, func(a, b APIRequestCount) int {
+		return cmp.Or(cmp.Compare(a.Host, b.Host), cmp.Compare(a.Code, b.Code))
+	})
+	return counts
+}
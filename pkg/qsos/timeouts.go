@@ -0,0 +1,68 @@
+package qsos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Timeouts are the maximal durations of the phases of the collection, so
+// that a huge repository does not block the evaluations. 0 is no limit.
+type Timeouts struct {
+	// GitHub is the timeout of the community stats, on any forge.
+	GitHub time.Duration
+	// SonarScan is the timeout of a run of sonar-scanner-cli.
+	SonarScan time.Duration
+	// SonarPoll is the maximal wait for the measures computed by Sonarqube
+	// after a scan. The measures are then incomplete.
+	SonarPoll time.Duration
+	// Scorecard is the timeout of the scorecard, with its retries.
+	Scorecard time.Duration
+}
+
+// DefaultTimeouts are the timeouts of the executors created from the env.
+var DefaultTimeouts = Timeouts{
+	GitHub:    30 * time.Minute,
+	SonarScan: time.Hour,
+	SonarPoll: 100 * time.Second,
+	Scorecard: 20 * time.Minute,
+}
+
+// timeoutsFromEnv returns the timeouts given by the env variables, like
+// QSOS_SONAR_SCAN_TIMEOUT=2h, with the defaults for the other ones.
+func timeoutsFromEnv() Timeouts {
+	timeouts := DefaultTimeouts
+	for name, timeout := range map[string]*time.Duration{
+		"QSOS_GITHUB_TIMEOUT":     &timeouts.GitHub,
+		"QSOS_SONAR_SCAN_TIMEOUT": &timeouts.SonarScan,
+		"QSOS_SONAR_POLL_TIMEOUT": &timeouts.SonarPoll,
+		"QSOS_SCORECARD_TIMEOUT":  &timeouts.Scorecard,
+	} {
+		if d, err := time.ParseDuration(os.Getenv(name)); err == nil {
+			*timeout = d
+		}
+	}
+	return timeouts
+}
+
+// withTimeout calls fn with a context canceled after the timeout, if it is
+// not 0. The error of a timeout names the phase and its env variable, and
+// wraps context.DeadlineExceeded.
+func withTimeout(ctx context.Context, timeout time.Duration, phase, variable string, fn func(context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			// The commands killed by the context fail with their signal
+			err = fmt.Errorf("%w (%w)", context.DeadlineExceeded, err)
+		}
+		return fmt.Errorf("%s timed out after %s, the timeout is set by %s: %w", phase, timeout, variable, err)
+	}
+	return err
+}
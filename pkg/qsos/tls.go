@@ -0,0 +1,43 @@
+package qsos
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// sonarqubeTLSConfig returns the TLS configuration for a self-hosted
+// Sonarqube server with an internal PKI, or nil if the options have none.
+func sonarqubeTLSConfig(opts *ExecutorOptions) (*tls.Config, error) {
+	if opts.SonarqubeCACert == "" && opts.SonarqubeClientCert == "" && !opts.SonarqubeInsecure {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: opts.SonarqubeInsecure}
+	if opts.SonarqubeCACert != "" {
+		pem, err := os.ReadFile(opts.SonarqubeCACert)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read SONARQUBE_CA_CERT: %w", err)
+		}
+		// The CA bundle is added to the system ones
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("Invalid SONARQUBE_CA_CERT: no PEM certificate in %s", opts.SonarqubeCACert)
+		}
+		config.RootCAs = pool
+	}
+	if opts.SonarqubeClientCert != "" {
+		if opts.SonarqubeClientKey == "" {
+			return nil, fmt.Errorf("SONARQUBE_CLIENT_KEY environment variable is not set")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.SonarqubeClientCert, opts.SonarqubeClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot load the client certificate of Sonarqube: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,123 @@
+package qsos
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces the evaluations, their phases, and the HTTP requests and the
+// commands of the collectors. The spans are dropped unless SetupTracing has
+// set an exporter.
+var tracer = otel.Tracer("github.com/linagora/qsos-lng/pkg/qsos")
+
+// SetupTracing exports the spans to the OTLP endpoint of the
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT env
+// variable, if one is set, with the protocol of OTEL_EXPORTER_OTLP_PROTOCOL:
+// http/protobuf (by default) or grpc. The other OTEL_* variables, like
+// OTEL_SERVICE_NAME or OTEL_EXPORTER_OTLP_HEADERS, are supported. It returns
+// the function flushing the spans, to call before exiting.
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch protocol := cmp.Or(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"), os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"), "http/protobuf"); protocol {
+	case "http/protobuf":
+		exporter, err = otlptracehttp.New(ctx)
+	case "grpc":
+		exporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("Invalid OTLP protocol %q, must be http/protobuf or grpc", protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the OTLP exporter: %w", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the service
+	// name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "qsos-lng")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the OTLP exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// startSpan starts a span, ended by the returned function with the error of
+// the traced operation.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// projectAttribute is the attribute of the spans of the evaluation of a
+// project.
+func projectAttribute(owner, repo string) attribute.KeyValue {
+	return attribute.String("qsos.project", owner+"/"+repo)
+}
+
+// traceCommand starts the span of a command run by a collector. Only the
+// program and its subcommand are recorded: the other arguments can have
+// tokens, like the clone URLs.
+func traceCommand(ctx context.Context, cmd *exec.Cmd) func(error) {
+	program := filepath.Base(cmd.Args[0])
+	name := program
+	if len(cmd.Args) > 1 {
+		name += " " + cmd.Args[1]
+	}
+	_, end := startSpan(ctx, name, attribute.String("process.executable.name", program))
+	return end
+}
+
+// tracingTransport traces the HTTP requests, until their response headers.
+// The query strings are not recorded, they can have tokens.
+type tracingTransport struct {
+	Next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.full", target.String())))
+	defer span.End()
+	res, err := t.Next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return res, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	if res.StatusCode >= 400 {
+		span.SetStatus(codes.Error, res.Status)
+	}
+	return res, nil
+}
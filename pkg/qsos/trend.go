@@ -0,0 +1,183 @@
+package qsos
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Trend is the evolution of the scores of a project, from its evaluations in
+// the history.
+type Trend struct {
+	Project string
+	// Dates are the dates of the evaluations, from the oldest.
+	Dates []time.Time
+	// Series are the scores of the criteria and the overall score, in the
+	// order of the dates.
+	Series []*TrendSeries
+}
+
+type TrendSeries struct {
+	Criterion string
+	Scores    []float64
+}
+
+// ComputeTrend returns the trend of the records of a project, from the
+// oldest, for the given criteria ("overall" for the overall score), or all
+// of them if none is given.
+func ComputeTrend(records []*HistoryRecord, criteria []string) (*Trend, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no evaluation of the project", ErrNotInHistory)
+	}
+	records = slices.SortedFunc(slices.Values(records), func(a, b *HistoryRecord) int {
+		return a.Date.Compare(b.Date)
+	})
+	trend := &Trend{Project: records[0].Evaluation.Name()}
+	names := []string{}
+	for _, c := range records[0].Evaluation.Scores.Criteria() {
+		names = append(names, c.Name)
+	}
+	names = append(names, "overall")
+	for _, name := range criteria {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("Unknown criterion %q, must be one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	for _, name := range names {
+		if len(criteria) == 0 || slices.Contains(criteria, name) {
+			trend.Series = append(trend.Series, &TrendSeries{Criterion: name})
+		}
+	}
+	for _, record := range records {
+		trend.Dates = append(trend.Dates, record.Date)
+		scores := map[string]float64{"overall": record.Evaluation.Scores.Overall}
+		for _, c := range record.Evaluation.Scores.Criteria() {
+			scores[c.Name] = float64(*c.Score)
+		}
+		for _, series := range trend.Series {
+			series.Scores = append(series.Scores, scores[series.Criterion])
+		}
+	}
+	return trend, nil
+}
+
+// sparkline returns the scores from 0 to 5 as a line of block characters.
+func sparkline(scores []float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	var b strings.Builder
+	for _, score := range scores {
+		level := int(score / 5 * float64(len(levels)-1))
+		b.WriteRune(levels[max(0, min(level, len(levels)-1))])
+	}
+	return b.String()
+}
+
+// PrintTrend prints the first and the last scores of each criterion, their
+// change, and a sparkline of their evolution.
+func PrintTrend(w io.Writer, trend *Trend, opts *ReportOptions) {
+	lang := opts.language()
+	last := len(trend.Dates) - 1
+	fmt.Fprintf(w, "%s: %d %s, %s to %s\n\n", trend.Project, len(trend.Dates), lang.tr("evaluations"),
+		trend.Dates[0].Format(time.DateOnly), trend.Dates[last].Format(time.DateOnly))
+	table := newTextTable(opts, "criterion", "first", "last", "change", "trend").alignRight(1, 2, 3)
+	for _, series := range trend.Series {
+		first, current := series.Scores[0], series.Scores[last]
+		change := current - first
+		cells := []string{series.Criterion, fmt.Sprint(first), opts.formatScore(int64(current)), fmt.Sprintf("%+g", change)}
+		if series.Criterion == "overall" {
+			cells[1], cells[2], cells[3] = fmt.Sprintf("%.2f", first), opts.formatOverall(current), fmt.Sprintf("%+.2f", change)
+		}
+		switch {
+		case change > 0:
+			cells[3] = opts.colorize(colorGreen, cells[3])
+		case change < 0:
+			cells[3] = opts.colorize(colorRed, cells[3])
+		}
+		table.add(append(cells, sparkline(series.Scores))...)
+	}
+	table.print(w)
+}
+
+// WriteTrendCSV writes the trend in CSV, with one line per evaluation.
+func WriteTrendCSV(w io.Writer, trend *Trend) error {
+	out := csv.NewWriter(w)
+	header := []string{"date"}
+	for _, series := range trend.Series {
+		header = append(header, series.Criterion)
+	}
+	out.Write(header)
+	for i, date := range trend.Dates {
+		record := []string{date.Format(time.RFC3339)}
+		for _, series := range trend.Series {
+			if series.Criterion == "overall" {
+				record = append(record, fmt.Sprintf("%.2f", series.Scores[i]))
+			} else {
+				record = append(record, fmt.Sprint(series.Scores[i]))
+			}
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// The chart of the trend, with the legend on the right.
+const (
+	trendWidth  = 760
+	trendHeight = 360
+	trendLeft   = 40
+	trendRight  = 560
+	trendTop    = 40
+	trendBottom = 320
+)
+
+// trendColors are the colors of the lines of the chart.
+var trendColors = []string{"#1a9850", "#d73027", "#4575b4", "#fdae61", "#762a83", "#1b7837", "#f46d43", "#74add1", "#9970ab", "#a6d96a", "#e08214", "#542788", "#66bd63", "#b2182b"}
+
+// WriteTrendSVG writes a line chart of the trend, with the dates on the x
+// axis and the scores from 0 to 5 on the y axis.
+func WriteTrendSVG(w io.Writer, trend *Trend) error {
+	first, last := trend.Dates[0], trend.Dates[len(trend.Dates)-1]
+	x := func(date time.Time) float64 {
+		if !last.After(first) {
+			return float64(trendLeft+trendRight) / 2
+		}
+		return trendLeft + float64(trendRight-trendLeft)*float64(date.Sub(first))/float64(last.Sub(first))
+	}
+	y := func(score float64) float64 {
+		return trendBottom - float64(trendBottom-trendTop)*score/5
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n", trendWidth, trendHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="20" text-anchor="middle" font-size="14">%s</text>`+"\n", (trendLeft+trendRight)/2, html.EscapeString(trend.Project))
+	for level := 0; level <= 5; level++ {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#cccccc"/>`+"\n", trendLeft, y(float64(level)), trendRight, y(float64(level)))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%d</text>`+"\n", trendLeft-6, y(float64(level))+4, level)
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", trendLeft, trendBottom+20, first.Format(time.DateOnly))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", trendRight, trendBottom+20, last.Format(time.DateOnly))
+	for i, series := range trend.Series {
+		color := trendColors[i%len(trendColors)]
+		width := 1.5
+		if series.Criterion == "overall" {
+			color, width = "#000000", 3
+		}
+		var points []string
+		for j, score := range series.Scores {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(trend.Dates[j]), y(score)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%g"/>`+"\n", strings.Join(points, " "), color, width)
+		legend := trendTop + 14*i
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%g"/>`+"\n", trendRight+20, legend, trendRight+40, legend, color, width)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", trendRight+46, legend+4, html.EscapeString(series.Criterion))
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
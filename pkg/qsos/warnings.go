@@ -0,0 +1,66 @@
+package qsos
+
+import (
+	"fmt"
+	"time"
+)
+
+// Warning is a caveat about the stats of a project, like a repository that
+// has been archived or a metric that could not be fully collected.
+type Warning struct {
+	// Code is a stable identifier of the kind of warning, like "archived".
+	Code    string
+	Message string
+}
+
+func (s *ProjectStats) addWarning(code, format string, args ...any) {
+	s.Warnings = append(s.Warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkWarnings adds the warnings for the stats that have been collected.
+func (s *ProjectStats) checkWarnings() {
+	if s.GitHub.Archived {
+		s.addWarning("archived", "the repository has been archived")
+	}
+	if s.GitHub.License == "" || s.GitHub.License == "NOASSERTION" {
+		s.addWarning("license-unknown", "the license of the project is unknown")
+	}
+	if s.GitHub.NoStars {
+		s.addWarning("stars-unavailable", "the forge has no stars nor forks, they are not used for the popularity")
+	}
+	if s.GitHub.ShallowHistory {
+		s.addWarning("shallow-history", "the git history is shallow, the first commit and the contributors are the ones of its last commits")
+	}
+	if s.GitHub.BotCommitShare > 75 {
+		s.addWarning("bot-churn", "%.0f%% of the recent commits are authored by bots", s.GitHub.BotCommitShare)
+	}
+	if s.GitHub.Issues == 0 {
+		s.addWarning("responsiveness-unknown", "the response time to the issues is not known, the responsiveness is left out of the overall score")
+	}
+	if s.GitHub.OpenIssues+s.GitHub.ClosedIssues == 0 {
+		s.addWarning("backlog-unknown", "the open and closed issues are not known, the backlog is left out of the overall score")
+	}
+	if len(s.GitHub.Releases) == 0 {
+		s.addWarning("releases-none", "no release nor tag with a version has been found, the industrialization scores are the lowest ones")
+	}
+	if latest, ok := latestRelease(s.GitHub.Releases); ok && s.GitHub.LastHumanCommitDate.Sub(latest.Date) > 365*24*time.Hour {
+		s.addWarning("unreleased", "the project has commits more than a year after its latest release %s", latest.Name)
+	}
+	if s.Sonar.Incomplete {
+		s.addWarning("sonar-incomplete", "the measures were not available in Sonarqube, the tech scores may be wrong")
+	}
+	switch {
+	case s.Sonar.LinesOfCode > 0 && s.Sonar.Functions == 0:
+		s.addWarning("functions-unknown", "no functions have been found, the complexity scores are the lowest ones")
+	case s.Sonar.FunctionsEstimated:
+		s.addWarning("functions-estimated", "the functions were not reported by Sonarqube, they have been counted by the lite analyzer")
+	}
+	if s.Advisories != nil && s.Advisories.Published == 0 {
+		s.addWarning("advisories-none", "no security advisory has been published, the security process is not scored")
+	}
+	for _, check := range s.ScoreCard.Checks {
+		if check.Score == -1 {
+			s.addWarning("scorecard-check-inconclusive", "the scorecard check %s was inconclusive", check.Name)
+		}
+	}
+}
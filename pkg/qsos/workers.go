@@ -0,0 +1,71 @@
+package qsos
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// limiter is a semaphore limiting the concurrent tasks. A nil limiter has
+// no limit.
+type limiter chan struct{}
+
+func newLimiter(n int) limiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(limiter, n)
+}
+
+// acquire waits for a slot, and returns the function releasing it.
+func (l limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limits are the limits of the concurrent evaluations, shared by the
+// executors created for the other forges.
+type limits struct {
+	// docker limits the containers of the scanners.
+	docker       limiter
+	forgeWorkers int
+
+	mu sync.Mutex
+	// forges limit the evaluations of the projects of each forge, by host.
+	forges map[string]limiter
+}
+
+// forge returns the limiter of the evaluations of a forge.
+func (l *limits) forge(forge *Forge) limiter {
+	if l == nil || l.forgeWorkers <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	host := forge.orDefault().URL.Host
+	if l.forges == nil {
+		l.forges = map[string]limiter{}
+	}
+	if l.forges[host] == nil {
+		l.forges[host] = newLimiter(l.forgeWorkers)
+	}
+	return l.forges[host]
+}
+
+// workersFromEnv returns the number of workers given by an env variable, or
+// the fallback.
+func workersFromEnv(name string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
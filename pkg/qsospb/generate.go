@@ -0,0 +1,5 @@
+// Package qsospb is the gRPC API of the evaluations, generated from
+// proto/qsos/v1/qsos.proto with buf, protoc-gen-go and protoc-gen-go-grpc.
+package qsospb
+
+//go:generate sh -c "cd ../../proto && buf generate"
@@ -0,0 +1,490 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: qsos/v1/qsos.proto
+
+package qsospb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EvaluateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Project is owner/repo, or the URL of the repository.
+	Project string `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	// Tags are the tags of the evaluation saved in the history.
+	Tags          []string `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvaluateRequest) Reset() {
+	*x = EvaluateRequest{}
+	mi := &file_qsos_v1_qsos_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvaluateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateRequest) ProtoMessage() {}
+
+func (x *EvaluateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qsos_v1_qsos_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateRequest.ProtoReflect.Descriptor instead.
+func (*EvaluateRequest) Descriptor() ([]byte, []int) {
+	return file_qsos_v1_qsos_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EvaluateRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *EvaluateRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type EvaluateEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*EvaluateEvent_Progress
+	//	*EvaluateEvent_Result
+	Event         isEvaluateEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvaluateEvent) Reset() {
+	*x = EvaluateEvent{}
+	mi := &file_qsos_v1_qsos_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvaluateEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateEvent) ProtoMessage() {}
+
+func (x *EvaluateEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_qsos_v1_qsos_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateEvent.ProtoReflect.Descriptor instead.
+func (*EvaluateEvent) Descriptor() ([]byte, []int) {
+	return file_qsos_v1_qsos_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EvaluateEvent) GetEvent() isEvaluateEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *EvaluateEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*EvaluateEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *EvaluateEvent) GetResult() *Result {
+	if x != nil {
+		if x, ok := x.Event.(*EvaluateEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isEvaluateEvent_Event interface {
+	isEvaluateEvent_Event()
+}
+
+type EvaluateEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type EvaluateEvent_Result struct {
+	Result *Result `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*EvaluateEvent_Progress) isEvaluateEvent_Event() {}
+
+func (*EvaluateEvent_Result) isEvaluateEvent_Event() {}
+
+// Progress is a step of a phase of the evaluation, like the start of the
+// sonar phase, or a page of commits fetched in the github phase.
+type Progress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Phase is github, scorecard, sonar, summary, packages, refs or advisories.
+	Phase string `protobuf:"bytes,1,opt,name=phase,proto3" json:"phase,omitempty"`
+	// Step is start, done, page, retry, scan or poll.
+	Step string `protobuf:"bytes,2,opt,name=step,proto3" json:"step,omitempty"`
+	// Count is the number of pages fetched, or of attempts.
+	Count int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// Total is the maximal count, if it is known.
+	Total int32 `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	// Elapsed is the duration since the start of the phase, for the done
+	// steps.
+	Elapsed       *durationpb.Duration   `protobuf:"bytes,5,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_qsos_v1_qsos_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_qsos_v1_qsos_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_qsos_v1_qsos_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *Progress) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+func (x *Progress) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Progress) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Progress) GetElapsed() *durationpb.Duration {
+	if x != nil {
+		return x.Elapsed
+	}
+	return nil
+}
+
+func (x *Progress) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type Result struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Project  string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Criteria []*CriterionScore      `protobuf:"bytes,2,rep,name=criteria,proto3" json:"criteria,omitempty"`
+	Overall  float64                `protobuf:"fixed64,3,opt,name=overall,proto3" json:"overall,omitempty"`
+	RedFlags []string               `protobuf:"bytes,4,rep,name=red_flags,json=redFlags,proto3" json:"red_flags,omitempty"`
+	// RecordId is the ID of the evaluation in the history, if it is enabled.
+	RecordId string `protobuf:"bytes,5,opt,name=record_id,json=recordId,proto3" json:"record_id,omitempty"`
+	// Evaluation is the evaluation in JSON, as in the JSON reports.
+	Evaluation    string `protobuf:"bytes,6,opt,name=evaluation,proto3" json:"evaluation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_qsos_v1_qsos_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_qsos_v1_qsos_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_qsos_v1_qsos_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Result) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Result) GetCriteria() []*CriterionScore {
+	if x != nil {
+		return x.Criteria
+	}
+	return nil
+}
+
+func (x *Result) GetOverall() float64 {
+	if x != nil {
+		return x.Overall
+	}
+	return 0
+}
+
+func (x *Result) GetRedFlags() []string {
+	if x != nil {
+		return x.RedFlags
+	}
+	return nil
+}
+
+func (x *Result) GetRecordId() string {
+	if x != nil {
+		return x.RecordId
+	}
+	return ""
+}
+
+func (x *Result) GetEvaluation() string {
+	if x != nil {
+		return x.Evaluation
+	}
+	return ""
+}
+
+type CriterionScore struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is the name of the criterion, like tech.codesmells.
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Score         int64  `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CriterionScore) Reset() {
+	*x = CriterionScore{}
+	mi := &file_qsos_v1_qsos_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CriterionScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CriterionScore) ProtoMessage() {}
+
+func (x *CriterionScore) ProtoReflect() protoreflect.Message {
+	mi := &file_qsos_v1_qsos_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CriterionScore.ProtoReflect.Descriptor instead.
+func (*CriterionScore) Descriptor() ([]byte, []int) {
+	return file_qsos_v1_qsos_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CriterionScore) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CriterionScore) GetScore() int64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+var File_qsos_v1_qsos_proto protoreflect.FileDescriptor
+
+const file_qsos_v1_qsos_proto_rawDesc = "" +
+	"\n" +
+	"\x12qsos/v1/qsos.proto\x12\aqsos.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"?\n" +
+	"\x0fEvaluateRequest\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\"t\n" +
+	"\rEvaluateEvent\x12/\n" +
+	"\bprogress\x18\x01 \x01(\v2\x11.qsos.v1.ProgressH\x00R\bprogress\x12)\n" +
+	"\x06result\x18\x02 \x01(\v2\x0f.qsos.v1.ResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\xc5\x01\n" +
+	"\bProgress\x12\x14\n" +
+	"\x05phase\x18\x01 \x01(\tR\x05phase\x12\x12\n" +
+	"\x04step\x18\x02 \x01(\tR\x04step\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x05R\x05total\x123\n" +
+	"\aelapsed\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\aelapsed\x12.\n" +
+	"\x04time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"\xcb\x01\n" +
+	"\x06Result\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x123\n" +
+	"\bcriteria\x18\x02 \x03(\v2\x17.qsos.v1.CriterionScoreR\bcriteria\x12\x18\n" +
+	"\aoverall\x18\x03 \x01(\x01R\aoverall\x12\x1b\n" +
+	"\tred_flags\x18\x04 \x03(\tR\bredFlags\x12\x1b\n" +
+	"\trecord_id\x18\x05 \x01(\tR\brecordId\x12\x1e\n" +
+	"\n" +
+	"evaluation\x18\x06 \x01(\tR\n" +
+	"evaluation\":\n" +
+	"\x0eCriterionScore\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x03R\x05score2M\n" +
+	"\vEvaluations\x12>\n" +
+	"\bEvaluate\x12\x18.qsos.v1.EvaluateRequest\x1a\x16.qsos.v1.EvaluateEvent0\x01B)Z'github.com/linagora/qsos-lng/pkg/qsospbb\x06proto3"
+
+var (
+	file_qsos_v1_qsos_proto_rawDescOnce sync.Once
+	file_qsos_v1_qsos_proto_rawDescData []byte
+)
+
+func file_qsos_v1_qsos_proto_rawDescGZIP() []byte {
+	file_qsos_v1_qsos_proto_rawDescOnce.Do(func() {
+		file_qsos_v1_qsos_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_qsos_v1_qsos_proto_rawDesc), len(file_qsos_v1_qsos_proto_rawDesc)))
+	})
+	return file_qsos_v1_qsos_proto_rawDescData
+}
+
+var file_qsos_v1_qsos_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_qsos_v1_qsos_proto_goTypes = []any{
+	(*EvaluateRequest)(nil),       // 0: qsos.v1.EvaluateRequest
+	(*EvaluateEvent)(nil),         // 1: qsos.v1.EvaluateEvent
+	(*Progress)(nil),              // 2: qsos.v1.Progress
+	(*Result)(nil),                // 3: qsos.v1.Result
+	(*CriterionScore)(nil),        // 4: qsos.v1.CriterionScore
+	(*durationpb.Duration)(nil),   // 5: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_qsos_v1_qsos_proto_depIdxs = []int32{
+	2, // 0: qsos.v1.EvaluateEvent.progress:type_name -> qsos.v1.Progress
+	3, // 1: qsos.v1.EvaluateEvent.result:type_name -> qsos.v1.Result
+	5, // 2: qsos.v1.Progress.elapsed:type_name -> google.protobuf.Duration
+	6, // 3: qsos.v1.Progress.time:type_name -> google.protobuf.Timestamp
+	4, // 4: qsos.v1.Result.criteria:type_name -> qsos.v1.CriterionScore
+	0, // 5: qsos.v1.Evaluations.Evaluate:input_type -> qsos.v1.EvaluateRequest
+	1, // 6: qsos.v1.Evaluations.Evaluate:output_type -> qsos.v1.EvaluateEvent
+	6, // [6:7] is the sub-list for method output_type
+	5, // [5:6] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_qsos_v1_qsos_proto_init() }
+func file_qsos_v1_qsos_proto_init() {
+	if File_qsos_v1_qsos_proto != nil {
+		return
+	}
+	file_qsos_v1_qsos_proto_msgTypes[1].OneofWrappers = []any{
+		(*EvaluateEvent_Progress)(nil),
+		(*EvaluateEvent_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_qsos_v1_qsos_proto_rawDesc), len(file_qsos_v1_qsos_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_qsos_v1_qsos_proto_goTypes,
+		DependencyIndexes: file_qsos_v1_qsos_proto_depIdxs,
+		MessageInfos:      file_qsos_v1_qsos_proto_msgTypes,
+	}.Build()
+	File_qsos_v1_qsos_proto = out.File
+	file_qsos_v1_qsos_proto_goTypes = nil
+	file_qsos_v1_qsos_proto_depIdxs = nil
+}
@@ -0,0 +1,134 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: qsos/v1/qsos.proto
+
+package qsospb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Evaluations_Evaluate_FullMethodName = "/qsos.v1.Evaluations/Evaluate"
+)
+
+// EvaluationsClient is the client API for Evaluations service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Evaluations evaluates the projects, like the POST /api/evaluations endpoint
+// of the HTTP API.
+type EvaluationsClient interface {
+	// Evaluate evaluates a project. It streams the progress of the phases of
+	// the evaluation, then its result.
+	Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EvaluateEvent], error)
+}
+
+type evaluationsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEvaluationsClient(cc grpc.ClientConnInterface) EvaluationsClient {
+	return &evaluationsClient{cc}
+}
+
+func (c *evaluationsClient) Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EvaluateEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Evaluations_ServiceDesc.Streams[0], Evaluations_Evaluate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EvaluateRequest, EvaluateEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Evaluations_EvaluateClient = grpc.ServerStreamingClient[EvaluateEvent]
+
+// EvaluationsServer is the server API for Evaluations service.
+// All implementations must embed UnimplementedEvaluationsServer
+// for forward compatibility.
+//
+// Evaluations evaluates the projects, like the POST /api/evaluations endpoint
+// of the HTTP API.
+type EvaluationsServer interface {
+	// Evaluate evaluates a project. It streams the progress of the phases of
+	// the evaluation, then its result.
+	Evaluate(*EvaluateRequest, grpc.ServerStreamingServer[EvaluateEvent]) error
+	mustEmbedUnimplementedEvaluationsServer()
+}
+
+// UnimplementedEvaluationsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEvaluationsServer struct{}
+
+func (UnimplementedEvaluationsServer) Evaluate(*EvaluateRequest, grpc.ServerStreamingServer[EvaluateEvent]) error {
+	return status.Error(codes.Unimplemented, "method Evaluate not implemented")
+}
+func (UnimplementedEvaluationsServer) mustEmbedUnimplementedEvaluationsServer() {}
+func (UnimplementedEvaluationsServer) testEmbeddedByValue()                     {}
+
+// UnsafeEvaluationsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EvaluationsServer will
+// result in compilation errors.
+type UnsafeEvaluationsServer interface {
+	mustEmbedUnimplementedEvaluationsServer()
+}
+
+func RegisterEvaluationsServer(s grpc.ServiceRegistrar, srv EvaluationsServer) {
+	// If the following call panics, it indicates UnimplementedEvaluationsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Evaluations_ServiceDesc, srv)
+}
+
+func _Evaluations_Evaluate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EvaluateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EvaluationsServer).Evaluate(m, &grpc.GenericServerStream[EvaluateRequest, EvaluateEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Evaluations_EvaluateServer = grpc.ServerStreamingServer[EvaluateEvent]
+
+// Evaluations_ServiceDesc is the grpc.ServiceDesc for Evaluations service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Evaluations_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "qsos.v1.Evaluations",
+	HandlerType: (*EvaluationsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Evaluate",
+			Handler:       _Evaluations_Evaluate_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "qsos/v1/qsos.proto",
+}
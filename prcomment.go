@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// prCommentMarker marks the comment of the pull requests, updated by the next
+// runs instead of posting a new comment.
+const prCommentMarker = "<!-- qsos-lng -->"
+
+// pullRequest is a GitHub pull request.
+type pullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (pr *pullRequest) String() string {
+	return fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.Number)
+}
+
+// parsePullRequest parses a pull request in the owner/repo#number format.
+func parsePullRequest(value string) (*pullRequest, error) {
+	project, number, _ := strings.Cut(value, "#")
+	owner, repo, err := qsos.ParseProject(project)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("Invalid pull request %q. Must be in the format: owner/repo#number", value)
+	}
+	return &pullRequest{Owner: owner, Repo: repo, Number: n}, nil
+}
+
+// postPRComment posts a comment on a pull request, or updates the comment of
+// a previous run.
+func postPRComment(ctx context.Context, client *github.Client, pr *pullRequest, body string) error {
+	comment := &github.IssueComment{Body: github.Ptr(prCommentMarker + "\n" + body)}
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, pr.Owner, pr.Repo, pr.Number, opts)
+		if err != nil {
+			return fmt.Errorf("Issues.ListComments failed: %w", err)
+		}
+		for _, previous := range comments {
+			if strings.HasPrefix(previous.GetBody(), prCommentMarker) {
+				if _, _, err := client.Issues.EditComment(ctx, pr.Owner, pr.Repo, previous.GetID(), comment); err != nil {
+					return fmt.Errorf("Issues.EditComment failed: %w", err)
+				}
+				slog.Info("pull request comment updated", "url", previous.GetHTMLURL())
+				return nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	created, _, err := client.Issues.CreateComment(ctx, pr.Owner, pr.Repo, pr.Number, comment)
+	if err != nil {
+		return fmt.Errorf("Issues.CreateComment failed: %w", err)
+	}
+	slog.Info("pull request comment posted", "url", created.GetHTMLURL())
+	return nil
+}
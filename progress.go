@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+func addProgressFlag(fs *flag.FlagSet) *string {
+	return fs.String("progress", "auto", "progress of the evaluations on the standard error: bar, log, json, none, or auto (bar on a terminal, none with --quiet, log otherwise)")
+}
+
+// setProgress sets the progress output of the executor.
+func setProgress(fs *flag.FlagSet, executor *qsos.Executor, mode string) {
+	if mode == "auto" {
+		mode = "log"
+		if quiet {
+			mode = "none"
+		} else if isTerminal(os.Stderr) && !noColor {
+			mode = "bar"
+		}
+	}
+	switch mode {
+	case "none":
+	case "log":
+		executor.SetProgress(func(event *qsos.ProgressEvent) {
+			slog.Info(formatProgress(event))
+		})
+	case "json":
+		var mu sync.Mutex
+		encoder := json.NewEncoder(os.Stderr)
+		executor.SetProgress(func(event *qsos.ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			encoder.Encode(event)
+		})
+	case "bar":
+		bar := &progressBar{w: os.Stderr}
+		// The logs are written above the bar
+		setLogOutput(bar)
+		executor.SetProgress(bar.update)
+	default:
+		usageError(fs, "invalid progress %q, must be bar, log, json, none or auto", mode)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func formatProgress(event *qsos.ProgressEvent) string {
+	var detail string
+	switch event.Step {
+	case qsos.StepStart:
+		detail = "started"
+	case qsos.StepDone:
+		detail = "done in " + event.Elapsed.Round(time.Second).String()
+	case qsos.StepPage:
+		detail = "page " + formatCount(event)
+	case qsos.StepRetry:
+		detail = "waiting for the GitHub statistics, attempt " + formatCount(event)
+	case qsos.StepScan:
+		detail = "scanning for " + event.Elapsed.Round(time.Second).String()
+	case qsos.StepPoll:
+		detail = "waiting for the measures, attempt " + formatCount(event)
+	default:
+		detail = event.Step
+	}
+	return fmt.Sprintf("%s: %s: %s", event.Project, event.Phase, detail)
+}
+
+func formatCount(event *qsos.ProgressEvent) string {
+	if event.Total > 0 {
+		return fmt.Sprintf("%d/%d", event.Count, event.Total)
+	}
+	return fmt.Sprint(event.Count)
+}
+
+const progressBarWidth = 20
+
+// progressBar shows the current step on the last line of a terminal, and the
+// ends of the phases and the logs above it.
+type progressBar struct {
+	mu   sync.Mutex
+	w    io.Writer
+	line string
+	// starts are the starts of the phases in progress, which run
+	// concurrently.
+	starts map[string]time.Time
+}
+
+func (b *progressBar) update(event *qsos.ProgressEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	phase := event.Project + " " + event.Phase
+	switch event.Step {
+	case qsos.StepStart:
+		if b.starts == nil {
+			b.starts = map[string]time.Time{}
+		}
+		b.starts[phase] = time.Now()
+	case qsos.StepDone:
+		delete(b.starts, phase)
+		fmt.Fprintf(b.w, "\r\033[K%s\n", formatProgress(event))
+		b.line = ""
+		return
+	}
+	start, ok := b.starts[phase]
+	if !ok {
+		// The scan and poll steps are reported for the Sonarqube component
+		start = time.Now().Add(-event.Elapsed)
+	}
+	bar := ""
+	if event.Total > 0 {
+		filled := min(progressBarWidth, progressBarWidth*event.Count/event.Total)
+		bar = "[" + strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled) + "] "
+	}
+	b.line = fmt.Sprintf("%s%s (%s)", bar, formatProgress(event), time.Since(start).Round(time.Second))
+	fmt.Fprintf(b.w, "\r\033[K%s", b.line)
+}
+
+// Write writes a log line above the bar.
+func (b *progressBar) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Fprint(b.w, "\r\033[K")
+	n, err := b.w.Write(p)
+	if b.line != "" {
+		fmt.Fprint(b.w, b.line)
+	}
+	return n, err
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// exporter exposes the latest scores and raw metrics of the projects of the
+// history, and the metrics of the collection, in the Prometheus text format.
+type exporter struct {
+	history *qsos.History
+	mu      sync.Mutex
+	phases  map[string]*phaseDurations
+}
+
+// phaseDurations are the durations of the phases of the evaluations, in
+// seconds.
+type phaseDurations struct {
+	sum   float64
+	count int64
+}
+
+// observe is the progress function of the executor. It records the
+// durations of the phases.
+func (e *exporter) observe(event *qsos.ProgressEvent) {
+	if event.Step != qsos.StepDone {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.phases == nil {
+		e.phases = map[string]*phaseDurations{}
+	}
+	durations, ok := e.phases[event.Phase]
+	if !ok {
+		durations = &phaseDurations{}
+		e.phases[event.Phase] = durations
+	}
+	durations.sum += event.Elapsed.Seconds()
+	durations.count++
+}
+
+// promWriter writes the metric families in the Prometheus text format.
+type promWriter struct {
+	*bufio.Writer
+}
+
+func (w promWriter) family(name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes a sample, with its labels given as name, value pairs.
+func (w promWriter) sample(name string, value float64, labels ...string) {
+	w.WriteString(name)
+	for i := 0; i+1 < len(labels); i += 2 {
+		sep := ","
+		if i == 0 {
+			sep = "{"
+		}
+		fmt.Fprintf(w, `%s%s="%s"`, sep, labels[i], labelEscaper.Replace(labels[i+1]))
+	}
+	if len(labels) > 0 {
+		w.WriteString("}")
+	}
+	fmt.Fprintf(w, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labelEscaper escapes the label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var latest []*qsos.HistoryRecord
+	if e.history != nil {
+		projects, err := historyProjects(e.history, &qsos.HistoryFilter{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, project := range projects {
+			latest = append(latest, project.Latest())
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := promWriter{bufio.NewWriter(w)}
+	defer func() {
+		if err := out.Flush(); err != nil {
+			slog.Error("cannot write response", "err", err)
+		}
+	}()
+
+	if e.history != nil {
+		out.family("qsos_score", "gauge", "Score of a criterion of the last evaluation of a project, from 1 to 5, or its overall score.")
+		for _, record := range latest {
+			name := record.Evaluation.Name()
+			out.sample("qsos_score", record.Evaluation.Scores.Overall, "project", name, "criterion", "overall")
+			for _, criterion := range record.Evaluation.Scores.Criteria() {
+				out.sample("qsos_score", float64(*criterion.Score), "project", name, "criterion", criterion.Name)
+			}
+		}
+		out.family("qsos_metric", "gauge", "Raw metric of the last evaluation of a project. The dates are in seconds since the Unix epoch.")
+		for _, record := range latest {
+			for _, metric := range record.Evaluation.Stats.RawMetrics() {
+				out.sample("qsos_metric", metric.Value, "project", record.Evaluation.Name(), "metric", metric.Name, "unit", metric.Unit)
+			}
+		}
+		out.family("qsos_red_flags", "gauge", "Number of red flags of the last evaluation of a project.")
+		for _, record := range latest {
+			out.sample("qsos_red_flags", float64(len(record.Evaluation.RedFlags)), "project", record.Evaluation.Name())
+		}
+		out.family("qsos_evaluation_timestamp_seconds", "gauge", "Date of the last evaluation of a project.")
+		for _, record := range latest {
+			out.sample("qsos_evaluation_timestamp_seconds", float64(record.Date.Unix()), "project", record.Evaluation.Name())
+		}
+	}
+
+	e.mu.Lock()
+	phases := make([]string, 0, len(e.phases))
+	for phase := range e.phases {
+		phases = append(phases, phase)
+	}
+	slices.Sort(phases)
+	out.family("qsos_phase_duration_seconds", "summary", "Duration of the phases of the evaluations, like github or sonar.")
+	for _, phase := range phases {
+		out.sample("qsos_phase_duration_seconds_sum", e.phases[phase].sum, "phase", phase)
+		out.sample("qsos_phase_duration_seconds_count", float64(e.phases[phase].count), "phase", phase)
+	}
+	e.mu.Unlock()
+
+	out.family("qsos_api_requests_total", "counter", "Requests sent to the APIs, by host and by status code, or \"error\" for the network errors.")
+	for _, count := range qsos.APIRequestCounts() {
+		out.sample("qsos_api_requests_total", float64(count.Count), "host", count.Host, "code", count.Code)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// runScheduler runs the scheduled evaluations, until the context is
+// canceled. The schedules are checked every minute.
+func (s *Server) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		s.runDueSchedules(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runDueSchedules(ctx context.Context) {
+	schedules, err := s.History.ListSchedules()
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		// A zero next run is a schedule which never matches
+		if ctx.Err() != nil || schedule.NextRun.IsZero() || schedule.NextRun.After(now) {
+			continue
+		}
+		// The scheduled evaluations share the slots with the API ones
+		select {
+		case s.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		err := s.runSchedule(schedule)
+		<-s.inFlight
+
+		s.schedules.Lock()
+		// The schedule may have been deleted during the evaluation
+		if _, getErr := s.History.GetSchedule(schedule.ID); getErr == nil {
+			schedule.LastRun = now
+			schedule.LastError = ""
+			if err != nil {
+				slog.Error(err.Error(), "schedule", schedule.ID)
+				schedule.LastError = err.Error()
+			}
+			if err := schedule.Validate(time.Now()); err != nil {
+				// Disabled, instead of running at each tick
+				slog.Error(err.Error(), "schedule", schedule.ID)
+				schedule.NextRun = time.Time{}
+			}
+			if err := s.History.SaveSchedule(schedule); err != nil {
+				slog.Error(err.Error())
+			}
+		}
+		s.schedules.Unlock()
+	}
+}
+
+// runSchedule evaluates the project of a schedule, saves the evaluation in
+// the history, and posts it to the targets of the schedule.
+func (s *Server) runSchedule(schedule *qsos.Schedule) error {
+	config, err := s.profileConfig(schedule.Profile)
+	if err != nil {
+		return err
+	}
+	owner, repo, err := qsos.ParseProject(schedule.Project)
+	if err != nil {
+		return err
+	}
+	slog.Info("running schedule", "schedule", schedule.ID, "project", schedule.Project)
+	evaluation, err := qsos.Evaluate(s.evaluations, s.Executor, config, owner, repo, "")
+	if err != nil {
+		return err
+	}
+	if _, err := s.History.Save(evaluation, []string{"scheduled"}); err != nil {
+		return err
+	}
+	var errs []error
+	for _, target := range schedule.Targets {
+		if err := postJSON(target, evaluation); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// profileConfig returns the configuration of a profile, which is a JSON
+// file in the profiles dir.
+func (s *Server) profileConfig(profile string) (*qsos.Config, error) {
+	if profile == "" {
+		return s.Config, nil
+	}
+	if s.ProfilesDir == "" {
+		return nil, fmt.Errorf("Unknown profile %q: QSOS_PROFILES_DIR is not set", profile)
+	}
+	return qsos.LoadConfig(filepath.Join(s.ProfilesDir, profile+".json"))
+}
+
+var notifyClient = &http.Client{Timeout: 30 * time.Second, Transport: qsos.NewTransport()}
+
+func postJSON(target string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	res, err := notifyClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (s *Server) handleScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	var schedule qsos.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	schedule.ID = ""
+	schedule.LastRun = time.Time{}
+	schedule.LastError = ""
+	if err := schedule.Validate(time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.profileConfig(schedule.Profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.schedules.Lock()
+	defer s.schedules.Unlock()
+	if err := s.History.SaveSchedule(&schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, schedule)
+}
+
+func (s *Server) handleScheduleList(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.History.ListSchedules()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, schedules)
+}
+
+func (s *Server) handleScheduleGet(w http.ResponseWriter, r *http.Request) {
+	schedule, err := s.History.GetSchedule(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), scheduleErrorStatus(err))
+		return
+	}
+	writeJSON(w, schedule)
+}
+
+func (s *Server) handleScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	s.schedules.Lock()
+	defer s.schedules.Unlock()
+	if err := s.History.DeleteSchedule(r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), scheduleErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func scheduleErrorStatus(err error) int {
+	if errors.Is(err, qsos.ErrNoSuchSchedule) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// Server exposes the evaluations with an HTTP API.
+type Server struct {
+	Executor *qsos.Executor
+	Config   *qsos.Config
+	// History is optional. It is needed for the schedules and the
+	// evaluation requests.
+	History *qsos.History
+	// ProfilesDir is the directory of the configurations that can be used
+	// by the schedules and the evaluation requests, as <profile>.json.
+	ProfilesDir string
+	// Mailer is optional. It notifies the evaluation requesters.
+	Mailer *Mailer
+	// PublicURL is the URL of the server, for the links in the
+	// notifications.
+	PublicURL string
+	// WebhookSecret is the secret of the GitHub webhooks. The webhooks
+	// endpoint is disabled if it is empty.
+	WebhookSecret string
+	// Watched are the projects re-evaluated on their GitHub webhooks.
+	Watched []string
+	// GRPCAddr is the address of the gRPC API, which is disabled if it is
+	// empty.
+	GRPCAddr string
+	// AllowedForges are the hosts of the forges, other than the one of the
+	// executor, of the projects that can be evaluated by URL.
+	AllowedForges []string
+	// inFlight is a semaphore for the evaluations in progress.
+	inFlight chan struct{}
+	// evaluations is the context of the evaluations, canceled when the
+	// drain timeout expires.
+	evaluations context.Context
+	draining    atomic.Bool
+	// schedules serializes the updates of the schedules.
+	schedules sync.Mutex
+	// requests wakes up the intake when a request is queued.
+	requests chan struct{}
+	// webhooks serializes the requests queued by the webhooks.
+	webhooks sync.Mutex
+	// progress streams the progress of the evaluations to the gRPC clients.
+	progress progressHub
+	// metrics exposes the scores and the collection metrics to Prometheus.
+	metrics *exporter
+}
+
+type EvaluationRequest struct {
+	Project string
+	Tags    []string
+}
+
+func NewServer(executor *qsos.Executor, config *qsos.Config, history *qsos.History, maxInFlight int) *Server {
+	return &Server{
+		Executor:    executor,
+		Config:      config,
+		History:     history,
+		inFlight:    make(chan struct{}, max(1, maxInFlight)),
+		evaluations: context.Background(),
+		requests:    make(chan struct{}, 1),
+		metrics:     &exporter{history: history},
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.Handle("GET /metrics", s.metrics)
+	mux.HandleFunc("POST /api/evaluations", s.handleEvaluate)
+	mux.HandleFunc("GET /api/history", s.handleHistorySearch)
+	mux.HandleFunc("GET /api/history/{id}", s.handleHistoryGet)
+	if s.History != nil {
+		mux.HandleFunc("POST /api/schedules", s.handleScheduleCreate)
+		mux.HandleFunc("GET /api/schedules", s.handleScheduleList)
+		mux.HandleFunc("GET /api/schedules/{id}", s.handleScheduleGet)
+		mux.HandleFunc("DELETE /api/schedules/{id}", s.handleScheduleDelete)
+		mux.HandleFunc("POST /api/requests", s.handleRequestCreate)
+		mux.HandleFunc("GET /api/requests/{id}", s.handleRequestGet)
+		mux.HandleFunc("GET /requests", s.handleIntakeForm)
+		mux.HandleFunc("POST /requests", s.handleIntakeSubmit)
+		mux.HandleFunc("GET /requests/{id}", s.handleIntakeStatus)
+		if s.WebhookSecret != "" {
+			mux.HandleFunc("POST /api/webhooks/github", s.handleGitHubWebhook)
+		}
+		mux.HandleFunc("GET /api/badges/{owner}/{repo}", s.handleBadge)
+		mux.HandleFunc("GET /dashboard", s.handleDashboard)
+		mux.HandleFunc("GET /dashboard/projects/{owner}/{repo}", s.handleDashboardProject)
+		mux.HandleFunc("GET /dashboard/compare", s.handleDashboardCompare)
+	}
+	return mux
+}
+
+// ListenAndServe runs the server, and the scheduled and requested evaluations,
+// until the context is canceled. Then, the server stops accepting new
+// evaluations and waits for the ones in progress, up to the drain timeout.
+// The ones still in progress are then canceled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, drainTimeout time.Duration) error {
+	evaluations, cancelEvaluations := context.WithCancel(context.Background())
+	defer cancelEvaluations()
+	s.evaluations = evaluations
+	s.Executor.SetProgress(func(event *qsos.ProgressEvent) {
+		s.metrics.observe(event)
+		s.progress.dispatch(event)
+	})
+	server := &http.Server{Addr: addr, Handler: s.Handler()}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	slog.Info("listening", "addr", addr)
+	var grpcServer *grpc.Server
+	if s.GRPCAddr != "" {
+		listener, err := net.Listen("tcp", s.GRPCAddr)
+		if err != nil {
+			server.Close()
+			return fmt.Errorf("Cannot listen for gRPC: %w", err)
+		}
+		grpcServer = s.newGRPCServer()
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				errs <- err
+			}
+		}()
+		slog.Info("listening for gRPC", "addr", s.GRPCAddr)
+	}
+
+	schedulerDone := make(chan struct{})
+	intakeDone := make(chan struct{})
+	if s.History != nil {
+		go func() {
+			s.runScheduler(ctx)
+			close(schedulerDone)
+		}()
+		go func() {
+			s.runIntake(ctx)
+			close(intakeDone)
+		}()
+	} else {
+		close(schedulerDone)
+		close(intakeDone)
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, waiting for the evaluations in progress", "in_flight", len(s.inFlight))
+	s.draining.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	grpcDone := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			// The streams end with their evaluations
+			grpcServer.GracefulStop()
+		}
+		close(grpcDone)
+	}()
+	if err := s.drain(shutdownCtx, server, schedulerDone, intakeDone, grpcDone); err != nil {
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		// The evaluations in progress remove their containers and their
+		// temporary dirs when they are canceled
+		cancelEvaluations()
+		s.waitEvaluations(cleanupTimeout)
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// cleanupTimeout is the maximal duration to wait for the canceled
+// evaluations.
+const cleanupTimeout = 30 * time.Second
+
+// drain waits for the end of the HTTP requests, of the gRPC streams, and of
+// the scheduled and requested evaluations, until the context is done.
+func (s *Server) drain(ctx context.Context, server *http.Server, schedulerDone, intakeDone, grpcDone <-chan struct{}) error {
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("Cannot shutdown gracefully: %w", err)
+	}
+	select {
+	case <-schedulerDone:
+	case <-ctx.Done():
+		return fmt.Errorf("Cannot shutdown gracefully: a scheduled evaluation is still in progress")
+	}
+	select {
+	case <-intakeDone:
+	case <-ctx.Done():
+		return fmt.Errorf("Cannot shutdown gracefully: a requested evaluation is still in progress")
+	}
+	select {
+	case <-grpcDone:
+	case <-ctx.Done():
+		return fmt.Errorf("Cannot shutdown gracefully: a gRPC evaluation is still in progress")
+	}
+	return nil
+}
+
+// waitEvaluations waits for the end of the evaluations in progress, by
+// taking all their slots, up to the timeout.
+func (s *Server) waitEvaluations(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for range cap(s.inFlight) {
+		select {
+		case s.inFlight <- struct{}{}:
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz tells if the server can accept a new evaluation.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case s.draining.Load():
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	case len(s.inFlight) == cap(s.inFlight):
+		http.Error(w, "too many evaluations in progress", http.StatusServiceUnavailable)
+	default:
+		w.Write([]byte("ok\n"))
+	}
+}
+
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	var req EvaluationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	executor, owner, repo, err := s.forProject(req.Project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case s.inFlight <- struct{}{}:
+		defer func() { <-s.inFlight }()
+	default:
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "too many evaluations in progress", http.StatusServiceUnavailable)
+		return
+	}
+
+	evaluation, err := qsos.Evaluate(s.evaluations, executor, s.Config, owner, repo, "")
+	if err != nil {
+		slog.Error(err.Error(), "project", req.Project)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if s.History != nil {
+		if _, err := s.History.Save(evaluation, req.Tags); err != nil {
+			slog.Error(err.Error(), "project", req.Project)
+		}
+	}
+	writeJSON(w, evaluation)
+}
+
+// forProject returns the executor of a project, which must be on the forge
+// of the server or on one of AllowedForges.
+func (s *Server) forProject(project string) (*qsos.Executor, string, string, error) {
+	if strings.Contains(project, "://") {
+		forge, _, _, err := qsos.ParseForgeURL(project, s.Executor.Forge)
+		if err != nil {
+			return nil, "", "", err
+		}
+		host := forge.URL.Host
+		allowed := func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), host) }
+		if !allowed(s.Executor.Forge.URL.Host) && !slices.ContainsFunc(s.AllowedForges, allowed) {
+			return nil, "", "", fmt.Errorf("The forge %s is not allowed", host)
+		}
+	}
+	return s.Executor.ForProject(project)
+}
+
+// handleHistorySearch searches the history, with the filters in the query
+// string: owner, repo, tag (can be repeated), since and until (YYYY-MM-DD),
+// min-score and max-score (like tech.size=3, can be repeated).
+func (s *Server) handleHistorySearch(w http.ResponseWriter, r *http.Request) {
+	if s.History == nil {
+		http.Error(w, "no history", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	filter := &qsos.HistoryFilter{
+		Owner:     query.Get("owner"),
+		Repo:      query.Get("repo"),
+		Tags:      query["tag"],
+		MinScores: scoresFlag{},
+		MaxScores: scoresFlag{},
+	}
+	var err error
+	if filter.Since, err = parseDate(query.Get("since")); err != nil {
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseDate(query.Get("until")); err != nil {
+		http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !filter.Until.IsZero() {
+		filter.Until = filter.Until.AddDate(0, 0, 1)
+	}
+	for _, value := range query["min-score"] {
+		if err := scoresFlag(filter.MinScores).Set(value); err != nil {
+			http.Error(w, "invalid min-score: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, value := range query["max-score"] {
+		if err := scoresFlag(filter.MaxScores).Set(value); err != nil {
+			http.Error(w, "invalid max-score: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := s.History.Search(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, records)
+}
+
+func (s *Server) handleHistoryGet(w http.ResponseWriter, r *http.Request) {
+	if s.History == nil {
+		http.Error(w, "no history", http.StatusNotFound)
+		return
+	}
+	record, err := s.History.Get(r.PathValue("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, qsos.ErrNotInHistory) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, record)
+}
+
+// badgeCacheSeconds is how long shields.io caches the badges of the API.
+const badgeCacheSeconds = 3600
+
+// handleBadge serves the shields.io endpoint badge of the last evaluation of
+// a project.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	projects, err := historyProjects(s.History, &qsos.HistoryFilter{Owner: r.PathValue("owner"), Repo: r.PathValue("repo")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(projects) == 0 {
+		http.Error(w, "no evaluation of the project in the history", http.StatusNotFound)
+		return
+	}
+	badge := qsos.NewBadge(projects[0].Latest().Evaluation)
+	badge.CacheSeconds = badgeCacheSeconds
+	writeJSON(w, badge)
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		slog.Error("cannot write response", "err", err)
+	}
+}
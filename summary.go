@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// Summary is a compact result of a run, for CI pipelines.
+type Summary struct {
+	Passed bool
+	// Overall is the average of the overall scores of the projects.
+	Overall  float64
+	Projects int
+	// Violations are the reasons of the failure of the run, with the
+	// regressions from the baseline.
+	Violations []string
+	// Regressions are the regressions from the baseline.
+	Regressions []qsos.Regression
+	// RedFlags are the red flags of all the projects, prefixed by the name
+	// of the project.
+	RedFlags []string
+	// Axes are the averages of the scores of the criteria of each axis,
+	// over the projects.
+	Axes        map[string]float64
+	evaluations []*qsos.Evaluation
+	// failures are the violations other than the regressions.
+	failures []string
+}
+
+// summaryAxes are the axes of the criteria, in the order of the reports.
+var summaryAxes = []string{"community", "tech", "security", "industrialization", "adoption"}
+
+// axisScores returns the averages of the scores of the criteria of each axis
+// of an evaluation.
+func axisScores(evaluation *qsos.Evaluation) map[string]float64 {
+	sums, counts := map[string]float64{}, map[string]int{}
+	for _, criterion := range evaluation.Scores.Criteria() {
+		axis, _, _ := strings.Cut(criterion.Name, ".")
+		sums[axis] += float64(*criterion.Score)
+		counts[axis]++
+	}
+	for axis := range sums {
+		sums[axis] /= float64(counts[axis])
+	}
+	return sums
+}
+
+func NewSummary(evaluations []*qsos.Evaluation, violations []string, regressions []qsos.Regression) *Summary {
+	summary := &Summary{
+		Passed:      len(violations)+len(regressions) == 0,
+		Projects:    len(evaluations),
+		Violations:  slices.Clone(violations),
+		Regressions: regressions,
+		Axes:        map[string]float64{},
+		evaluations: evaluations,
+		failures:    violations,
+	}
+	for _, r := range regressions {
+		summary.Violations = append(summary.Violations, fmt.Sprintf("regression for %s", r))
+	}
+	for _, evaluation := range evaluations {
+		summary.Overall += evaluation.Scores.Overall / float64(len(evaluations))
+		for axis, score := range axisScores(evaluation) {
+			summary.Axes[axis] += score / float64(len(evaluations))
+		}
+		for _, flag := range evaluation.RedFlags {
+			summary.RedFlags = append(summary.RedFlags, fmt.Sprintf("%s: %s", evaluation.Name(), flag.Message))
+		}
+	}
+	return summary
+}
+
+// Write appends the summary to a file, in the format of the GitHub Actions
+// outputs (name=value lines, and a delimited block for the violations), so
+// that the path of $GITHUB_OUTPUT can be used directly.
+func (s *Summary) Write(path string) error {
+	var b strings.Builder
+	result := "fail"
+	if s.Passed {
+		result = "pass"
+	}
+	fmt.Fprintf(&b, "result=%s\n", result)
+	fmt.Fprintf(&b, "overall=%.2f\n", s.Overall)
+	fmt.Fprintf(&b, "projects=%d\n", s.Projects)
+	for _, axis := range summaryAxes {
+		fmt.Fprintf(&b, "%s=%.2f\n", axis, s.Axes[axis])
+	}
+	b.WriteString("violations<<QSOS_VIOLATIONS\n")
+	for _, violation := range s.Violations {
+		b.WriteString(strings.ReplaceAll(violation, "\n", " ") + "\n")
+	}
+	b.WriteString("QSOS_VIOLATIONS\n")
+	b.WriteString("red_flags<<QSOS_RED_FLAGS\n")
+	for _, flag := range s.RedFlags {
+		b.WriteString(strings.ReplaceAll(flag, "\n", " ") + "\n")
+	}
+	b.WriteString("QSOS_RED_FLAGS\n")
+	return appendSummary(path, b.String())
+}
+
+// WriteMarkdown appends the summary to a file in Markdown, so that the path
+// of $GITHUB_STEP_SUMMARY can be used directly.
+func (s *Summary) WriteMarkdown(path string) error {
+	return appendSummary(path, s.Markdown()+"\n")
+}
+
+// Markdown returns the summary in Markdown, with the scores of the projects
+// by axis.
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+	result := ":x: failed"
+	if s.Passed {
+		result = ":white_check_mark: passed"
+	}
+	fmt.Fprintf(&b, "## QSOS evaluation %s\n\n", result)
+	b.WriteString("| Project | overall |")
+	for _, axis := range summaryAxes {
+		fmt.Fprintf(&b, " %s |", axis)
+	}
+	b.WriteString("\n|---|---|" + strings.Repeat("---|", len(summaryAxes)) + "\n")
+	for _, evaluation := range s.evaluations {
+		axes := axisScores(evaluation)
+		fmt.Fprintf(&b, "| %s | **%.2f** |", evaluation.Name(), evaluation.Scores.Overall)
+		for _, axis := range summaryAxes {
+			fmt.Fprintf(&b, " %.2f |", axes[axis])
+		}
+		b.WriteString("\n")
+	}
+	if len(s.Regressions) > 0 {
+		b.WriteString("\n### Regressions\n\n| Project | Criterion | Baseline | Current |\n|---|---|---|---|\n")
+		for _, r := range s.Regressions {
+			fmt.Fprintf(&b, "| %s | %s | %g | %g |\n", r.Project, r.Criterion, r.Baseline, r.Current)
+		}
+	}
+	if len(s.failures) > 0 {
+		b.WriteString("\n### Violations\n\n")
+		for _, violation := range s.failures {
+			fmt.Fprintf(&b, "- %s\n", violation)
+		}
+	}
+	if len(s.RedFlags) > 0 {
+		b.WriteString("\n### Red flags\n\n")
+		for _, flag := range s.RedFlags {
+			fmt.Fprintf(&b, "- %s\n", flag)
+		}
+	}
+	return b.String()
+}
+
+func appendSummary(path, summary string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("Cannot open summary file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(summary); err != nil {
+		return fmt.Errorf("Cannot write summary file: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+// maxWebhookSize is the maximal size of the payloads of the webhooks. GitHub
+// caps them at 25 MB.
+const maxWebhookSize = 25 << 20
+
+// githubWebhook has the fields of the push and release events that are used.
+type githubWebhook struct {
+	Ref        string
+	Action     string
+	Repository struct {
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+	}
+}
+
+// validSignature checks the X-Hub-Signature-256 header of a webhook, the
+// HMAC of the payload with the secret.
+func validSignature(secret string, payload []byte, signature string) bool {
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
+
+// watches tells if the webhooks of a project trigger its re-evaluation.
+func (s *Server) watches(project string) bool {
+	return slices.ContainsFunc(s.Watched, func(watched string) bool {
+		return strings.EqualFold(watched, project)
+	})
+}
+
+// handleGitHubWebhook queues the re-evaluation of a watched project on a push
+// on its default branch, or on a published release. The requests are
+// deduplicated: a project is queued once until its evaluation starts.
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validSignature(s.WebhookSecret, payload, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var hook githubWebhook
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		http.Error(w, "invalid payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	event := r.Header.Get("X-GitHub-Event")
+	project := hook.Repository.FullName
+	ignore := func(reason string) {
+		slog.Debug("webhook ignored", "event", event, "project", project, "reason", reason)
+		w.Write([]byte("ignored: " + reason + "\n"))
+	}
+	switch {
+	case event == "ping":
+		w.Write([]byte("pong\n"))
+		return
+	case event == "push" && hook.Ref != "refs/heads/"+hook.Repository.DefaultBranch:
+		ignore("not a push on the default branch")
+		return
+	case event == "release" && hook.Action != "published":
+		ignore("not a published release")
+		return
+	case event != "push" && event != "release":
+		ignore("not a push or a release event")
+		return
+	case !s.watches(project):
+		ignore("the project is not watched")
+		return
+	}
+	if s.draining.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.webhooks.Lock()
+	defer s.webhooks.Unlock()
+	requests, err := s.History.ListRequests()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, request := range requests {
+		if request.Status == qsos.RequestQueued && strings.EqualFold(request.Project, project) {
+			writeJSON(w, request)
+			return
+		}
+	}
+	request, err := qsos.NewIntakeRequest(project, "", "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.Tags = []string{"webhook:" + event}
+	if err := s.enqueue(request); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("re-evaluation queued by a webhook", "event", event, "project", project, "request", request.ID)
+	w.Header().Set("Location", "/api/requests/"+request.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, request)
+}
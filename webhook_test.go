@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linagora/qsos-lng/pkg/qsos"
+)
+
+func TestGitHubWebhook(t *testing.T) {
+	history, err := qsos.OpenHistory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(nil, qsos.DefaultConfig(), history, 1)
+	server.WebhookSecret = "secret"
+	server.Watched = []string{"minio/minio"}
+	handler := server.Handler()
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(payload))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	push := `{"ref": "refs/heads/main", "repository": {"full_name": "MinIO/minio", "default_branch": "main"}}`
+	tests := []struct {
+		name      string
+		event     string
+		payload   string
+		signature string
+		status    int
+		body      string
+	}{
+		{"ping", "ping", `{}`, sign(`{}`), http.StatusOK, "pong"},
+		{"invalid signature", "push", push, sign(`{}`), http.StatusUnauthorized, "invalid signature"},
+		{"no signature", "push", push, "", http.StatusUnauthorized, "invalid signature"},
+		{"push on the default branch", "push", push, sign(push), http.StatusAccepted, `"Status": "queued"`},
+		{"push again", "push", push, sign(push), http.StatusOK, `"Status": "queued"`},
+		{
+			"push on another branch", "push",
+			`{"ref": "refs/heads/dev", "repository": {"full_name": "minio/minio", "default_branch": "main"}}`, "",
+			http.StatusOK, "not a push on the default branch",
+		},
+		{
+			"draft release", "release",
+			`{"action": "created", "repository": {"full_name": "minio/minio"}}`, "",
+			http.StatusOK, "not a published release",
+		},
+		{
+			"project not watched", "push",
+			`{"ref": "refs/heads/main", "repository": {"full_name": "other/repo", "default_branch": "main"}}`, "",
+			http.StatusOK, "the project is not watched",
+		},
+		{"issue", "issues", `{}`, sign(`{}`), http.StatusOK, "not a push or a release event"},
+	}
+	for _, test := range tests {
+		signature := test.signature
+		if signature == "" && test.name != "no signature" {
+			signature = sign(test.payload)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/github", strings.NewReader(test.payload))
+		req.Header.Set("X-GitHub-Event", test.event)
+		req.Header.Set("X-Hub-Signature-256", signature)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.status || !strings.Contains(w.Body.String(), test.body) {
+			t.Errorf("%s: %d %s, want %d %s", test.name, w.Code, w.Body, test.status, test.body)
+		}
+	}
+
+	// The pushes are deduplicated while the project is queued
+	requests, err := history.ListRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].Project != "MinIO/minio" || requests[0].Tags[0] != "webhook:push" {
+		t.Errorf("requests = %+v, want one request queued by a push", requests)
+	}
+}